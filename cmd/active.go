@@ -2,46 +2,42 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"sort"
 
 	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
 	"github.com/spf13/cobra"
 )
 
+var flagState string
+
 var activeCmd = &cobra.Command{
 	Use:   "active",
 	Short: "Show only active (running) sessions",
-	RunE:  runActive,
+	Long: `Show only active (running) sessions, with what each is doing:
+working (thinking or running tools), waiting (its turn is over, or a tool
+call awaits approval), idle (quiet for a while) or error.`,
+	Example: "  omnisess active --state waiting",
+	RunE:    runActive,
 }
 
 func init() {
+	activeCmd.Flags().StringVar(&flagState, "state", "", "Only sessions in this state: working, waiting, idle or error")
 	rootCmd.AddCommand(activeCmd)
 }
 
 func runActive(cmd *cobra.Command, args []string) error {
-	sources := getSources()
 	opts := getListOptions()
 	opts.Active = true
-
-	var all []model.Session
-	for _, s := range sources {
-		sessions, err := s.List(opts)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", s.Name(), err)
-			continue
-		}
-		all = append(all, sessions...)
+	switch st := model.State(flagState); st {
+	case "", model.StateWorking, model.StateWaiting, model.StateIdle, model.StateError:
+		opts.State = st
+	default:
+		return fmt.Errorf("invalid --state %q: want working, waiting, idle or error", flagState)
 	}
 
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].UpdatedAt.After(all[j].UpdatedAt)
-	})
-
-	if opts.Limit > 0 && len(all) > opts.Limit {
-		all = all[:opts.Limit]
-	}
+	all, warnings := source.ListAll(cmd.Context(), getSources(), opts, flagTimeout)
+	printWarnings(warnings)
 
 	output.RenderSessions(all, getFormat())
 	return nil
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
@@ -14,6 +16,7 @@ import (
 	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/output"
 	"github.com/psacc/omnisess/internal/resume"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
@@ -30,15 +33,15 @@ type errSource struct{}
 
 func (e *errSource) Name() model.Tool { return errSourceName }
 
-func (e *errSource) List(_ source.ListOptions) ([]model.Session, error) {
+func (e *errSource) List(_ context.Context, _ source.ListOptions) ([]model.Session, error) {
 	return nil, errors.New("mock list error")
 }
 
-func (e *errSource) Get(_ string) (*model.Session, error) {
+func (e *errSource) Get(_ context.Context, _ string) (*model.Session, error) {
 	return nil, nil
 }
 
-func (e *errSource) Search(_ string, _ source.ListOptions) ([]model.SearchResult, error) {
+func (e *errSource) Search(_ context.Context, _ search.Matcher, _ source.ListOptions) ([]model.SearchResult, error) {
 	return nil, errors.New("mock search error")
 }
 
@@ -52,13 +55,14 @@ type activeSource struct{}
 
 func (a *activeSource) Name() model.Tool { return activeSourceName }
 
-func (a *activeSource) List(opts source.ListOptions) ([]model.Session, error) {
+func (a *activeSource) List(_ context.Context, opts source.ListOptions) ([]model.Session, error) {
 	makeSess := func(id string) model.Session {
 		return model.Session{
 			ID:        id,
 			Tool:      activeSourceName,
 			Project:   "/tmp/test-project",
 			Active:    true,
+			State:     model.StateWorking,
 			UpdatedAt: time.Now(),
 			StartedAt: time.Now(),
 			Preview:   "test active session",
@@ -72,11 +76,11 @@ func (a *activeSource) List(opts source.ListOptions) ([]model.Session, error) {
 	return []model.Session{s1, s2}, nil
 }
 
-func (a *activeSource) Get(_ string) (*model.Session, error) {
+func (a *activeSource) Get(_ context.Context, _ string) (*model.Session, error) {
 	return nil, nil
 }
 
-func (a *activeSource) Search(_ string, _ source.ListOptions) ([]model.SearchResult, error) {
+func (a *activeSource) Search(_ context.Context, _ search.Matcher, _ source.ListOptions) ([]model.SearchResult, error) {
 	makeSess := func(id string) model.Session {
 		return model.Session{
 			ID:        id,
@@ -109,12 +113,14 @@ const getErrSourceName = model.Tool("test-get-err-src")
 
 type getErrSource struct{}
 
-func (g *getErrSource) Name() model.Tool                                   { return getErrSourceName }
-func (g *getErrSource) List(_ source.ListOptions) ([]model.Session, error) { return nil, nil }
-func (g *getErrSource) Get(_ string) (*model.Session, error) {
+func (g *getErrSource) Name() model.Tool { return getErrSourceName }
+func (g *getErrSource) List(_ context.Context, _ source.ListOptions) ([]model.Session, error) {
+	return nil, nil
+}
+func (g *getErrSource) Get(_ context.Context, _ string) (*model.Session, error) {
 	return nil, errors.New("mock get error")
 }
-func (g *getErrSource) Search(_ string, _ source.ListOptions) ([]model.SearchResult, error) {
+func (g *getErrSource) Search(_ context.Context, _ search.Matcher, _ source.ListOptions) ([]model.SearchResult, error) {
 	return nil, nil
 }
 
@@ -123,20 +129,47 @@ const getSessionSourceName = model.Tool("test-get-session-src")
 
 type getSessionSource struct{}
 
-func (g *getSessionSource) Name() model.Tool                                   { return getSessionSourceName }
-func (g *getSessionSource) List(_ source.ListOptions) ([]model.Session, error) { return nil, nil }
-func (g *getSessionSource) Get(_ string) (*model.Session, error) {
+func (g *getSessionSource) Name() model.Tool { return getSessionSourceName }
+func (g *getSessionSource) List(_ context.Context, _ source.ListOptions) ([]model.Session, error) {
+	return nil, nil
+}
+func (g *getSessionSource) Get(_ context.Context, _ string) (*model.Session, error) {
 	return &model.Session{ID: "test-session-id", Tool: getSessionSourceName}, nil
 }
-func (g *getSessionSource) Search(_ string, _ source.ListOptions) ([]model.SearchResult, error) {
+func (g *getSessionSource) Search(_ context.Context, _ search.Matcher, _ source.ListOptions) ([]model.SearchResult, error) {
 	return nil, nil
 }
 
+// slowSource blocks in List and Search until its context's deadline, as a
+// source stuck on a slow read does. Without a deadline (no --timeout) it
+// returns nothing at once, so tests over every source do not hang.
+const slowSourceName = model.Tool("test-slow-src")
+
+type slowSource struct{}
+
+func (s *slowSource) Name() model.Tool { return slowSourceName }
+func (s *slowSource) List(ctx context.Context, _ source.ListOptions) ([]model.Session, error) {
+	return nil, s.wait(ctx)
+}
+func (s *slowSource) Get(_ context.Context, _ string) (*model.Session, error) { return nil, nil }
+func (s *slowSource) Search(ctx context.Context, _ search.Matcher, _ source.ListOptions) ([]model.SearchResult, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *slowSource) wait(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
 func init() {
 	source.Register(&errSource{})
 	source.Register(&activeSource{})
 	source.Register(&getErrSource{})
 	source.Register(&getSessionSource{})
+	source.Register(&slowSource{})
 	resume.Register(&mockResumer{})
 }
 
@@ -146,7 +179,7 @@ func init() {
 
 // TestShowSession_GetError covers the "failed to get session" error path.
 func TestShowSession_GetError(t *testing.T) {
-	err := showSession(&getErrSource{}, "test-get-err-src:abc", "abc", output.FormatTable)
+	err := showSession(context.Background(), []source.Source{&getErrSource{}}, "test-get-err-src:abc", "abc", output.FormatTable)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -158,7 +191,7 @@ func TestShowSession_GetError(t *testing.T) {
 // TestShowSession_Found covers the "session found and rendered" success path.
 func TestShowSession_Found(t *testing.T) {
 	silenceOutput(t)
-	err := showSession(&getSessionSource{}, "test-get-session-src:test-session-id", "test-session-id", output.FormatTable)
+	err := showSession(context.Background(), []source.Source{&getSessionSource{}}, "test-get-session-src:test-session-id", "test-session-id", output.FormatTable)
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
@@ -171,10 +204,31 @@ func TestShowSession_Found(t *testing.T) {
 // resetFlags resets all package-level flags to their zero values between tests.
 func resetFlags() {
 	flagJSON = false
+	flagNDJSON = false
 	flagTool = ""
 	flagSince = ""
 	flagLimit = 0
 	flagProject = ""
+	flagClaudeRoot = ""
+	flagCodexRoot = ""
+	flagCursorRoot = ""
+	flagGeminiRoot = ""
+	flagHost = ""
+	flagHostDirs = nil
+	flagNoCache = false
+	flagRegex = false
+	flagFuzzy = false
+	flagIn = "content"
+	flagTimeout = 0
+	flagState = ""
+	flagInterval = time.Second
+	flagTailActive = false
+	flagTailInterval = time.Second
+	flagHooks = false
+	flagDryRun = false
+	flagGroupBy = "project"
+	flagCSV = false
+	flagSort = "recent"
 }
 
 // silenceOutput redirects stdout/stderr for the duration of the test so that
@@ -196,9 +250,11 @@ func silenceOutput(t *testing.T) {
 }
 
 // newNoopCmd returns a minimal *cobra.Command suitable for passing to run
-// functions that accept a *cobra.Command argument but do not use it.
+// functions, carrying the background context cobra gives executed commands.
 func newNoopCmd() *cobra.Command {
-	return &cobra.Command{}
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	return cmd
 }
 
 // ---------------------------------------------------------------------------
@@ -328,6 +384,7 @@ func TestParseQualifiedID(t *testing.T) {
 		name      string
 		input     string
 		wantTool  model.Tool
+		wantHost  string
 		wantID    string
 		wantErr   bool
 		errSubstr string
@@ -356,6 +413,19 @@ func TestParseQualifiedID(t *testing.T) {
 			wantTool: model.ToolGemini,
 			wantID:   "jkl012",
 		},
+		{
+			name:     "remote host",
+			input:    "codex@devvm:ghi789",
+			wantTool: model.ToolCodex,
+			wantHost: "devvm",
+			wantID:   "ghi789",
+		},
+		{
+			name:      "unknown tool with host",
+			input:     "vim@devvm:abc123",
+			wantErr:   true,
+			errSubstr: "unknown tool",
+		},
 		{
 			name:      "no colon — format error",
 			input:     "claude-abc123",
@@ -372,7 +442,7 @@ func TestParseQualifiedID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tool, id, err := parseQualifiedID(tt.input)
+			tool, host, id, err := parseQualifiedID(tt.input)
 			if tt.wantErr {
 				if err == nil {
 					t.Fatalf("parseQualifiedID(%q) returned nil error, want error containing %q",
@@ -386,6 +456,9 @@ func TestParseQualifiedID(t *testing.T) {
 			if tool != tt.wantTool {
 				t.Errorf("tool = %q, want %q", tool, tt.wantTool)
 			}
+			if host != tt.wantHost {
+				t.Errorf("host = %q, want %q", host, tt.wantHost)
+			}
 			if id != tt.wantID {
 				t.Errorf("id = %q, want %q", id, tt.wantID)
 			}
@@ -445,6 +518,29 @@ func TestRunList_SourceError(t *testing.T) {
 	}
 }
 
+// TestRunList_Timeout checks that --timeout gives up on a stuck source with a
+// warning instead of hanging.
+func TestRunList_Timeout(t *testing.T) {
+	resetFlags()
+	flagTool = string(slowSourceName)
+	flagTimeout = 10 * time.Millisecond
+
+	r, w, _ := os.Pipe()
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	err := runList(newNoopCmd(), nil)
+	os.Stdout, os.Stderr = origStdout, origStderr
+	w.Close()
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Errorf("runList returned error on timeout (expected nil): %v", err)
+	}
+	if want := "warning: test-slow-src: timed out after 10ms"; !strings.Contains(string(out), want) {
+		t.Errorf("output = %q, want it to contain %q", out, want)
+	}
+}
+
 func TestRunList_JSONFormat(t *testing.T) {
 	silenceOutput(t)
 	resetFlags()
@@ -456,6 +552,40 @@ func TestRunList_JSONFormat(t *testing.T) {
 	}
 }
 
+// TestRunList_SortDuration checks that --sort duration lists every session
+// before applying --limit.
+func TestRunList_SortDuration(t *testing.T) {
+	resetFlags()
+	flagTool = string(activeSourceName)
+	flagSort = "duration"
+	flagLimit = 1
+	flagNDJSON = true
+
+	r, w, _ := os.Pipe()
+	origStdout := os.Stdout
+	os.Stdout = w
+	err := runList(newNoopCmd(), nil)
+	os.Stdout = origStdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatalf("runList (--sort duration) returned unexpected error: %v", err)
+	}
+	if n := strings.Count(string(out), "\n"); n != 1 {
+		t.Errorf("runList (--sort duration --limit 1) printed %d sessions, want 1:\n%s", n, out)
+	}
+}
+
+func TestRunList_InvalidSort(t *testing.T) {
+	resetFlags()
+	flagSort = "size"
+	err := runList(newNoopCmd(), nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid --sort "size"`) {
+		t.Errorf("runList(--sort size) error = %v, want invalid --sort", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // runActive
 // ---------------------------------------------------------------------------
@@ -471,6 +601,40 @@ func TestRunActive_HappyPath(t *testing.T) {
 	}
 }
 
+func TestRunActive_State(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(activeSourceName)
+	flagJSON = true
+
+	for state, want := range map[string]int{"working": 2, "waiting": 0} {
+		flagState = state
+		origStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := runActive(newNoopCmd(), nil)
+		os.Stdout = origStdout
+		w.Close()
+		out, _ := io.ReadAll(r)
+
+		if err != nil {
+			t.Errorf("runActive(--state %s) error: %v", state, err)
+		}
+		var got []model.Session
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("--state %s: invalid JSON %q: %v", state, out, err)
+		}
+		if len(got) != want {
+			t.Errorf("--state %s: got %d sessions, want %d", state, len(got), want)
+		}
+	}
+
+	flagState = "ended"
+	if err := runActive(newNoopCmd(), nil); err == nil || !strings.Contains(err.Error(), "invalid --state") {
+		t.Errorf("runActive(--state ended) error = %v, want invalid --state", err)
+	}
+}
+
 func TestRunActive_SourceError(t *testing.T) {
 	silenceOutput(t)
 	resetFlags()
@@ -506,6 +670,15 @@ func TestRunActive_LimitApplied(t *testing.T) {
 	}
 }
 
+func TestGetFormat_NDJSON(t *testing.T) {
+	resetFlags()
+	flagNDJSON = true
+	t.Cleanup(resetFlags)
+	if got := getFormat(); got != output.FormatNDJSON {
+		t.Errorf("getFormat() = %q, want %q", got, output.FormatNDJSON)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // runSearch
 // ---------------------------------------------------------------------------
@@ -557,6 +730,114 @@ func TestRunSearch_LimitApplied(t *testing.T) {
 	}
 }
 
+func TestRunSearch_NDJSONStreams(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagNDJSON = true
+
+	tests := []struct {
+		tool  model.Tool
+		limit int
+		want  string
+	}{
+		{activeSourceName, 1, `{"Session":{"ID":"test-active-session-id-`},
+		{errSourceName, 0, "warning: test-error-src: mock search error"},
+	}
+	for _, tt := range tests {
+		flagTool, flagLimit = string(tt.tool), tt.limit
+		origStdout, origStderr := os.Stdout, os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stdout, os.Stderr = w, w
+		err := runSearch(newNoopCmd(), []string{"match"})
+		os.Stdout, os.Stderr = origStdout, origStderr
+		w.Close()
+		out, _ := io.ReadAll(r)
+
+		if err != nil {
+			t.Errorf("runSearch(%s) error: %v", tt.tool, err)
+		}
+		if lines := strings.Count(string(out), "\n"); lines != 1 || !strings.HasPrefix(string(out), tt.want) {
+			t.Errorf("runSearch(%s) output = %q, want one line starting %q", tt.tool, out, tt.want)
+		}
+	}
+}
+
+func TestRunSearch_InvalidQuery(t *testing.T) {
+	silenceOutput(t)
+	resetFlags()
+	flagTool = string(activeSourceName)
+	if err := runSearch(newNoopCmd(), []string{`"unterminated`}); err == nil {
+		t.Error("expected a parse error for an unterminated quote")
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+
+	tests := []struct {
+		regex, fuzzy bool
+		args         []string
+		want         string
+	}{
+		{args: []string{"exact phrase", "x"}, want: `"exact phrase" x`},
+		{fuzzy: true, args: []string{"exact phrase"}, want: `"exact phrase"`},
+		{regex: true, args: []string{"migrat(e|ion)", `\d+`}, want: `migrat(e|ion) \d+`},
+	}
+	for _, tt := range tests {
+		flagRegex, flagFuzzy = tt.regex, tt.fuzzy
+		q, err := parseSearchQuery(tt.args)
+		if err != nil {
+			t.Fatalf("parseSearchQuery(%q): %v", tt.args, err)
+		}
+		if q.String() != tt.want {
+			t.Errorf("parseSearchQuery(%q) = %q, want %q", tt.args, q.String(), tt.want)
+		}
+	}
+
+	flagRegex, flagFuzzy = true, false
+	if _, err := parseSearchQuery([]string{"("}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+
+	flagRegex = false
+	flagIn = "tools"
+	q, err := parseSearchQuery([]string{"x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.FTS(); ok {
+		t.Error("--in tools should not search through the content index")
+	}
+	flagIn = "everywhere"
+	if _, err := parseSearchQuery([]string{"x"}); err == nil || !strings.Contains(err.Error(), "--in") {
+		t.Errorf("expected an --in error, got %v", err)
+	}
+}
+
+func TestRankSearchResults(t *testing.T) {
+	now := time.Now()
+	result := func(id string, score float64, age time.Duration) model.SearchResult {
+		return model.SearchResult{Session: model.Session{ID: id, UpdatedAt: now.Add(-age)}, Score: score}
+	}
+	results := []model.SearchResult{
+		result("scanned-old", 0, 2*time.Hour),
+		result("weak", 1.5, 3*time.Hour),
+		result("scanned-new", 0, time.Hour),
+		result("strong", 4, 5*time.Hour),
+	}
+	source.RankSearchResults(results)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Session.ID)
+	}
+	want := []string{"strong", "weak", "scanned-new", "scanned-old"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // runShow
 // ---------------------------------------------------------------------------
@@ -635,7 +916,7 @@ func TestRunShow_FoundSession(t *testing.T) {
 	if len(sources) == 0 {
 		t.Skip("no claude source registered")
 	}
-	sessions, err := sources[0].List(source.ListOptions{Limit: 1})
+	sessions, err := sources[0].List(context.Background(), source.ListOptions{Limit: 1})
 	if err != nil || len(sessions) == 0 {
 		t.Skip("no claude sessions available on this machine")
 	}
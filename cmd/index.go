@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/spf13/cobra"
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the session metadata index",
+	Long:  "Sessions' per-file metadata is cached in $XDG_CACHE_HOME/omnisess/index.db (default ~/.cache/omnisess) and reused while a file's size and mtime are unchanged.",
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Clear the metadata index and re-index every session",
+	Args:  cobra.NoArgs,
+	RunE:  runIndexRebuild,
+}
+
+func init() {
+	indexCmd.AddCommand(indexRebuildCmd)
+	rootCmd.AddCommand(indexCmd)
+}
+
+func runIndexRebuild(cmd *cobra.Command, args []string) error {
+	ix := source.Index()
+	if ix == nil {
+		return fmt.Errorf("index rebuild: the index is disabled (--no-cache) or could not be opened")
+	}
+	if err := ix.Reset(); err != nil {
+		return err
+	}
+
+	// Listing every source repopulates the index as a side effect.
+	sessions, warnings := source.ListAll(cmd.Context(), source.All(), source.ListOptions{}, flagTimeout)
+	printWarnings(warnings)
+	fmt.Printf("Indexed %d sessions.\n", len(sessions))
+	return nil
+}
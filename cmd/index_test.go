@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/source"
+)
+
+// ---------------------------------------------------------------------------
+// openIndex / closeIndex
+// ---------------------------------------------------------------------------
+
+func TestOpenIndex(t *testing.T) {
+	silenceOutput(t)
+	t.Cleanup(func() {
+		resetFlags()
+		closeIndex()
+	})
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", xdg)
+
+	resetFlags()
+	openIndex()
+	if source.Index() == nil {
+		t.Fatal("openIndex() did not set the index")
+	}
+	if _, err := os.Stat(filepath.Join(xdg, "omnisess", "index.db")); err != nil {
+		t.Errorf("index.db not created: %v", err)
+	}
+
+	flagNoCache = true
+	openIndex()
+	if source.Index() != nil {
+		t.Error("--no-cache should leave the index unset")
+	}
+
+	// An unusable cache dir is a warning, not an error.
+	flagNoCache = false
+	file := filepath.Join(xdg, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CACHE_HOME", file)
+	openIndex()
+	if source.Index() != nil {
+		t.Error("a failed open should leave the index unset")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// index rebuild
+// ---------------------------------------------------------------------------
+
+func TestRunIndexRebuild(t *testing.T) {
+	silenceOutput(t)
+	resetFlags()
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(closeIndex)
+
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.SetIndex(ix)
+	// errSource fails to list and is reported as a warning.
+	if err := runIndexRebuild(newNoopCmd(), nil); err != nil {
+		t.Errorf("runIndexRebuild() error: %v", err)
+	}
+
+	ix.Close()
+	if err := runIndexRebuild(newNoopCmd(), nil); err == nil {
+		t.Error("expected error resetting a closed index")
+	}
+}
+
+func TestRunIndexRebuild_Disabled(t *testing.T) {
+	closeIndex()
+	err := runIndexRebuild(newNoopCmd(), nil)
+	if err == nil || !strings.Contains(err.Error(), "--no-cache") {
+		t.Errorf("err = %v, want mention of --no-cache", err)
+	}
+}
+
+func TestIndexRebuild_Execute(t *testing.T) {
+	silenceOutput(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() {
+		resetFlags()
+		rootCmd.SetArgs(nil)
+	})
+
+	rootCmd.SetArgs([]string{"index", "rebuild"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if source.Index() != nil {
+		t.Error("the index should be closed after the command")
+	}
+
+	rootCmd.SetArgs([]string{"index", "rebuild", "--no-cache"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("index rebuild --no-cache: expected error")
+	}
+}
@@ -2,45 +2,51 @@ package cmd
 
 import (
 	"fmt"
-	"os"
-	"sort"
 
-	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
 	"github.com/spf13/cobra"
 )
 
+var flagSort string
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List sessions across all tools",
-	RunE:  runList,
+	Long: `List sessions across all tools, most recently updated first, or with
+--sort duration by time on task: the time between messages, not counting
+pauses longer than 15 minutes. Timing a session reads its whole transcript,
+so DURATION shows only the timings already in the metadata index, and
+"pending" for the others, unless --sort duration asks for every session's. Cursor records no message times,
+so its sessions have no duration and sort last.`,
+	Example: "  omnisess list --since 7d --sort duration --limit 10",
+	RunE:    runList,
 }
 
 func init() {
+	listCmd.Flags().StringVar(&flagSort, "sort", "recent", "Order by recent (last updated first) or duration (most time on task first)")
 	rootCmd.AddCommand(listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	sources := getSources()
 	opts := getListOptions()
-
-	var all []model.Session
-	for _, s := range sources {
-		sessions, err := s.List(opts)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", s.Name(), err)
-			continue
-		}
-		all = append(all, sessions...)
+	limit := opts.Limit
+	switch flagSort {
+	case "recent":
+	case "duration":
+		opts.Limit = 0 // the longest sessions may be anywhere
+		opts.Timing = true
+	default:
+		return fmt.Errorf("invalid --sort %q: want recent or duration", flagSort)
 	}
 
-	// Sort by UpdatedAt descending
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].UpdatedAt.After(all[j].UpdatedAt)
-	})
-
-	if opts.Limit > 0 && len(all) > opts.Limit {
-		all = all[:opts.Limit]
+	all, warnings := source.ListAll(cmd.Context(), getSources(), opts, flagTimeout)
+	printWarnings(warnings)
+	if flagSort == "duration" {
+		source.SortByDuration(all)
+		if limit > 0 && len(all) > limit {
+			all = all[:limit]
+		}
 	}
 
 	output.RenderSessions(all, getFormat())
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/psacc/omnisess/internal/config"
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/pricing"
 	"github.com/psacc/omnisess/internal/source"
 	"github.com/spf13/cobra"
 
@@ -19,44 +26,194 @@ import (
 
 var (
 	flagJSON    bool
+	flagNDJSON  bool
 	flagTool    string
 	flagSince   string
 	flagLimit   int
 	flagProject string
+	flagHost    string
+	flagNoCache bool
+	flagTimeout time.Duration
+
+	flagClaudeRoot string
+	flagCodexRoot  string
+	flagCursorRoot string
+	flagGeminiRoot string
+	flagHostDirs   []string
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "omnisess",
 	Short: "Aggregate AI coding sessions across tools",
 	Long:  "Search, list, and monitor AI coding sessions from Claude Code, Cursor, Codex, and Gemini.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyRoots(); err != nil {
+			return err
+		}
+		openIndex()
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		closeIndex()
+	},
 }
 
+// Execute runs the root command. Ctrl-C cancels the command's context, so
+// sources stop early and whatever they found is still shown.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&flagJSON, "json", false, "Output as JSON")
+	rootCmd.PersistentFlags().BoolVar(&flagNDJSON, "ndjson", false, "Output as newline-delimited JSON, one object per line (search prints results as they are found)")
 	rootCmd.PersistentFlags().StringVar(&flagTool, "tool", "", "Filter by tool (claude, cursor, codex, gemini)")
 	rootCmd.PersistentFlags().StringVar(&flagSince, "since", "", "Only sessions updated within duration (e.g., 24h, 7d, 2w)")
 	rootCmd.PersistentFlags().IntVar(&flagLimit, "limit", 0, "Max results (0 = unlimited)")
-	rootCmd.PersistentFlags().StringVar(&flagProject, "project", "", "Filter by project path substring")
+	rootCmd.PersistentFlags().StringVar(&flagProject, "project", "", "Filter by project path substring, ignoring case")
+	rootCmd.PersistentFlags().StringVar(&flagClaudeRoot, "claude-root", "", "Claude data directory (default $CLAUDE_CONFIG_DIR or ~/.claude)")
+	rootCmd.PersistentFlags().StringVar(&flagCodexRoot, "codex-root", "", "Codex data directory (default $CODEX_HOME or ~/.codex)")
+	rootCmd.PersistentFlags().StringVar(&flagCursorRoot, "cursor-root", "", "Cursor data directory (default ~/.cursor)")
+	rootCmd.PersistentFlags().StringVar(&flagGeminiRoot, "gemini-root", "", "Gemini data directory (default ~/.gemini)")
+	rootCmd.PersistentFlags().StringArrayVar(&flagHostDirs, "host-dir", nil, "Add another machine's synced data as host=dir (dir holds .claude, .codex, ...; repeatable)")
+	rootCmd.PersistentFlags().BoolVar(&flagNoCache, "no-cache", false, "Bypass the metadata index and re-read every session file")
+	rootCmd.PersistentFlags().StringVar(&flagHost, "host", "", "Filter by host (\"local\" for this machine)")
+	rootCmd.PersistentFlags().DurationVar(&flagTimeout, "timeout", 0, "Give up on a tool after this long and show partial results (e.g., 5s; 0 = no limit)")
+	rootCmd.MarkFlagsMutuallyExclusive("json", "ndjson")
+}
+
+// applyRoots points each source at its data directory. The `roots` keys of
+// config.yaml are applied first and --<tool>-root flags override them; tools
+// with neither fall back to their own env var or ~/.<tool> (see source.Root).
+// Other machines' roots are then registered by applyHosts, and the prices
+// of config.yaml applied by applyPrices.
+func applyRoots() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	flagRoots := map[model.Tool]string{
+		model.ToolClaude: flagClaudeRoot,
+		model.ToolCodex:  flagCodexRoot,
+		model.ToolCursor: flagCursorRoot,
+		model.ToolGemini: flagGeminiRoot,
+	}
+	for name, dir := range cfg.Roots {
+		if _, ok := flagRoots[model.Tool(name)]; !ok {
+			return fmt.Errorf("config: unknown tool %q in roots", name)
+		}
+		source.SetRoot(model.Tool(name), dir)
+	}
+	for tool, dir := range flagRoots {
+		if dir != "" {
+			source.SetRoot(tool, dir)
+		}
+	}
+	if err := applyHosts(cfg); err != nil {
+		return err
+	}
+	return applyPrices(cfg)
+}
+
+// prices estimates the costs transcripts do not record: the built-in table
+// with the `prices` of config.yaml over it.
+var prices = pricing.Default
+
+func applyPrices(cfg *config.Config) error {
+	t, err := pricing.Default.With(cfg.Prices)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	prices = t
+	return nil
+}
+
+// applyHosts registers the extra roots of other machines: the `hosts` keys
+// of config.yaml plus --host-dir flags, which win for the same host. Roots
+// from a previous run are cleared first.
+func applyHosts(cfg *config.Config) error {
+	hosts := map[string]string{}
+	for host, dir := range cfg.Hosts {
+		hosts[host] = dir
+	}
+	for _, hd := range flagHostDirs {
+		host, dir, ok := strings.Cut(hd, "=")
+		if !ok || host == "" || dir == "" {
+			return fmt.Errorf("invalid --host-dir %q, expected host=dir", hd)
+		}
+		hosts[host] = dir
+	}
+
+	names := make([]string, 0, len(hosts))
+	for host := range hosts {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+
+	source.ClearHosts()
+	for _, host := range names {
+		if err := source.AddHostHome(host, hosts[host]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openIndex points the sources at the metadata index unless --no-cache is
+// set. The index is only an optimization: when it cannot be opened, a
+// warning is printed and every file is read directly.
+func openIndex() {
+	closeIndex()
+	if flagNoCache {
+		return
+	}
+	ix, err := index.OpenDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v (continuing without the index)\n", err)
+		return
+	}
+	source.SetIndex(ix)
+}
+
+// closeIndex closes the metadata index opened by openIndex, if any.
+func closeIndex() {
+	source.Index().Close()
+	source.SetIndex(nil)
+}
+
+// printWarnings reports the sources that failed or timed out. Their results
+// are missing from (or incomplete in) the output, which still goes ahead.
+func printWarnings(warnings []error) {
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+	}
 }
 
 func getFormat() output.Format {
-	if flagJSON {
+	switch {
+	case flagJSON:
 		return output.FormatJSON
+	case flagNDJSON:
+		return output.FormatNDJSON
 	}
 	return output.FormatTable
 }
 
+// getSources returns the registered sources selected by --tool and --host.
 func getSources() []source.Source {
-	if flagTool != "" {
-		return source.ByName(model.Tool(flagTool))
+	var filtered []source.Source
+	for _, s := range source.ByName(model.Tool(flagTool)) {
+		if source.MatchesHost(s, flagHost) {
+			filtered = append(filtered, s)
+		}
 	}
-	return source.All()
+	return filtered
 }
 
 func getListOptions() source.ListOptions {
@@ -1,8 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/psacc/omnisess/internal/config"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/pricing"
+	"github.com/psacc/omnisess/internal/source"
 )
 
 func TestParseDuration(t *testing.T) {
@@ -87,3 +96,296 @@ func TestParseDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyRoots(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "/env/codex")
+	t.Cleanup(func() {
+		resetFlags()
+		for _, tool := range []model.Tool{model.ToolClaude, model.ToolCodex, model.ToolCursor, model.ToolGemini} {
+			source.SetRoot(tool, "")
+		}
+	})
+
+	cfgDir := filepath.Join(xdg, "omnisess")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := "roots:\n  claude: /cfg/claude\n  cursor: /cfg/cursor\n"
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	flagCursorRoot = "/flag/cursor"
+
+	if err := applyRoots(); err != nil {
+		t.Fatalf("applyRoots() error: %v", err)
+	}
+
+	want := map[model.Tool]string{
+		model.ToolClaude: "/cfg/claude",                  // config key
+		model.ToolCursor: "/flag/cursor",                 // flag beats config
+		model.ToolCodex:  "/env/codex",                   // tool env var
+		model.ToolGemini: filepath.Join(home, ".gemini"), // default
+	}
+	for tool, w := range want {
+		got, err := source.Root(tool)
+		if err != nil {
+			t.Fatalf("Root(%s) error: %v", tool, err)
+		}
+		if got != w {
+			t.Errorf("Root(%s) = %q, want %q", tool, got, w)
+		}
+	}
+}
+
+func TestApplyRoots_Errors(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	cfgDir := filepath.Join(xdg, "omnisess")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfgPath := filepath.Join(cfgDir, "config.yaml")
+
+	if err := os.WriteFile(cfgPath, []byte("roots:\n  vim: /x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRoots(); err == nil || !strings.Contains(err.Error(), "vim") {
+		t.Errorf("unknown tool: err = %v, want mention of vim", err)
+	}
+
+	if err := os.WriteFile(cfgPath, []byte("roots: [bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRoots(); err == nil {
+		t.Error("malformed config: expected error")
+	}
+
+	if err := os.WriteFile(cfgPath, []byte("prices:\n  gpt-5:\n    input: -1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyRoots(); err == nil || !strings.Contains(err.Error(), "negative price") {
+		t.Errorf("negative price: err = %v, want negative price", err)
+	}
+
+	t.Cleanup(resetFlags)
+	flagHostDirs = []string{"devvm"}
+	if err := applyRoots(); err == nil || !strings.Contains(err.Error(), "--host-dir") {
+		t.Errorf("bad --host-dir: err = %v, want --host-dir", err)
+	}
+}
+
+func TestApplyPrices(t *testing.T) {
+	t.Cleanup(func() { prices = pricing.Default })
+	cfg := &config.Config{Prices: pricing.Table{"gpt-5-codex": {Input: 2}, "my-model": {Output: 1}}}
+	if err := applyPrices(cfg); err != nil {
+		t.Fatalf("applyPrices() error: %v", err)
+	}
+	for name, want := range map[string]pricing.Price{
+		"gpt-5-codex-2025": {Input: 2},
+		"gpt-5":            pricing.Default["gpt-5"],
+		"my-model":         {Output: 1},
+	} {
+		if got, _ := prices.Lookup(name); got != want {
+			t.Errorf("prices.Lookup(%q) = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+// TestRootFlag_ThreadsIntoSources runs a real command with --codex-root
+// pointing at a fixture directory, without touching HOME.
+func TestRootFlag_ThreadsIntoSources(t *testing.T) {
+	silenceOutput(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() {
+		resetFlags()
+		source.SetRoot(model.ToolCodex, "")
+		rootCmd.SetArgs(nil)
+	})
+
+	codexRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(codexRoot, "history.jsonl"),
+		[]byte(`{"session_id":"aabbccdd-1234-5678-9abc-def012345678","ts":1739091671,"text":"hello"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"list", "--tool", "codex", "--codex-root", codexRoot})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if got, _ := source.Root(model.ToolCodex); got != codexRoot {
+		t.Errorf("Root(codex) = %q, want %q", got, codexRoot)
+	}
+	sessions, err := source.ByName(model.ToolCodex)[0].List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Preview != "hello" {
+		t.Errorf("List() via --codex-root = %+v", sessions)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// applyHosts / --host
+// ---------------------------------------------------------------------------
+
+// writeHostHome creates a synced home for another machine holding a Codex
+// rollout for sessionID (plus any extra empty tool dirs).
+func writeHostHome(t *testing.T, sessionID string, extraTools ...string) string {
+	t.Helper()
+	home := t.TempDir()
+	day := filepath.Join(home, ".codex", "sessions", "2026", "02", "09")
+	if err := os.MkdirAll(day, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	rollout := `{"timestamp":"2026-02-09T10:01:11Z","type":"session_meta","payload":{"id":"` + sessionID + `","cwd":"/home/dev/app"}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"remote hello"}]}}` + "\n"
+	if err := os.WriteFile(filepath.Join(day, "rollout-2026-02-09T10-01-11-"+sessionID+".jsonl"), []byte(rollout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range extraTools {
+		if err := os.MkdirAll(filepath.Join(home, "."+tool), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+// hostsOf returns the host label of every registered source for tool.
+func hostsOf(tool model.Tool) []string {
+	var hosts []string
+	for _, s := range source.ByName(tool) {
+		if h := source.HostOf(s); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+func TestApplyHosts(t *testing.T) {
+	resetFlags()
+	t.Cleanup(func() {
+		resetFlags()
+		source.ClearHosts()
+	})
+
+	devvm := writeHostHome(t, "11111111-0000-0000-0000-000000000000", "claude")
+	laptop := writeHostHome(t, "22222222-0000-0000-0000-000000000000")
+	cfg := &config.Config{Hosts: map[string]string{"devvm": "/does/not/exist", "laptop": laptop}}
+	flagHostDirs = []string{"devvm=" + devvm} // flag beats config
+
+	if err := applyHosts(cfg); err != nil {
+		t.Fatalf("applyHosts() error: %v", err)
+	}
+	if got := strings.Join(hostsOf(model.ToolCodex), ","); got != "devvm,laptop" {
+		t.Errorf("codex hosts = %q, want devvm,laptop", got)
+	}
+	if got := strings.Join(hostsOf(model.ToolClaude), ","); got != "devvm" {
+		t.Errorf("claude hosts = %q, want devvm", got)
+	}
+
+	// A second run starts from a clean slate instead of accumulating roots.
+	flagHostDirs = nil
+	if err := applyHosts(&config.Config{}); err != nil {
+		t.Fatalf("applyHosts() error: %v", err)
+	}
+	if got := hostsOf(model.ToolCodex); len(got) != 0 {
+		t.Errorf("codex hosts after reset = %v, want none", got)
+	}
+}
+
+func TestApplyHosts_Errors(t *testing.T) {
+	t.Cleanup(func() {
+		resetFlags()
+		source.ClearHosts()
+	})
+
+	for _, hd := range []string{"devvm", "=/srv/devvm", "devvm="} {
+		resetFlags()
+		flagHostDirs = []string{hd}
+		if err := applyHosts(&config.Config{}); err == nil || !strings.Contains(err.Error(), "--host-dir") {
+			t.Errorf("--host-dir %q: err = %v, want invalid --host-dir", hd, err)
+		}
+	}
+
+	resetFlags()
+	if err := applyHosts(&config.Config{Hosts: map[string]string{"devvm": t.TempDir()}}); err == nil {
+		t.Error("home without tool dirs: expected error")
+	}
+}
+
+// TestHostFlag_FiltersSources runs real commands against a synced host home
+// and checks --host selection and host-qualified IDs.
+func TestHostFlag_FiltersSources(t *testing.T) {
+	silenceOutput(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(func() {
+		resetFlags()
+		source.ClearHosts()
+		rootCmd.SetArgs(nil)
+	})
+
+	const id = "33333333-0000-0000-0000-000000000000"
+	devvm := writeHostHome(t, id)
+
+	rootCmd.SetArgs([]string{"list", "--tool", "codex", "--host", "devvm", "--host-dir", "devvm=" + devvm})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	srcs := getSources()
+	if len(srcs) != 1 || source.HostOf(srcs[0]) != "devvm" {
+		t.Fatalf("getSources() with --host devvm = %v", srcs)
+	}
+	sessions, err := srcs[0].List(context.Background(), source.ListOptions{})
+	if err != nil || len(sessions) != 1 || sessions[0].QualifiedID() != "codex@devvm:"+id {
+		t.Errorf("List() = %+v, %v", sessions, err)
+	}
+
+	flagHost = source.LocalHost
+	for _, s := range getSources() {
+		if source.HostOf(s) != "" {
+			t.Errorf("--host local returned %s source for host %s", s.Name(), source.HostOf(s))
+		}
+	}
+
+	flagHost = ""
+	if err := runShow(newNoopCmd(), []string{"codex@devvm:" + id[:8]}); err != nil {
+		t.Errorf("runShow(host-qualified) error: %v", err)
+	}
+	if err := runShow(newNoopCmd(), []string{"codex:" + id[:8]}); err != nil {
+		t.Errorf("runShow(unqualified, falls through to devvm) error: %v", err)
+	}
+	if err := runShow(newNoopCmd(), []string{"codex@laptop:" + id[:8]}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("runShow(unknown host) err = %v, want not found", err)
+	}
+}
+
+// TestExecute_ConfigError verifies that a broken config.yaml aborts the
+// command before any source is read.
+func TestExecute_ConfigError(t *testing.T) {
+	silenceOutput(t)
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Cleanup(func() {
+		resetFlags()
+		rootCmd.SetArgs(nil)
+	})
+	if err := os.MkdirAll(filepath.Join(xdg, "omnisess"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "omnisess", "config.yaml"), []byte("roots: [bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Execute() with malformed config: expected error")
+	}
+}
@@ -1,50 +1,110 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"sort"
+	"strings"
 
-	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/search"
+	"github.com/psacc/omnisess/internal/source"
 	"github.com/spf13/cobra"
 )
 
 var searchCmd = &cobra.Command{
-	Use:   "search <query>",
+	Use:   "search <query>...",
 	Short: "Search across session content",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runSearch,
+	Long: `Search across session content. All arguments form one query:
+
+  deploy "exact phrase"        case-insensitive substrings, all required
+  a OR b, NOT a, -a, (a OR b)  boolean operators (AND is implicit)
+  role:user  tool:claude  model:opus  branch:main  project:api
+  before:2026-09-01  after:2026-09-01  has:toolcall
+
+With --fuzzy, terms also match words a typo or two away. With --regex, the
+arguments are one case-insensitive RE2 regular expression instead.
+
+--in tools searches the inputs and outputs of tool calls (shell commands,
+file paths, command output) and reasoning summaries instead of message text;
+--in all searches both.
+
+Results are ranked by relevance once every tool has been searched. With
+--ndjson they are printed unranked, one per line, as soon as they are found.`,
+	Example: `  omnisess search database migration
+  omnisess search '"connection refused" -role:assistant after:2026-09-01'
+  omnisess search --fuzzy migraton
+  omnisess search --regex 'migrat(e|ion) \d+'
+  omnisess search --in tools internal/search/eval.go`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSearch,
 }
 
+var (
+	flagRegex bool
+	flagFuzzy bool
+	flagIn    string
+)
+
 func init() {
+	searchCmd.Flags().BoolVar(&flagRegex, "regex", false, "Match the query as an RE2 regular expression")
+	searchCmd.Flags().BoolVar(&flagFuzzy, "fuzzy", false, "Let query terms match words with typos")
+	searchCmd.Flags().StringVar(&flagIn, "in", "content", "Where to search: content, tools (tool call inputs and outputs, reasoning) or all")
+	searchCmd.MarkFlagsMutuallyExclusive("regex", "fuzzy")
 	rootCmd.AddCommand(searchCmd)
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	query := args[0]
-	sources := getSources()
+	query, err := parseSearchQuery(args)
+	if err != nil {
+		return err
+	}
 	opts := getListOptions()
+	if getFormat() == output.FormatNDJSON {
+		streamSearch(cmd.Context(), query, opts)
+		return nil
+	}
+	all, warnings := source.SearchAll(cmd.Context(), getSources(), query, opts, flagTimeout)
+	printWarnings(warnings)
+
+	output.RenderSearchResults(all, getFormat())
+	return nil
+}
 
-	var all []model.SearchResult
-	for _, s := range sources {
-		results, err := s.Search(query, opts)
+// streamSearch prints each result as soon as a source finds it, unranked,
+// and stops the search once --limit results are out.
+func streamSearch(ctx context.Context, query search.Matcher, opts source.ListOptions) {
+	n := 0
+	for r, err := range source.SearchStream(ctx, getSources(), query, opts, flagTimeout) {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", s.Name(), err)
+			printWarnings([]error{err})
 			continue
 		}
-		all = append(all, results...)
+		output.StreamSearchResult(r)
+		if n++; n == opts.Limit {
+			return
+		}
 	}
+}
 
-	// Sort by recency
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].Session.UpdatedAt.After(all[j].Session.UpdatedAt)
-	})
-
-	if opts.Limit > 0 && len(all) > opts.Limit {
-		all = all[:opts.Limit]
+// parseSearchQuery parses the search arguments in the mode chosen by
+// --regex or --fuzzy, searching the texts chosen by --in. A regular
+// expression is taken as written, spaces included.
+func parseSearchQuery(args []string) (*search.Query, error) {
+	scope, err := search.ParseScope(flagIn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --in %q: want content, tools or all", flagIn)
 	}
-
-	output.RenderSearchResults(all, getFormat())
-	return nil
+	var q *search.Query
+	switch {
+	case flagRegex:
+		q, err = search.ParseMode(strings.Join(args, " "), search.ModeRegex)
+	case flagFuzzy:
+		q, err = search.ParseMode(search.JoinArgs(args), search.ModeFuzzy)
+	default:
+		q, err = search.Parse(search.JoinArgs(args))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q.In(scope), nil
 }
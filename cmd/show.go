@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -11,7 +12,7 @@ import (
 )
 
 var showCmd = &cobra.Command{
-	Use:   "show <tool:session-id>",
+	Use:   "show <tool[@host]:session-id>",
 	Short: "Show full session details",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runShow,
@@ -22,36 +23,75 @@ func init() {
 }
 
 func runShow(cmd *cobra.Command, args []string) error {
-	toolName, sessionID, err := parseQualifiedID(args[0])
+	toolName, host, sessionID, err := parseQualifiedID(args[0])
 	if err != nil {
 		return err
 	}
-	// parseQualifiedID validates the tool name, so source.ByName always returns ≥ 1 element.
-	return showSession(source.ByName(toolName)[0], args[0], sessionID, getFormat())
+	if host == "" {
+		host = flagHost
+	}
+	return showSession(cmd.Context(), hostSources(toolName, host), args[0], sessionID, getFormat())
 }
 
-func showSession(src source.Source, qualifiedID, sessionID string, format output.Format) error {
-	session, err := src.Get(sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to get session: %w", err)
+// hostSources returns the tool's sources on host ("" for every host).
+func hostSources(tool model.Tool, host string) []source.Source {
+	var sources []source.Source
+	for _, s := range source.ByName(tool) {
+		if source.MatchesHost(s, host) {
+			sources = append(sources, s)
+		}
 	}
-	if session == nil {
-		return fmt.Errorf("session not found: %s", qualifiedID)
+	return sources
+}
+
+// showSession renders the first session found in sources, which are tried in
+// registry order so the local roots win over other hosts.
+func showSession(ctx context.Context, sources []source.Source, qualifiedID, sessionID string, format output.Format) error {
+	session, _, err := getSession(ctx, sources, qualifiedID, sessionID)
+	if err != nil {
+		return err
 	}
 	output.RenderSession(session, format)
 	return nil
 }
 
-func parseQualifiedID(s string) (model.Tool, string, error) {
+// getSession returns the first session found in sources, in registry order,
+// with the source it came from. Costs the transcript does not record are
+// estimated from prices.
+func getSession(ctx context.Context, sources []source.Source, qualifiedID, sessionID string) (*model.Session, source.Source, error) {
+	var firstErr error
+	for _, src := range sources {
+		session, err := src.Get(ctx, sessionID)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if session != nil {
+			prices.Estimate(session)
+			return session, src, nil
+		}
+	}
+	if firstErr != nil {
+		return nil, nil, fmt.Errorf("failed to get session: %w", firstErr)
+	}
+	return nil, nil, fmt.Errorf("session not found: %s", qualifiedID)
+}
+
+// parseQualifiedID splits "tool:id" or "tool@host:id" into its parts. The
+// host is empty when the ID does not name one.
+func parseQualifiedID(s string) (model.Tool, string, string, error) {
 	parts := strings.SplitN(s, ":", 2)
 	if len(parts) != 2 {
-		return "", "", fmt.Errorf("expected format tool:session-id (e.g., claude:5c3f2742), got %q", s)
+		return "", "", "", fmt.Errorf("expected format tool:session-id (e.g., claude:5c3f2742 or claude@devvm:5c3f2742), got %q", s)
 	}
-	tool := model.Tool(parts[0])
+	toolPart, host, _ := strings.Cut(parts[0], "@")
+	tool := model.Tool(toolPart)
 	switch tool {
 	case model.ToolClaude, model.ToolCursor, model.ToolCodex, model.ToolGemini:
-		return tool, parts[1], nil
+		return tool, host, parts[1], nil
 	default:
-		return "", "", fmt.Errorf("unknown tool %q, expected one of: claude, cursor, codex, gemini", parts[0])
+		return "", "", "", fmt.Errorf("unknown tool %q, expected one of: claude, cursor, codex, gemini", toolPart)
 	}
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/psacc/omnisess/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagGroupBy string
+	flagCSV     bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Aggregate sessions, messages, tool calls, tokens and cost",
+	Long: `Aggregate the sessions selected by --since, --tool, --project and --host
+into sessions, messages, tool calls, tokens and cost per tool, project,
+model, branch, day or week. Every session is read in full, so this takes
+longer than list.
+
+Tokens are what the transcripts record: Claude Code and Codex record them,
+Cursor and Gemini do not. Where no cost is recorded (Codex, newer Claude
+Code versions) it is estimated from the model's price, and marked with ~;
+the prices of config.yaml override the built-in ones.`,
+	Example: `  omnisess stats --since 7d
+  omnisess stats --group-by week --since 8w --csv > weekly.csv`,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&flagGroupBy, "group-by", "project", "Group by tool, project, model, branch, day or week")
+	statsCmd.Flags().BoolVar(&flagCSV, "csv", false, "Output as CSV, one line per group (not with --json or --ndjson)")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	by, err := stats.ParseGroupBy(flagGroupBy)
+	if err != nil {
+		return fmt.Errorf("invalid --group-by: %w", err)
+	}
+	format := getFormat()
+	if flagCSV {
+		if flagJSON || flagNDJSON {
+			return errors.New("give --csv or --json/--ndjson, not both")
+		}
+		format = output.FormatCSV
+	}
+
+	sources := getSources()
+	listed, warnings := source.ListAll(cmd.Context(), sources, getListOptions(), flagTimeout)
+	sessions, getWarnings := source.GetAll(cmd.Context(), sources, listed, flagTimeout)
+	printWarnings(append(warnings, getWarnings...))
+	for i := range sessions {
+		prices.Estimate(&sessions[i])
+	}
+
+	output.RenderStats(stats.Aggregate(sessions, by), format)
+	return nil
+}
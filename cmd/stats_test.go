@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/stats"
+)
+
+// statsOutput returns what runStats writes to stdout.
+func statsOutput(t *testing.T) string {
+	t.Helper()
+	origStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := runStats(newNoopCmd(), nil)
+	os.Stdout = origStdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("runStats() error: %v", err)
+	}
+	return string(out)
+}
+
+func TestRunStats_InvalidGroupBy(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagGroupBy = "month"
+	if err := runStats(newNoopCmd(), nil); err == nil || !strings.Contains(err.Error(), "invalid --group-by") {
+		t.Errorf("runStats(--group-by month) error = %v, want invalid --group-by", err)
+	}
+}
+
+func TestRunStats_CSVWithJSON(t *testing.T) {
+	for _, format := range []*bool{&flagJSON, &flagNDJSON} {
+		resetFlags()
+		t.Cleanup(resetFlags)
+		flagCSV, *format = true, true
+		if err := runStats(newNoopCmd(), nil); err == nil || !strings.Contains(err.Error(), "--csv") {
+			t.Errorf("runStats(--csv with JSON) error = %v, want one naming --csv", err)
+		}
+	}
+}
+
+// TestRunStats reads the Claude fixture session in full, so that its
+// messages, tool calls and usage are counted, and the cost it does not
+// record is estimated.
+func TestRunStats(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(model.ToolClaude)
+	flagGroupBy = "model"
+	transcript, _ := claudeFixture(t)
+	appendString(t, transcript, `{"type":"assistant","model":"claude-opus-4","costUSD":0.25,"message":{"id":"msg_01","role":"assistant","content":[{"type":"tool_use","name":"Bash","input":{"command":"make"}}],"usage":{"input_tokens":100,"output_tokens":20}}}`+"\n"+
+		// No recorded cost: estimated at the model's price.
+		`{"type":"assistant","model":"claude-opus-4","message":{"id":"msg_02","role":"assistant","content":[{"type":"text","text":"Done."}],"usage":{"input_tokens":1000}}}`+"\n")
+
+	flagJSON = true
+	var r stats.Report
+	if err := json.Unmarshal([]byte(statsOutput(t)), &r); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := stats.Row{Key: "claude-opus-4", Sessions: 1, Messages: 3, ToolCalls: 1, Usage: model.Usage{InputTokens: 1100, OutputTokens: 20, CostUSD: 0.25, EstimatedCostUSD: 0.015}}
+	if r.GroupBy != stats.ByModel || len(r.Rows) != 1 || r.Rows[0] != want {
+		t.Errorf("report = %+v, want one row %+v", r, want)
+	}
+
+	flagJSON, flagCSV = false, true
+	if out := statsOutput(t); !strings.Contains(out, "claude-opus-4,1,3,1,1100,0,0,20,0,0.25,0.015\n") {
+		t.Errorf("CSV output = %q, want the model's line", out)
+	}
+}
+
+func TestRunStats_Warnings(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	silenceOutput(t)
+	// activeSource lists sessions it cannot Get.
+	flagTool = string(activeSourceName)
+	if err := runStats(newNoopCmd(), nil); err != nil {
+		t.Errorf("runStats() error: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/psacc/omnisess/internal/tail"
+	"github.com/psacc/omnisess/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagTailActive   bool
+	flagTailInterval time.Duration
+)
+
+var tailCmd = &cobra.Command{
+	Use:   "tail [<tool[@host]:session-id>]",
+	Short: "Follow a session's conversation as it is written",
+	Long: `Print a session like show, then keep printing its new messages and tool
+calls as the agent writes them, until interrupted. With --active, follow the
+most recently updated active session.
+
+Claude Code and Codex transcripts, another host's too, are read as they grow,
+a line once it is complete; a transcript that is replaced or truncated is read again from the
+start. Other sessions are read again whenever their files change. With --json
+or --ndjson each message is printed as a line {"index", "message"}; a message
+that gains tool calls is printed again under the same index.`,
+	Example: "  omnisess tail claude:5c3f2742\n  omnisess tail --active --tool codex",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    runTail,
+}
+
+func init() {
+	tailCmd.Flags().BoolVar(&flagTailActive, "active", false, "Follow the most recently updated active session")
+	tailCmd.Flags().DurationVar(&flagTailInterval, "interval", 2*time.Second, "Check the transcript at least this often")
+	rootCmd.AddCommand(tailCmd)
+}
+
+func runTail(cmd *cobra.Command, args []string) error {
+	if flagTailInterval <= 0 {
+		return fmt.Errorf("invalid --interval %s: must be positive", flagTailInterval)
+	}
+	ctx := cmd.Context()
+
+	var sess *model.Session
+	var src source.Source
+	var err error
+	switch {
+	case flagTailActive && len(args) > 0:
+		return errors.New("give a session or --active, not both")
+	case flagTailActive:
+		sess, src, err = mostRecentActive(ctx)
+	case len(args) == 1:
+		var toolName model.Tool
+		var host, sessionID string
+		toolName, host, sessionID, err = parseQualifiedID(args[0])
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = flagHost
+		}
+		sess, src, err = getSession(ctx, hostSources(toolName, host), args[0], sessionID)
+	default:
+		return errors.New("expected a session (tool:session-id) or --active")
+	}
+	if err != nil {
+		return err
+	}
+	followSession(ctx, src, sess, getFormat())
+	return nil
+}
+
+// mostRecentActive returns the active session updated last among the
+// selected sources, read in full.
+func mostRecentActive(ctx context.Context) (*model.Session, source.Source, error) {
+	opts := getListOptions()
+	opts.Active = true
+	opts.Limit = 1
+	active, warnings := source.ListAll(ctx, getSources(), opts, flagTimeout)
+	printWarnings(warnings)
+	if len(active) == 0 {
+		return nil, nil, errors.New("no active session")
+	}
+	s := active[0]
+	// Other machines' sessions are never active.
+	return getSession(ctx, hostSources(s.Tool, source.LocalHost), s.QualifiedID(), s.ID)
+}
+
+// followSession prints sess and then what is added to it, until ctx is done.
+// Followers' transcripts are read as they grow; other sessions are read
+// again with Get. Each is checked whenever inotify reports a change under
+// its directories, and every --interval. Errors are warnings, each printed
+// once: the transcript may be readable again at the next check.
+func followSession(ctx context.Context, src source.Source, sess *model.Session, format output.Format) {
+	next := func() ([]model.Message, error) {
+		s, err := src.Get(ctx, sess.ID)
+		if s == nil {
+			return nil, err
+		}
+		return s.Messages, err
+	}
+	dirs, _ := source.DirsOf(src)
+	out := output.NewTail(sess, format)
+	if f, ok := src.(source.Follower); ok {
+		if path, err := f.TranscriptPath(ctx, sess.ID); err == nil && path != "" {
+			if file, err := tail.Open(path); err == nil {
+				defer file.Close()
+				next = transcriptReader(f, file, out)
+				dirs = []string{filepath.Dir(path)}
+			}
+		}
+	}
+
+	changes := watch.Changes(ctx, dirs)
+	ticker := time.NewTicker(flagTailInterval)
+	defer ticker.Stop()
+	warned := map[string]bool{}
+	for {
+		msgs, err := next()
+		if err != nil && !warned[err.Error()] {
+			warned[err.Error()] = true
+			printWarnings([]error{err})
+		}
+		if err == nil {
+			out.Messages(msgs)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// transcriptReader returns a function that feeds the lines appended to
+// file since its last call to a Transcript, and returns its messages. A
+// replaced or truncated file starts a new Transcript, printed from the start.
+func transcriptReader(f source.Follower, file *tail.File, out *output.Tail) func() ([]model.Message, error) {
+	tr := f.NewTranscript()
+	return func() ([]model.Message, error) {
+		lines, restarted, err := file.Read()
+		if err != nil {
+			return nil, err
+		}
+		if restarted {
+			tr = f.NewTranscript()
+			out.Restart()
+		}
+		for _, l := range lines {
+			tr.Add(l)
+		}
+		return tr.Messages(), nil
+	}
+}
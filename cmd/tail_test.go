@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
+)
+
+const claudeFixtureID = "claude:5c3f2742"
+
+func appendString(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunTail_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func()
+		args   []string
+		errMsg string
+	}{
+		{"bad interval", func() { flagTailInterval = -time.Second }, []string{claudeFixtureID}, "invalid --interval"},
+		{"session and --active", func() { flagTailActive = true }, []string{claudeFixtureID}, "not both"},
+		{"nothing to follow", func() {}, nil, "expected a session"},
+		{"malformed id", func() {}, []string{"claude-5c3f2742"}, "expected format"},
+		{"not found", func() {}, []string{"gemini:any-session-id"}, "session not found"},
+		{"no active session", func() { flagTailActive = true; flagTool = string(errSourceName) }, nil, "no active session"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetFlags()
+			t.Cleanup(resetFlags)
+			silenceOutput(t)
+			tc.setup()
+			err := runTail(newNoopCmd(), tc.args)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("runTail(%v) error = %v, want %q", tc.args, err, tc.errMsg)
+			}
+		})
+	}
+}
+
+// TestRunTail_FollowsTranscript follows a Claude transcript as lines are
+// appended (one in two writes), the file is rewritten, and it is replaced by
+// something unreadable.
+func TestRunTail_FollowsTranscript(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTailInterval = 20 * time.Millisecond
+	transcript, _ := claudeFixture(t)
+
+	lines, stop := startCmd(t, runTail, claudeFixtureID)
+	defer stop()
+	nextLine(t, lines, "Session: 5c3f2742 (claude)")
+	nextLine(t, lines, "fix the build")
+
+	reply := `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Fixed."},` +
+		`{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go build"}}]}}` + "\n"
+	appendString(t, transcript, reply[:40])
+	time.Sleep(50 * time.Millisecond)
+	appendString(t, transcript, reply[40:])
+	nextLine(t, lines, "Fixed.")
+	nextLine(t, lines, "[tool: Bash]")
+
+	rewritten := `{"type":"user","message":{"role":"user","content":"start over"}}` + "\n"
+	if err := os.WriteFile(transcript, []byte(rewritten), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nextLine(t, lines, "start over")
+
+	if err := os.Remove(transcript); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(transcript, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	nextLine(t, lines, "warning:")
+}
+
+func TestRunTail_Active(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTailActive = true
+	flagTool = string(model.ToolClaude)
+	flagNDJSON = true
+	claudeFixture(t)
+
+	lines, stop := startCmd(t, runTail)
+	defer stop()
+	l := nextLine(t, lines, `"index":0`)
+	if !strings.Contains(l, "fix the build") {
+		t.Errorf("first message = %s", l)
+	}
+}
+
+// TestFollowSession_Get covers sources that are not Followers: the session
+// is read again with Get, and a failing Get warns once.
+func TestFollowSession_Get(t *testing.T) {
+	tests := []struct {
+		src  source.Source
+		want string
+	}{
+		{&getSessionSource{}, ""},
+		{&getErrSource{}, "warning: mock get error"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.src.Name()), func(t *testing.T) {
+			resetFlags()
+			t.Cleanup(resetFlags)
+			flagTailInterval = 5 * time.Millisecond
+
+			r, w, _ := os.Pipe()
+			origStdout, origStderr := os.Stdout, os.Stderr
+			os.Stdout, os.Stderr = w, w
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			followSession(ctx, tc.src, &model.Session{ID: "test-session-id", Tool: tc.src.Name()}, output.FormatTable)
+			os.Stdout, os.Stderr = origStdout, origStderr
+			w.Close()
+			b, _ := io.ReadAll(r)
+			all := string(b)
+
+			if !strings.HasPrefix(all, "Session: test-ses") {
+				t.Errorf("output = %q, want the session header", all)
+			}
+			if tc.want != "" && strings.Count(all, tc.want) != 1 {
+				t.Errorf("output = %q, want %q once", all, tc.want)
+			}
+		})
+	}
+}
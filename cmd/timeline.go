@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/psacc/omnisess/internal/timeline"
+	"github.com/spf13/cobra"
+)
+
+// defaultTimelineSince is the window of timeline without --since.
+const defaultTimelineSince = 7 * 24 * time.Hour
+
+var timelineCmd = &cobra.Command{
+	Use:   "timeline",
+	Short: "Show when sessions were active, and which ran at once",
+	Long: `Show when sessions were active over --since (default 7d), from the
+timestamps of their messages: a heatmap of messages per hour of each day,
+then one lane per session running at once, with a bar for each stretch of
+activity, drawn per tool. A session quiet for over 30 minutes gets a new bar.
+
+With --json, the heatmap and the spans with their lanes are printed for
+charting elsewhere.`,
+	Example: `  omnisess timeline
+  omnisess timeline --since 2d --tool claude
+  omnisess timeline --since 30d --json > timeline.json`,
+	RunE: runTimeline,
+}
+
+func init() {
+	rootCmd.AddCommand(timelineCmd)
+}
+
+func runTimeline(cmd *cobra.Command, args []string) error {
+	opts := getListOptions()
+	if opts.Since == 0 {
+		opts.Since = defaultTimelineSince
+	}
+	to := time.Now()
+	from := to.Add(-opts.Since)
+
+	sources := getSources()
+	listed, warnings := source.ListAll(cmd.Context(), sources, opts, flagTimeout)
+	sessions, getWarnings := source.GetAll(cmd.Context(), sources, listed, flagTimeout)
+	printWarnings(append(warnings, getWarnings...))
+
+	output.RenderTimeline(timeline.Build(sessions, from, to), getFormat())
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/timeline"
+)
+
+// TestRunTimeline lays out the Claude fixture session from the timestamps
+// of its messages, over the default window.
+func TestRunTimeline(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(model.ToolClaude)
+	transcript, _ := claudeFixture(t)
+	start := time.Now().Add(-2 * time.Hour).UTC()
+	for _, ts := range []time.Time{start, start.Add(10 * time.Minute)} {
+		appendString(t, transcript, `{"type":"assistant","timestamp":"`+ts.Format(time.RFC3339)+`","message":{"role":"assistant","content":[{"type":"text","text":"On it."}]}}`+"\n")
+	}
+
+	flagJSON = true
+	origStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	err := runTimeline(newNoopCmd(), nil)
+	os.Stdout = origStdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("runTimeline() error: %v", err)
+	}
+
+	var tl timeline.Timeline
+	if err := json.Unmarshal(out, &tl); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out, err)
+	}
+	if window := tl.To.Sub(tl.From); window != defaultTimelineSince {
+		t.Errorf("window = %s, want %s", window, defaultTimelineSince)
+	}
+	if len(tl.Spans) != 1 || tl.Spans[0].Messages != 2 || tl.Spans[0].Session != "claude:5c3f2742-0000-0000-0000-000000000000" {
+		t.Errorf("spans = %+v, want the fixture's two timed messages", tl.Spans)
+	}
+	if len(tl.Heatmap) != 8 {
+		t.Errorf("heatmap has %d days, want 8", len(tl.Heatmap))
+	}
+
+	silenceOutput(t)
+	flagJSON = false
+	flagSince = "1d"
+	if err := runTimeline(newNoopCmd(), nil); err != nil {
+		t.Errorf("runTimeline(--since 1d) error: %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
-	"sort"
 	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/resume"
+	"github.com/psacc/omnisess/internal/source"
 	"github.com/psacc/omnisess/internal/tui"
 
 	// Register resumers via init() (behind !windows, same as this file).
@@ -68,7 +68,6 @@ func buildToolModes() map[model.Tool][]string {
 }
 
 func runTUI(cmd *cobra.Command, args []string) error {
-	sources := getSources()
 	opts := getListOptions()
 
 	// Apply default limit if none specified.
@@ -76,25 +75,8 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		opts.Limit = defaultTUILimit
 	}
 
-	var all []model.Session
-	for _, s := range sources {
-		sessions, err := s.List(opts)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", s.Name(), err)
-			continue
-		}
-		all = append(all, sessions...)
-	}
-
-	// Sort by UpdatedAt descending.
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].UpdatedAt.After(all[j].UpdatedAt)
-	})
-
-	// Apply limit after merging all sources.
-	if opts.Limit > 0 && len(all) > opts.Limit {
-		all = all[:opts.Limit]
-	}
+	all, warnings := source.ListAll(cmd.Context(), getSources(), opts, flagTimeout)
+	printWarnings(warnings)
 
 	if len(all) == 0 {
 		fmt.Fprintln(os.Stderr, "No sessions found.")
@@ -123,6 +105,12 @@ func handleTUIResult(finalModel tea.Model) error {
 		return nil // user quit without selecting
 	}
 
+	// Sessions synced from another machine have no local project dir or
+	// process to resume; every mode would act on the wrong machine.
+	if sess.Host != "" {
+		return fmt.Errorf("%s is from host %s; resume it on that machine", sess.QualifiedID(), sess.Host)
+	}
+
 	mode := resume.Mode(result.SelectedMode())
 
 	// AoE mode is handled directly (no resumer needed).
@@ -330,6 +330,31 @@ func TestHandleTUIResult_NoResumer(t *testing.T) {
 	_ = mdl
 }
 
+// TestHandleTUIResult_RemoteHost verifies that sessions from another host are
+// never resumed or opened locally.
+func TestHandleTUIResult_RemoteHost(t *testing.T) {
+	mockModes := map[model.Tool][]string{mockResumerTool: {string(resume.ModeResume)}}
+	sess := model.Session{
+		ID:        "dddddddd-4444-4444-4444-444444444444",
+		Tool:      mockResumerTool,
+		Host:      "devvm",
+		Project:   "/tmp/test",
+		UpdatedAt: time.Now(),
+		StartedAt: time.Now(),
+	}
+	m := tui.New([]model.Session{sess}, mockModes)
+	var mdl tea.Model = m
+	mdl, _ = mdl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	err := handleTUIResult(mdl)
+	if err == nil {
+		t.Fatal("handleTUIResult (remote host): expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "host devvm") {
+		t.Errorf("handleTUIResult (remote host): unexpected error message: %v", err)
+	}
+}
+
 // TestHandleTUIResult_ResumerExec verifies that handleTUIResult calls
 // resumer.Exec when a resumer is registered for the session's tool.
 // Uses the mockResumer registered in cmd_test.go init().
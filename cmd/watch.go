@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/psacc/omnisess/internal/hooks"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/output"
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/psacc/omnisess/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagInterval time.Duration
+	flagHooks    bool
+	flagDryRun   bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Follow active sessions as they change",
+	Long: `Follow active sessions as they change. The table of active sessions is
+redrawn whenever a transcript is written (inotify on Linux) and every
+--interval, which also catches sessions whose process exits.
+
+With --ndjson (or --json), one event per line is written instead:
+session_started, session_updated, session_waiting, session_idle,
+session_error and session_ended, each with the session.
+
+With --hooks, watch runs as a daemon that fires the hooks of
+~/.config/omnisess/hooks.yaml on the events of sessions (started, waiting,
+idle, ended, error) from then on, logging each hook it runs; --dry-run only
+logs them.`,
+	Example: `  omnisess watch
+  omnisess watch --ndjson --tool claude | jq -r 'select(.event == "session_waiting") | .session.ID'
+  omnisess watch --hooks --dry-run`,
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&flagInterval, "interval", 2*time.Second, "Check sessions at least this often")
+	watchCmd.Flags().BoolVar(&flagHooks, "hooks", false, "Run the hooks of hooks.yaml on session events instead of printing sessions")
+	watchCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "With --hooks, print the hooks that would fire without running them")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// clearScreen moves the cursor home and clears the terminal, like watch(1).
+const clearScreen = "\033[H\033[2J"
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if flagInterval <= 0 {
+		return fmt.Errorf("invalid --interval %s: must be positive", flagInterval)
+	}
+	if flagDryRun && !flagHooks {
+		return errors.New("--dry-run needs --hooks")
+	}
+	if flagLimit != 0 {
+		// A limit would silently stop watching some active sessions.
+		return errors.New("--limit is not supported by watch: every active session is watched")
+	}
+	var runHooks func(events []watch.Event, sessions []model.Session)
+	if flagHooks {
+		cfg, err := hooks.Load()
+		if err != nil {
+			return err
+		}
+		if len(cfg.Hooks) == 0 {
+			return errors.New("no hooks configured in hooks.yaml")
+		}
+		d := &hooks.Dispatcher{Hooks: cfg.Hooks, DryRun: flagDryRun, Log: os.Stdout}
+		defer d.Wait()
+		first := true
+		runHooks = func(events []watch.Event, sessions []model.Session) {
+			// Sessions already running at start fire no hooks.
+			if first {
+				first = false
+				fmt.Fprintf(os.Stdout, "%d active, checked every %s (Ctrl-C to stop); hooks fire on changes\n",
+					len(sessions), flagInterval)
+				return
+			}
+			d.Handle(cmd.Context(), events)
+		}
+	}
+
+	opts := getListOptions()
+	opts.Active = true
+	sources := getSources()
+
+	// Other machines' sessions are never active: only the local directories
+	// of the selected tools are watched (a tool's host sources share its
+	// name, and watching a directory twice is a no-op).
+	var dirs []string
+	for _, s := range sources {
+		if d, err := source.SessionDirs(s.Name()); err == nil {
+			dirs = append(dirs, d...)
+		}
+	}
+
+	format := getFormat()
+	warned := map[string]bool{}
+	watch.Run(cmd.Context(), watch.Options{
+		Interval: flagInterval,
+		Dirs:     dirs,
+		List: func(ctx context.Context) []model.Session {
+			all, warnings := source.ListAll(ctx, sources, opts, flagTimeout)
+			// A failing source would warn at every check: say it once.
+			var fresh []error
+			for _, w := range warnings {
+				if !warned[w.Error()] {
+					warned[w.Error()] = true
+					fresh = append(fresh, w)
+				}
+			}
+			printWarnings(fresh)
+			return all
+		},
+		Emit: func(events []watch.Event, sessions []model.Session) {
+			if runHooks != nil {
+				runHooks(events, sessions)
+				return
+			}
+			if format != output.FormatTable {
+				for _, e := range events {
+					output.StreamSessionEvent(string(e.Type), e.Time, e.Session)
+				}
+				return
+			}
+			fmt.Fprint(os.Stdout, clearScreen)
+			fmt.Fprintf(os.Stdout, "%d active, checked every %s (Ctrl-C to stop)  %s\n\n",
+				len(sessions), flagInterval, time.Now().Format("15:04:05"))
+			output.RenderSessions(sessions, output.FormatTable)
+		},
+	})
+	return nil
+}
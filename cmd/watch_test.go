@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/detect"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/source"
+	"github.com/spf13/cobra"
+)
+
+// startCmd runs a long-running command (watch, tail) in the background with
+// stdout and stderr piped to the returned channel, one line at a time, until
+// the returned stop is called.
+func startCmd(t *testing.T, run func(*cobra.Command, []string) error, args ...string) (lines <-chan string, stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := newNoopCmd()
+	cmd.SetContext(ctx)
+
+	r, w, _ := os.Pipe()
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, w
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := run(cmd, args); err != nil {
+			t.Errorf("run error: %v", err)
+		}
+	}()
+
+	ch := make(chan string, 100)
+	go func() {
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			ch <- sc.Text()
+		}
+		close(ch)
+	}()
+	return ch, func() {
+		cancel()
+		<-done
+		os.Stdout, os.Stderr = origStdout, origStderr
+		w.Close()
+	}
+}
+
+// nextLine returns the next line containing want, failing after 2s.
+func nextLine(t *testing.T, lines <-chan string, want string) string {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case l := <-lines:
+			if strings.Contains(l, want) {
+				return l
+			}
+		case <-timeout:
+			t.Fatalf("no line containing %q", want)
+		}
+	}
+}
+
+// claudeFixture sets up a Claude root holding one session, whose transcript
+// it returns, and a fake /proc in which a claude process (pidDir) has it open.
+func claudeFixture(t *testing.T) (transcript, pidDir string) {
+	t.Helper()
+	root := t.TempDir()
+	projDir := filepath.Join(root, "projects", "-tmp-proj")
+	if err := os.MkdirAll(projDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "history.jsonl"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	transcript = filepath.Join(projDir, "5c3f2742-0000-0000-0000-000000000000.jsonl")
+	prompt := `{"type":"user","message":{"role":"user","content":"fix the build"},"cwd":"/tmp/proj"}` + "\n"
+	if err := os.WriteFile(transcript, []byte(prompt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	source.SetRoot(model.ToolClaude, root)
+	t.Cleanup(func() { source.SetRoot(model.ToolClaude, "") })
+
+	proc := t.TempDir()
+	pidDir = filepath.Join(proc, "4242")
+	if err := os.MkdirAll(filepath.Join(pidDir, "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pidDir, "cmdline"), []byte("claude\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(transcript, filepath.Join(pidDir, "fd", "3")); err != nil {
+		t.Fatal(err)
+	}
+	detect.SetProcRoot(proc)
+	t.Cleanup(func() { detect.SetProcRoot(detect.DefaultProcRoot) })
+	return transcript, pidDir
+}
+
+func TestRunWatch_InvalidInterval(t *testing.T) {
+	resetFlags()
+	flagInterval = 0
+	if err := runWatch(newNoopCmd(), nil); err == nil || !strings.Contains(err.Error(), "invalid --interval") {
+		t.Errorf("runWatch(--interval 0) error = %v, want invalid --interval", err)
+	}
+}
+
+func TestRunWatch_RejectsLimit(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagLimit = 1
+	if err := runWatch(newNoopCmd(), nil); err == nil || !strings.Contains(err.Error(), "--limit") {
+		t.Errorf("runWatch(--limit 1) error = %v, want --limit rejected", err)
+	}
+}
+
+func TestRunWatch_Table(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(activeSourceName)
+
+	lines, stop := startCmd(t, runWatch)
+	defer stop()
+	nextLine(t, lines, clearScreen+"2 active, checked every 1s")
+	nextLine(t, lines, "test-project")
+}
+
+func TestRunWatch_WarnsOnce(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(errSourceName)
+	flagNDJSON = true
+	flagInterval = 5 * time.Millisecond
+
+	lines, stop := startCmd(t, runWatch)
+	nextLine(t, lines, "warning: test-error-src")
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	for l := range lines {
+		if strings.Contains(l, "warning") {
+			t.Errorf("warning repeated: %q", l)
+		}
+	}
+}
+
+// TestRunWatch_FollowsTranscript drives watch through a Claude transcript
+// and a fake /proc: the session starts with its process, waits when the
+// assistant answers, and ends when the process exits.
+func TestRunWatch_FollowsTranscript(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(model.ToolClaude)
+	flagNDJSON = true
+	flagInterval = 20 * time.Millisecond
+
+	transcript, pidDir := claudeFixture(t)
+
+	lines, stop := startCmd(t, runWatch)
+	defer stop()
+
+	l := nextLine(t, lines, `"event":"session_started"`)
+	if !strings.Contains(l, `"PID":4242`) || !strings.Contains(l, `"State":"working"`) {
+		t.Errorf("started event = %s, want PID 4242 working", l)
+	}
+
+	appendString(t, transcript, `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Fixed."}],"stop_reason":"end_turn"}}`+"\n")
+	l = nextLine(t, lines, `"event":"session_waiting"`)
+	if !strings.Contains(l, `"State":"waiting"`) {
+		t.Errorf("waiting event = %s, want waiting", l)
+	}
+
+	if err := os.RemoveAll(pidDir); err != nil {
+		t.Fatal(err)
+	}
+	l = nextLine(t, lines, `"event":"session_ended"`)
+	if !strings.Contains(l, `"State":"ended"`) {
+		t.Errorf("ended event = %s, want ended", l)
+	}
+}
+
+// writeHooksConfig writes hooks.yaml under a fresh XDG_CONFIG_HOME.
+func writeHooksConfig(t *testing.T, content string) {
+	t.Helper()
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	if content == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(xdg, "omnisess"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "omnisess", "hooks.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunWatch_HooksErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		hooks  bool
+		config string
+		errMsg string
+	}{
+		{"dry run without hooks", false, "", "--dry-run needs --hooks"},
+		{"no hooks", true, "", "no hooks configured"},
+		{"bad hooks", true, "hooks:\n  - on: [done]\n    run: true\n", "unknown event"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetFlags()
+			t.Cleanup(resetFlags)
+			flagHooks, flagDryRun = tc.hooks, !tc.hooks
+			writeHooksConfig(t, tc.config)
+			err := runWatch(newNoopCmd(), nil)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("runWatch() error = %v, want %q", err, tc.errMsg)
+			}
+		})
+	}
+}
+
+// TestRunWatch_Hooks runs watch as a hooks daemon over a Claude transcript:
+// the session running at start fires nothing, its switch to waiting and its
+// end fire the matching hooks.
+func TestRunWatch_Hooks(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	flagTool = string(model.ToolClaude)
+	flagHooks = true
+	flagInterval = 20 * time.Millisecond
+	writeHooksConfig(t, `hooks:
+  - name: notify
+    on: [waiting, ended]
+    tool: claude
+    project: proj
+    run: echo "fired $OMNISESS_EVENT"
+  - on: [started]
+    run: echo never
+`)
+	transcript, pidDir := claudeFixture(t)
+
+	lines, stop := startCmd(t, runWatch)
+	defer stop()
+	nextLine(t, lines, "1 active")
+
+	appendString(t, transcript, `{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Fixed."}],"stop_reason":"end_turn"}}`+"\n")
+	nextLine(t, lines, "waiting claude:5c3f2742-0000-0000-0000-000000000000: run notify")
+	nextLine(t, lines, "fired waiting")
+
+	if err := os.RemoveAll(pidDir); err != nil {
+		t.Fatal(err)
+	}
+	nextLine(t, lines, "fired ended")
+}
@@ -0,0 +1,68 @@
+// Package config loads omnisess's optional user configuration from
+// $XDG_CONFIG_HOME/omnisess/config.yaml (default ~/.config/omnisess).
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/psacc/omnisess/internal/pricing"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the decoded config.yaml. All fields are optional.
+type Config struct {
+	// Roots overrides the data directory per tool, keyed by tool name
+	// ("claude", "codex", "cursor", "gemini"). Same as --<tool>-root.
+	Roots map[string]string `yaml:"roots"`
+
+	// Hosts maps a host name to a directory holding that machine's synced
+	// tool data, laid out like a home directory (e.g. /srv/sessions/devvm
+	// containing .claude and .codex). Same as --host-dir host=dir.
+	Hosts map[string]string `yaml:"hosts"`
+
+	// Prices adds to or overrides the built-in model prices used to
+	// estimate costs the transcripts do not record, keyed by model name
+	// or name prefix.
+	Prices pricing.Table `yaml:"prices"`
+}
+
+// Dir returns the omnisess config directory: $XDG_CONFIG_HOME/omnisess, or
+// ~/.config/omnisess when XDG_CONFIG_HOME is unset.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "omnisess"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, ".config", "omnisess"), nil
+}
+
+// Load reads config.yaml from Dir. A missing file yields an empty Config.
+func Load() (*Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return LoadFile(filepath.Join(dir, "config.yaml"))
+}
+
+// LoadFile reads a config file. A missing file yields an empty Config.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/pricing"
+)
+
+func TestDir(t *testing.T) {
+	t.Run("XDG_CONFIG_HOME", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/xdg")
+		got, err := Dir()
+		if err != nil || got != "/xdg/omnisess" {
+			t.Errorf("Dir() = %q, %v; want /xdg/omnisess", got, err)
+		}
+	})
+
+	t.Run("default under HOME", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", home)
+		got, err := Dir()
+		if want := filepath.Join(home, ".config", "omnisess"); err != nil || got != want {
+			t.Errorf("Dir() = %q, %v; want %q", got, err, want)
+		}
+	})
+
+	t.Run("HOME unresolvable", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("HOME", "")
+		if _, err := Dir(); err == nil {
+			t.Error("expected error when HOME is empty")
+		}
+		if _, err := Load(); err == nil {
+			t.Error("Load: expected error when HOME is empty")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() without file: %v", err)
+	}
+	if len(cfg.Roots) != 0 {
+		t.Errorf("missing file: Roots = %v, want empty", cfg.Roots)
+	}
+
+	dir := filepath.Join(xdg, "omnisess")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "roots:\n  claude: /shared/claude\n  codex: ~/codex\nhosts:\n  devvm: /srv/sessions/devvm\n" +
+		"prices:\n  gpt-5-codex:\n    input: 1.5\n    output: 12\n    cache_read: 0.15\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	if cfg.Roots["claude"] != "/shared/claude" || cfg.Roots["codex"] != "~/codex" {
+		t.Errorf("Roots = %v", cfg.Roots)
+	}
+	if cfg.Hosts["devvm"] != "/srv/sessions/devvm" {
+		t.Errorf("Hosts = %v", cfg.Hosts)
+	}
+	if p := cfg.Prices["gpt-5-codex"]; p != (pricing.Price{Input: 1.5, Output: 12, CacheRead: 0.15}) {
+		t.Errorf("Prices = %v", cfg.Prices)
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("roots: [unclosed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(bad); err == nil {
+		t.Error("expected parse error for malformed YAML")
+	}
+
+	// A directory where the file should be cannot be read.
+	if _, err := LoadFile(dir); err == nil {
+		t.Error("expected read error for a directory")
+	}
+}
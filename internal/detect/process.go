@@ -2,10 +2,11 @@ package detect
 
 import (
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/psacc/omnisess/internal/model"
 )
 
 // ActiveThreshold is how recently a session file must have been modified
@@ -13,31 +14,10 @@ import (
 // user reading time, and gaps between tool calls.
 const ActiveThreshold = 10 * time.Minute
 
-// IsToolRunning checks if a tool's process is currently running.
-// Uses exact process name matching (-x) for claude to avoid matching
-// Claude.app, ShipIt, browser tabs, etc. Falls back to -f for tools
-// where the process name differs from the pattern.
+// IsToolRunning reports whether a process of the tool is running, according
+// to the invocation's process snapshot (see CurrentSnapshot).
 func IsToolRunning(toolName string) bool {
-	switch toolName {
-	case "claude":
-		// Use -x for exact process name match. This avoids matching
-		// Claude.app, ShipIt updater, Cursor extension processes, etc.
-		// The CLI binary is literally named "claude".
-		cmd := exec.Command("pgrep", "-x", "claude")
-		return cmd.Run() == nil
-	case "cursor":
-		// Match the main Cursor Electron process specifically.
-		cmd := exec.Command("pgrep", "-f", "Cursor.app/Contents/MacOS/Cursor")
-		return cmd.Run() == nil
-	case "codex":
-		cmd := exec.Command("pgrep", "-x", "codex")
-		return cmd.Run() == nil
-	case "gemini":
-		cmd := exec.Command("pgrep", "-f", "gemini")
-		return cmd.Run() == nil
-	default:
-		return false
-	}
+	return CurrentSnapshot().Running(toolName)
 }
 
 // IsFileRecentlyModified returns true if the file was modified within the given threshold.
@@ -75,18 +55,96 @@ func isSessionTreeRecentlyModified(sessionFilePath string, threshold time.Durati
 	return false
 }
 
-// toolRunnerFn is the function used by IsSessionActive to check whether a tool
-// process is currently running. Tests may replace it to avoid spawning real
-// processes under a specific pgrep-visible name.
-var toolRunnerFn = IsToolRunning
+// cwdBound are the tools run from the session's project, so that the
+// working directory of their process tells which sessions it may run.
+// Cursor is an IDE: its working directory says nothing about the workspace.
+var cwdBound = map[string]bool{"claude": true, "codex": true, "gemini": true}
 
-// IsSessionActive returns true if the tool is running AND the session file
-// (or its subagent files) was recently modified.
-func IsSessionActive(toolName string, sessionFilePath string) bool {
-	if !toolRunnerFn(toolName) {
-		return false
+// Activity is what active detection found out about a session.
+type Activity struct {
+	Active     bool
+	PID        int // 0 when no single process could be bound
+	Confidence model.Confidence
+}
+
+// SessionActivity reports whether a session is running, and in which
+// process. A process of the tool that has the session file (or one of its
+// subagent files) open runs it, exactly. Otherwise the file must have
+// changed within ActiveThreshold, and a process of the tool must be running
+// in the session's project: with the process snapshot from /proc, a process
+// whose cwd is elsewhere cannot run the session, which tells apart the
+// sessions of several projects. Without cwds (no /proc, Cursor, or an
+// unknown project) any process of the tool will do and no PID is known.
+func SessionActivity(toolName, sessionFilePath, project string) Activity {
+	snap := CurrentSnapshot()
+	var procs []Process
+	for _, p := range snap.Processes {
+		if toolOf(p.Args) == toolName {
+			procs = append(procs, p)
+		}
+	}
+	if len(procs) == 0 {
+		return Activity{}
+	}
+	if pid := openedBy(procs, sessionFilePath); pid != 0 {
+		return Activity{Active: true, PID: pid, Confidence: model.ConfidenceExact}
+	}
+	if !isSessionTreeRecentlyModified(sessionFilePath, ActiveThreshold) {
+		return Activity{}
+	}
+	heuristic := Activity{Active: true, Confidence: model.ConfidenceHeuristic}
+	if !snap.Detailed || !cwdBound[toolName] || project == "" {
+		return heuristic
+	}
+	// A cwd read from /proc has its symlinks resolved.
+	dir := filepath.Clean(project)
+	if resolved, err := filepath.EvalSymlinks(project); err == nil {
+		dir = resolved
+	}
+	unknown := false
+	for _, p := range procs {
+		switch p.CWD {
+		case "":
+			unknown = true
+		case dir:
+			heuristic.PID = p.PID
+			return heuristic
+		}
+	}
+	if unknown {
+		return heuristic
+	}
+	return Activity{}
+}
+
+// MarkActive sets the Active, PID and Confidence of a local session read
+// from sessionFilePath, by SessionActivity, and its State. turn reads the
+// source's transcript tail for running sessions; nil when it cannot tell.
+func MarkActive(sess *model.Session, sessionFilePath string, turn func(path string) Turn) {
+	a := SessionActivity(string(sess.Tool), sessionFilePath, sess.Project)
+	sess.Active, sess.PID, sess.Confidence = a.Active, a.PID, a.Confidence
+	markState(sess, sessionFilePath, turn)
+}
+
+// openedBy returns the first process with the session file or one of its
+// subagent files (<session>/subagents/*.jsonl) open, or 0.
+func openedBy(procs []Process, sessionFilePath string) int {
+	path := sessionFilePath
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	subagents := strings.TrimSuffix(path, ".jsonl") + string(filepath.Separator)
+	for _, p := range procs {
+		for _, f := range p.Files {
+			if f == path || strings.HasPrefix(f, subagents) {
+				return p.PID
+			}
+		}
 	}
-	return isSessionTreeRecentlyModified(sessionFilePath, ActiveThreshold)
+	return 0
 }
 
 // Truncate returns s truncated to maxLen with "..." appended if needed.
@@ -0,0 +1,230 @@
+package detect
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Process is a running process as seen in a Snapshot.
+type Process struct {
+	PID  int
+	Args []string // argv; split on spaces when read from ps
+
+	// For the processes of known tools read from /proc: the working
+	// directory and the files open, which bind the process to a session.
+	CWD   string
+	Files []string
+}
+
+// Snapshot is the list of processes running at one moment. Taking one costs
+// a read of /proc (or one ps run where there is no /proc), so every session
+// of an invocation is checked against the same snapshot.
+type Snapshot struct {
+	Processes []Process
+	// Detailed is set when the snapshot was read from /proc, so tool
+	// processes carry their CWD and Files.
+	Detailed bool
+}
+
+// DefaultProcRoot is where processes are read from on Linux.
+const DefaultProcRoot = "/proc"
+
+var (
+	snapshotMu sync.Mutex
+	procRoot   = DefaultProcRoot
+	current    *Snapshot
+)
+
+// psOutputFn lists processes as "pid command..." lines where there is no
+// /proc (macOS). Tests may replace it.
+var psOutputFn = func() ([]byte, error) {
+	return exec.Command("ps", "-axo", "pid=,command=").Output()
+}
+
+// SetProcRoot makes snapshots read processes from dir, laid out like /proc
+// (dir/<pid>/cmdline), and drops the current snapshot. Tests use it to fake
+// running processes.
+func SetProcRoot(dir string) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	procRoot = dir
+	current = nil
+}
+
+// Refresh drops the current snapshot, so that the next check sees the
+// processes running then. Long-running commands (watch) call it before each
+// round of checks.
+func Refresh() {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	current = nil
+}
+
+// CurrentSnapshot returns the snapshot shared by every source of this
+// invocation, taking it on first use.
+func CurrentSnapshot() *Snapshot {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	if current == nil {
+		current = takeSnapshot(procRoot)
+	}
+	return current
+}
+
+// takeSnapshot reads the processes under root, or from ps when root cannot
+// be read. A snapshot that cannot be taken at all is empty: nothing is
+// reported active.
+func takeSnapshot(root string) *Snapshot {
+	if procs, err := readProc(root); err == nil {
+		return &Snapshot{Processes: procs, Detailed: true}
+	}
+	out, err := psOutputFn()
+	if err != nil {
+		return &Snapshot{}
+	}
+	return &Snapshot{Processes: parsePS(out)}
+}
+
+// readProc reads the command line of every process under root, and the
+// working directory and open files of those of known tools. Kernel threads
+// (empty cmdline) and processes that exit meanwhile are skipped; the cwd and
+// fds of other users' processes are unreadable and left empty.
+func readProc(root string) ([]Process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, e.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		p := Process{PID: pid, Args: strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")}
+		if toolOf(p.Args) != "" {
+			dir := filepath.Join(root, e.Name())
+			p.CWD, _ = os.Readlink(filepath.Join(dir, "cwd"))
+			p.Files = openFiles(filepath.Join(dir, "fd"))
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// openFiles returns the paths of the regular files behind the fd links in
+// dir; sockets, pipes and the like ("socket:[123]") are not paths.
+func openFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err == nil && filepath.IsAbs(target) {
+			files = append(files, target)
+		}
+	}
+	return files
+}
+
+// parsePS parses the output of `ps -axo pid=,command=`.
+func parsePS(out []byte) []Process {
+	var procs []Process
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		procs = append(procs, Process{PID: pid, Args: fields[1:]})
+	}
+	return procs
+}
+
+// toolNames are the executable base names of each tool's processes on
+// Linux and macOS. They also match the script run by an interpreter (node
+// --no-warnings /usr/local/bin/gemini). Claude Code and Codex name their process after
+// the CLI, so Claude.app does not count; Cursor is the Electron app
+// (Cursor.app/Contents/MacOS/Cursor on macOS, a "cursor" binary or AppImage
+// on Linux) or its cursor-agent CLI.
+var toolNames = map[string][]string{
+	"claude": {"claude"},
+	"codex":  {"codex"},
+	"cursor": {"cursor", "Cursor", "cursor-agent"},
+	"gemini": {"gemini"},
+}
+
+// interpreters run a script named by their first argument.
+var interpreters = map[string]bool{"node": true, "bun": true, "deno": true}
+
+// Running reports whether a process of the tool is in the snapshot.
+func (s *Snapshot) Running(toolName string) bool {
+	names, ok := toolNames[toolName]
+	if !ok {
+		return false
+	}
+	for _, proc := range s.Processes {
+		if matchesName(proc.Args, names) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolOf returns the known tool a process runs, or "".
+func toolOf(args []string) string {
+	for tool, names := range toolNames {
+		if matchesName(args, names) {
+			return tool
+		}
+	}
+	return ""
+}
+
+// matchesName reports whether the process runs one of names, directly or
+// as an interpreted script.
+func matchesName(args, names []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	run := []string{execName(args[0])}
+	if interpreters[run[0]] {
+		// The script is the first argument that is not an option.
+		for _, a := range args[1:] {
+			if !strings.HasPrefix(a, "-") {
+				run = append(run, execName(a))
+				break
+			}
+		}
+	}
+	for _, r := range run {
+		if slices.Contains(names, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// execName is the base name of an executable or script, without a script
+// extension: "/usr/bin/node" → "node", ".../bin/codex.js" → "codex".
+func execName(arg string) string {
+	base := path.Base(filepath.ToSlash(arg))
+	for _, ext := range []string{".js", ".mjs", ".cjs"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	return base
+}
@@ -0,0 +1,200 @@
+package detect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// useProcRoot fakes /proc with one cmdline file per pid for the test.
+func useProcRoot(t *testing.T, cmdlines map[int]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for pid, cmdline := range cmdlines {
+		dir := filepath.Join(root, strconv.Itoa(pid))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetProcRoot(root)
+	t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+	return root
+}
+
+// addProcLinks gives a fake process a cwd (unless cwd is "") and open files,
+// plus a socket, as /proc links.
+func addProcLinks(t *testing.T, root string, pid int, cwd string, files ...string) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if cwd != "" {
+		if err := os.Symlink(cwd, filepath.Join(dir, "cwd")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fd := filepath.Join(dir, "fd")
+	if err := os.MkdirAll(fd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	targets := append([]string{"socket:[4242]"}, files...)
+	for i, target := range targets {
+		if err := os.Symlink(target, filepath.Join(fd, strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// usePS replaces the ps fallback for the test.
+func usePS(t *testing.T, out string, err error) {
+	t.Helper()
+	orig := psOutputFn
+	psOutputFn = func() ([]byte, error) { return []byte(out), err }
+	t.Cleanup(func() { psOutputFn = orig })
+}
+
+// ---------------------------------------------------------------------------
+// Reading /proc
+// ---------------------------------------------------------------------------
+
+func TestCurrentSnapshot_ReadsProc(t *testing.T) {
+	root := useProcRoot(t, map[int]string{
+		1:   "/sbin/init\x00",
+		7:   "", // kernel thread
+		300: "node\x00--no-warnings\x00/usr/local/bin/gemini\x00",
+	})
+	addProcLinks(t, root, 1, "/")
+	addProcLinks(t, root, 300, "/home/u/proj", "/home/u/.gemini/tmp/chats/session.json")
+	// Entries that are not processes, or whose cmdline vanished.
+	if err := os.MkdirAll(filepath.Join(root, "self-not-a-pid"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "99"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	snap := CurrentSnapshot()
+	if len(snap.Processes) != 2 {
+		t.Fatalf("got %d processes, want 2: %+v", len(snap.Processes), snap.Processes)
+	}
+	byPID := map[int]Process{}
+	for _, p := range snap.Processes {
+		byPID[p.PID] = p
+	}
+	// Only the processes of tools are looked into.
+	if got := byPID[1]; len(got.Args) != 1 || got.Args[0] != "/sbin/init" || got.CWD != "" || got.Files != nil {
+		t.Errorf("pid 1 = %+v", got)
+	}
+	got := byPID[300]
+	if len(got.Args) != 3 || got.CWD != "/home/u/proj" {
+		t.Errorf("pid 300 = %+v, want 3 args and the cwd", got)
+	}
+	if len(got.Files) != 1 || got.Files[0] != "/home/u/.gemini/tmp/chats/session.json" {
+		t.Errorf("pid 300 files = %q, want the session file only", got.Files)
+	}
+	if !snap.Detailed {
+		t.Error("expected a snapshot from /proc to be detailed")
+	}
+}
+
+func TestCurrentSnapshot_SharedUntilReset(t *testing.T) {
+	root := useProcRoot(t, map[int]string{10: "claude\x00"})
+	first := CurrentSnapshot()
+
+	// A process started after the snapshot is not seen by this invocation.
+	if err := os.MkdirAll(filepath.Join(root, "11"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "11", "cmdline"), []byte("codex\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if CurrentSnapshot() != first || IsToolRunning("codex") {
+		t.Error("expected the snapshot to be taken once")
+	}
+
+	Refresh()
+	if !IsToolRunning("codex") {
+		t.Error("expected a new snapshot after Refresh")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// ps fallback
+// ---------------------------------------------------------------------------
+
+func TestCurrentSnapshot_FallsBackToPS(t *testing.T) {
+	SetProcRoot(filepath.Join(t.TempDir(), "no-proc"))
+	t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+	usePS(t, "    1 /sbin/launchd\n"+
+		"  512 /Applications/Cursor.app/Contents/MacOS/Cursor --type=renderer\n"+
+		"\n"+
+		"  PID COMMAND\n"+
+		"  600\n", nil)
+
+	snap := CurrentSnapshot()
+	if len(snap.Processes) != 2 {
+		t.Fatalf("got %d processes, want 2: %+v", len(snap.Processes), snap.Processes)
+	}
+	if snap.Processes[1].PID != 512 || !snap.Running("cursor") {
+		t.Errorf("expected Cursor.app as pid 512, got %+v", snap.Processes[1])
+	}
+}
+
+// TestPSOutputFn runs the real ps where there is one; its output depends on
+// the machine, so only a successful run is checked for a process.
+func TestPSOutputFn(t *testing.T) {
+	out, err := psOutputFn()
+	if err == nil && len(parsePS(out)) == 0 {
+		t.Errorf("ps succeeded but listed no processes: %q", out)
+	}
+}
+
+func TestCurrentSnapshot_PSFails(t *testing.T) {
+	SetProcRoot(filepath.Join(t.TempDir(), "no-proc"))
+	t.Cleanup(func() { SetProcRoot(DefaultProcRoot) })
+	usePS(t, "", errors.New("ps: not found"))
+
+	if snap := CurrentSnapshot(); len(snap.Processes) != 0 {
+		t.Errorf("expected an empty snapshot, got %+v", snap.Processes)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Matching tools
+// ---------------------------------------------------------------------------
+
+func TestSnapshot_Running(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		tool string
+		want bool
+	}{
+		{"claude binary", []string{"/home/u/.local/bin/claude"}, "claude", true},
+		{"claude via node", []string{"node", "/usr/lib/node_modules/.bin/claude"}, "claude", true},
+		{"Claude.app is not the CLI", []string{"/Applications/Claude.app/Contents/MacOS/Claude"}, "claude", false},
+		{"claude in an argument", []string{"vim", "claude"}, "claude", false},
+		{"codex binary", []string{"codex", "exec"}, "codex", true},
+		{"codex js", []string{"/usr/bin/node", "/opt/codex/bin/codex.js"}, "codex", true},
+		{"cursor on linux", []string{"/usr/share/cursor/cursor", "--no-sandbox"}, "cursor", true},
+		{"cursor appimage", []string{"/tmp/.mount_Cursor/Cursor"}, "cursor", true},
+		{"cursor agent", []string{"cursor-agent"}, "cursor", true},
+		{"cursor on macos", []string{"/Applications/Cursor.app/Contents/MacOS/Cursor"}, "cursor", true},
+		{"gemini via bun", []string{"bun", "/home/u/.bun/bin/gemini"}, "gemini", true},
+		{"gemini in a grep", []string{"grep", "gemini"}, "gemini", false},
+		{"lone interpreter", []string{"node"}, "gemini", false},
+		{"no args", nil, "claude", false},
+		{"unknown tool", []string{"claude"}, "aider", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snap := &Snapshot{Processes: []Process{{PID: 1, Args: tt.args}}}
+			if got := snap.Running(tt.tool); got != tt.want {
+				t.Errorf("Running(%q) with %q = %v, want %v", tt.tool, tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package detect
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// Turn is what the last entries of a transcript say about the agent's turn.
+// Each source reads its own format into a Turn (see TailLines).
+type Turn int
+
+const (
+	TurnUnknown     Turn = iota // nothing telling, or the format is not read
+	TurnRunning                 // the agent is thinking or was handed a tool result
+	TurnToolPending             // a tool call has no result yet
+	TurnApproval                // the agent asked the user to approve a tool call
+	TurnDone                    // the agent ended its turn, or the user interrupted it
+	TurnFailed                  // the turn ended in an error
+)
+
+// PermissionWait is how long a tool call may go without a result before the
+// agent is taken to be blocked on a permission prompt, which transcripts do
+// not record. Slow tools (builds, test runs) look the same.
+const PermissionWait = 30 * time.Second
+
+// IdleAfter is how long a running session may go without writing to its
+// transcript before it counts as idle rather than working or waiting. A
+// working agent writes every few seconds, so this is shorter than
+// model.IdleThreshold, the pause still counted as time on task: a quiet
+// agent is reported long before its session stops counting as active.
+const IdleAfter = 5 * time.Minute
+
+// tailBytes is how much of the end of a transcript TailLines reads: enough
+// for the last few entries, however long the transcript.
+const tailBytes = 64 * 1024
+
+// TailLines returns the lines in the last 64KB of the file, last line
+// first, without reading the rest. The partial line the window starts in is
+// left out; the last line may be one still being written, which callers
+// parsing JSON will skip. It returns nil if the file cannot be read.
+func TailLines(path string) [][]byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	// A failed seek or read leaves fewer bytes, hence fewer lines.
+	size, _ := f.Seek(0, io.SeekEnd)
+	start := max(size-tailBytes, 0)
+	buf := make([]byte, size-start)
+	n, _ := f.ReadAt(buf, start)
+	buf = buf[:n]
+
+	if start > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			return nil
+		}
+		buf = buf[i+1:]
+	}
+
+	var lines [][]byte
+	for len(buf) > 0 {
+		i := bytes.LastIndexByte(buf, '\n')
+		if line := bytes.TrimSpace(buf[i+1:]); len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if i < 0 {
+			break
+		}
+		buf = buf[:i]
+	}
+	return lines
+}
+
+// markState sets the State of a session whose Active is already known. An
+// inactive session has ended. A running one is classified by turn, which
+// reads the transcript tail and is only called for running sessions (nil
+// when the source cannot tell), and by how long ago the transcript changed.
+func markState(sess *model.Session, sessionFilePath string, turn func(path string) Turn) {
+	if !sess.Active {
+		sess.State = model.StateEnded
+		return
+	}
+	t := TurnUnknown
+	if turn != nil {
+		t = turn(sessionFilePath)
+	}
+	var quiet time.Duration
+	if info, err := os.Stat(sessionFilePath); err == nil {
+		quiet = time.Since(info.ModTime())
+	}
+	sess.State = stateOf(t, quiet)
+}
+
+// stateOf classifies a running session by its turn and how long its
+// transcript has been quiet.
+func stateOf(t Turn, quiet time.Duration) model.State {
+	switch t {
+	case TurnFailed:
+		return model.StateError
+	case TurnApproval:
+		return model.StateWaiting
+	case TurnToolPending:
+		if quiet >= PermissionWait {
+			return model.StateWaiting
+		}
+		return model.StateWorking
+	case TurnDone:
+		if quiet >= IdleAfter {
+			return model.StateIdle
+		}
+		return model.StateWaiting
+	}
+	if quiet >= IdleAfter {
+		return model.StateIdle
+	}
+	return model.StateWorking
+}
@@ -0,0 +1,113 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// TailLines
+// ---------------------------------------------------------------------------
+
+func TestTailLines(t *testing.T) {
+	long := strings.Repeat("x", tailBytes)
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"last line first", "a\nb\nc\n", []string{"c", "b", "a"}},
+		{"line being written", "a\nb\n{\"partial", []string{"{\"partial", "b", "a"}},
+		{"blank lines", "a\n\n  \nb\n", []string{"b", "a"}},
+		{"no newline", "{}", []string{"{}"}},
+		{"empty", "", nil},
+		{"window starts mid-line", "first\n" + long + "\nlast\n", []string{"last"}},
+		{"one line longer than the window", long + "\n", nil},
+		{"no newline in the window", long + "xx", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "s.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, l := range TailLines(path) {
+				got = append(got, string(l))
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("TailLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := TailLines(filepath.Join(t.TempDir(), "missing.jsonl")); got != nil {
+		t.Errorf("TailLines(missing) = %q, want nil", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// States
+// ---------------------------------------------------------------------------
+
+func TestStateOf(t *testing.T) {
+	tests := []struct {
+		turn  Turn
+		quiet time.Duration
+		want  model.State
+	}{
+		{TurnFailed, 0, model.StateError},
+		{TurnApproval, 0, model.StateWaiting},
+		{TurnToolPending, time.Second, model.StateWorking},
+		{TurnToolPending, PermissionWait, model.StateWaiting},
+		{TurnToolPending, time.Hour, model.StateWaiting},
+		{TurnDone, time.Second, model.StateWaiting},
+		{TurnDone, IdleAfter, model.StateIdle},
+		{TurnRunning, time.Minute, model.StateWorking},
+		{TurnRunning, IdleAfter, model.StateIdle},
+		{TurnUnknown, time.Second, model.StateWorking},
+		{TurnUnknown, time.Hour, model.StateIdle},
+	}
+	for _, tt := range tests {
+		if got := stateOf(tt.turn, tt.quiet); got != tt.want {
+			t.Errorf("stateOf(%d, %s) = %q, want %q", tt.turn, tt.quiet, got, tt.want)
+		}
+	}
+}
+
+func TestMarkState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var read []string
+	turn := func(p string) Turn {
+		read = append(read, p)
+		return TurnDone
+	}
+
+	sess := model.Session{}
+	markState(&sess, path, turn)
+	if sess.State != model.StateEnded || len(read) != 0 {
+		t.Errorf("inactive: State = %q after %d tail reads, want ended without reading", sess.State, len(read))
+	}
+
+	sess = model.Session{Active: true}
+	markState(&sess, path, turn)
+	if sess.State != model.StateWaiting || len(read) != 1 {
+		t.Errorf("active: State = %q, want waiting from the tail", sess.State)
+	}
+
+	// No turn reader: recent writes mean work; a file that cannot be
+	// stat'ed has no age.
+	sess = model.Session{Active: true}
+	markState(&sess, filepath.Join(t.TempDir(), "gone.jsonl"), nil)
+	if sess.State != model.StateWorking {
+		t.Errorf("unknown turn: State = %q, want working", sess.State)
+	}
+}
@@ -0,0 +1,217 @@
+// Package hooks runs user commands when sessions change, as configured in
+// $XDG_CONFIG_HOME/omnisess/hooks.yaml (default ~/.config/omnisess):
+//
+//	hooks:
+//	  - name: claude-waiting
+//	    on: [waiting]
+//	    tool: claude
+//	    run: notify-send "Claude is waiting" "$(jq -r .Title)"
+//	  - on: [ended, error]
+//	    tool: codex
+//	    project: ~/src/*
+//	    run: ~/bin/codex-done.sh
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/psacc/omnisess/internal/config"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/watch"
+	"gopkg.in/yaml.v3"
+)
+
+// Event is what a hook fires on.
+type Event string
+
+const (
+	Started Event = "started" // a session became active
+	Waiting Event = "waiting" // it waits for the user: its turn is over, or a tool needs approval
+	Idle    Event = "idle"    // it went quiet
+	Ended   Event = "ended"   // its process exited
+	Error   Event = "error"   // its turn failed
+)
+
+// events maps the watch events that fire hooks to theirs.
+var events = map[watch.EventType]Event{
+	watch.SessionStarted: Started,
+	watch.SessionWaiting: Waiting,
+	watch.SessionIdle:    Idle,
+	watch.SessionEnded:   Ended,
+	watch.SessionError:   Error,
+}
+
+// Hook runs a shell command on some events of the sessions it matches.
+type Hook struct {
+	// Name identifies the hook in logs; the command when empty.
+	Name string `yaml:"name"`
+	// On lists the events the hook fires on. Required.
+	On []Event `yaml:"on"`
+	// Tool restricts the hook to one tool's sessions ("claude", ...).
+	Tool string `yaml:"tool"`
+	// Project restricts the hook to sessions whose project path matches
+	// this glob (filepath.Match; a leading ~/ is the home directory). A
+	// pattern without a slash matches the project's directory name.
+	Project string `yaml:"project"`
+	// Run is the command, run with sh -c. It reads the session as JSON on
+	// stdin, and finds the event in $OMNISESS_EVENT and the session's
+	// tool:id in $OMNISESS_SESSION. Required.
+	Run string `yaml:"run"`
+}
+
+// Config is the decoded hooks.yaml.
+type Config struct {
+	Hooks []Hook `yaml:"hooks"`
+}
+
+// Load reads hooks.yaml from config.Dir. A missing file yields no hooks.
+func Load() (*Config, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("load hooks: %w", err)
+	}
+	return LoadFile(filepath.Join(dir, "hooks.yaml"))
+}
+
+// LoadFile reads and checks a hooks file. A missing file yields no hooks.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hooks %s: %w", path, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse hooks %s: %w", path, err)
+	}
+	for i := range cfg.Hooks {
+		if err := cfg.Hooks[i].check(); err != nil {
+			return nil, fmt.Errorf("hooks %s: hook %d: %w", path, i+1, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// check validates h and expands the ~ of its Project.
+func (h *Hook) check() error {
+	if strings.TrimSpace(h.Run) == "" {
+		return errors.New("run is required")
+	}
+	if len(h.On) == 0 {
+		return errors.New("on is required: started, waiting, idle, ended or error")
+	}
+	for _, e := range h.On {
+		switch e {
+		case Started, Waiting, Idle, Ended, Error:
+		default:
+			return fmt.Errorf("unknown event %q: want started, waiting, idle, ended or error", e)
+		}
+	}
+	if rest, ok := strings.CutPrefix(h.Project, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("expand project %q: %w", h.Project, err)
+		}
+		h.Project = filepath.Join(home, rest)
+	}
+	if _, err := filepath.Match(h.Project, ""); err != nil {
+		return fmt.Errorf("bad project pattern %q: %w", h.Project, err)
+	}
+	return nil
+}
+
+// String names the hook for logs.
+func (h Hook) String() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return h.Run
+}
+
+// Matches reports whether h fires on e for s.
+func (h Hook) Matches(e Event, s model.Session) bool {
+	if !slices.Contains(h.On, e) || (h.Tool != "" && model.Tool(h.Tool) != s.Tool) {
+		return false
+	}
+	if h.Project == "" {
+		return true
+	}
+	name := s.Project
+	if !strings.Contains(h.Project, "/") {
+		name = filepath.Base(s.Project)
+	}
+	// The pattern was checked when loaded.
+	ok, _ := filepath.Match(h.Project, name)
+	return ok
+}
+
+// Dispatcher fires the hooks of each watch event.
+type Dispatcher struct {
+	Hooks []Hook
+	// DryRun logs the hooks that would fire without running them.
+	DryRun bool
+	// Log receives a line per hook fired.
+	Log io.Writer
+
+	wg sync.WaitGroup
+}
+
+// Handle fires the hooks matching events. Commands run in the background,
+// each until it exits or ctx is done; a failure is logged as a warning.
+func (d *Dispatcher) Handle(ctx context.Context, evs []watch.Event) {
+	for _, we := range evs {
+		e, ok := events[we.Type]
+		if !ok {
+			continue
+		}
+		for _, h := range d.Hooks {
+			if !h.Matches(e, we.Session) {
+				continue
+			}
+			verb := "run"
+			if d.DryRun {
+				verb = "would run"
+			}
+			fmt.Fprintf(d.Log, "%s %s %s: %s %s\n",
+				we.Time.Local().Format(time.DateTime), e, we.Session.QualifiedID(), verb, h)
+			if !d.DryRun {
+				d.wg.Go(func() {
+					if err := run(ctx, h, e, we.Session); err != nil {
+						log.Printf("warning: hook %s for %s: %v", h, we.Session.QualifiedID(), err)
+					}
+				})
+			}
+		}
+	}
+}
+
+// Wait waits for the commands started by Handle to exit.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// run runs h's command for event e of s, with s as JSON on stdin. The
+// command's output goes to omnisess's.
+func run(ctx context.Context, h Hook, e Event, s model.Session) error {
+	data, _ := json.Marshal(s) // a Session has nothing that cannot be encoded
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Run)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), "OMNISESS_EVENT="+string(e), "OMNISESS_SESSION="+s.QualifiedID())
+	return cmd.Run()
+}
@@ -0,0 +1,205 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/watch"
+)
+
+// ---------------------------------------------------------------------------
+// Load / LoadFile
+// ---------------------------------------------------------------------------
+
+func writeHooks(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("HOME", "/home/me")
+
+	cfg, err := Load()
+	if err != nil || len(cfg.Hooks) != 0 {
+		t.Fatalf("Load() without file = %+v, %v; want no hooks", cfg, err)
+	}
+
+	dir := filepath.Join(xdg, "omnisess")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := `hooks:
+  - name: claude-waiting
+    on: [waiting]
+    tool: claude
+    run: notify-send waiting
+  - on: [ended, error]
+    project: ~/src/*
+    run: ./done.sh
+`
+	if err := os.WriteFile(filepath.Join(dir, "hooks.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load(): %v", err)
+	}
+	if len(cfg.Hooks) != 2 {
+		t.Fatalf("Hooks = %+v, want 2", cfg.Hooks)
+	}
+	if h := cfg.Hooks[0]; h.Name != "claude-waiting" || h.Tool != "claude" || len(h.On) != 1 || h.On[0] != Waiting {
+		t.Errorf("hook 1 = %+v", h)
+	}
+	if h := cfg.Hooks[1]; h.Project != "/home/me/src/*" {
+		t.Errorf("hook 2 Project = %q, want ~ expanded", h.Project)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "")
+	if _, err := Load(); err == nil {
+		t.Error("Load(): expected error when HOME is empty")
+	}
+}
+
+func TestLoadFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"bad yaml", "hooks: [", "parse hooks"},
+		{"no run", "hooks:\n  - on: [idle]\n", "run is required"},
+		{"no events", "hooks:\n  - run: true\n", "on is required"},
+		{"unknown event", "hooks:\n  - on: [finished]\n    run: true\n", `hook 1: unknown event "finished"`},
+		{"bad pattern", "hooks:\n  - on: [idle]\n    project: \"[\"\n    run: true\n", "bad project pattern"},
+		{"no home", "hooks:\n  - on: [idle]\n    project: ~/src\n    run: true\n", "expand project"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("HOME", "")
+			_, err := LoadFile(writeHooks(t, tc.content))
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("LoadFile() error = %v, want %q", err, tc.errMsg)
+			}
+		})
+	}
+
+	t.Run("unreadable", func(t *testing.T) {
+		if _, err := LoadFile(t.TempDir()); err == nil || !strings.Contains(err.Error(), "read hooks") {
+			t.Errorf("LoadFile(dir) error = %v, want read error", err)
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Hook
+// ---------------------------------------------------------------------------
+
+func TestHook_String(t *testing.T) {
+	if got := (Hook{Name: "n", Run: "cmd"}).String(); got != "n" {
+		t.Errorf("String() = %q, want the name", got)
+	}
+	if got := (Hook{Run: "cmd"}).String(); got != "cmd" {
+		t.Errorf("String() = %q, want the command", got)
+	}
+}
+
+func TestHook_Matches(t *testing.T) {
+	sess := model.Session{Tool: model.ToolClaude, Project: "/home/me/src/omnisess"}
+	tests := []struct {
+		name string
+		hook Hook
+		e    Event
+		want bool
+	}{
+		{"event", Hook{On: []Event{Waiting, Error}}, Error, true},
+		{"other event", Hook{On: []Event{Waiting}}, Ended, false},
+		{"tool", Hook{On: []Event{Waiting}, Tool: "claude"}, Waiting, true},
+		{"other tool", Hook{On: []Event{Waiting}, Tool: "codex"}, Waiting, false},
+		{"path glob", Hook{On: []Event{Waiting}, Project: "/home/me/src/*"}, Waiting, true},
+		{"path glob elsewhere", Hook{On: []Event{Waiting}, Project: "/work/*"}, Waiting, false},
+		{"name glob", Hook{On: []Event{Waiting}, Project: "omni*"}, Waiting, true},
+		{"other name", Hook{On: []Event{Waiting}, Project: "src"}, Waiting, false},
+	}
+	for _, tc := range tests {
+		if got := tc.hook.Matches(tc.e, sess); got != tc.want {
+			t.Errorf("%s: Matches() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Dispatcher
+// ---------------------------------------------------------------------------
+
+func TestDispatcher(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	sess := model.Session{ID: "5c3f2742", Tool: model.ToolClaude, Title: "fix the build"}
+	evs := []watch.Event{
+		{Type: watch.SessionUpdated, Time: at, Session: sess},
+		{Type: watch.SessionWaiting, Time: at, Session: sess},
+	}
+	hooks := []Hook{
+		{Name: "record", On: []Event{Waiting}, Run: `cat > "` + dir + `/stdin.json"; echo "$OMNISESS_EVENT $OMNISESS_SESSION" > "` + dir + `/env"`},
+		{Name: "fails", On: []Event{Waiting, Ended}, Run: "exit 3"},
+		{Name: "never", On: []Event{Ended}, Run: "touch " + dir + "/never"},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		var logged bytes.Buffer
+		d := &Dispatcher{Hooks: hooks, DryRun: true, Log: &logged}
+		d.Handle(context.Background(), evs)
+		d.Wait()
+		if got := strings.Count(logged.String(), "waiting claude:5c3f2742: would run"); got != 2 {
+			t.Errorf("log = %q, want 2 hooks that would run", logged.String())
+		}
+		if _, err := os.Stat(filepath.Join(dir, "env")); err == nil {
+			t.Error("dry run ran a hook")
+		}
+	})
+
+	t.Run("run", func(t *testing.T) {
+		var warnings bytes.Buffer
+		log.SetOutput(&warnings)
+		t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+		var logged bytes.Buffer
+		d := &Dispatcher{Hooks: hooks, Log: &logged}
+		d.Handle(context.Background(), evs)
+		d.Wait()
+
+		if !strings.Contains(logged.String(), "waiting claude:5c3f2742: run record") {
+			t.Errorf("log = %q", logged.String())
+		}
+		env, _ := os.ReadFile(filepath.Join(dir, "env"))
+		if string(env) != "waiting claude:5c3f2742\n" {
+			t.Errorf("hook env = %q", env)
+		}
+		var got model.Session
+		data, _ := os.ReadFile(filepath.Join(dir, "stdin.json"))
+		if err := json.Unmarshal(data, &got); err != nil || got.Title != "fix the build" {
+			t.Errorf("hook stdin = %q (%v), want the session", data, err)
+		}
+		if !strings.Contains(warnings.String(), "warning: hook fails for claude:5c3f2742: exit status 3") {
+			t.Errorf("warnings = %q", warnings.String())
+		}
+		if _, err := os.Stat(filepath.Join(dir, "never")); err == nil {
+			t.Error("hook on another event ran")
+		}
+	})
+}
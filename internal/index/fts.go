@@ -0,0 +1,239 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// The full-text index stores one FTS5 row per message. The trigram tokenizer
+// keeps search semantics those of the scan it replaces: case-insensitive
+// substring matching, for terms of at least MinTermLen characters.
+const ftsSchema = `
+CREATE TABLE IF NOT EXISTS transcripts (
+	path    TEXT    PRIMARY KEY,
+	root    TEXT    NOT NULL,
+	tool    TEXT    NOT NULL,
+	session TEXT    NOT NULL,
+	size    INTEGER NOT NULL,
+	mtime   INTEGER NOT NULL,
+	offset  INTEGER NOT NULL,
+	cursor  TEXT    NOT NULL
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS messages USING fts5(
+	content,
+	root    UNINDEXED,
+	tool    UNINDEXED,
+	session UNINDEXED,
+	path    UNINDEXED,
+	msg     UNINDEXED,
+	role    UNINDEXED,
+	ts      UNINDEXED,
+	tokenize = 'trigram'
+)`
+
+// MinTermLen is the shortest term the trigram tokenizer can match.
+const MinTermLen = 3
+
+// Transcript identifies a session file in the full-text index.
+type Transcript struct {
+	Root    string // data directory of the source that owns the file
+	Tool    string
+	Session string
+	Path    string
+}
+
+// Doc is one indexed message.
+type Doc struct {
+	Message   int // position of the message in the session's parsed transcript
+	Role      string
+	Content   string
+	Timestamp time.Time
+}
+
+// Cursor carries a LineParser's position across incremental syncs.
+type Cursor struct {
+	Message int    // index the next new message will get
+	Role    string // role of the last message, for lines merged into it
+}
+
+// LineParser turns one complete, non-empty JSONL line into the docs it adds,
+// advancing cur for every message the line creates.
+type LineParser func(line []byte, cur *Cursor) []Doc
+
+// transcriptState is what the index remembers about a transcript.
+type transcriptState struct {
+	found  bool
+	size   int64
+	mtime  int64
+	offset int64
+	cursor Cursor
+}
+
+func (ix *Index) transcriptState(path string) (transcriptState, error) {
+	var (
+		st     transcriptState
+		cursor string
+	)
+	err := ix.db.QueryRow(
+		"SELECT size, mtime, offset, cursor FROM transcripts WHERE path = ?", path,
+	).Scan(&st.size, &st.mtime, &st.offset, &cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("index %s: %w", path, err)
+	}
+	st.found = true
+	// The cursor was written by json.Marshal; a mangled one restarts at zero.
+	_ = json.Unmarshal([]byte(cursor), &st.cursor)
+	return st, nil
+}
+
+// SyncLines brings the index up to date with a JSONL transcript, parsing
+// only the complete lines appended since the last sync. A trailing partial
+// line is left for the next sync. A file that was truncated or replaced —
+// it shrank, its modification time went back, or it changed without
+// growing — is re-indexed from the start.
+func (ix *Index) SyncLines(t Transcript, parse LineParser) error {
+	st, err := ix.transcriptState(t.Path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(t.Path)
+	if err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+	defer f.Close()
+	fi, err := statFile(f)
+	if err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+
+	size, mtime := fi.Size(), fi.ModTime().UnixNano()
+	reset := st.found && (size < st.offset || mtime < st.mtime || (size == st.size && mtime != st.mtime))
+	if reset {
+		st.offset, st.cursor = 0, Cursor{}
+	}
+	if st.found && !reset && size == st.offset {
+		return nil
+	}
+	if _, err := f.Seek(st.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+
+	var docs []Doc
+	r := bufio.NewReaderSize(f, 64*1024)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break // partial line: wait for the writer to finish it
+		}
+		if err != nil {
+			return fmt.Errorf("index %s: %w", t.Path, err)
+		}
+		st.offset += int64(len(line))
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			docs = append(docs, parse(line, &st.cursor)...)
+		}
+	}
+
+	st.size, st.mtime = size, mtime
+	return ix.writeTranscript(t, reset, docs, st)
+}
+
+// SyncFile re-indexes a transcript that is rewritten rather than appended
+// to, parsing it again whenever its size or modification time changed.
+func (ix *Index) SyncFile(t Transcript, parse func() ([]Doc, error)) error {
+	st, err := ix.transcriptState(t.Path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(t.Path)
+	if err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+	size, mtime := fi.Size(), fi.ModTime().UnixNano()
+	if st.found && st.size == size && st.mtime == mtime {
+		return nil
+	}
+	docs, err := parse()
+	if err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+	st.size, st.mtime, st.offset = size, mtime, size
+	return ix.writeTranscript(t, st.found, docs, st)
+}
+
+// writeTranscript stores docs and the new transcript state in one
+// transaction, first dropping the transcript's rows when reset is set.
+func (ix *Index) writeTranscript(t Transcript, reset bool, docs []Doc, st transcriptState) error {
+	tx, err := ix.db.Begin()
+	if err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+	defer tx.Rollback()
+
+	if reset {
+		if _, err := tx.Exec("DELETE FROM messages WHERE path = ?", t.Path); err != nil {
+			return fmt.Errorf("index %s: %w", t.Path, err)
+		}
+	}
+	for _, d := range docs {
+		if _, err := tx.Exec(
+			"INSERT INTO messages (content, root, tool, session, path, msg, role, ts) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			d.Content, t.Root, t.Tool, t.Session, t.Path, d.Message, d.Role, d.Timestamp.UnixNano(),
+		); err != nil {
+			return fmt.Errorf("index %s: %w", t.Path, err)
+		}
+	}
+	cursor, _ := json.Marshal(st.cursor) // a struct of an int and a string always marshals
+	if _, err := tx.Exec(
+		`INSERT OR REPLACE INTO transcripts (path, root, tool, session, size, mtime, offset, cursor)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.Path, t.Root, t.Tool, t.Session, st.size, st.mtime, st.offset, string(cursor),
+	); err != nil {
+		return fmt.Errorf("index %s: %w", t.Path, err)
+	}
+	return tx.Commit()
+}
+
+// Search returns the sessions under root with a message matching expr, an
+// FTS5 query expression, each scored by the BM25 relevance of its best
+// message. Scores are negated ranks: higher is more relevant.
+func (ix *Index) Search(root, expr string) (map[string]float64, error) {
+	// bm25() is unavailable inside aggregates, so the best rank per session
+	// is picked here.
+	rows, err := ix.db.Query(
+		"SELECT session, bm25(messages) FROM messages WHERE messages MATCH ? AND root = ?",
+		expr, root,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search index: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]float64)
+	for rows.Next() {
+		var (
+			session string
+			rank    float64
+		)
+		if err := rows.Scan(&session, &rank); err != nil {
+			return nil, fmt.Errorf("search index: %w", err)
+		}
+		if score, ok := scores[session]; !ok || -rank > score {
+			scores[session] = -rank
+		}
+	}
+	return scores, rows.Err()
+}
+
+// statFile is (*os.File).Stat, replaceable in tests.
+var statFile = func(f *os.File) (os.FileInfo, error) { return f.Stat() }
@@ -0,0 +1,420 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// lineDocs is a LineParser treating every line as one message, with the
+// line's first word as its role. Lines starting with "+" extend the previous
+// message instead, like a tool call merged into an assistant turn.
+func lineDocs(line []byte, cur *Cursor) []Doc {
+	role, content, _ := strings.Cut(string(line), " ")
+	if role == "+" {
+		return []Doc{{Message: cur.Message - 1, Role: cur.Role, Content: content}}
+	}
+	d := Doc{Message: cur.Message, Role: role, Content: content, Timestamp: time.Unix(int64(cur.Message), 0)}
+	cur.Message++
+	cur.Role = role
+	return []Doc{d}
+}
+
+func transcript(path string) Transcript {
+	return Transcript{Root: "/root", Tool: "test", Session: "s1", Path: path}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rows returns "msg:role:content" for every indexed message of path, in
+// insertion order.
+func rows(t *testing.T, ix *Index, path string) []string {
+	t.Helper()
+	rs, err := ix.db.Query("SELECT msg, role, content FROM messages WHERE path = ? ORDER BY rowid", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Close()
+	var out []string
+	for rs.Next() {
+		var (
+			msg           int
+			role, content string
+		)
+		if err := rs.Scan(&msg, &role, &content); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, fmt.Sprintf("%d:%s:%s", msg, role, content))
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	return strings.Join(a, "\n") == strings.Join(b, "\n")
+}
+
+// ---------------------------------------------------------------------------
+// SyncLines
+// ---------------------------------------------------------------------------
+
+func TestSyncLines_Incremental(t *testing.T) {
+	ix := openTemp(t)
+	path := writeFile(t, t.TempDir(), "s.jsonl", "user fix the parser\nassistant looking at parser.go\nuser par")
+
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatalf("SyncLines() error: %v", err)
+	}
+	// The trailing partial line is not indexed yet.
+	want := []string{"0:user:fix the parser", "1:assistant:looking at parser.go"}
+	if got := rows(t, ix, path); !equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+
+	// Finishing the line and appending a merged one only parses new bytes:
+	// the cursor carries the message index and role across syncs.
+	appendFile(t, path, "tial line\n+ ran the tests\n")
+	calls := 0
+	counting := func(line []byte, cur *Cursor) []Doc { calls++; return lineDocs(line, cur) }
+	if err := ix.SyncLines(transcript(path), counting); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("parsed %d lines, want 2", calls)
+	}
+	want = append(want, "2:user:partial line", "2:user:ran the tests")
+	if got := rows(t, ix, path); !equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+
+	// Nothing appended: no parsing at all.
+	calls = 0
+	if err := ix.SyncLines(transcript(path), counting); err != nil || calls != 0 {
+		t.Errorf("unchanged sync parsed %d lines, err %v", calls, err)
+	}
+}
+
+func TestSyncLines_TruncatedFileIsReindexed(t *testing.T) {
+	ix := openTemp(t)
+	path := writeFile(t, t.TempDir(), "s.jsonl", "user first version of the file\nuser more\n")
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("user rewritten\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows(t, ix, path), []string{"0:user:rewritten"}; !equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestSyncLines_ReplacedFileIsReindexed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		mtime   time.Duration // relative to the first version's
+	}{
+		{"same length, newer", "user second\n", time.Second},
+		{"longer, older", "user second version\n", -time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ix := openTemp(t)
+			path := writeFile(t, t.TempDir(), "s.jsonl", "user first!\n")
+			first := time.Now().Add(-time.Hour)
+			if err := os.Chtimes(path, first, first); err != nil {
+				t.Fatal(err)
+			}
+			if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(path, first.Add(tt.mtime), first.Add(tt.mtime)); err != nil {
+				t.Fatal(err)
+			}
+			if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+				t.Fatal(err)
+			}
+			want := "0:user:" + strings.TrimPrefix(strings.TrimSpace(tt.content), "user ")
+			if got := rows(t, ix, path); !equal(got, []string{want}) {
+				t.Errorf("rows = %v, want [%s]", got, want)
+			}
+		})
+	}
+}
+
+func TestSyncLines_MangledCursorRestartsAtZero(t *testing.T) {
+	ix := openTemp(t)
+	path := writeFile(t, t.TempDir(), "s.jsonl", "user one\n")
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.db.Exec("UPDATE transcripts SET cursor = 'garbage'"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path, "user two\n")
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows(t, ix, path), []string{"0:user:one", "0:user:two"}; !equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestSyncLines_Errors(t *testing.T) {
+	t.Run("missing file", func(t *testing.T) {
+		ix := openTemp(t)
+		if err := ix.SyncLines(transcript(filepath.Join(t.TempDir(), "nope")), lineDocs); err == nil {
+			t.Error("expected error for a missing file")
+		}
+	})
+
+	t.Run("closed database", func(t *testing.T) {
+		ix := openTemp(t)
+		ix.Close()
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error on a closed index")
+		}
+	})
+
+	t.Run("stat failure", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		orig := statFile
+		statFile = func(*os.File) (os.FileInfo, error) { return nil, errors.New("boom") }
+		defer func() { statFile = orig }()
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error when stat fails")
+		}
+	})
+
+	t.Run("bad offset", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ix.db.Exec("UPDATE transcripts SET offset = -1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error seeking to a negative offset")
+		}
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		ix := openTemp(t)
+		if err := ix.SyncLines(transcript(t.TempDir()), lineDocs); err == nil {
+			t.Error("expected error reading a directory")
+		}
+	})
+
+	t.Run("transaction failure", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		closing := func(line []byte, cur *Cursor) []Doc { ix.Close(); return lineDocs(line, cur) }
+		if err := ix.SyncLines(transcript(path), closing); err == nil {
+			t.Error("expected error when the transaction cannot start")
+		}
+	})
+
+	t.Run("insert failure", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		if _, err := ix.db.Exec("DROP TABLE messages"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error inserting into a missing table")
+		}
+	})
+
+	t.Run("delete failure", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user one\nuser two\n")
+		if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ix.db.Exec("DROP TABLE messages"); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("user 1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error deleting from a missing table")
+		}
+	})
+
+	t.Run("state write failure", func(t *testing.T) {
+		ix := openTemp(t)
+		path := writeFile(t, t.TempDir(), "s.jsonl", "user x\n")
+		if _, err := ix.db.Exec(
+			"CREATE TRIGGER fail BEFORE INSERT ON transcripts BEGIN SELECT RAISE(ABORT, 'boom'); END",
+		); err != nil {
+			t.Fatal(err)
+		}
+		if err := ix.SyncLines(transcript(path), lineDocs); err == nil {
+			t.Error("expected error writing the transcript state")
+		}
+		// The transaction rolled back: no half-indexed messages.
+		if got := rows(t, ix, path); len(got) != 0 {
+			t.Errorf("rows survived rollback: %v", got)
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// SyncFile
+// ---------------------------------------------------------------------------
+
+func TestSyncFile(t *testing.T) {
+	ix := openTemp(t)
+	path := writeFile(t, t.TempDir(), "chat.json", "v1")
+
+	calls := 0
+	parse := func() ([]Doc, error) {
+		calls++
+		data, err := os.ReadFile(path)
+		return []Doc{{Message: 0, Role: "user", Content: "version " + string(data)}}, err
+	}
+	for i := 0; i < 2; i++ {
+		if err := ix.SyncFile(transcript(path), parse); err != nil {
+			t.Fatalf("SyncFile() error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("parsed %d times, want 1", calls)
+	}
+
+	// A rewrite replaces the file's rows.
+	if err := os.WriteFile(path, []byte("v22"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.SyncFile(transcript(path), parse); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rows(t, ix, path), []string{"0:user:version v22"}; !equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
+
+func TestSyncFile_Errors(t *testing.T) {
+	errBoom := errors.New("boom")
+	parse := func() ([]Doc, error) { return nil, nil }
+
+	ix := openTemp(t)
+	if err := ix.SyncFile(transcript(filepath.Join(t.TempDir(), "nope")), parse); err == nil {
+		t.Error("expected error for a missing file")
+	}
+
+	path := writeFile(t, t.TempDir(), "chat.json", "x")
+	if err := ix.SyncFile(transcript(path), func() ([]Doc, error) { return nil, errBoom }); !errors.Is(err, errBoom) {
+		t.Errorf("SyncFile() error = %v, want %v", err, errBoom)
+	}
+
+	ix.Close()
+	if err := ix.SyncFile(transcript(path), parse); err == nil {
+		t.Error("expected error on a closed index")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Search
+// ---------------------------------------------------------------------------
+
+func TestSearch_ScoresSessions(t *testing.T) {
+	ix := openTemp(t)
+	dir := t.TempDir()
+	sync := func(root, session, content string) {
+		t.Helper()
+		path := writeFile(t, dir, session+root[1:]+".jsonl", content)
+		if err := ix.SyncLines(Transcript{Root: root, Tool: "test", Session: session, Path: path}, lineDocs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sync("/root", "weak", "user a long message that mentions deploy only once among many other words\n")
+	sync("/root", "strong", "user nothing here\nassistant deploy deploy deploy\nuser deploy once more in a long message\n")
+	sync("/root", "rollback", "user deploy then rollback\n")
+	sync("/root", "none", "user nothing relevant here\n")
+	sync("/other", "elsewhere", "user deploy\n")
+
+	scores, err := ix.Search("/root", `"DEPLOY"`)
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(scores) != 3 || scores["none"] != 0 || scores["elsewhere"] != 0 {
+		t.Fatalf("scores = %v, want weak, strong and rollback", scores)
+	}
+	if !(scores["strong"] > scores["weak"]) || scores["weak"] <= 0 {
+		t.Errorf("scores = %v, want strong > weak > 0", scores)
+	}
+
+	// Expressions use FTS5 syntax.
+	scores, err = ix.Search("/root", `"deploy" NOT "rollback"`)
+	if err != nil || len(scores) != 2 || scores["rollback"] != 0 {
+		t.Errorf("Search(NOT) = %v, %v", scores, err)
+	}
+}
+
+func TestSearch_Errors(t *testing.T) {
+	ix := openTemp(t)
+	if _, err := ix.Search("/root", `"unterminated`); err == nil {
+		t.Error("expected error for a malformed expression")
+	}
+
+	// A row whose session is NULL cannot be scanned.
+	if _, err := ix.db.Exec("INSERT INTO messages (content, root, msg, ts) VALUES ('broken row', '/root', 0, 0)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.Search("/root", `"broken"`); err == nil {
+		t.Error("expected error scanning a malformed row")
+	}
+
+	ix.Close()
+	if _, err := ix.Search("/root", `"anything"`); err == nil {
+		t.Error("expected error on a closed index")
+	}
+}
+
+func TestReset_ClearsMessages(t *testing.T) {
+	ix := openTemp(t)
+	path := writeFile(t, t.TempDir(), "s.jsonl", "user indexed text\n")
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if got := rows(t, ix, path); len(got) != 0 {
+		t.Errorf("rows after Reset: %v", got)
+	}
+	// The transcript state is gone too, so the next sync re-indexes it.
+	if err := ix.SyncLines(transcript(path), lineDocs); err != nil {
+		t.Fatal(err)
+	}
+	if got := rows(t, ix, path); len(got) != 1 {
+		t.Errorf("rows after re-sync = %v, want 1", got)
+	}
+}
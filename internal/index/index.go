@@ -0,0 +1,165 @@
+// Package index is omnisess's on-disk cache of per-file session metadata.
+// Sources memoize whatever they extract from a file (peeked headers,
+// previews, parsed history) keyed by the file path, and the entry is reused
+// while the file's size and modification time are unchanged, so unchanged
+// files are never reopened.
+//
+// The same database holds a full-text index of message content (see
+// SyncLines and Search), kept current by appending new transcript lines.
+//
+// The database lives at $XDG_CACHE_HOME/omnisess/index.db (default
+// ~/.cache/omnisess). A nil *Index is valid and caches nothing.
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// schemaVersion is stored in PRAGMA user_version. Bump it whenever a cached
+// value changes shape; older databases are then wiped on open.
+const schemaVersion = 3
+
+const schema = `
+CREATE TABLE IF NOT EXISTS files (
+	kind  TEXT    NOT NULL,
+	path  TEXT    NOT NULL,
+	size  INTEGER NOT NULL,
+	mtime INTEGER NOT NULL,
+	data  BLOB    NOT NULL,
+	PRIMARY KEY (kind, path)
+)`
+
+// Index is an open cache database.
+type Index struct {
+	db *sql.DB
+}
+
+// Dir returns the omnisess cache directory: $XDG_CACHE_HOME/omnisess, or
+// ~/.cache/omnisess when XDG_CACHE_HOME is unset.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "omnisess"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, ".cache", "omnisess"), nil
+}
+
+// OpenDefault opens index.db in Dir, creating it if needed.
+func OpenDefault() (*Index, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	return Open(filepath.Join(dir, "index.db"))
+}
+
+// Open opens the cache database at path, creating it and its parent
+// directory if needed. A database written by another schema version is
+// wiped.
+func Open(path string) (*Index, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	// Concurrent omnisess processes (e.g. watch + list) share the file:
+	// WAL keeps readers unblocked and busy_timeout waits out writers.
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	// sql.Open with the registered modernc.org/sqlite driver never fails.
+	db, _ := sql.Open("sqlite", dsn)
+	// A single connection serializes writers within this process.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open index %s: %w", path, err)
+	}
+	return &Index{db: db}, nil
+}
+
+// migrate creates the schema, dropping tables from another schema version.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	stmts := schema + ";\n" + ftsSchema + fmt.Sprintf(";\nPRAGMA user_version = %d", schemaVersion)
+	if version != schemaVersion {
+		stmts = "DROP TABLE IF EXISTS files;\nDROP TABLE IF EXISTS transcripts;\nDROP TABLE IF EXISTS messages;\n" + stmts
+	}
+	_, err := db.Exec(stmts)
+	return err
+}
+
+// Close closes the database. Closing a nil Index is a no-op.
+func (ix *Index) Close() error {
+	if ix == nil {
+		return nil
+	}
+	return ix.db.Close()
+}
+
+// Reset removes every cached entry and indexed message.
+func (ix *Index) Reset() error {
+	if _, err := ix.db.Exec("DELETE FROM files; DELETE FROM transcripts; DELETE FROM messages"); err != nil {
+		return fmt.Errorf("reset index: %w", err)
+	}
+	return nil
+}
+
+// Load returns the value cached under kind for path if the file's size and
+// modification time still match, and otherwise calls compute and caches
+// its result. Kinds namespace the values stored for the same file (e.g.
+// "claude.meta", "claude.preview").
+//
+// Cache failures are never fatal: a nil Index, an unreadable entry or a
+// failed write all fall back to compute. Errors from compute are returned
+// and not cached.
+func Load[T any](ix *Index, kind, path string, compute func() (T, error)) (T, error) {
+	if ix == nil {
+		return compute()
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return compute()
+	}
+	size, mtime := fi.Size(), fi.ModTime().UnixNano()
+
+	var data []byte
+	err = ix.db.QueryRow(
+		"SELECT data FROM files WHERE kind = ? AND path = ? AND size = ? AND mtime = ?",
+		kind, path, size, mtime,
+	).Scan(&data)
+	if err == nil {
+		var v T
+		if json.Unmarshal(data, &v) == nil {
+			return v, nil
+		}
+	}
+
+	v, err := compute()
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		// Best effort: a failed write only costs a recompute next time.
+		_, _ = ix.db.Exec(
+			"INSERT OR REPLACE INTO files (kind, path, size, mtime, data) VALUES (?, ?, ?, ?, ?)",
+			kind, path, size, mtime, data,
+		)
+	}
+	return v, nil
+}
+
+// Memo is Load for computations that cannot fail.
+func Memo[T any](ix *Index, kind, path string, compute func() T) T {
+	v, _ := Load(ix, kind, path, func() (T, error) { return compute(), nil })
+	return v
+}
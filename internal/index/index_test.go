@@ -0,0 +1,292 @@
+package index
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// openTemp opens a fresh index in a temp dir and closes it after the test.
+func openTemp(t *testing.T) *Index {
+	t.Helper()
+	ix, err := Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	t.Cleanup(func() { ix.Close() })
+	return ix
+}
+
+// writeFile writes content to a new file in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+type meta struct {
+	Branch string
+	Count  int
+}
+
+// ---------------------------------------------------------------------------
+// Dir / Open
+// ---------------------------------------------------------------------------
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	if got, err := Dir(); err != nil || got != "/xdg/cache/omnisess" {
+		t.Errorf("Dir() with XDG = %q, %v", got, err)
+	}
+
+	home := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", home)
+	if got, err := Dir(); err != nil || got != filepath.Join(home, ".cache", "omnisess") {
+		t.Errorf("Dir() = %q, %v", got, err)
+	}
+
+	t.Setenv("HOME", "")
+	if _, err := Dir(); err == nil {
+		t.Error("expected error when HOME is empty")
+	}
+}
+
+func TestOpenDefault(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", xdg)
+	ix, err := OpenDefault()
+	if err != nil {
+		t.Fatalf("OpenDefault() error: %v", err)
+	}
+	defer ix.Close()
+	if _, err := os.Stat(filepath.Join(xdg, "omnisess", "index.db")); err != nil {
+		t.Errorf("index.db not created: %v", err)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "")
+	if _, err := OpenDefault(); err == nil {
+		t.Error("expected error when HOME is empty")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	// The parent "directory" is a regular file.
+	file := writeFile(t, dir, "file", "")
+	if _, err := Open(filepath.Join(file, "index.db")); err == nil {
+		t.Error("expected error when the parent is a file")
+	}
+
+	// The database path is a directory.
+	if _, err := Open(dir); err == nil {
+		t.Error("expected error when the path is a directory")
+	}
+
+	// An old schema whose "files" is a view cannot be dropped as a table.
+	old := filepath.Join(dir, "old.db")
+	db, _ := sql.Open("sqlite", old)
+	if _, err := db.Exec("CREATE VIEW files AS SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := Open(old); err == nil {
+		t.Error("expected error migrating an incompatible database")
+	}
+}
+
+func TestOpen_WipesOtherSchemaVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "index.db")
+	src := writeFile(t, t.TempDir(), "s.jsonl", "x")
+
+	ix, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(ix, "k", src, func() (meta, error) { return meta{Count: 1}, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ix.db.Exec("PRAGMA user_version = 0"); err != nil {
+		t.Fatal(err)
+	}
+	ix.Close()
+
+	ix, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	defer ix.Close()
+	var n int
+	if err := ix.db.QueryRow("SELECT COUNT(*) FROM files").Scan(&n); err != nil || n != 0 {
+		t.Errorf("entries after version change = %d, %v; want 0", n, err)
+	}
+}
+
+func TestClose_Nil(t *testing.T) {
+	var ix *Index
+	if err := ix.Close(); err != nil {
+		t.Errorf("nil Close() = %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Load
+// ---------------------------------------------------------------------------
+
+func TestLoad_CachesUntilFileChanges(t *testing.T) {
+	ix := openTemp(t)
+	src := writeFile(t, t.TempDir(), "s.jsonl", "line1\n")
+
+	calls := 0
+	compute := func() (meta, error) {
+		calls++
+		return meta{Branch: "main", Count: calls}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := Load(ix, "test.meta", src, compute)
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if got != (meta{Branch: "main", Count: 1}) {
+			t.Errorf("Load() #%d = %+v", i, got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("compute called %d times, want 1", calls)
+	}
+
+	// Another kind for the same file is cached separately.
+	if _, err := Load(ix, "test.other", src, compute); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("compute called %d times after new kind, want 2", calls)
+	}
+
+	// Appending changes size and mtime, so the entry is recomputed.
+	if err := os.WriteFile(src, []byte("line1\nline2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(src, later, later); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(ix, "test.meta", src, compute)
+	if err != nil || got.Count != 3 {
+		t.Errorf("Load() after change = %+v, %v; want Count 3", got, err)
+	}
+}
+
+func TestLoad_Fallbacks(t *testing.T) {
+	src := writeFile(t, t.TempDir(), "s.jsonl", "x")
+	errBoom := errors.New("boom")
+
+	t.Run("nil index", func(t *testing.T) {
+		got, err := Load(nil, "k", src, func() (int, error) { return 7, nil })
+		if err != nil || got != 7 {
+			t.Errorf("Load(nil) = %d, %v", got, err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		ix := openTemp(t)
+		got, err := Load(ix, "k", filepath.Join(t.TempDir(), "nope"), func() (int, error) { return 7, nil })
+		if err != nil || got != 7 {
+			t.Errorf("Load(missing) = %d, %v", got, err)
+		}
+	})
+
+	t.Run("compute error is not cached", func(t *testing.T) {
+		ix := openTemp(t)
+		if _, err := Load(ix, "k", src, func() (int, error) { return 0, errBoom }); !errors.Is(err, errBoom) {
+			t.Errorf("Load() error = %v, want %v", err, errBoom)
+		}
+		got, err := Load(ix, "k", src, func() (int, error) { return 8, nil })
+		if err != nil || got != 8 {
+			t.Errorf("Load() after error = %d, %v; want 8", got, err)
+		}
+	})
+
+	t.Run("unmarshalable value", func(t *testing.T) {
+		ix := openTemp(t)
+		ch := make(chan int)
+		got, err := Load(ix, "k", src, func() (chan int, error) { return ch, nil })
+		if err != nil || got != ch {
+			t.Errorf("Load(chan) = %v, %v", got, err)
+		}
+	})
+
+	t.Run("corrupt entry", func(t *testing.T) {
+		ix := openTemp(t)
+		if _, err := Load(ix, "k", src, func() (int, error) { return 1, nil }); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ix.db.Exec("UPDATE files SET data = 'not json'"); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Load(ix, "k", src, func() (int, error) { return 2, nil })
+		if err != nil || got != 2 {
+			t.Errorf("Load(corrupt) = %d, %v; want 2", got, err)
+		}
+	})
+
+	t.Run("closed database", func(t *testing.T) {
+		ix := openTemp(t)
+		ix.Close()
+		got, err := Load(ix, "k", src, func() (int, error) { return 3, nil })
+		if err != nil || got != 3 {
+			t.Errorf("Load(closed) = %d, %v; want 3", got, err)
+		}
+	})
+}
+
+func TestMemo(t *testing.T) {
+	ix := openTemp(t)
+	src := writeFile(t, t.TempDir(), "s.jsonl", "x")
+
+	calls := 0
+	compute := func() string { calls++; return "preview" }
+	for i := 0; i < 2; i++ {
+		if got := Memo(ix, "k", src, compute); got != "preview" {
+			t.Errorf("Memo() = %q, want preview", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("compute called %d times, want 1", calls)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Reset
+// ---------------------------------------------------------------------------
+
+func TestReset(t *testing.T) {
+	ix := openTemp(t)
+	src := writeFile(t, t.TempDir(), "s.jsonl", "x")
+
+	calls := 0
+	compute := func() (int, error) { calls++; return calls, nil }
+	if _, err := Load(ix, "k", src, compute); err != nil {
+		t.Fatal(err)
+	}
+	if err := ix.Reset(); err != nil {
+		t.Fatalf("Reset() error: %v", err)
+	}
+	if got, _ := Load(ix, "k", src, compute); got != 2 {
+		t.Errorf("Load() after Reset = %d, want recomputed 2", got)
+	}
+
+	ix.Close()
+	if err := ix.Reset(); err == nil {
+		t.Error("expected error resetting a closed index")
+	}
+}
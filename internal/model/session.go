@@ -20,23 +20,78 @@ const (
 	RoleTool      Role = "tool"
 )
 
+// Confidence says how an active session was bound to its process.
+type Confidence string
+
+const (
+	ConfidenceExact     Confidence = "exact"     // the process has the session file open
+	ConfidenceHeuristic Confidence = "heuristic" // a tool process runs (in the project, where known) and the file changed recently
+)
+
+// State is what a session is doing. Running sessions are classified from
+// the end of their transcript; the rest have ended.
+type State string
+
+const (
+	StateWorking State = "working" // the agent is thinking or running tools
+	StateWaiting State = "waiting" // the agent waits on the user: its turn is over, or a tool call needs approval
+	StateIdle    State = "idle"    // running, but the transcript has been quiet for a while
+	StateEnded   State = "ended"   // no process runs the session
+	StateError   State = "error"   // the last turn failed
+)
+
 type Session struct {
 	ID        string    `json:"ID"`
 	Tool      Tool      `json:"Tool"`
+	Host      string    `json:"Host,omitempty"` // empty for the local machine
 	Project   string    `json:"Project,omitempty"`
 	Branch    string    `json:"Branch,omitempty"`
 	Title     string    `json:"Title,omitempty"`
 	Summary   string    `json:"Summary,omitempty"`
 	Model     string    `json:"Model,omitempty"`
+	Version   string    `json:"Version,omitempty"` // of the tool that wrote the session, where recorded
 	StartedAt time.Time `json:"StartedAt"`
 	UpdatedAt time.Time `json:"UpdatedAt"`
-	Active    bool      `json:"Active"`
-	Messages  []Message `json:"Messages,omitempty"`
-	Preview   string    `json:"Preview,omitempty"`
+	// EndedAt is when the last message was sent; Duration is the wall-clock
+	// time from StartedAt to it and ActiveDuration the part spent on task
+	// (see Timing). All three are zero when the source records no message
+	// timestamps.
+	EndedAt        time.Time     `json:"EndedAt,omitzero"`
+	Duration       time.Duration `json:"Duration,omitempty"`       // in nanoseconds in JSON
+	ActiveDuration time.Duration `json:"ActiveDuration,omitempty"` // in nanoseconds in JSON
+	// Untimed is set on a listed session whose timing is not in the
+	// metadata index yet (see ListOptions.Timing): the fields above are
+	// zero until it is read in full.
+	Untimed bool `json:"Untimed,omitempty"`
+	Active  bool `json:"Active"`
+	// PID and Confidence describe the process of an active session. PID is
+	// 0 when the session could not be bound to a single process.
+	PID        int        `json:"PID,omitempty"`
+	Confidence Confidence `json:"Confidence,omitempty"`
+	State      State      `json:"State,omitempty"`
+	Messages   []Message  `json:"Messages,omitempty"`
+	Preview    string     `json:"Preview,omitempty"`
+	// Usage totals the Usage of Messages; it is only set with them.
+	Usage Usage `json:"Usage,omitzero"`
+}
+
+// SetTiming sets StartedAt, EndedAt, Duration and ActiveDuration from t.
+// A session whose messages carry no timestamps keeps its StartedAt.
+func (s *Session) SetTiming(t Timing) {
+	if t.Start.IsZero() {
+		return
+	}
+	s.StartedAt, s.EndedAt = t.Start, t.End
+	s.Duration = t.End.Sub(t.Start)
+	s.ActiveDuration = t.Active
 }
 
 // QualifiedID returns the tool-prefixed session ID (e.g., "claude:5c3f2742").
+// Sessions from another host carry it after the tool ("claude@devvm:5c3f2742").
 func (s Session) QualifiedID() string {
+	if s.Host != "" {
+		return string(s.Tool) + "@" + s.Host + ":" + s.ID
+	}
 	return string(s.Tool) + ":" + s.ID
 }
 
@@ -84,21 +139,140 @@ type Message struct {
 	Content   string
 	Timestamp time.Time
 	ToolCalls []ToolCall
+	Reasoning string `json:",omitempty"` // summary of the model's reasoning, where recorded
+	Model     string `json:",omitempty"` // that wrote the message, where recorded
+	Usage     Usage  `json:",omitzero"`  // of the model call that wrote the message
+}
+
+// Usage is what model calls consumed, as recorded in the transcript: tokens
+// by kind, the cost where the tool records it, and the time the calls took.
+// Fields not recorded are zero.
+type Usage struct {
+	InputTokens         int64   `json:",omitempty"` // prompt tokens not read from the cache
+	CacheReadTokens     int64   `json:",omitempty"` // prompt tokens read from the cache
+	CacheCreationTokens int64   `json:",omitempty"` // prompt tokens written to the cache
+	OutputTokens        int64   `json:",omitempty"` // including ReasoningTokens
+	ReasoningTokens     int64   `json:",omitempty"`
+	CostUSD             float64 `json:",omitempty"` // as recorded
+	// EstimatedCostUSD prices the calls that record no cost from their
+	// tokens (see internal/pricing).
+	EstimatedCostUSD float64       `json:",omitempty"`
+	Duration         time.Duration `json:",omitempty"` // in nanoseconds in JSON
+}
+
+// Add adds o to u.
+func (u *Usage) Add(o Usage) {
+	u.InputTokens += o.InputTokens
+	u.CacheReadTokens += o.CacheReadTokens
+	u.CacheCreationTokens += o.CacheCreationTokens
+	u.OutputTokens += o.OutputTokens
+	u.ReasoningTokens += o.ReasoningTokens
+	u.CostUSD += o.CostUSD
+	u.EstimatedCostUSD += o.EstimatedCostUSD
+	u.Duration += o.Duration
+}
+
+// Tokens is the number of tokens read and written.
+func (u Usage) Tokens() int64 {
+	return u.InputTokens + u.CacheReadTokens + u.CacheCreationTokens + u.OutputTokens
+}
+
+// Cost is the recorded cost plus the estimated one.
+func (u Usage) Cost() float64 {
+	return u.CostUSD + u.EstimatedCostUSD
+}
+
+// SumUsage totals the Usage of msgs.
+func SumUsage(msgs []Message) Usage {
+	var u Usage
+	for _, m := range msgs {
+		u.Add(m.Usage)
+	}
+	return u
+}
+
+// IdleThreshold is the longest pause between two messages counted as time
+// on task; a longer one is taken for a break. Reading a reply and writing
+// the next prompt takes minutes, so it is longer than detect.IdleAfter, which
+// flags a running agent that has gone quiet.
+const IdleThreshold = 15 * time.Minute
+
+// Timing is when the messages of a session were sent: the first, the last,
+// and the active time between them.
+type Timing struct {
+	Start, End time.Time
+	Active     time.Duration // the pauses between messages up to IdleThreshold
+}
+
+// TimingOf returns the timing of msgs, in transcript order. Messages without
+// a timestamp are skipped, and one stamped before an earlier message (as
+// when subagents interleave) adds no active time.
+func TimingOf(msgs []Message) Timing {
+	var t Timing
+	for _, m := range msgs {
+		ts := m.Timestamp
+		if ts.IsZero() {
+			continue
+		}
+		if t.Start.IsZero() {
+			t.Start, t.End = ts, ts
+			continue
+		}
+		if ts.Before(t.Start) {
+			t.Start = ts
+		}
+		if gap := ts.Sub(t.End); gap > 0 {
+			if gap <= IdleThreshold {
+				t.Active += gap
+			}
+			t.End = ts
+		}
+	}
+	return t
 }
 
 type ToolCall struct {
 	Name   string
-	Input  string // truncated
-	Output string // truncated
+	Input  string // truncated, see TruncateToolText
+	Output string // truncated, see TruncateToolText
+}
+
+// TruncateToolText caps tool call text at 200 bytes, as every source stores
+// it.
+func TruncateToolText(s string) string {
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
 }
 
 type SearchResult struct {
 	Session Session
 	Matches []SearchMatch
+	Score   float64 `json:",omitempty"` // full-text relevance, higher is better; 0 when scanned
 }
 
 type SearchMatch struct {
 	MessageIndex int
 	Snippet      string // ~200 char context around match
 	Role         Role
+	Highlights   []Span `json:",omitempty"` // matched ranges of Snippet
+	Location     MatchLocation
+	ToolCall     int // index into the message's ToolCalls for tool locations
+}
+
+// MatchLocation is the part of a message a search match was found in.
+type MatchLocation string
+
+const (
+	LocationContent    MatchLocation = "content"
+	LocationToolInput  MatchLocation = "tool_input"
+	LocationToolOutput MatchLocation = "tool_output"
+	LocationReasoning  MatchLocation = "reasoning"
+)
+
+// Span is the byte range [Start, End) of a string.
+type Span struct {
+	Start int
+	End   int
 }
@@ -1,6 +1,10 @@
 package model
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestQualifiedID(t *testing.T) {
 	tests := []struct {
@@ -23,6 +27,11 @@ func TestQualifiedID(t *testing.T) {
 			sess: Session{ID: "", Tool: ToolClaude},
 			want: "claude:",
 		},
+		{
+			name: "remote host session",
+			sess: Session{ID: "abc12345", Tool: ToolCodex, Host: "devvm"},
+			want: "codex@devvm:abc12345",
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,3 +189,80 @@ func TestSplitPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSumUsage(t *testing.T) {
+	msgs := []Message{
+		{Role: RoleUser},
+		{Role: RoleAssistant, Usage: Usage{InputTokens: 10, CacheReadTokens: 100, OutputTokens: 5, CostUSD: 0.01, Duration: time.Second}},
+		{Role: RoleAssistant, Usage: Usage{InputTokens: 2, CacheCreationTokens: 50, OutputTokens: 7, ReasoningTokens: 3, CostUSD: 0.02, Duration: 2 * time.Second}},
+		{Role: RoleAssistant, Usage: Usage{EstimatedCostUSD: 0.5}},
+	}
+	got := SumUsage(msgs)
+	want := Usage{InputTokens: 12, CacheReadTokens: 100, CacheCreationTokens: 50, OutputTokens: 12, ReasoningTokens: 3, CostUSD: 0.03, EstimatedCostUSD: 0.5, Duration: 3 * time.Second}
+	if got.CostUSD < 0.0299 || got.CostUSD > 0.0301 {
+		t.Errorf("CostUSD = %v, want 0.03", got.CostUSD)
+	}
+	got.CostUSD = want.CostUSD
+	if got != want {
+		t.Errorf("SumUsage() = %+v, want %+v", got, want)
+	}
+	if n := got.Tokens(); n != 174 {
+		t.Errorf("Tokens() = %d, want 174", n)
+	}
+	if c := got.Cost(); c != 0.53 {
+		t.Errorf("Cost() = %v, want 0.53", c)
+	}
+	if SumUsage(nil) != (Usage{}) {
+		t.Error("SumUsage(nil) should be zero")
+	}
+}
+
+func TestTimingOf(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	at := func(min int) Message { return Message{Timestamp: t0.Add(time.Duration(min) * time.Minute)} }
+
+	got := TimingOf([]Message{
+		{}, // no timestamp
+		at(0), at(5), at(12),
+		at(8),          // interleaved: adds nothing
+		at(60), at(70), // after a break
+	})
+	want := Timing{Start: t0, End: t0.Add(70 * time.Minute), Active: 22 * time.Minute}
+	if got != want {
+		t.Errorf("TimingOf() = %+v, want %+v", got, want)
+	}
+
+	if got := TimingOf([]Message{at(10), at(-5)}); got.Start != t0.Add(-5*time.Minute) || got.End != t0.Add(10*time.Minute) || got.Active != 0 {
+		t.Errorf("TimingOf(out of order) = %+v, want -5m to 10m and no active time", got)
+	}
+	if TimingOf([]Message{{Role: RoleUser}}) != (Timing{}) {
+		t.Error("TimingOf(untimed) should be zero")
+	}
+}
+
+func TestSetTiming(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	s := Session{StartedAt: t0.Add(-time.Hour)}
+	s.SetTiming(Timing{})
+	if s.StartedAt != t0.Add(-time.Hour) || !s.EndedAt.IsZero() || s.Duration != 0 {
+		t.Errorf("SetTiming(zero) changed the session: %+v", s)
+	}
+
+	s.SetTiming(Timing{Start: t0, End: t0.Add(90 * time.Minute), Active: 20 * time.Minute})
+	if s.StartedAt != t0 || s.EndedAt != t0.Add(90*time.Minute) || s.Duration != 90*time.Minute || s.ActiveDuration != 20*time.Minute {
+		t.Errorf("SetTiming() = %+v", s)
+	}
+}
+
+func TestTruncateToolText(t *testing.T) {
+	if got := TruncateToolText("short"); got != "short" {
+		t.Errorf("TruncateToolText(short) = %q", got)
+	}
+	if got := TruncateToolText(strings.Repeat("x", 200)); len(got) != 200 {
+		t.Errorf("TruncateToolText(200 bytes) len = %d, want it kept whole", len(got))
+	}
+	got := TruncateToolText(strings.Repeat("x", 250))
+	if len(got) != 203 || !strings.HasSuffix(got, "...") {
+		t.Errorf("TruncateToolText(long) len = %d, want 203 with ellipsis", len(got))
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/psacc/omnisess/internal/model"
 )
 
@@ -37,31 +39,43 @@ func sanitizeSession(s *model.Session) model.Session {
 	out.Project = sanitizeString(out.Project)
 	out.Branch = sanitizeString(out.Branch)
 	out.Model = sanitizeString(out.Model)
+	out.Version = sanitizeString(out.Version)
+	out.Host = sanitizeString(out.Host)
 
 	if len(s.Messages) > 0 {
 		out.Messages = make([]model.Message, len(s.Messages))
 		for i, m := range s.Messages {
-			out.Messages[i] = model.Message{
-				Role:      m.Role,
-				Content:   sanitizeString(m.Content),
-				Timestamp: m.Timestamp,
-			}
-			if len(m.ToolCalls) > 0 {
-				out.Messages[i].ToolCalls = make([]model.ToolCall, len(m.ToolCalls))
-				for j, tc := range m.ToolCalls {
-					out.Messages[i].ToolCalls[j] = model.ToolCall{
-						Name:   sanitizeString(tc.Name),
-						Input:  sanitizeString(tc.Input),
-						Output: sanitizeString(tc.Output),
-					}
-				}
-			}
+			out.Messages[i] = sanitizeMessage(m)
 		}
 	}
 
 	return out
 }
 
+// sanitizeMessage returns a copy of m with its content and tool calls
+// sanitized.
+func sanitizeMessage(m model.Message) model.Message {
+	out := model.Message{
+		Role:      m.Role,
+		Content:   sanitizeString(m.Content),
+		Timestamp: m.Timestamp,
+		Reasoning: sanitizeString(m.Reasoning),
+		Model:     sanitizeString(m.Model),
+		Usage:     m.Usage,
+	}
+	if len(m.ToolCalls) > 0 {
+		out.ToolCalls = make([]model.ToolCall, len(m.ToolCalls))
+		for j, tc := range m.ToolCalls {
+			out.ToolCalls[j] = model.ToolCall{
+				Name:   sanitizeString(tc.Name),
+				Input:  sanitizeString(tc.Input),
+				Output: sanitizeString(tc.Output),
+			}
+		}
+	}
+	return out
+}
+
 // sanitizeSearchResults returns a sanitized copy of search results for
 // safe JSON output.
 func sanitizeSearchResults(results []model.SearchResult) []model.SearchResult {
@@ -70,12 +84,16 @@ func sanitizeSearchResults(results []model.SearchResult) []model.SearchResult {
 		out[i] = model.SearchResult{
 			Session: sanitizeSession(&r.Session),
 			Matches: make([]model.SearchMatch, len(r.Matches)),
+			Score:   r.Score,
 		}
 		for j, m := range r.Matches {
 			out[i].Matches[j] = model.SearchMatch{
 				MessageIndex: m.MessageIndex,
 				Snippet:      sanitizeString(m.Snippet),
 				Role:         m.Role,
+				Highlights:   m.Highlights,
+				Location:     m.Location,
+				ToolCall:     m.ToolCall,
 			}
 		}
 	}
@@ -95,8 +113,10 @@ func sanitizeSessions(sessions []model.Session) []model.Session {
 type Format string
 
 const (
-	FormatTable Format = "table"
-	FormatJSON  Format = "json"
+	FormatTable  Format = "table"
+	FormatJSON   Format = "json"
+	FormatNDJSON Format = "ndjson" // one JSON object per line
+	FormatCSV    Format = "csv"    // stats only
 )
 
 // RenderSessions outputs a list of sessions in the given format.
@@ -104,6 +124,8 @@ func RenderSessions(sessions []model.Session, format Format) {
 	switch format {
 	case FormatJSON:
 		renderJSON(os.Stdout, sanitizeSessions(sessions))
+	case FormatNDJSON:
+		renderNDJSON(os.Stdout, sanitizeSessions(sessions))
 	default:
 		renderTable(os.Stdout, sessions)
 	}
@@ -117,6 +139,8 @@ func RenderSession(session *model.Session, format Format) {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		enc.Encode(sanitized)
+	case FormatNDJSON:
+		renderNDJSON(os.Stdout, []model.Session{sanitizeSession(session)})
 	default:
 		renderSessionDetail(os.Stdout, session)
 	}
@@ -129,39 +153,119 @@ func RenderSearchResults(results []model.SearchResult, format Format) {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		enc.Encode(sanitizeSearchResults(results))
+	case FormatNDJSON:
+		renderNDJSON(os.Stdout, sanitizeSearchResults(results))
 	default:
 		renderSearchTable(os.Stdout, results)
 	}
 }
 
+// StreamSearchResult writes one search result as a line of NDJSON, for
+// printing results as they are found.
+func StreamSearchResult(r model.SearchResult) {
+	renderNDJSON(os.Stdout, sanitizeSearchResults([]model.SearchResult{r}))
+}
+
+// sessionEvent is a line of `watch --ndjson` output.
+type sessionEvent struct {
+	Event   string        `json:"event"`
+	Time    time.Time     `json:"time"`
+	Session model.Session `json:"session"`
+}
+
+// StreamSessionEvent writes what happened to a session ("session_started",
+// ...) as a line of NDJSON, for following sessions as they change.
+func StreamSessionEvent(event string, at time.Time, s model.Session) {
+	renderNDJSON(os.Stdout, []sessionEvent{{event, at, sanitizeSession(&s)}})
+}
+
 func renderTable(w io.Writer, sessions []model.Session) {
 	if len(sessions) == 0 {
 		fmt.Fprintln(w, "No sessions found.")
 		return
 	}
 
+	// The HOST column only appears when other machines' roots are read.
+	withHost := false
+	for _, s := range sessions {
+		if s.Host != "" {
+			withHost = true
+			break
+		}
+	}
+	hostCol := func(host string) string {
+		if !withHost {
+			return ""
+		}
+		if host == "" {
+			host = "local"
+		}
+		return fmt.Sprintf("%-14s ", truncate(host, 14))
+	}
+
+	// The PID column appears when an active session was bound to its process.
+	withPID := false
+	for _, s := range sessions {
+		if s.PID != 0 {
+			withPID = true
+			break
+		}
+	}
+	statusCol := func(status, pid string) string {
+		if !withPID {
+			return status
+		}
+		return fmt.Sprintf("%-7s %s", status, pid)
+	}
+
 	// Header
-	fmt.Fprintf(w, "%-8s %-28s %-18s %-50s %-18s %s\n",
-		"TOOL", "PROJECT", "BRANCH", "PREVIEW", "STARTED", "STATUS")
-	fmt.Fprintln(w, strings.Repeat("-", 140))
+	fmt.Fprintf(w, "%-8s %s%-28s %-18s %-50s %-18s %-9s %s\n",
+		"TOOL", hostCol("HOST"), "PROJECT", "BRANCH", "PREVIEW", "STARTED", "DURATION", statusCol("STATUS", "PID"))
+	fmt.Fprintln(w, strings.Repeat("-", 150+len(hostCol(""))))
 
+	pending := false
 	for _, s := range sessions {
-		status := "-"
-		if s.Active {
-			status = "ACTIVE"
+		status := StatusLabel(s)
+		pid := "-"
+		if s.PID != 0 {
+			pid = strconv.Itoa(s.PID)
 		}
 		branch := truncate(s.Branch, 16)
 		project := truncate(s.ShortProject(), 26)
 		preview := truncate(s.Preview, 48)
 		started := s.StartedAt.Local().Format("2006-01-02 15:04")
+		// Time on task; unknown without message timestamps, pending until
+		// the index has timed the session.
+		duration := "-"
+		switch {
+		case !s.EndedAt.IsZero():
+			duration = FormatElapsed(s.ActiveDuration)
+		case s.Untimed:
+			duration = "pending"
+			pending = true
+		}
 
-		fmt.Fprintf(w, "%-8s %-28s %-18s %-50s %-18s %s\n",
-			s.Tool, project, branch, preview, started, status)
+		fmt.Fprintf(w, "%-8s %s%-28s %-18s %-50s %-18s %-9s %s\n",
+			s.Tool, hostCol(s.Host), project, branch, preview, started, duration, statusCol(status, pid))
+	}
+	if pending {
+		fmt.Fprintln(w, "\nDURATION pending: not timed yet; `omnisess list --sort duration` times every session.")
 	}
 }
 
 func renderSessionDetail(w io.Writer, s *model.Session) {
+	renderDetailHeader(w, s)
+	for _, m := range s.Messages {
+		renderMessage(w, m)
+	}
+}
+
+// renderDetailHeader writes the session fields that head the detail view.
+func renderDetailHeader(w io.Writer, s *model.Session) {
 	fmt.Fprintf(w, "Session: %s (%s)\n", s.ShortID(), s.Tool)
+	if s.Host != "" {
+		fmt.Fprintf(w, "Host:    %s\n", s.Host)
+	}
 	fmt.Fprintf(w, "Project: %s\n", s.Project)
 	if s.Branch != "" {
 		fmt.Fprintf(w, "Branch:  %s\n", s.Branch)
@@ -169,21 +273,188 @@ func renderSessionDetail(w io.Writer, s *model.Session) {
 	if s.Model != "" {
 		fmt.Fprintf(w, "Model:   %s\n", s.Model)
 	}
+	if s.Version != "" {
+		fmt.Fprintf(w, "Version: %s\n", s.Version)
+	}
 	fmt.Fprintf(w, "Started: %s\n", s.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	if !s.EndedAt.IsZero() {
+		fmt.Fprintf(w, "Ended:   %s\n", s.EndedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "Time:    %s active of %s\n", FormatElapsed(s.ActiveDuration), FormatElapsed(s.Duration))
+	}
 	if s.Active {
-		fmt.Fprintf(w, "Status:  ACTIVE\n")
+		fmt.Fprintf(w, "Status:  %s%s\n", StatusLabel(*s), processNote(s))
+	}
+	if s.Usage != (model.Usage{}) {
+		fmt.Fprintf(w, "Usage:   %s\n", usageLine(s.Usage))
+	}
+	fmt.Fprintln(w)
+}
+
+// usageLine summarizes u as its token total broken down by kind, then the
+// cost, recorded or estimated (~), and the model time when the transcript
+// records it, e.g.
+// "12.3k tokens (in 1.2k, cache read 10.0k, out 1.1k), $0.132, 2m5s".
+func usageLine(u model.Usage) string {
+	var kinds []string
+	for _, k := range []struct {
+		name string
+		n    int64
+	}{
+		{"in", u.InputTokens},
+		{"cache read", u.CacheReadTokens},
+		{"cache write", u.CacheCreationTokens},
+		{"out", u.OutputTokens},
+		{"reasoning", u.ReasoningTokens},
+	} {
+		if k.n != 0 {
+			kinds = append(kinds, k.name+" "+FormatTokens(k.n))
+		}
 	}
+	line := FormatTokens(u.Tokens()) + " tokens"
+	if len(kinds) > 0 {
+		line += " (" + strings.Join(kinds, ", ") + ")"
+	}
+	switch {
+	case u.EstimatedCostUSD == 0:
+		if u.CostUSD != 0 {
+			line += fmt.Sprintf(", $%.3f", u.CostUSD)
+		}
+	case u.CostUSD == 0:
+		line += fmt.Sprintf(", ~$%.3f estimated", u.EstimatedCostUSD)
+	default:
+		line += fmt.Sprintf(", $%.3f + ~$%.3f estimated", u.CostUSD, u.EstimatedCostUSD)
+	}
+	if u.Duration != 0 {
+		line += ", " + u.Duration.Round(time.Second).String()
+	}
+	return line
+}
+
+// renderMessage writes one message of the detail view.
+func renderMessage(w io.Writer, m model.Message) {
+	ts := m.Timestamp.Local().Format("15:04:05")
+	fmt.Fprintf(w, "--- [%s] %s ---\n", m.Role, ts)
+	fmt.Fprintln(w, m.Content)
+	renderReasoning(w, m.Reasoning)
+	renderToolCalls(w, m.ToolCalls)
 	fmt.Fprintln(w)
+}
 
-	for _, m := range s.Messages {
-		ts := m.Timestamp.Local().Format("15:04:05")
-		fmt.Fprintf(w, "--- [%s] %s ---\n", m.Role, ts)
-		fmt.Fprintln(w, m.Content)
-		for _, tc := range m.ToolCalls {
+// renderReasoning prints a reasoning summary on one line, like a tool call.
+func renderReasoning(w io.Writer, reasoning string) {
+	if text := strings.Join(strings.Fields(reasoning), " "); text != "" {
+		fmt.Fprintf(w, "  [reasoning] %s\n", text)
+	}
+}
+
+func renderToolCalls(w io.Writer, calls []model.ToolCall) {
+	for _, tc := range calls {
+		if tc.Input == "" {
 			fmt.Fprintf(w, "  [tool: %s]\n", tc.Name)
+			continue
+		}
+		// Keep each call on one line so commands read like a log.
+		fmt.Fprintf(w, "  [tool: %s] %s\n", tc.Name, strings.Join(strings.Fields(tc.Input), " "))
+	}
+}
+
+// Tail prints a session's messages as they are written, for `omnisess
+// tail`: in the detail view's layout, or in the JSON formats as NDJSON lines
+// of {"index", "message"}, where a message printed again under the same
+// index (with tool calls or reasoning added) replaces the earlier one.
+type Tail struct {
+	w         io.Writer
+	format    Format
+	msgs      int // messages printed
+	calls     int // tool calls of the last of them printed
+	reasoning int // bytes of its reasoning printed
+}
+
+// NewTail starts printing s, writing the detail view's header in the table
+// format. Its messages are left to Messages.
+func NewTail(s *model.Session, format Format) *Tail {
+	t := &Tail{w: os.Stdout, format: format}
+	if format == FormatTable {
+		renderDetailHeader(t.w, s)
+	}
+	return t
+}
+
+// Messages prints what msgs holds beyond what was printed: reasoning and
+// tool calls added to the last message printed, then the new messages. msgs shorter than what
+// was printed means the transcript was rewritten, and it is printed again
+// from the start.
+func (t *Tail) Messages(msgs []model.Message) {
+	if len(msgs) < t.msgs {
+		t.Restart()
+	}
+	if t.msgs > 0 {
+		if last := msgs[t.msgs-1]; len(last.ToolCalls) > t.calls || len(last.Reasoning) > t.reasoning {
+			if t.format == FormatTable {
+				renderReasoning(t.w, last.Reasoning[min(t.reasoning, len(last.Reasoning)):])
+				renderToolCalls(t.w, last.ToolCalls[min(t.calls, len(last.ToolCalls)):])
+				fmt.Fprintln(t.w)
+			} else {
+				t.stream(t.msgs-1, last)
+			}
+		}
+	}
+	for i := t.msgs; i < len(msgs); i++ {
+		if t.format == FormatTable {
+			renderMessage(t.w, msgs[i])
+		} else {
+			t.stream(i, msgs[i])
 		}
-		fmt.Fprintln(w)
 	}
+	if len(msgs) > 0 {
+		last := msgs[len(msgs)-1]
+		t.msgs, t.calls, t.reasoning = len(msgs), len(last.ToolCalls), len(last.Reasoning)
+	}
+}
+
+// Restart forgets what was printed, for a transcript that was replaced: the
+// next Messages prints all of its messages.
+func (t *Tail) Restart() {
+	t.msgs, t.calls, t.reasoning = 0, 0, 0
+}
+
+// tailMessage is a line of `tail --ndjson` output.
+type tailMessage struct {
+	Index   int           `json:"index"`
+	Message model.Message `json:"message"`
+}
+
+func (t *Tail) stream(i int, m model.Message) {
+	renderNDJSON(t.w, []tailMessage{{i, sanitizeMessage(m)}})
+}
+
+// StatusLabel is the STATUS of a session in tables: its state in capitals
+// while it runs ("WAITING"), ACTIVE when the state is unknown, and "-" once
+// it has ended.
+func StatusLabel(s model.Session) string {
+	switch {
+	case !s.Active:
+		return "-"
+	case s.State == "":
+		return "ACTIVE"
+	}
+	return strings.ToUpper(string(s.State))
+}
+
+// processNote describes how an active session was bound to its process:
+// " (pid 4242, exact)", or "" when nothing is known.
+func processNote(s *model.Session) string {
+	var parts []string
+	if s.PID != 0 {
+		parts = append(parts, fmt.Sprintf("pid %d", s.PID))
+	}
+	if s.Confidence != "" {
+		parts = append(parts, string(s.Confidence))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
 }
 
 func renderSearchTable(w io.Writer, results []model.SearchResult) {
@@ -192,6 +463,10 @@ func renderSearchTable(w io.Writer, results []model.SearchResult) {
 		return
 	}
 
+	// Matched text is emphasized only when w is a terminal.
+	style := lipgloss.NewRenderer(w).NewStyle().Bold(true).Foreground(lipgloss.Color("3")) // yellow
+	mark := func(s string) string { return style.Render(s) }
+
 	for _, r := range results {
 		fmt.Fprintf(w, "%s  %-28s  %s\n",
 			r.Session.QualifiedID(),
@@ -199,18 +474,55 @@ func renderSearchTable(w io.Writer, results []model.SearchResult) {
 			r.Session.StartedAt.Local().Format("2006-01-02"))
 
 		for _, m := range r.Matches {
-			fmt.Fprintf(w, "  [%s] %s\n", m.Role, m.Snippet)
+			fmt.Fprintf(w, "  [%s] %s\n", matchLabel(m), highlight(m.Snippet, m.Highlights, mark))
 		}
 		fmt.Fprintln(w)
 	}
 }
 
+// matchLabel names where a match is: its message's role, plus the tool call
+// for matches in a tool input or output ("assistant tool_input#1").
+func matchLabel(m model.SearchMatch) string {
+	switch m.Location {
+	case model.LocationToolInput, model.LocationToolOutput:
+		return fmt.Sprintf("%s %s#%d", m.Role, m.Location, m.ToolCall)
+	case model.LocationReasoning:
+		return fmt.Sprintf("%s %s", m.Role, m.Location)
+	}
+	return string(m.Role)
+}
+
+// highlight applies mark to the given spans of s, ignoring spans that are
+// out of order or out of range.
+func highlight(s string, spans []model.Span, mark func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		if sp.Start < last || sp.End <= sp.Start || sp.End > len(s) {
+			continue
+		}
+		b.WriteString(s[last:sp.Start])
+		b.WriteString(mark(s[sp.Start:sp.End]))
+		last = sp.End
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
 func renderJSON(w io.Writer, v interface{}) {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	enc.Encode(v)
 }
 
+// renderNDJSON writes each item as one line of JSON.
+func renderNDJSON[T any](w io.Writer, items []T) {
+	enc := json.NewEncoder(w)
+	for _, it := range items {
+		enc.Encode(it)
+	}
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -218,6 +530,28 @@ func truncate(s string, maxLen int) string {
 	return s[:maxLen-3] + "..."
 }
 
+// FormatTokens returns a token count in short form: "950", "12.3k", "4.1M".
+func FormatTokens(n int64) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 1000000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	}
+}
+
+// FormatElapsed returns a time spent on a session to the minute: "0m",
+// "42m", "3h05m".
+func FormatElapsed(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", m/60, m%60)
+}
+
 // FormatDuration returns a human-readable duration like "2h", "3d", "1w".
 func FormatDuration(d time.Duration) string {
 	hours := int(d.Hours())
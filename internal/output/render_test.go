@@ -3,7 +3,9 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -183,6 +185,100 @@ func TestRenderTable_WithSessions(t *testing.T) {
 	if !strings.Contains(got, "fix the login bug") {
 		t.Error("expected session preview in table output")
 	}
+	if strings.Contains(got, "HOST") {
+		t.Error("expected no HOST column without remote sessions")
+	}
+}
+
+func TestRenderTable_Duration(t *testing.T) {
+	start := time.Date(2024, 2, 15, 10, 0, 0, 0, time.UTC)
+	sessions := []model.Session{
+		{ID: "abc12345", Tool: model.ToolClaude, StartedAt: start, EndedAt: start.Add(3 * time.Hour), Duration: 3 * time.Hour, ActiveDuration: 65 * time.Minute},
+		{ID: "def67890", Tool: model.ToolCursor, StartedAt: start}, // no timestamps
+		{ID: "0a1b2c3d", Tool: model.ToolCodex, StartedAt: start, Untimed: true},
+	}
+
+	var buf bytes.Buffer
+	renderTable(&buf, sessions)
+	lines := strings.Split(buf.String(), "\n")
+
+	if !strings.HasSuffix(lines[0], "STARTED            DURATION  STATUS") {
+		t.Errorf("expected DURATION header before STATUS, got %q", lines[0])
+	}
+	if len(lines[1]) != 150 {
+		t.Errorf("rule is %d wide, want 150", len(lines[1]))
+	}
+	if !strings.HasSuffix(lines[2], " 1h05m     -") {
+		t.Errorf("timed row = %q, want its active time", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], " -         -") {
+		t.Errorf("row without timestamps = %q, want no duration", lines[3])
+	}
+	if !strings.HasSuffix(lines[4], " pending   -") {
+		t.Errorf("untimed row = %q, want its duration pending", lines[4])
+	}
+	if !strings.Contains(buf.String(), "DURATION pending: not timed yet") {
+		t.Error("expected a note on pending durations")
+	}
+}
+
+func TestRenderTable_WithHosts(t *testing.T) {
+	sessions := []model.Session{
+		{ID: "abc12345", Tool: model.ToolClaude, Project: "/Users/foo/local"},
+		{ID: "def67890", Tool: model.ToolCodex, Project: "/home/dev/remote", Host: "devvm"},
+	}
+
+	var buf bytes.Buffer
+	renderTable(&buf, sessions)
+	lines := strings.Split(buf.String(), "\n")
+
+	if !strings.Contains(lines[0], "HOST") {
+		t.Errorf("expected HOST header, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "claude   local ") {
+		t.Errorf("local row = %q, want host \"local\"", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "codex    devvm ") {
+		t.Errorf("remote row = %q, want host \"devvm\"", lines[3])
+	}
+}
+
+func TestRenderTable_WithPIDs(t *testing.T) {
+	sessions := []model.Session{
+		{ID: "abc12345", Tool: model.ToolClaude, Active: true, PID: 4242, Confidence: model.ConfidenceExact},
+		{ID: "def67890", Tool: model.ToolCursor, Active: true, Confidence: model.ConfidenceHeuristic},
+	}
+
+	var buf bytes.Buffer
+	renderTable(&buf, sessions)
+	lines := strings.Split(buf.String(), "\n")
+
+	if !strings.HasSuffix(lines[0], "STATUS  PID") {
+		t.Errorf("expected PID header after STATUS, got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[2], "ACTIVE  4242") {
+		t.Errorf("bound row = %q, want its PID", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], "ACTIVE  -") {
+		t.Errorf("unbound row = %q, want no PID", lines[3])
+	}
+}
+
+func TestStatusLabel(t *testing.T) {
+	tests := []struct {
+		sess model.Session
+		want string
+	}{
+		{model.Session{State: model.StateEnded}, "-"},
+		{model.Session{Active: true}, "ACTIVE"},
+		{model.Session{Active: true, State: model.StateWaiting}, "WAITING"},
+		{model.Session{Active: true, State: model.StateError}, "ERROR"},
+	}
+	for _, tt := range tests {
+		if got := StatusLabel(tt.sess); got != tt.want {
+			t.Errorf("StatusLabel(%+v) = %q, want %q", tt.sess, got, tt.want)
+		}
+	}
 }
 
 func TestRenderJSON(t *testing.T) {
@@ -217,6 +313,7 @@ func TestRenderSessionDetail(t *testing.T) {
 		Project:   "/Users/foo/myproject",
 		Branch:    "main",
 		Model:     "claude-sonnet-4-20250514",
+		Version:   "2.0.14",
 		StartedAt: time.Date(2024, 2, 15, 10, 0, 0, 0, time.UTC),
 		Active:    true,
 		Messages: []model.Message{
@@ -229,8 +326,10 @@ func TestRenderSessionDetail(t *testing.T) {
 				Role:      model.RoleAssistant,
 				Content:   "hi there!",
 				Timestamp: time.Date(2024, 2, 15, 10, 0, 5, 0, time.UTC),
+				Reasoning: "**Reading**\n\n**Testing**",
 				ToolCalls: []model.ToolCall{
 					{Name: "Read"},
+					{Name: "shell", Input: "go test\n  ./..."},
 				},
 			},
 		},
@@ -256,6 +355,9 @@ func TestRenderSessionDetail(t *testing.T) {
 	if !strings.Contains(got, "claude-sonnet-4-20250514") {
 		t.Error("expected model in detail output")
 	}
+	if !strings.Contains(got, "Version: 2.0.14\n") {
+		t.Error("expected version in detail output")
+	}
 	if !strings.Contains(got, "ACTIVE") {
 		t.Error("expected ACTIVE status in detail output")
 	}
@@ -273,9 +375,15 @@ func TestRenderSessionDetail(t *testing.T) {
 	if !strings.Contains(got, "hi there!") {
 		t.Error("expected assistant message content in detail output")
 	}
-	if !strings.Contains(got, "[tool: Read]") {
+	if !strings.Contains(got, "hi there!\n  [reasoning] **Reading** **Testing**\n  [tool: Read]\n") {
+		t.Error("expected reasoning on one line before the tool calls in detail output")
+	}
+	if !strings.Contains(got, "[tool: Read]\n") {
 		t.Error("expected tool call in detail output")
 	}
+	if !strings.Contains(got, "[tool: shell] go test ./...\n") {
+		t.Error("expected tool input flattened onto one line in detail output")
+	}
 }
 
 func TestRenderSessionDetail_NoBranch(t *testing.T) {
@@ -299,6 +407,160 @@ func TestRenderSessionDetail_NoBranch(t *testing.T) {
 	if strings.Contains(got, "ACTIVE") {
 		t.Error("expected no ACTIVE when not active")
 	}
+	if strings.Contains(got, "Host:") {
+		t.Error("expected no Host line for a local session")
+	}
+}
+
+func TestRenderSessionDetail_Process(t *testing.T) {
+	tests := []struct {
+		name string
+		sess model.Session
+		want string
+	}{
+		{"exact", model.Session{Active: true, PID: 4242, Confidence: model.ConfidenceExact, State: model.StateWorking}, "Status:  WORKING (pid 4242, exact)\n"},
+		{"heuristic", model.Session{Active: true, Confidence: model.ConfidenceHeuristic}, "Status:  ACTIVE (heuristic)\n"},
+		{"unknown", model.Session{Active: true}, "Status:  ACTIVE\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			renderSessionDetail(&buf, &tt.sess)
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("expected %q, got:\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestRenderSessionDetail_Timing(t *testing.T) {
+	start := time.Date(2024, 2, 15, 10, 0, 0, 0, time.Local)
+	sess := &model.Session{ID: "abc12345", Tool: model.ToolClaude, StartedAt: start,
+		EndedAt: start.Add(3*time.Hour + 12*time.Minute), Duration: 3*time.Hour + 12*time.Minute, ActiveDuration: 42 * time.Minute}
+
+	var buf bytes.Buffer
+	renderSessionDetail(&buf, sess)
+	if want := "Started: 2024-02-15 10:00:00\nEnded:   2024-02-15 13:12:00\nTime:    42m active of 3h12m\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("expected %q, got:\n%s", want, buf.String())
+	}
+
+	buf.Reset()
+	renderSessionDetail(&buf, &model.Session{ID: "abc12345", Tool: model.ToolCursor, StartedAt: start})
+	if strings.Contains(buf.String(), "Ended:") || strings.Contains(buf.String(), "Time:") {
+		t.Errorf("expected no timing without message timestamps, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderSessionDetail_Host(t *testing.T) {
+	sess := &model.Session{ID: "abc12345", Tool: model.ToolCodex, Host: "devvm"}
+
+	var buf bytes.Buffer
+	renderSessionDetail(&buf, sess)
+	if !strings.Contains(buf.String(), "Host:    devvm") {
+		t.Errorf("expected Host line, got:\n%s", buf.String())
+	}
+}
+
+func TestRenderSessionDetail_Usage(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage model.Usage
+		want  string
+	}{
+		{"none", model.Usage{}, ""},
+		{
+			"claude",
+			model.Usage{InputTokens: 1200, CacheReadTokens: 10000, CacheCreationTokens: 500, OutputTokens: 650, CostUSD: 0.1316, Duration: 125400 * time.Millisecond},
+			"Usage:   12.3k tokens (in 1.2k, cache read 10.0k, cache write 500, out 650), $0.132, 2m5s\n",
+		},
+		{
+			"codex",
+			model.Usage{InputTokens: 300000, CacheReadTokens: 1200000, OutputTokens: 9000, ReasoningTokens: 4000},
+			"Usage:   1.5M tokens (in 300.0k, cache read 1.2M, out 9.0k, reasoning 4.0k)\n",
+		},
+		{"cost only", model.Usage{CostUSD: 0.5}, "Usage:   0 tokens, $0.500\n"},
+		{"estimated", model.Usage{InputTokens: 2000, EstimatedCostUSD: 0.0025}, "Usage:   2.0k tokens (in 2.0k), ~$0.003 estimated\n"},
+		{"partly estimated", model.Usage{OutputTokens: 10, CostUSD: 0.1, EstimatedCostUSD: 0.02}, "Usage:   10 tokens (out 10), $0.100 + ~$0.020 estimated\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			renderSessionDetail(&buf, &model.Session{ID: "abc12345", Tool: model.ToolClaude, Usage: tt.usage})
+			got := buf.String()
+			if tt.want == "" {
+				if strings.Contains(got, "Usage:") {
+					t.Errorf("expected no Usage line, got:\n%s", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("expected %q, got:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderSession_JSONUsage(t *testing.T) {
+	sess := &model.Session{
+		ID:       "abc12345",
+		Tool:     model.ToolClaude,
+		Usage:    model.Usage{InputTokens: 10, OutputTokens: 5, CostUSD: 0.01},
+		Messages: []model.Message{{Role: model.RoleAssistant, Usage: model.Usage{InputTokens: 10, OutputTokens: 5, CostUSD: 0.01}}},
+	}
+	var buf bytes.Buffer
+	renderJSON(&buf, sanitizeSession(sess))
+
+	var parsed model.Session
+	if err := json.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if parsed.Usage != sess.Usage || parsed.Messages[0].Usage != sess.Usage {
+		t.Errorf("round-trip usage = %+v / %+v, want %+v", parsed.Usage, parsed.Messages[0].Usage, sess.Usage)
+	}
+
+	buf.Reset()
+	renderJSON(&buf, sanitizeSession(&model.Session{ID: "abc12345"}))
+	if strings.Contains(buf.String(), "Usage") {
+		t.Errorf("expected no Usage for a session without it, got: %s", buf.String())
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0m"},
+		{59 * time.Second, "0m"},
+		{42 * time.Minute, "42m"},
+		{time.Hour, "1h00m"},
+		{3*time.Hour + 5*time.Minute + 30*time.Second, "3h05m"},
+		{30 * time.Hour, "30h00m"},
+	}
+	for _, tt := range tests {
+		if got := FormatElapsed(tt.d); got != tt.want {
+			t.Errorf("FormatElapsed(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0k"},
+		{12345, "12.3k"},
+		{999949, "999.9k"},
+		{4100000, "4.1M"},
+	}
+	for _, tt := range tests {
+		if got := FormatTokens(tt.n); got != tt.want {
+			t.Errorf("FormatTokens(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
 }
 
 func TestRenderSearchTable_Empty(t *testing.T) {
@@ -324,12 +586,26 @@ func TestRenderSearchTable_WithResults(t *testing.T) {
 					MessageIndex: 0,
 					Snippet:      "found the bug in login",
 					Role:         model.RoleUser,
+					Highlights:   []model.Span{{Start: 10, End: 13}},
 				},
 				{
 					MessageIndex: 1,
 					Snippet:      "fixing the bug now",
 					Role:         model.RoleAssistant,
 				},
+				{
+					MessageIndex: 1,
+					Snippet:      "FAIL: bug_test.go",
+					Role:         model.RoleAssistant,
+					Location:     model.LocationToolOutput,
+					ToolCall:     2,
+				},
+				{
+					MessageIndex: 1,
+					Snippet:      "**Finding the bug**",
+					Role:         model.RoleAssistant,
+					Location:     model.LocationReasoning,
+				},
 			},
 		},
 	}
@@ -350,6 +626,12 @@ func TestRenderSearchTable_WithResults(t *testing.T) {
 	if !strings.Contains(got, "[assistant]") {
 		t.Error("expected assistant role marker in search output")
 	}
+	if !strings.Contains(got, "[assistant tool_output#2] FAIL: bug_test.go") {
+		t.Errorf("expected tool call location in search output, got: %s", got)
+	}
+	if !strings.Contains(got, "[assistant reasoning] **Finding the bug**") {
+		t.Errorf("expected reasoning location in search output, got: %s", got)
+	}
 }
 
 // TestRenderSessions_Table exercises the public RenderSessions with table format.
@@ -461,7 +743,8 @@ func TestRenderSearchResults_JSON(t *testing.T) {
 	results := []model.SearchResult{
 		{
 			Session: model.Session{ID: "sr-test", Tool: model.ToolClaude},
-			Matches: []model.SearchMatch{{Snippet: "found it", Role: model.RoleUser}},
+			Matches: []model.SearchMatch{{Snippet: "found it", Role: model.RoleUser, Highlights: []model.Span{{Start: 0, End: 5}}, Location: model.LocationToolInput, ToolCall: 1}},
+			Score:   2.5,
 		},
 	}
 	RenderSearchResults(results, FormatJSON)
@@ -475,6 +758,157 @@ func TestRenderSearchResults_JSON(t *testing.T) {
 	if !strings.Contains(buf.String(), "sr-test") {
 		t.Error("expected session ID in search results JSON output")
 	}
+	if !strings.Contains(buf.String(), `"Score": 2.5`) {
+		t.Errorf("expected Score in search results JSON output, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"Location": "tool_input"`) || !strings.Contains(buf.String(), `"ToolCall": 1`) {
+		t.Errorf("expected match location in search results JSON output, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), `"Start": 0`) {
+		t.Errorf("expected Highlights in search results JSON output, got %s", buf.String())
+	}
+}
+
+func TestRender_NDJSON(t *testing.T) {
+	sessions := []model.Session{{ID: "nd-1", Tool: model.ToolClaude}, {ID: "nd-2", Title: "bell\x07", Tool: model.ToolCodex}}
+	results := []model.SearchResult{{Session: sessions[0]}, {Session: sessions[1]}}
+	tests := []struct {
+		name   string
+		render func()
+		lines  int
+	}{
+		{"RenderSessions", func() { RenderSessions(sessions, FormatNDJSON) }, 2},
+		{"RenderSession", func() { RenderSession(&sessions[1], FormatNDJSON) }, 1},
+		{"RenderSearchResults", func() { RenderSearchResults(results, FormatNDJSON) }, 2},
+		{"StreamSearchResult", func() { StreamSearchResult(results[1]) }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			tt.render()
+			w.Close()
+			os.Stdout = old
+
+			var buf bytes.Buffer
+			buf.ReadFrom(r)
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != tt.lines {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), tt.lines, buf.String())
+			}
+			for _, line := range lines {
+				if !json.Valid([]byte(line)) || strings.Contains(line, `\u0007`) {
+					t.Errorf("line is not sanitized JSON: %s", line)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamSessionEvent(t *testing.T) {
+	r, w, _ := os.Pipe()
+	orig := os.Stdout
+	os.Stdout = w
+	at := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	StreamSessionEvent("session_started", at, model.Session{ID: "abc", Tool: model.ToolCodex, Title: "bell\x07"})
+	os.Stdout = orig
+	w.Close()
+	out, _ := io.ReadAll(r)
+
+	want := `{"event":"session_started","time":"2026-03-01T10:00:00Z","session":{"ID":"abc","Tool":"codex","Title":"bell"`
+	if !strings.HasPrefix(string(out), want) || strings.Count(string(out), "\n") != 1 {
+		t.Errorf("StreamSessionEvent() = %q, want one line starting %q", out, want)
+	}
+}
+
+func TestTail(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	user := model.Message{Role: model.RoleUser, Content: "run the tests", Timestamp: ts}
+	call := model.Message{Role: model.RoleAssistant, Timestamp: ts,
+		ToolCalls: []model.ToolCall{{Name: "shell", Input: "go  test\n./..."}}}
+	called := call
+	called.ToolCalls = append(slices.Clone(call.ToolCalls), model.ToolCall{Name: "read"})
+	called.Reasoning = "**Reading\nthe output**"
+	reply := model.Message{Role: model.RoleAssistant, Content: "All green.", Timestamp: ts}
+	sess := &model.Session{ID: "abc", Tool: model.ToolCodex, Project: "/tmp/p"}
+
+	t.Run("table", func(t *testing.T) {
+		var buf bytes.Buffer
+		orig := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		tail := NewTail(sess, FormatTable)
+		os.Stdout = orig
+		w.Close()
+		header, _ := io.ReadAll(r)
+		if !strings.HasPrefix(string(header), "Session: abc (codex)\nProject: /tmp/p\n") {
+			t.Errorf("NewTail header = %q", header)
+		}
+
+		tail.w = &buf
+		tail.Messages([]model.Message{user, call})
+		if out := buf.String(); !strings.Contains(out, "run the tests") || !strings.Contains(out, "  [tool: shell] go test ./...\n") {
+			t.Errorf("first messages = %q", out)
+		}
+		buf.Reset()
+		tail.Messages([]model.Message{user, called, reply})
+		want := "  [reasoning] **Reading the output**\n  [tool: read]\n\n--- [assistant] " + ts.Local().Format("15:04:05") + " ---\nAll green.\n\n"
+		if buf.String() != want {
+			t.Errorf("update = %q, want %q", buf.String(), want)
+		}
+		buf.Reset()
+		tail.Messages([]model.Message{user, called, reply})
+		if buf.Len() != 0 {
+			t.Errorf("nothing new printed %q", buf.String())
+		}
+		// A rewritten transcript is printed again.
+		tail.Messages([]model.Message{user})
+		if !strings.Contains(buf.String(), "run the tests") {
+			t.Errorf("after rewrite = %q", buf.String())
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		var buf bytes.Buffer
+		tail := NewTail(sess, FormatNDJSON)
+		tail.w = &buf
+		tail.Messages([]model.Message{user, call})
+		tail.Messages([]model.Message{user, called, reply})
+		tail.Restart()
+		tail.Messages(nil)
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		var idx []int
+		for _, l := range lines {
+			var tm tailMessage
+			if err := json.Unmarshal([]byte(l), &tm); err != nil {
+				t.Fatalf("line %q: %v", l, err)
+			}
+			idx = append(idx, tm.Index)
+		}
+		if !slices.Equal(idx, []int{0, 1, 1, 2}) {
+			t.Errorf("indexes = %v, want [0 1 1 2] (message 1 again with its new call)", idx)
+		}
+	})
+}
+
+func TestHighlight(t *testing.T) {
+	mark := func(s string) string { return "<" + s + ">" }
+	tests := []struct {
+		spans []model.Span
+		want  string
+	}{
+		{nil, "fix the bug"},
+		{[]model.Span{{Start: 0, End: 3}, {Start: 8, End: 11}}, "<fix> the <bug>"},
+		{[]model.Span{{Start: 4, End: 7}}, "fix <the> bug"},
+		// Overlapping, empty and out-of-range spans are skipped.
+		{[]model.Span{{Start: 0, End: 5}, {Start: 2, End: 7}, {Start: 8, End: 8}, {Start: 8, End: 20}}, "<fix t>he bug"},
+	}
+	for _, tt := range tests {
+		if got := highlight("fix the bug", tt.spans, mark); got != tt.want {
+			t.Errorf("highlight(%v) = %q, want %q", tt.spans, got, tt.want)
+		}
+	}
 }
 
 func TestSanitizeString(t *testing.T) {
@@ -554,14 +988,16 @@ func TestSanitizeSession(t *testing.T) {
 		Project: "/clean/path",
 		Branch:  "feat/\x07bell-branch",
 		Model:   "claude-\x00opus",
+		Version: "1.\x1b0",
 		Messages: []model.Message{
 			{
 				Role:    model.RoleUser,
 				Content: "content with \x07bell and \x1b[31mANSI\x1b[0m",
 			},
 			{
-				Role:    model.RoleAssistant,
-				Content: "clean content",
+				Role:      model.RoleAssistant,
+				Content:   "clean content",
+				Reasoning: "reasoning with \x1b[1mbold",
 				ToolCalls: []model.ToolCall{
 					{
 						Name:   "Read\x00File",
@@ -596,9 +1032,15 @@ func TestSanitizeSession(t *testing.T) {
 	if sanitized.Model != "claude-opus" {
 		t.Errorf("expected sanitized model, got %q", sanitized.Model)
 	}
+	if sanitized.Version != "1.0" {
+		t.Errorf("expected sanitized version, got %q", sanitized.Version)
+	}
 	if sanitized.Messages[0].Content != "content with bell and [31mANSI[0m" {
 		t.Errorf("expected sanitized message content, got %q", sanitized.Messages[0].Content)
 	}
+	if sanitized.Messages[1].Reasoning != "reasoning with [1mbold" {
+		t.Errorf("expected sanitized reasoning, got %q", sanitized.Messages[1].Reasoning)
+	}
 	if sanitized.Messages[1].ToolCalls[0].Name != "ReadFile" {
 		t.Errorf("expected sanitized tool call name, got %q", sanitized.Messages[1].ToolCalls[0].Name)
 	}
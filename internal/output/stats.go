@@ -0,0 +1,100 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/psacc/omnisess/internal/stats"
+)
+
+// RenderStats outputs a stats report in the given format: a table with a
+// total line, the report as JSON, its rows as NDJSON, or its rows as CSV
+// with one column per token kind. The table adds estimated costs to
+// recorded ones and marks them with ~; the other formats keep them apart.
+func RenderStats(r stats.Report, format Format) {
+	r = sanitizeReport(r)
+	switch format {
+	case FormatJSON:
+		renderJSON(os.Stdout, r)
+	case FormatNDJSON:
+		renderNDJSON(os.Stdout, r.Rows)
+	case FormatCSV:
+		renderStatsCSV(os.Stdout, r)
+	default:
+		renderStatsTable(os.Stdout, r)
+	}
+}
+
+// sanitizeReport returns r with its keys, which come from session fields,
+// sanitized.
+func sanitizeReport(r stats.Report) stats.Report {
+	rows := make([]stats.Row, len(r.Rows))
+	for i, row := range r.Rows {
+		row.Key = sanitizeString(row.Key)
+		rows[i] = row
+	}
+	r.Rows = rows
+	return r
+}
+
+func renderStatsTable(w io.Writer, r stats.Report) {
+	if len(r.Rows) == 0 {
+		fmt.Fprintln(w, "No sessions found.")
+		return
+	}
+	line := func(key string, row stats.Row) {
+		cost := "-"
+		switch {
+		case row.Usage.EstimatedCostUSD != 0:
+			cost = fmt.Sprintf("~$%.2f", row.Usage.Cost())
+		case row.Usage.CostUSD != 0:
+			cost = fmt.Sprintf("$%.2f", row.Usage.CostUSD)
+		}
+		fmt.Fprintf(w, "%-40s %8d %9d %10d %9s %9s\n",
+			truncate(key, 40), row.Sessions, row.Messages, row.ToolCalls, FormatTokens(row.Usage.Tokens()), cost)
+	}
+	rule := strings.Repeat("-", 91)
+
+	fmt.Fprintf(w, "%-40s %8s %9s %10s %9s %9s\n",
+		strings.ToUpper(string(r.GroupBy)), "SESSIONS", "MESSAGES", "TOOL CALLS", "TOKENS", "COST")
+	fmt.Fprintln(w, rule)
+	for _, row := range r.Rows {
+		key := row.Key
+		if key == "" {
+			key = "-"
+		}
+		line(key, row)
+	}
+	fmt.Fprintln(w, rule)
+	line("TOTAL", r.Total)
+	if r.Total.Usage.EstimatedCostUSD != 0 {
+		fmt.Fprintln(w, "\n~ partly estimated from model prices")
+	}
+}
+
+func renderStatsCSV(w io.Writer, r stats.Report) {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{string(r.GroupBy), "sessions", "messages", "tool_calls",
+		"input_tokens", "cache_read_tokens", "cache_creation_tokens", "output_tokens", "reasoning_tokens", "cost_usd", "estimated_cost_usd"})
+	for _, row := range r.Rows {
+		u := row.Usage
+		cw.Write([]string{
+			row.Key,
+			strconv.Itoa(row.Sessions),
+			strconv.Itoa(row.Messages),
+			strconv.Itoa(row.ToolCalls),
+			strconv.FormatInt(u.InputTokens, 10),
+			strconv.FormatInt(u.CacheReadTokens, 10),
+			strconv.FormatInt(u.CacheCreationTokens, 10),
+			strconv.FormatInt(u.OutputTokens, 10),
+			strconv.FormatInt(u.ReasoningTokens, 10),
+			strconv.FormatFloat(u.CostUSD, 'f', -1, 64),
+			strconv.FormatFloat(u.EstimatedCostUSD, 'f', -1, 64),
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/stats"
+)
+
+var report = stats.Report{
+	GroupBy: stats.ByProject,
+	Rows: []stats.Row{
+		{Key: "/src/api", Sessions: 2, Messages: 40, ToolCalls: 12, Usage: model.Usage{InputTokens: 1200, CacheReadTokens: 30000, OutputTokens: 800, CostUSD: 1.234}},
+		{Key: "", Sessions: 1, Messages: 3},
+	},
+	Total: stats.Row{Sessions: 3, Messages: 43, ToolCalls: 12, Usage: model.Usage{InputTokens: 1200, CacheReadTokens: 30000, OutputTokens: 800, CostUSD: 1.234}},
+}
+
+// renderStats returns what RenderStats writes to stdout.
+func renderStats(t *testing.T, r stats.Report, format Format) string {
+	t.Helper()
+	old := os.Stdout
+	pr, pw, _ := os.Pipe()
+	os.Stdout = pw
+	RenderStats(r, format)
+	pw.Close()
+	os.Stdout = old
+
+	var buf bytes.Buffer
+	buf.ReadFrom(pr)
+	return buf.String()
+}
+
+func TestRenderStats_Table(t *testing.T) {
+	got := renderStats(t, report, FormatTable)
+	for _, want := range []string{
+		"PROJECT                                  SESSIONS  MESSAGES TOOL CALLS    TOKENS      COST\n",
+		"/src/api                                        2        40         12     32.0k     $1.23\n",
+		"-                                               1         3          0         0         -\n",
+		"TOTAL                                           3        43         12     32.0k     $1.23\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q, got:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "estimated") {
+		t.Errorf("expected no estimate note without estimates, got:\n%s", got)
+	}
+
+	if got := renderStats(t, stats.Report{GroupBy: stats.ByTool}, FormatTable); got != "No sessions found.\n" {
+		t.Errorf("empty report = %q, want No sessions found.", got)
+	}
+}
+
+func TestRenderStats_TableEstimated(t *testing.T) {
+	r := stats.Report{
+		GroupBy: stats.ByModel,
+		Rows: []stats.Row{
+			{Key: "gpt-5-codex", Sessions: 1, Usage: model.Usage{InputTokens: 5000, EstimatedCostUSD: 0.5}},
+			{Key: "claude-opus-4", Sessions: 1, Usage: model.Usage{InputTokens: 100, CostUSD: 0.25}},
+		},
+		Total: stats.Row{Sessions: 2, Usage: model.Usage{InputTokens: 5100, CostUSD: 0.25, EstimatedCostUSD: 0.5}},
+	}
+	got := renderStats(t, r, FormatTable)
+	for _, want := range []string{
+		"gpt-5-codex                                     1         0          0      5.0k    ~$0.50\n",
+		"claude-opus-4                                   1         0          0       100     $0.25\n",
+		"TOTAL                                           2         0          0      5.1k    ~$0.75\n",
+		"~ partly estimated from model prices\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderStats_JSON(t *testing.T) {
+	r := report
+	r.Rows = append([]stats.Row{{Key: "/src/\x1bweb"}}, r.Rows...)
+	got := renderStats(t, r, FormatJSON)
+
+	var parsed stats.Report
+	if err := json.Unmarshal([]byte(got), &parsed); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v\n%s", err, got)
+	}
+	if parsed.GroupBy != stats.ByProject || len(parsed.Rows) != 3 || parsed.Rows[1] != report.Rows[0] || parsed.Total != report.Total {
+		t.Errorf("round-trip report = %+v, want %+v", parsed, r)
+	}
+	if parsed.Rows[0].Key != "/src/web" {
+		t.Errorf("Key = %q, want it sanitized", parsed.Rows[0].Key)
+	}
+	if r.Rows[0].Key != "/src/\x1bweb" {
+		t.Error("RenderStats modified the report")
+	}
+}
+
+func TestRenderStats_NDJSON(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(renderStats(t, report, FormatNDJSON)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per row, got %q", lines)
+	}
+	var row stats.Row
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil || row != report.Rows[0] {
+		t.Errorf("first line = %s (%v), want %+v", lines[0], err, report.Rows[0])
+	}
+}
+
+func TestRenderStats_CSV(t *testing.T) {
+	r := report
+	r.Rows = append(r.Rows, stats.Row{Key: "/src/web", Sessions: 1, Usage: model.Usage{InputTokens: 10, EstimatedCostUSD: 0.05}})
+	want := "project,sessions,messages,tool_calls,input_tokens,cache_read_tokens,cache_creation_tokens,output_tokens,reasoning_tokens,cost_usd,estimated_cost_usd\n" +
+		"/src/api,2,40,12,1200,30000,0,800,0,1.234,0\n" +
+		",1,3,0,0,0,0,0,0,0,0\n" +
+		"/src/web,1,0,0,10,0,0,0,0,0,0.05\n"
+	if got := renderStats(t, r, FormatCSV); got != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+}
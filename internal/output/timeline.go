@@ -0,0 +1,165 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/timeline"
+)
+
+// ganttWidth is the number of columns the lanes of the timeline span.
+const ganttWidth = 96
+
+// heatLevels shade the heatmap's cells, from no messages to the busiest
+// hour.
+var heatLevels = []string{"· ", "░░", "▒▒", "▓▓", "██"}
+
+// toolBars draws each tool's spans in the lanes with its own block and
+// color, so they tell apart without colors too.
+var toolBars = map[model.Tool]struct {
+	block rune
+	color string
+}{
+	model.ToolClaude: {'█', "208"}, // orange
+	model.ToolCodex:  {'▆', "2"},   // green
+	model.ToolCursor: {'▄', "4"},   // blue
+	model.ToolGemini: {'▂', "5"},   // magenta
+}
+
+// RenderTimeline outputs a timeline: the heatmap and the lanes, or the
+// timeline as JSON (NDJSON: on one line).
+func RenderTimeline(t timeline.Timeline, format Format) {
+	t = sanitizeTimeline(t)
+	switch format {
+	case FormatJSON:
+		renderJSON(os.Stdout, t)
+	case FormatNDJSON:
+		renderNDJSON(os.Stdout, []timeline.Timeline{t})
+	default:
+		renderHeatmap(os.Stdout, t)
+		fmt.Fprintln(os.Stdout)
+		renderLanes(os.Stdout, t)
+	}
+}
+
+// sanitizeTimeline returns t with the projects of its spans sanitized.
+func sanitizeTimeline(t timeline.Timeline) timeline.Timeline {
+	spans := make([]timeline.Span, len(t.Spans))
+	for i, s := range t.Spans {
+		s.Project = sanitizeString(s.Project)
+		spans[i] = s
+	}
+	t.Spans = spans
+	return t
+}
+
+// renderHeatmap writes one line per day with a cell per hour, shaded by
+// its messages relative to the busiest hour, and the day's total.
+func renderHeatmap(w io.Writer, t timeline.Timeline) {
+	busiest := 0
+	for _, d := range t.Heatmap {
+		for _, n := range d.Hours {
+			busiest = max(busiest, n)
+		}
+	}
+	fmt.Fprintf(w, "Messages per hour (busiest: %d)\n", busiest)
+
+	ticks := []byte(strings.Repeat(" ", 48))
+	for _, h := range []int{0, 6, 12, 18} {
+		copy(ticks[2*h:], fmt.Sprint(h))
+	}
+	fmt.Fprintf(w, "%-9s  %s\n", "", strings.TrimRight(string(ticks), " "))
+
+	for _, d := range t.Heatmap {
+		var cells strings.Builder
+		total := 0
+		for _, n := range d.Hours {
+			level := 0
+			if n > 0 {
+				level = (n*(len(heatLevels)-1) + busiest - 1) / busiest
+			}
+			cells.WriteString(heatLevels[level])
+			total += n
+		}
+		date, _ := time.ParseInLocation(time.DateOnly, d.Date, time.Local)
+		fmt.Fprintf(w, "%-9s  %s  %d\n", date.Format("Mon 01-02"), cells.String(), total)
+	}
+}
+
+// renderLanes draws the spans of t as bars on their lanes, under an axis
+// marking the start of each day.
+func renderLanes(w io.Writer, t timeline.Timeline) {
+	if len(t.Spans) == 0 {
+		fmt.Fprintln(w, "No sessions found.")
+		return
+	}
+	sessions := map[string]bool{}
+	for _, s := range t.Spans {
+		sessions[s.Session] = true
+	}
+	fmt.Fprintf(w, "%d sessions, up to %d at once\n", len(sessions), t.Lanes)
+
+	window := t.To.Sub(t.From)
+	col := func(ts time.Time) int {
+		if window <= 0 {
+			return 0
+		}
+		return min(int(float64(ts.Sub(t.From))/float64(window)*ganttWidth), ganttWidth-1)
+	}
+
+	axis := []rune(strings.Repeat(" ", ganttWidth+10))
+	end := -1
+	for _, d := range t.Heatmap {
+		day, _ := time.ParseInLocation(time.DateOnly, d.Date, time.Local)
+		c := col(later(day, t.From))
+		if c <= end {
+			continue
+		}
+		label := []rune("|" + day.Format("Mon 01-02"))
+		copy(axis[c:], label)
+		end = c + len(label)
+	}
+	fmt.Fprintf(w, "%-8s %s\n", "", strings.TrimRight(string(axis), " "))
+
+	r := lipgloss.NewRenderer(w)
+	lanes := make([][]string, t.Lanes)
+	for i := range lanes {
+		lanes[i] = make([]string, ganttWidth)
+		for c := range lanes[i] {
+			lanes[i][c] = " "
+		}
+	}
+	for _, s := range t.Spans {
+		bar, ok := toolBars[s.Tool]
+		if !ok {
+			bar.block = '▪'
+		}
+		cell := r.NewStyle().Foreground(lipgloss.Color(bar.color)).Render(string(bar.block))
+		for c := col(s.Start); c <= col(s.End); c++ {
+			lanes[s.Lane][c] = cell
+		}
+	}
+	for i, lane := range lanes {
+		fmt.Fprintf(w, "lane %-3d %s\n", i+1, strings.TrimRight(strings.Join(lane, ""), " "))
+	}
+
+	var legend []string
+	for _, tool := range []model.Tool{model.ToolClaude, model.ToolCodex, model.ToolCursor, model.ToolGemini} {
+		bar := toolBars[tool]
+		legend = append(legend, r.NewStyle().Foreground(lipgloss.Color(bar.color)).Render(string(bar.block))+" "+string(tool))
+	}
+	fmt.Fprintf(w, "%-8s %s\n", "", strings.Join(legend, "  "))
+}
+
+// later returns the later of two times.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/timeline"
+)
+
+// local returns 2026-10-<day> hh:00 in local time.
+func local(day, hh int) time.Time { return time.Date(2026, 10, day, hh, 0, 0, 0, time.Local) }
+
+// testTimeline covers the 96 hours from the 10th to the 14th, one per column.
+func testTimeline() timeline.Timeline {
+	t := timeline.Timeline{
+		From: local(10, 0), To: local(14, 0),
+		Heatmap: []timeline.Day{{Date: "2026-10-10"}, {Date: "2026-10-11"}, {Date: "2026-10-12"}, {Date: "2026-10-13"}, {Date: "2026-10-14"}},
+		Spans: []timeline.Span{
+			{Session: "claude:a", Tool: model.ToolClaude, Project: "/src/\x1bapi", Start: local(10, 1), End: local(10, 4), Messages: 8, Lane: 0},
+			{Session: "codex:b", Tool: model.ToolCodex, Start: local(10, 2), End: local(10, 2), Messages: 1, Lane: 1},
+			{Session: "claude:a", Tool: model.ToolClaude, Start: local(11, 0), End: local(11, 1), Messages: 2, Lane: 0},
+			{Session: "other:c", Tool: "other", Start: local(13, 23), End: local(14, 0), Lane: 1},
+		},
+		Lanes: 2,
+	}
+	t.Heatmap[0].Hours[1] = 1
+	t.Heatmap[0].Hours[2] = 8
+	t.Heatmap[0].Hours[3] = 2
+	t.Heatmap[1].Hours[23] = 5
+	return t
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	var buf bytes.Buffer
+	renderHeatmap(&buf, testTimeline())
+	want := "Messages per hour (busiest: 8)\n" +
+		"           0           6           12          18\n" +
+		"Sat 10-10  · ░░██░░· · · · · · · · · · · · · · · · · · · ·   11\n" +
+		"Sun 10-11  · · · · · · · · · · · · · · · · · · · · · · · ▓▓  5\n" +
+		"Mon 10-12  · · · · · · · · · · · · · · · · · · · · · · · ·   0\n"
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("heatmap =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderLanes(t *testing.T) {
+	var buf bytes.Buffer
+	renderLanes(&buf, testTimeline())
+	want := "3 sessions, up to 2 at once\n" +
+		"         |Sat 10-10              |Sun 10-11              |Mon 10-12              |Tue 10-13             |Wed 10-14\n" +
+		"lane 1    ████                   ██\n" +
+		"lane 2     ▆                                                                                            ▪\n" +
+		"         █ claude  ▆ codex  ▄ cursor  ▂ gemini\n"
+	if got := buf.String(); got != want {
+		t.Errorf("lanes =\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	renderLanes(&buf, timeline.Timeline{})
+	if buf.String() != "No sessions found.\n" {
+		t.Errorf("empty lanes = %q, want No sessions found.", buf.String())
+	}
+
+	// A window of no length draws everything in the first column, and
+	// day labels that would overlap are skipped.
+	buf.Reset()
+	tl := testTimeline()
+	tl.To = tl.From
+	renderLanes(&buf, tl)
+	if !strings.Contains(buf.String(), "         |Sat 10-10\nlane 1   █\nlane 2   ▪\n") {
+		t.Errorf("lanes of an empty window =\n%s", buf.String())
+	}
+}
+
+func TestRenderTimeline(t *testing.T) {
+	capture := func(format Format) string {
+		old := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		RenderTimeline(testTimeline(), format)
+		w.Close()
+		os.Stdout = old
+		var buf bytes.Buffer
+		buf.ReadFrom(r)
+		return buf.String()
+	}
+
+	got := capture(FormatTable)
+	if !strings.Contains(got, "Messages per hour") || !strings.Contains(got, "up to 2 at once") {
+		t.Errorf("table output =\n%s\nwant the heatmap and the lanes", got)
+	}
+
+	for _, format := range []Format{FormatJSON, FormatNDJSON} {
+		out := capture(format)
+		var parsed timeline.Timeline
+		if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+			t.Fatalf("%s: invalid JSON: %v\n%s", format, err, out)
+		}
+		if len(parsed.Spans) != 4 || parsed.Lanes != 2 || parsed.Heatmap[0].Hours[2] != 8 {
+			t.Errorf("%s: timeline = %+v", format, parsed)
+		}
+		if parsed.Spans[0].Project != "/src/api" {
+			t.Errorf("%s: Project = %q, want it sanitized", format, parsed.Spans[0].Project)
+		}
+	}
+	if n := strings.Count(capture(FormatNDJSON), "\n"); n != 1 {
+		t.Errorf("NDJSON output has %d lines, want 1", n)
+	}
+}
@@ -0,0 +1,134 @@
+// Package pricing estimates what model calls cost from their token usage,
+// for the transcripts that record tokens but no cost (Codex, newer Claude
+// Code versions).
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// Price is what a model charges, in USD per million tokens.
+type Price struct {
+	Input      float64 `yaml:"input"`       // prompt tokens not read from the cache
+	Output     float64 `yaml:"output"`      // including reasoning tokens
+	CacheRead  float64 `yaml:"cache_read"`  // prompt tokens read from the cache
+	CacheWrite float64 `yaml:"cache_write"` // prompt tokens written to the cache
+}
+
+// Cost returns what u costs at p.
+func (p Price) Cost(u model.Usage) float64 {
+	return (p.Input*float64(u.InputTokens) +
+		p.Output*float64(u.OutputTokens) +
+		p.CacheRead*float64(u.CacheReadTokens) +
+		p.CacheWrite*float64(u.CacheCreationTokens)) / 1e6
+}
+
+// Table maps model names, as in Session.Model, to their prices. A name also
+// prices the dated and minor versions of the model, so "claude-sonnet-4"
+// covers "claude-sonnet-4-20250514" and "claude-sonnet-4-5-20250929"; the
+// longest matching name wins. Other variants ("gpt-4.1-mini", "o3-pro") need
+// names of their own.
+type Table map[string]Price
+
+// Default holds the list prices of the models Claude Code and Codex use.
+var Default = Table{
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4":    {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+
+	"gpt-5":              {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-codex":        {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5.1":            {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5.1-codex":      {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5.1-codex-max":  {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":         {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":         {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+	"gpt-5-codex-mini":   {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5.1-codex-mini": {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-4.1":            {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3":                 {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3-mini":            {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o4-mini":            {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+	"codex-mini":         {Input: 1.5, Output: 6, CacheRead: 0.375},
+}
+
+// Lookup returns the price of the model named name.
+func (t Table) Lookup(name string) (Price, bool) {
+	var (
+		best  string
+		price Price
+		found bool
+	)
+	for prefix, p := range t {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if ok && versionSuffix(rest) && (!found || len(prefix) > len(best)) {
+			best, price, found = prefix, p, true
+		}
+	}
+	return price, found
+}
+
+// versionSuffix reports whether s, what a model name adds to a name in the
+// table, is only dates and version numbers: "", "-20250514", "-1-20250805",
+// "-2025-04-14" or "@20250514".
+func versionSuffix(s string) bool {
+	for s != "" {
+		if s[0] != '-' && s[0] != '@' {
+			return false
+		}
+		n := 1
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
+		}
+		if n == 1 {
+			return false
+		}
+		s = s[n:]
+	}
+	return true
+}
+
+// With returns a copy of t in which the prices of overrides replace or add
+// to its own. A negative price is an error.
+func (t Table) With(overrides Table) (Table, error) {
+	out := make(Table, len(t)+len(overrides))
+	for name, p := range t {
+		out[name] = p
+	}
+	for name, p := range overrides {
+		if p.Input < 0 || p.Output < 0 || p.CacheRead < 0 || p.CacheWrite < 0 {
+			return nil, fmt.Errorf("negative price for model %q", name)
+		}
+		out[name] = p
+	}
+	return out, nil
+}
+
+// Estimate sets the EstimatedCostUSD of the messages of s that used tokens
+// but record no cost, at the price of the model that wrote each (s's model
+// when the message does not say), and totals them into s.Usage. Messages of
+// models not in t are left unestimated.
+func (t Table) Estimate(s *model.Session) {
+	for i := range s.Messages {
+		m := &s.Messages[i]
+		if m.Usage.CostUSD != 0 {
+			continue
+		}
+		name := m.Model
+		if name == "" {
+			name = s.Model
+		}
+		if p, ok := t.Lookup(name); ok {
+			m.Usage.EstimatedCostUSD = p.Cost(m.Usage)
+		}
+	}
+	s.Usage = model.SumUsage(s.Messages)
+}
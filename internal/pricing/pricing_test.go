@@ -0,0 +1,120 @@
+package pricing
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestPrice_Cost(t *testing.T) {
+	p := Price{Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}
+	u := model.Usage{InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 2_000_000, CacheCreationTokens: 400_000, ReasoningTokens: 50_000}
+	// 3 + 1.5 + 0.6 + 1.5; reasoning tokens are part of the output.
+	if got := p.Cost(u); !near(got, 6.6) {
+		t.Errorf("Cost() = %v, want 6.6", got)
+	}
+}
+
+func TestTable_Lookup(t *testing.T) {
+	tests := []struct {
+		model string
+		want  float64 // input price
+		found bool
+	}{
+		{"claude-sonnet-4-5-20250929", 3, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-opus-4-5-20251101", 5, true}, // the longer prefix wins
+		{"claude-sonnet-4@20250514", 3, true},
+		{"gpt-5-codex", 1.25, true},
+		{"gpt-5-codex-mini", 0.25, true},
+		{"gpt-4.1-2025-04-14", 2, true},
+		{"o3-mini", 1.1, true},
+		// Only dates and versions fall back to the base model's price.
+		{"gpt-4.1-mini", 0, false},
+		{"o3-pro", 0, false},
+		{"gpt-5.2", 0, false},
+		{"claude-sonnet-4-", 0, false},
+		{"gemini-2.5-pro", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := Default.Lookup(tt.model)
+		if ok != tt.found || p.Input != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %v; want input %v, %v", tt.model, p, ok, tt.want, tt.found)
+		}
+	}
+}
+
+func TestTable_With(t *testing.T) {
+	base := Table{"a": {Input: 1}, "b": {Input: 2}}
+	got, err := base.With(Table{"b": {Input: 20}, "c": {Input: 30}})
+	if err != nil {
+		t.Fatalf("With() error: %v", err)
+	}
+	for name, want := range map[string]float64{"a": 1, "b": 20, "c": 30} {
+		if got[name].Input != want {
+			t.Errorf("With()[%q].Input = %v, want %v", name, got[name].Input, want)
+		}
+	}
+	if base["b"].Input != 2 {
+		t.Error("With() modified the table")
+	}
+
+	if _, err := base.With(Table{"c": {CacheWrite: -1}}); err == nil || !strings.Contains(err.Error(), `"c"`) {
+		t.Errorf("With(negative) error = %v, want one naming the model", err)
+	}
+}
+
+func TestTable_Estimate(t *testing.T) {
+	table := Table{"m": {Input: 1, Output: 10}}
+	s := &model.Session{
+		Model: "m-1",
+		Messages: []model.Message{
+			{Role: model.RoleUser},
+			{Role: model.RoleAssistant, Usage: model.Usage{InputTokens: 1000, OutputTokens: 100, CostUSD: 0.5}},
+			{Role: model.RoleAssistant, Usage: model.Usage{InputTokens: 2000, OutputTokens: 300}},
+		},
+	}
+	table.Estimate(s)
+	if e := s.Messages[1].Usage.EstimatedCostUSD; e != 0 {
+		t.Errorf("recorded message estimated at %v, want none", e)
+	}
+	if e := s.Messages[2].Usage.EstimatedCostUSD; !near(e, 0.005) {
+		t.Errorf("EstimatedCostUSD = %v, want 0.005", e)
+	}
+	if s.Usage.CostUSD != 0.5 || !near(s.Usage.EstimatedCostUSD, 0.005) || s.Usage.InputTokens != 3000 {
+		t.Errorf("Usage = %+v, want the totals", s.Usage)
+	}
+
+	other := &model.Session{Model: "x", Messages: []model.Message{{Usage: model.Usage{InputTokens: 1000}}}}
+	table.Estimate(other)
+	if other.Usage.EstimatedCostUSD != 0 || other.Messages[0].Usage.EstimatedCostUSD != 0 {
+		t.Errorf("unknown model estimated: %+v", other)
+	}
+}
+
+func TestTable_Estimate_MessageModel(t *testing.T) {
+	table := Table{"cheap": {Input: 1}, "dear": {Input: 10}}
+	s := &model.Session{
+		Model: "cheap",
+		Messages: []model.Message{
+			{Role: model.RoleAssistant, Usage: model.Usage{InputTokens: 1000}},
+			// The model was switched mid-session.
+			{Role: model.RoleAssistant, Model: "dear", Usage: model.Usage{InputTokens: 1000}},
+			{Role: model.RoleAssistant, Model: "unknown", Usage: model.Usage{InputTokens: 1000}},
+		},
+	}
+	table.Estimate(s)
+	for i, want := range []float64{0.001, 0.01, 0} {
+		if e := s.Messages[i].Usage.EstimatedCostUSD; !near(e, want) {
+			t.Errorf("Messages[%d] estimated at %v, want %v", i, e, want)
+		}
+	}
+	if !near(s.Usage.EstimatedCostUSD, 0.011) {
+		t.Errorf("Usage.EstimatedCostUSD = %v, want 0.011", s.Usage.EstimatedCostUSD)
+	}
+}
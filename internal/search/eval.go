@@ -0,0 +1,270 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// node is one element of a parsed query.
+type node interface {
+	match(m *target) bool
+}
+
+// target is the text of a message a query is evaluated against: its
+// content, or the input or output of one of its tool calls. content is the
+// text folded to lower case once.
+type target struct {
+	sess    *model.Session
+	msg     *model.Message
+	text    string
+	content string
+}
+
+// termNode matches a text term, as written (folded), using its mode's finder.
+type termNode struct {
+	text string
+	f    finder
+}
+
+// fieldNode matches a qualifier against the message or its session.
+type fieldNode struct {
+	field string
+	value string    // lower-cased
+	at    time.Time // before: and after: dates
+}
+
+type notNode struct{ x node }
+
+type andNode []node
+
+type orNode []node
+
+func (n termNode) match(m *target) bool { return n.f.match(m) }
+
+func (n notNode) match(m *target) bool { return !n.x.match(m) }
+
+func (n andNode) match(m *target) bool {
+	for _, x := range n {
+		if !x.match(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(m *target) bool {
+	for _, x := range n {
+		if x.match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n fieldNode) match(m *target) bool {
+	switch n.field {
+	case "role":
+		return strings.EqualFold(string(m.msg.Role), n.value)
+	case "tool":
+		return strings.EqualFold(string(m.sess.Tool), n.value)
+	case "model":
+		return containsFold(m.sess.Model, n.value)
+	case "branch":
+		return containsFold(m.sess.Branch, n.value)
+	case "project":
+		return containsFold(m.sess.Project, n.value)
+	case "before":
+		return messageTime(m).Before(n.at)
+	case "after":
+		return !messageTime(m).Before(n.at)
+	default: // "has"; Parse only accepts has:toolcall
+		return len(m.msg.ToolCalls) > 0
+	}
+}
+
+// containsFold reports whether s contains the lower-cased substr, ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), substr)
+}
+
+// messageTime is the message's timestamp, or the session's last update for
+// sources that do not record one per message.
+func messageTime(m *target) time.Time {
+	if m.msg.Timestamp.IsZero() {
+		return m.sess.UpdatedAt
+	}
+	return m.msg.Timestamp
+}
+
+// Matcher decides which messages of a session a search matches. Sources
+// search through it rather than matching content themselves; *Query
+// implements it for every Mode.
+type Matcher interface {
+	// Matches returns one match per message of sess that matches, with a
+	// snippet highlighting every matched span.
+	Matches(sess *model.Session, messages []model.Message) []model.SearchMatch
+	// FTS returns an expression for the full-text index matching a superset
+	// of the messages, or ok false when the index cannot narrow the search.
+	FTS() (expr string, ok bool)
+}
+
+// snippetLen is the length snippets aim for, in bytes.
+const snippetLen = 200
+
+// Match reports whether q matches msg, a message of sess.
+func (q *Query) Match(sess *model.Session, msg *model.Message) bool {
+	return q.root.match(&target{sess: sess, msg: msg, text: msg.Content, content: fold(msg.Content)})
+}
+
+// Matches evaluates q against the texts of each of sess's messages in q's
+// scope and returns one match per matching text, with a ~200 character
+// snippet around the first span of a text term (or the start of the text
+// when q matched on qualifiers alone) and every term span within it
+// highlighted. Each text is evaluated on its own: all the terms of an AND
+// must occur in the same content, tool input or tool output.
+func (q *Query) Matches(sess *model.Session, messages []model.Message) []model.SearchMatch {
+	var matches []model.SearchMatch
+	for i := range messages {
+		msg := &messages[i]
+		for _, loc := range q.locations(msg) {
+			t := &target{sess: sess, msg: msg, text: loc.text, content: fold(loc.text)}
+			if !q.root.match(t) {
+				continue
+			}
+			var spans []model.Span
+			for _, term := range q.terms {
+				spans = append(spans, term.f.findAll(t)...)
+			}
+			text, highlights := snippet(loc.text, mergeSpans(spans), snippetLen)
+			matches = append(matches, model.SearchMatch{
+				MessageIndex: i,
+				Snippet:      text,
+				Role:         msg.Role,
+				Highlights:   highlights,
+				Location:     loc.location,
+				ToolCall:     loc.call,
+			})
+		}
+	}
+	return matches
+}
+
+// Scope selects which texts of a message a query searches.
+type Scope int
+
+const (
+	ScopeContent Scope = iota // message content (the default)
+	ScopeTools                // tool call inputs and outputs, and reasoning summaries
+	ScopeAll                  // both
+)
+
+// ParseScope parses the name of a scope: content, tools or all.
+func ParseScope(s string) (Scope, error) {
+	switch s {
+	case "content":
+		return ScopeContent, nil
+	case "tools":
+		return ScopeTools, nil
+	case "all":
+		return ScopeAll, nil
+	}
+	return 0, fmt.Errorf("search: unknown scope %q (want content, tools or all)", s)
+}
+
+// In returns a copy of q that searches the given texts of each message.
+func (q *Query) In(scope Scope) *Query {
+	c := *q
+	c.scope = scope
+	return &c
+}
+
+// location is one searchable text of a message.
+type location struct {
+	location model.MatchLocation
+	call     int
+	text     string
+}
+
+// locations returns the texts of msg that q's scope searches. Empty tool
+// texts are skipped, but content is searched even when empty so that
+// qualifier-only queries still match messages without text.
+func (q *Query) locations(msg *model.Message) []location {
+	var locs []location
+	if q.scope != ScopeTools {
+		locs = append(locs, location{location: model.LocationContent, text: msg.Content})
+	}
+	if q.scope != ScopeContent {
+		if msg.Reasoning != "" {
+			locs = append(locs, location{location: model.LocationReasoning, text: msg.Reasoning})
+		}
+		for i, tc := range msg.ToolCalls {
+			if tc.Input != "" {
+				locs = append(locs, location{location: model.LocationToolInput, call: i, text: tc.Input})
+			}
+			if tc.Output != "" {
+				locs = append(locs, location{location: model.LocationToolOutput, call: i, text: tc.Output})
+			}
+		}
+	}
+	return locs
+}
+
+// snippet returns about targetLen bytes of content centred on the first of
+// spans, with "..." marking cut ends, and the spans that fall within it
+// relative to the snippet. Control characters such as newlines become
+// spaces so the snippet fits on one line.
+func snippet(content string, spans []model.Span, targetLen int) (string, []model.Span) {
+	start, end := 0, len(content)
+	if len(content) > targetLen {
+		var first model.Span
+		if len(spans) > 0 {
+			first = spans[0]
+		}
+		half := max((targetLen-(first.End-first.Start))/2, 0)
+		start, end = first.Start-half, first.End+half
+		if start < 0 {
+			end -= start
+			start = 0
+		}
+		if end > len(content) {
+			start = max(start-(end-len(content)), 0)
+			end = len(content)
+		}
+		// Never cut a multi-byte rune in half.
+		for start > 0 && !utf8.RuneStart(content[start]) {
+			start--
+		}
+		for end < len(content) && !utf8.RuneStart(content[end]) {
+			end++
+		}
+	}
+
+	prefix, suffix := "", ""
+	if start > 0 {
+		prefix = "..."
+	}
+	if end < len(content) {
+		suffix = "..."
+	}
+	text := []byte(content[start:end])
+	for i, c := range text {
+		if c < 0x20 {
+			text[i] = ' '
+		}
+	}
+
+	var highlights []model.Span
+	for _, s := range spans {
+		s.Start, s.End = max(s.Start, start), min(s.End, end)
+		if s.Start >= s.End {
+			continue
+		}
+		shift := len(prefix) - start
+		highlights = append(highlights, model.Span{Start: s.Start + shift, End: s.End + shift})
+	}
+	return prefix + string(text) + suffix, highlights
+}
@@ -0,0 +1,251 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Match
+// ---------------------------------------------------------------------------
+
+func TestMatch(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 12, 0, 0, 0, time.Local) }
+	sess := &model.Session{
+		Tool:      model.ToolClaude,
+		Model:     "claude-opus-4",
+		Branch:    "feat/Config",
+		Project:   "/home/me/My-API",
+		UpdatedAt: day(20),
+	}
+	user := &model.Message{Role: model.RoleUser, Content: "Fix the Deploy script", Timestamp: day(10)}
+	asst := &model.Message{
+		Role:      model.RoleAssistant,
+		Content:   "Running the deploy",
+		ToolCalls: []model.ToolCall{{Name: "Bash"}},
+	}
+	thought := &model.Message{Role: model.RoleAssistant, Reasoning: "**Planning the deploy**"}
+
+	tests := []struct {
+		query string
+		msg   *model.Message
+		want  bool
+	}{
+		{"deploy", user, true},
+		{"DEPLOY script", user, true},
+		{`"deploy script"`, user, true},
+		{`"script deploy"`, user, false},
+		{"deploy missing", user, false},
+		{"missing OR fix", user, true},
+		{"missing OR other", user, false},
+		{"NOT deploy", user, false},
+		{"-missing", user, true},
+		{"role:user", user, true},
+		{"role:USER", asst, false},
+		{"tool:claude", user, true},
+		{"tool:claud", user, false},
+		{"tool:codex", user, false},
+		{"model:OPUS", user, true},
+		{"model:sonnet", user, false},
+		{"branch:config", user, true},
+		{"branch:main", user, false},
+		{"project:my-api", user, true},
+		{"project:web", user, false},
+		{"before:2026-09-11", user, true},
+		{"before:2026-09-10T12:00", user, false},
+		{"after:2026-09-10T12:00", user, true},
+		{"after:2026-09-11", user, false},
+		// Messages without a timestamp use the session's last update.
+		{"before:2026-09-11", asst, false},
+		{"after:2026-09-20", asst, true},
+		{"has:toolcall", asst, true},
+		{"has:toolcall", user, false},
+		{"deploy -has:toolcall", asst, false},
+		// A reasoning summary is not a tool call.
+		{"has:toolcall", thought, false},
+		{"(fix OR running) role:assistant", asst, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.query, tt.msg.Role), func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.Match(sess, tt.msg); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Matches
+// ---------------------------------------------------------------------------
+
+// marked renders a match's snippet with its highlights in brackets.
+func marked(m model.SearchMatch) string {
+	var b strings.Builder
+	last := 0
+	for _, h := range m.Highlights {
+		b.WriteString(m.Snippet[last:h.Start] + "[" + m.Snippet[h.Start:h.End] + "]")
+		last = h.End
+	}
+	return b.String() + m.Snippet[last:]
+}
+
+func TestMatches(t *testing.T) {
+	sess := &model.Session{Tool: model.ToolCodex}
+	messages := []model.Message{
+		{Role: model.RoleUser, Content: "please fix the Bug in deploy, the bug!"},
+		{Role: model.RoleAssistant, Content: "nothing\nhere"},
+		{Role: model.RoleAssistant, Content: "Deploy fixed the bugs: debugging"},
+	}
+
+	tests := []struct {
+		query string
+		mode  Mode
+		want  []string // "index:role:marked snippet"
+	}{
+		{"bug deploy", ModeSubstring, []string{
+			"0:user:please fix the [Bug] in [deploy], the [bug]!",
+			"2:assistant:[Deploy] fixed the [bug]s: de[bug]ging",
+		}},
+		{"role:assistant", ModeSubstring, []string{"1:assistant:nothing here", "2:assistant:Deploy fixed the bugs: debugging"}},
+		{"role:assistant -deploy", ModeSubstring, []string{"1:assistant:nothing here"}},
+		{"here -(bug)", ModeSubstring, []string{"1:assistant:nothing [here]"}},
+		{"fix OR fixed", ModeSubstring, []string{"0:user:please [fix] the Bug in deploy, the bug!", "2:assistant:Deploy [fixed] the bugs: debugging"}},
+		{"missing", ModeSubstring, nil},
+		{"deplyo", ModeFuzzy, []string{"0:user:please fix the Bug in [deploy], the bug!", "2:assistant:[Deploy] fixed the bugs: debugging"}},
+		{"ding", ModeFuzzy, nil},
+		{`bugs?\b`, ModeRegex, []string{"0:user:please fix the [Bug] in deploy, the [bug]!", "2:assistant:Deploy fixed the [bugs]: debugging"}},
+		{`z*`, ModeRegex, []string{
+			"0:user:please fix the Bug in deploy, the bug!",
+			"1:assistant:nothing here",
+			"2:assistant:Deploy fixed the bugs: debugging",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := ParseMode(tt.query, tt.mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range q.Matches(sess, messages) {
+				got = append(got, fmt.Sprintf("%d:%s:%s", m.MessageIndex, m.Role, marked(m)))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Matches(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches_Scope(t *testing.T) {
+	sess := &model.Session{Tool: model.ToolClaude}
+	messages := []model.Message{
+		{Role: model.RoleUser, Content: "run go test on internal/search"},
+		{Role: model.RoleAssistant, Content: "running it", Reasoning: "**Checking internal/search**", ToolCalls: []model.ToolCall{
+			{Name: "Read", Input: `{"file_path":"internal/search/eval.go"}`},
+			{Name: "Bash", Input: `{"command":"go test ./internal/search"}`, Output: "FAIL internal/search"},
+		}},
+	}
+
+	tests := []struct {
+		query string
+		scope Scope
+		want  []string // "message:location#call:marked snippet"
+	}{
+		{"internal/search", ScopeContent, []string{"0:content#0:run go test on [internal/search]"}},
+		{"internal/search", ScopeTools, []string{
+			"1:reasoning#0:**Checking [internal/search]**",
+			`1:tool_input#0:{"file_path":"[internal/search]/eval.go"}`,
+			`1:tool_input#1:{"command":"go test ./[internal/search]"}`,
+			"1:tool_output#1:FAIL [internal/search]",
+		}},
+		{"fail OR running", ScopeAll, []string{"1:content#0:[running] it", "1:tool_output#1:[FAIL] internal/search"}},
+		// Terms must occur in the same text.
+		{"running eval.go", ScopeAll, nil},
+		{"role:user", ScopeTools, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range q.In(tt.scope).Matches(sess, messages) {
+				got = append(got, fmt.Sprintf("%d:%s#%d:%s", m.MessageIndex, m.Location, m.ToolCall, marked(m)))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Matches(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	for s, want := range map[string]Scope{"content": ScopeContent, "tools": ScopeTools, "all": ScopeAll} {
+		if got, err := ParseScope(s); err != nil || got != want {
+			t.Errorf("ParseScope(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseScope("everything"); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+}
+
+func TestIn_LeavesQueryUnchanged(t *testing.T) {
+	q, _ := Parse("x")
+	if tools := q.In(ScopeTools); tools.scope != ScopeTools || q.scope != ScopeContent {
+		t.Errorf("In: copy scope %v, original scope %v", tools.scope, q.scope)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// snippet
+// ---------------------------------------------------------------------------
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("a", 100) + "MATCH" + strings.Repeat("b", 100)
+	tests := []struct {
+		name    string
+		content string
+		spans   []model.Span
+		target  int
+		want    string
+	}{
+		{"short content is kept whole", "fix\tthe\r\nbug", []model.Span{{Start: 9, End: 12}}, 200, "fix the  [bug]"},
+		{"centred on the first span", long, []model.Span{{Start: 100, End: 105}}, 25, "...aaaaaaaaaa[MATCH]bbbbbbbbbb..."},
+		{"no spans starts at the beginning", long, nil, 10, "aaaaaaaaaa..."},
+		{"shifted right at the start", long, []model.Span{{Start: 2, End: 3}}, 11, "aa[a]aaaaaaaa..."},
+		{"shifted left at the end", long, []model.Span{{Start: 203, End: 205}}, 10, "...bbbbbbbb[bb]"},
+		{"spans outside are dropped, cut ones clipped", long, []model.Span{{Start: 99, End: 101}, {Start: 101, End: 120}, {Start: 150, End: 160}}, 6, "...aa[aM][AT]..."},
+		{"match longer than target", long, []model.Span{{Start: 90, End: 110}}, 10, "...[aaaaaaaaaaMATCHbbbbb]..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, highlights := snippet(tt.content, tt.spans, tt.target)
+			if got := marked(model.SearchMatch{Snippet: text, Highlights: highlights}); got != tt.want {
+				t.Errorf("snippet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippet_RuneBoundaries(t *testing.T) {
+	content := strings.Repeat("é", 50) + "x" + strings.Repeat("é", 50)
+	text, _ := snippet(content, []model.Span{{Start: 100, End: 101}}, 11)
+	if !utf8.ValidString(text) {
+		t.Errorf("snippet cut a rune: %q", text)
+	}
+	if !strings.Contains(text, "x") {
+		t.Errorf("snippet %q lost the match", text)
+	}
+}
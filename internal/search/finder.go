@@ -0,0 +1,238 @@
+package search
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// Mode selects how the text of a query matches message content.
+type Mode int
+
+const (
+	// ModeSubstring matches terms as case-insensitive substrings.
+	ModeSubstring Mode = iota
+	// ModeRegex treats the whole query as one RE2 regular expression,
+	// case-insensitive unless it says otherwise with (?-i).
+	ModeRegex
+	// ModeFuzzy matches terms as substrings or as words within a small edit
+	// distance, so "migraton" finds "migration".
+	ModeFuzzy
+)
+
+// finder locates one text term in a message.
+type finder interface {
+	match(t *target) bool
+	// findAll returns the non-empty spans of the message content that match,
+	// in order and non-overlapping.
+	findAll(t *target) []model.Span
+}
+
+// ---------------------------------------------------------------------------
+// Substring
+// ---------------------------------------------------------------------------
+
+// substringFinder matches a folded substring of the folded content.
+type substringFinder string
+
+func (f substringFinder) match(t *target) bool { return strings.Contains(t.content, string(f)) }
+
+func (f substringFinder) findAll(t *target) []model.Span {
+	if f == "" {
+		return nil
+	}
+	var spans []model.Span
+	for off := 0; ; {
+		i := strings.Index(t.content[off:], string(f))
+		if i < 0 {
+			return spans
+		}
+		start := off + i
+		off = start + len(f)
+		spans = append(spans, model.Span{Start: start, End: off})
+	}
+}
+
+// fold lower-cases s rune by rune, leaving alone runes whose lower case has
+// a different UTF-8 length (and invalid bytes), so that byte offsets into
+// the result are byte offsets into s.
+func fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if l := unicode.ToLower(r); r != utf8.RuneError && utf8.RuneLen(l) == n {
+			b.WriteRune(l)
+		} else {
+			b.WriteString(s[i : i+n])
+		}
+		i += n
+	}
+	return b.String()
+}
+
+// ---------------------------------------------------------------------------
+// Regex
+// ---------------------------------------------------------------------------
+
+// regexFinder matches a regular expression against the original text.
+type regexFinder struct{ re *regexp.Regexp }
+
+func (f regexFinder) match(t *target) bool { return f.re.MatchString(t.text) }
+
+func (f regexFinder) findAll(t *target) []model.Span {
+	var spans []model.Span
+	for _, m := range f.re.FindAllStringIndex(t.text, -1) {
+		if m[1] > m[0] {
+			spans = append(spans, model.Span{Start: m[0], End: m[1]})
+		}
+	}
+	return spans
+}
+
+// ---------------------------------------------------------------------------
+// Fuzzy
+// ---------------------------------------------------------------------------
+
+// fuzzyFinder matches a term either as a substring or as a run of content
+// words each within maxEdits of the corresponding term word.
+type fuzzyFinder struct {
+	sub   substringFinder
+	words []string
+}
+
+func newFuzzyFinder(term string) fuzzyFinder {
+	f := fuzzyFinder{sub: substringFinder(term)}
+	for _, w := range wordSpans(term) {
+		f.words = append(f.words, term[w.Start:w.End])
+	}
+	return f
+}
+
+func (f fuzzyFinder) match(t *target) bool {
+	return f.sub.match(t) || len(f.fuzzy(t.content, true)) > 0
+}
+
+func (f fuzzyFinder) findAll(t *target) []model.Span {
+	return mergeSpans(append(f.sub.findAll(t), f.fuzzy(t.content, false)...))
+}
+
+// fuzzy returns the spans of the word runs in content that are close to the
+// term's words, stopping at the first one when first is set.
+func (f fuzzyFinder) fuzzy(content string, first bool) []model.Span {
+	if len(f.words) == 0 {
+		return nil
+	}
+	words := wordSpans(content)
+	var spans []model.Span
+	for i := 0; i+len(f.words) <= len(words); i++ {
+		ok := true
+		for j, w := range f.words {
+			c := words[i+j]
+			if editDistance(w, content[c.Start:c.End], maxEdits(w)) > maxEdits(w) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			spans = append(spans, model.Span{Start: words[i].Start, End: words[i+len(f.words)-1].End})
+			if first {
+				return spans
+			}
+			i += len(f.words) - 1
+		}
+	}
+	return spans
+}
+
+// maxEdits is the number of typos tolerated in a word: none in short words,
+// where one edit makes most words into other words.
+func maxEdits(word string) int {
+	switch n := utf8.RuneCountInString(word); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// wordSpans returns the spans of the runs of letters and digits in s.
+func wordSpans(s string) []model.Span {
+	var spans []model.Span
+	start := -1
+	for i, r := range s {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, model.Span{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, model.Span{Start: start, End: len(s)})
+	}
+	return spans
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: insertions, deletions, substitutions and swaps of adjacent runes each
+// count as one edit. Once the distance is known to exceed limit it returns
+// limit+1 without finishing.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// mergeSpans sorts spans and merges the ones that overlap.
+func mergeSpans(spans []model.Span) []model.Span {
+	if len(spans) < 2 {
+		return spans
+	}
+	slices.SortFunc(spans, func(a, b model.Span) int { return a.Start - b.Start })
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.Start < last.End {
+			last.End = max(last.End, s.End)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package search
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+func findAll(f finder, content string) []model.Span {
+	return f.findAll(&target{msg: &model.Message{Content: content}, content: fold(content)})
+}
+
+// ---------------------------------------------------------------------------
+// Substring
+// ---------------------------------------------------------------------------
+
+func TestSubstringFinder(t *testing.T) {
+	tests := []struct {
+		term, content string
+		want          string
+	}{
+		{"ab", "xABxabab", "[{1 3} {4 6} {6 8}]"},
+		{"aa", "aaa", "[{0 2}]"},
+		{"ab", "xyz", "[]"},
+		{"", "xyz", "[]"},
+		// Offsets stay byte offsets into the original content even where
+		// lower-casing would change a rune's length (İ is 2 bytes, i̇ is 3).
+		{"x", "İx", "[{2 3}]"},
+		{"é", "CAFÉ é", "[{3 5} {6 8}]"},
+	}
+	for _, tt := range tests {
+		got := fmt.Sprint(findAll(substringFinder(fold(tt.term)), tt.content))
+		if got == "[]" || got == "<nil>" {
+			got = "[]"
+		}
+		if got != tt.want {
+			t.Errorf("findAll(%q in %q) = %s, want %s", tt.term, tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, s := range []string{"Hello", "İSTANBUL", "ÀÉÎ", "bad\xffbyte", "Ⱥ"} {
+		if got := fold(s); len(got) != len(s) {
+			t.Errorf("fold(%q) = %q changes the length", s, got)
+		}
+	}
+	if got := fold("HeLLo ÀÉ"); got != "hello àé" {
+		t.Errorf("fold = %q", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Fuzzy
+// ---------------------------------------------------------------------------
+
+func TestFuzzyFinder(t *testing.T) {
+	tests := []struct {
+		term, content string
+		want          string
+	}{
+		{"migraton", "run the Migration now", "[{8 17}]"},
+		{"migartion", "run the migration", "[{8 17}]"},
+		{"deploymnet", "deployment deplymnt", "[{0 10} {11 19}]"},
+		{"database migraton", "the databse migration ran", "[{4 21}]"},
+		{"database migraton", "the database, then migration", "[]"},
+		// Short words must match exactly; substrings always match.
+		{"bug", "bag bugs", "[{4 7}]"},
+		{"fix", "fox", "[]"},
+		// Words far from the term do not match.
+		{"migraton", "integration", "[]"},
+		{"->", "a -> b", "[{2 4}]"},
+		{"->", "a - b", "[]"},
+	}
+	for _, tt := range tests {
+		f := newFuzzyFinder(fold(tt.term))
+		spans := findAll(f, tt.content)
+		got := fmt.Sprint(spans)
+		if len(spans) == 0 {
+			got = "[]"
+		}
+		if got != tt.want {
+			t.Errorf("findAll(%q in %q) = %s, want %s", tt.term, tt.content, got, tt.want)
+		}
+		tg := &target{msg: &model.Message{Content: tt.content}, content: fold(tt.content)}
+		if f.match(tg) != (len(spans) > 0) {
+			t.Errorf("match(%q in %q) disagrees with findAll", tt.term, tt.content)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"kitten", "kitten", 2, 0},
+		{"kitten", "sitten", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"migration", "migartion", 2, 1}, // a swap is one edit
+		{"abc", "", 5, 3},
+		{"", "ab", 5, 2},
+		{"abcdef", "a", 2, 3},      // length difference alone exceeds the limit
+		{"abcdef", "uvwxyz", 1, 2}, // gives up early
+		{"ünï", "uni", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMaxEdits(t *testing.T) {
+	for word, want := range map[string]int{"fix": 0, "deploy": 1, "migration": 2, "éèêë": 1} {
+		if got := maxEdits(word); got != want {
+			t.Errorf("maxEdits(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestWordSpans(t *testing.T) {
+	got := fmt.Sprint(wordSpans("  fix_the-bug2 ünï"))
+	if want := "[{2 5} {6 9} {10 14} {15 20}]"; got != want {
+		t.Errorf("wordSpans = %s, want %s", got, want)
+	}
+}
+
+func TestMergeSpans(t *testing.T) {
+	tests := []struct {
+		in   []model.Span
+		want string
+	}{
+		{nil, "[]"},
+		{[]model.Span{{Start: 1, End: 2}}, "[{1 2}]"},
+		{[]model.Span{{Start: 5, End: 8}, {Start: 0, End: 2}, {Start: 6, End: 10}, {Start: 7, End: 9}, {Start: 10, End: 11}}, "[{0 2} {5 10} {10 11}]"},
+	}
+	for _, tt := range tests {
+		got := fmt.Sprint(mergeSpans(tt.in))
+		if len(tt.in) == 0 {
+			got = "[]"
+		}
+		if got != tt.want {
+			t.Errorf("mergeSpans(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/psacc/omnisess/internal/index"
+)
+
+// FTS translates q into an FTS5 expression for the full-text index that
+// matches a superset of the messages q matches, so the index can narrow
+// the sessions worth evaluating. Qualifiers and terms shorter than
+// index.MinTermLen cannot be looked up and match everything, as do regex
+// and fuzzy terms. ok is false when nothing narrows the query (e.g.
+// "role:user" or "NOT x" alone) or searches tool calls, which are not
+// indexed.
+func (q *Query) FTS() (expr string, ok bool) {
+	if q.scope != ScopeContent {
+		return "", false
+	}
+	f := ftsOf(q.root)
+	return f.expr, !f.all
+}
+
+// ftsExpr is the translation of one node.
+type ftsExpr struct {
+	expr  string
+	all   bool // matches every message: the node could not be translated
+	exact bool // expr matches exactly the node's messages, so it can be negated
+}
+
+func ftsOf(n node) ftsExpr {
+	switch n := n.(type) {
+	case termNode:
+		sub, ok := n.f.(substringFinder)
+		if !ok || utf8.RuneCountInString(string(sub)) < index.MinTermLen {
+			return ftsExpr{all: true}
+		}
+		return ftsExpr{expr: `"` + strings.ReplaceAll(string(sub), `"`, `""`) + `"`, exact: true}
+	case andNode:
+		return ftsAnd(n)
+	case orNode:
+		out := ftsExpr{exact: true}
+		parts := make([]string, 0, len(n))
+		for _, x := range n {
+			f := ftsOf(x)
+			if f.all {
+				return ftsExpr{all: true}
+			}
+			out.exact = out.exact && f.exact
+			parts = append(parts, f.expr)
+		}
+		out.expr = "(" + strings.Join(parts, " OR ") + ")"
+		return out
+	default: // fieldNode, or notNode outside an AND
+		return ftsExpr{all: true}
+	}
+}
+
+// ftsAnd translates a conjunction. FTS5's NOT is binary ("a NOT b"), so
+// negated children subtract from the AND of the others; a conjunction with
+// nothing to subtract from matches everything.
+func ftsAnd(n andNode) ftsExpr {
+	var pos, neg []string
+	exact := true
+	for _, x := range n {
+		if not, ok := x.(notNode); ok {
+			if f := ftsOf(not.x); f.exact {
+				neg = append(neg, f.expr)
+				continue
+			}
+			exact = false
+			continue
+		}
+		f := ftsOf(x)
+		if f.all {
+			exact = false
+			continue
+		}
+		exact = exact && f.exact
+		pos = append(pos, f.expr)
+	}
+	if len(pos) == 0 {
+		return ftsExpr{all: true}
+	}
+	expr := "(" + strings.Join(pos, " AND ") + ")"
+	for _, e := range neg {
+		expr = "(" + expr + " NOT " + e + ")"
+	}
+	return ftsExpr{expr: expr, exact: exact}
+}
@@ -0,0 +1,68 @@
+package search
+
+import "testing"
+
+// ---------------------------------------------------------------------------
+// FTS
+// ---------------------------------------------------------------------------
+
+func TestFTS(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string // "" when the query cannot narrow the index
+	}{
+		{"deploy", `"deploy"`},
+		{"Deploy fix", `("deploy" AND "fix")`},
+		{`say"hi`, `"say""hi"`},
+		{`"exact phrase"`, `"exact phrase"`},
+		{"ab", ""},
+		{"ab deploy", `("deploy")`},
+		{"deploy OR fix", `("deploy" OR "fix")`},
+		{"deploy OR ab", ""},
+		{"role:user", ""},
+		{"role:user deploy", `("deploy")`},
+		{"NOT deploy", ""},
+		{"-deploy", ""},
+		{"role:user -deploy", ""},
+		{"deploy -wip", `(("deploy") NOT "wip")`},
+		{"deploy -wip -draft", `((("deploy") NOT "wip") NOT "draft")`},
+		{"deploy -(wip OR draft)", `(("deploy") NOT ("wip" OR "draft"))`},
+		// A NOT of something inexact would exclude too much: drop it.
+		{"deploy -(wip role:user)", `("deploy")`},
+		{"deploy -role:user", `("deploy")`},
+		{"deploy -ab", `("deploy")`},
+		{"deploy -(wip -draft)", `(("deploy") NOT (("wip") NOT "draft"))`},
+		// An inexact AND cannot be subtracted either.
+		{"deploy -(wip -role:user)", `("deploy")`},
+		{"(deploy OR fix) -wip", `((("deploy" OR "fix")) NOT "wip")`},
+		{"(deploy role:user) OR fix", `(("deploy") OR "fix")`},
+		{"deploy -((fix role:user) OR wip)", `("deploy")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := q.FTS()
+			if tt.want == "" {
+				if ok {
+					t.Errorf("FTS(%q) = %q, want no expression", tt.query, got)
+				}
+				return
+			}
+			if !ok || got != tt.want {
+				t.Errorf("FTS(%q) = %q, %v; want %q", tt.query, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTS_ToolScopes(t *testing.T) {
+	q, _ := Parse("deploy")
+	for _, scope := range []Scope{ScopeTools, ScopeAll} {
+		if expr, ok := q.In(scope).FTS(); ok {
+			t.Errorf("FTS in scope %v = %q, want no expression: tool calls are not indexed", scope, expr)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+// Package search implements omnisess's search query language.
+//
+// A query is a sequence of terms, implicitly ANDed:
+//
+//	deploy "exact phrase"      substrings, case-insensitive
+//	a OR b, a AND b, NOT a     boolean operators (upper case), NOT > AND > OR
+//	-a, -"a b", -role:user     exclusion, shorthand for NOT
+//	(a OR b) c                 grouping
+//
+// and field qualifiers:
+//
+//	role:user                  message role
+//	tool:claude                session tool (exact)
+//	model:opus                 session model (substring)
+//	branch:main                session git branch (substring)
+//	project:api                session project path (substring)
+//	before:2026-09-01          message sent before the date (exclusive)
+//	after:2026-09-01           message sent on or after the date
+//	has:toolcall               message made at least one tool call
+//
+// Queries are evaluated per message: a session matches when at least one of
+// its messages does, and those messages are its matches. Session qualifiers
+// hold for every message of a matching session.
+//
+// ParseMode selects other ways for terms to match: ModeFuzzy tolerates typos
+// in each term, and ModeRegex takes the whole query as one regular
+// expression instead of the language above. Query.In extends a search from
+// message content to tool call inputs and outputs.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Query is a parsed search query.
+type Query struct {
+	raw   string
+	root  node
+	terms []termNode // text terms not under a NOT, for highlighting
+	scope Scope
+}
+
+// String returns the query as it was written.
+func (q *Query) String() string { return q.raw }
+
+// fields is the set of qualifiers Parse recognizes. A word whose prefix
+// before ':' is not listed here is an ordinary term (e.g. a URL).
+var fields = map[string]bool{
+	"role": true, "tool": true, "model": true, "branch": true,
+	"project": true, "before": true, "after": true, "has": true,
+}
+
+// dateLayouts are the accepted before:/after: value formats. Dates without
+// a time are midnight local time.
+var dateLayouts = []string{"2006-01-02", "2006-01-02T15:04", time.RFC3339}
+
+// Parse parses a query string in ModeSubstring.
+func Parse(s string) (*Query, error) {
+	return ParseMode(s, ModeSubstring)
+}
+
+// ParseMode parses a query string whose terms match in the given mode.
+func ParseMode(s string, mode Mode) (*Query, error) {
+	if mode == ModeRegex {
+		return parseRegex(s)
+	}
+	toks, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, errors.New("search: empty query")
+	}
+	p := &parser{toks: toks, mode: mode}
+	// lex only emits ")" tokens that close an open "(", so a successful
+	// parseOr consumes every token.
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{raw: s, root: root}
+	collectTerms(root, false, &q.terms)
+	return q, nil
+}
+
+// parseRegex builds the single-term query of ModeRegex.
+func parseRegex(s string) (*Query, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("search: empty query")
+	}
+	re, err := regexp.Compile("(?i)" + s)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	t := termNode{text: s, f: regexFinder{re}}
+	return &Query{raw: s, root: t, terms: []termNode{t}}, nil
+}
+
+// JoinArgs joins command-line arguments into one query. An argument the
+// shell kept together because it was quoted (omnisess search "exact phrase")
+// is quoted again so it stays a phrase, unless it uses query syntax itself
+// (omnisess search 'a OR b').
+func JoinArgs(args []string) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if strings.ContainsFunc(a, unicode.IsSpace) && isPlain(a) {
+			a = `"` + a + `"`
+		}
+		parts[i] = a
+	}
+	return strings.Join(parts, " ")
+}
+
+// isPlain reports whether s lexes as bare words only: no quotes,
+// operators, exclusions, groups or qualifiers.
+func isPlain(s string) bool {
+	if strings.ContainsAny(s, `"()`) {
+		return false
+	}
+	toks, _ := lex(s) // without quotes, lex cannot fail
+	for _, tok := range toks {
+		if tok.kind != tokAtom || tok.negate || tok.field != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ---------------------------------------------------------------------------
+// Lexer
+// ---------------------------------------------------------------------------
+
+type tokKind int
+
+const (
+	tokAtom tokKind = iota // term, phrase or field
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind   tokKind
+	text   string // term text or field value
+	field  string // qualifier name for field atoms
+	negate bool   // written with a leading "-"
+}
+
+func lex(s string) ([]token, error) {
+	var (
+		toks  []token
+		depth int
+	)
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			toks = append(toks, token{kind: tokLParen})
+			depth++
+			i++
+			continue
+		case r == ')' && depth > 0:
+			toks = append(toks, token{kind: tokRParen})
+			depth--
+			i++
+			continue
+		}
+
+		var tok token
+		if r == '-' && i+1 < len(rs) && !unicode.IsSpace(rs[i+1]) {
+			tok.negate = true
+			i++
+			if rs[i] == '(' {
+				toks = append(toks, token{kind: tokNot})
+				continue
+			}
+		}
+		if rs[i] == '"' {
+			text, next, err := readQuoted(rs, i)
+			if err != nil {
+				return nil, err
+			}
+			tok.text, i = text, next
+			toks = append(toks, tok)
+			continue
+		}
+
+		start := i
+		for i < len(rs) && !unicode.IsSpace(rs[i]) && !(rs[i] == ')' && depth > 0) {
+			if rs[i] == '"' && i > start && rs[i-1] == ':' && fields[strings.ToLower(string(rs[start:i-1]))] {
+				break // quoted field value: project:"my api"
+			}
+			i++
+		}
+		word := string(rs[start:i])
+
+		if !tok.negate {
+			switch word {
+			case "AND":
+				toks = append(toks, token{kind: tokAnd})
+				continue
+			case "OR":
+				toks = append(toks, token{kind: tokOr})
+				continue
+			case "NOT":
+				toks = append(toks, token{kind: tokNot})
+				continue
+			}
+		}
+
+		if name, value, ok := strings.Cut(word, ":"); ok && fields[strings.ToLower(name)] {
+			tok.field = strings.ToLower(name)
+			if value == "" && i < len(rs) && rs[i] == '"' {
+				text, next, err := readQuoted(rs, i)
+				if err != nil {
+					return nil, err
+				}
+				value, i = text, next
+			}
+			tok.text = value
+		} else {
+			tok.text = word
+		}
+		toks = append(toks, tok)
+	}
+	return toks, nil
+}
+
+// readQuoted reads the phrase starting at the quote rs[i], returning its text
+// and the index just past the closing quote.
+func readQuoted(rs []rune, i int) (string, int, error) {
+	end := i + 1
+	for end < len(rs) && rs[end] != '"' {
+		end++
+	}
+	if end == len(rs) {
+		return "", 0, errors.New("search: unterminated quote")
+	}
+	return string(rs[i+1 : end]), end + 1, nil
+}
+
+// ---------------------------------------------------------------------------
+// Parser
+// ---------------------------------------------------------------------------
+
+type parser struct {
+	toks []token
+	pos  int
+	mode Mode
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.toks) {
+		return token{}, false
+	}
+	return p.toks[p.pos], true
+}
+
+func (p *parser) parseOr() (node, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	xs := []node{x}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind != tokOr {
+			break
+		}
+		p.pos++
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, y)
+	}
+	if len(xs) == 1 {
+		return x, nil
+	}
+	return orNode(xs), nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	var xs []node
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokOr || tok.kind == tokRParen {
+			break
+		}
+		if tok.kind == tokAnd {
+			if len(xs) == 0 {
+				return nil, errors.New("search: AND needs a term on both sides")
+			}
+			p.pos++
+			if next, ok := p.peek(); !ok || next.kind == tokOr || next.kind == tokRParen || next.kind == tokAnd {
+				return nil, errors.New("search: AND needs a term on both sides")
+			}
+			continue
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+	switch len(xs) {
+	case 0:
+		if tok, ok := p.peek(); (!ok || tok.kind == tokRParen) && p.toks[p.pos-1].kind == tokLParen {
+			return nil, errors.New("search: empty parentheses")
+		}
+		return nil, errors.New("search: OR needs a term on both sides")
+	case 1:
+		return xs[0], nil
+	}
+	return andNode(xs), nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	tok := p.toks[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokNot:
+		if next, ok := p.peek(); !ok || next.kind == tokOr || next.kind == tokAnd || next.kind == tokRParen {
+			return nil, errors.New("search: NOT needs a term")
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next, ok := p.peek(); !ok || next.kind != tokRParen {
+			return nil, errors.New("search: missing )")
+		}
+		p.pos++
+		return x, nil
+	}
+
+	x, err := p.newAtom(tok)
+	if err != nil {
+		return nil, err
+	}
+	if tok.negate {
+		return notNode{x}, nil
+	}
+	return x, nil
+}
+
+// newAtom builds the node for a term, phrase or field token.
+func (p *parser) newAtom(tok token) (node, error) {
+	if tok.field == "" {
+		text := fold(tok.text)
+		if p.mode == ModeFuzzy {
+			return termNode{text: text, f: newFuzzyFinder(text)}, nil
+		}
+		return termNode{text: text, f: substringFinder(text)}, nil
+	}
+	if tok.text == "" {
+		return nil, fmt.Errorf("search: %s: needs a value", tok.field)
+	}
+	f := fieldNode{field: tok.field, value: strings.ToLower(tok.text)}
+	switch tok.field {
+	case "before", "after":
+		t, err := parseDate(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("search: %s:%s: want a date like 2026-09-01", tok.field, tok.text)
+		}
+		f.at = t
+	case "has":
+		if f.value != "toolcall" {
+			return nil, fmt.Errorf("search: has:%s: only has:toolcall is supported", tok.text)
+		}
+	}
+	return f, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// collectTerms appends the text terms of n that are not negated.
+func collectTerms(n node, negated bool, terms *[]termNode) {
+	switch n := n.(type) {
+	case termNode:
+		if !negated && n.text != "" {
+			*terms = append(*terms, n)
+		}
+	case notNode:
+		collectTerms(n.x, !negated, terms)
+	case andNode:
+		for _, x := range n {
+			collectTerms(x, negated, terms)
+		}
+	case orNode:
+		for _, x := range n {
+			collectTerms(x, negated, terms)
+		}
+	}
+}
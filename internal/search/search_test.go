@@ -0,0 +1,201 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Parse
+// ---------------------------------------------------------------------------
+
+// dump renders a node tree compactly for comparison.
+func dump(n node) string {
+	switch n := n.(type) {
+	case termNode:
+		return `"` + n.text + `"`
+	case fieldNode:
+		return n.field + ":" + n.value
+	case notNode:
+		return "NOT(" + dump(n.x) + ")"
+	case andNode:
+		parts := make([]string, len(n))
+		for i, x := range n {
+			parts[i] = dump(x)
+		}
+		return "AND(" + strings.Join(parts, " ") + ")"
+	case orNode:
+		parts := make([]string, len(n))
+		for i, x := range n {
+			parts[i] = dump(x)
+		}
+		return "OR(" + strings.Join(parts, " ") + ")"
+	}
+	return "?"
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"deploy", `"deploy"`},
+		{"Deploy Fix", `AND("deploy" "fix")`},
+		{`"exact Phrase"`, `"exact phrase"`},
+		{"a OR b c", `OR("a" AND("b" "c"))`},
+		{"a AND b OR c", `OR(AND("a" "b") "c")`},
+		{"NOT a b", `AND(NOT("a") "b")`},
+		{"NOT NOT a", `NOT(NOT("a"))`},
+		{"(a OR b) c", `AND(OR("a" "b") "c")`},
+		{"-a", `NOT("a")`},
+		{`-"a b"`, `NOT("a b")`},
+		{"-(a OR b)", `NOT(OR("a" "b"))`},
+		{"-role:user", `NOT(role:user)`},
+		{"role:User", `role:user`},
+		{"ROLE:user", `role:user`},
+		{`project:"My API" x`, `AND(project:my api "x")`},
+		{"has:toolcall", `has:toolcall`},
+		{"https://example.com/a", `"https://example.com/a"`},
+		{"a)", `"a)"`},
+		{"(a)b", `AND("a" "b")`},
+		{"a - b", `AND("a" "-" "b")`},
+		{"and or not", `AND("and" "or" "not")`},
+		{`""`, `""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.query, err)
+			}
+			if got := dump(q.root); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+			if q.String() != tt.query {
+				t.Errorf("String() = %q, want %q", q.String(), tt.query)
+			}
+		})
+	}
+}
+
+func TestParse_Dates(t *testing.T) {
+	for _, query := range []string{"before:2026-09-01", "after:2026-09-01T10:30", "before:2026-09-01T10:30:00Z"} {
+		q, err := Parse(query)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", query, err)
+		}
+		if f := q.root.(fieldNode); f.at.IsZero() {
+			t.Errorf("Parse(%q): date not set", query)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "empty query"},
+		{"   ", "empty query"},
+		{"AND a", "AND needs a term on both sides"},
+		{"a AND", "AND needs a term on both sides"},
+		{"a AND OR b", "AND needs a term on both sides"},
+		{"a AND AND b", "AND needs a term on both sides"},
+		{"(a AND)", "AND needs a term on both sides"},
+		{"OR a", "OR needs a term on both sides"},
+		{"a OR", "OR needs a term on both sides"},
+		{"()", "empty parentheses"},
+		{"(a OR)", "OR needs a term on both sides"},
+		{"(OR a)", "OR needs a term on both sides"},
+		{"NOT", "NOT needs a term"},
+		{"NOT OR a", "NOT needs a term"},
+		{"(NOT)", "NOT needs a term"},
+		{"NOT (", "empty parentheses"},
+		{"(a", "missing )"},
+		{`"a`, "unterminated quote"},
+		{`project:"a`, "unterminated quote"},
+		{"role:", "role: needs a value"},
+		{"before:soon", "before:soon: want a date like 2026-09-01"},
+		{"has:image", "has:image: only has:toolcall is supported"},
+		{"NOT has:image", "has:image: only has:toolcall is supported"},
+		{"(has:image)", "has:image: only has:toolcall is supported"},
+		{"a OR has:image", "has:image: only has:toolcall is supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			if err == nil {
+				t.Fatalf("Parse(%q): expected error", tt.query)
+			}
+			if !strings.HasPrefix(err.Error(), "search: ") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %q, want %q", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Terms(t *testing.T) {
+	q, err := Parse(`Foo (bar OR -baz) NOT (qux -quux) role:user ""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var terms []string
+	for _, term := range q.terms {
+		terms = append(terms, term.text)
+	}
+	got := strings.Join(terms, ",")
+	if want := "foo,bar,quux"; got != want {
+		t.Errorf("terms = %s, want %s", got, want)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	q, err := ParseMode("Migraton -role:user", ModeFuzzy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.terms[0].f.(fuzzyFinder); !ok || dump(q.root) != `AND("migraton" NOT(role:user))` {
+		t.Errorf("fuzzy query = %s with %T", dump(q.root), q.terms[0].f)
+	}
+
+	// A regex is one term: operators and quotes are regex text.
+	q, err = ParseMode(`migrat(e|ion) "\d+" OR x`, ModeRegex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := q.root.(termNode).f.(regexFinder); !ok || len(q.terms) != 1 {
+		t.Errorf("regex query root = %#v", q.root)
+	}
+
+	for _, query := range []string{" ", "migrat(e"} {
+		if _, err := ParseMode(query, ModeRegex); err == nil || !strings.HasPrefix(err.Error(), "search: ") {
+			t.Errorf("ParseMode(%q, ModeRegex) error = %v", query, err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// JoinArgs
+// ---------------------------------------------------------------------------
+
+func TestJoinArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"deploy"}, "deploy"},
+		{[]string{"deploy", "fix"}, "deploy fix"},
+		{[]string{"exact phrase"}, `"exact phrase"`},
+		{[]string{"exact phrase", "-role:user"}, `"exact phrase" -role:user`},
+		{[]string{"a OR b"}, "a OR b"},
+		{[]string{"deploy -role:assistant"}, "deploy -role:assistant"},
+		{[]string{"role:user bug"}, "role:user bug"},
+		{[]string{`"a b" OR c`}, `"a b" OR c`},
+		{[]string{"(a b)"}, "(a b)"},
+	}
+	for _, tt := range tests {
+		if got := JoinArgs(tt.args); got != tt.want {
+			t.Errorf("JoinArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
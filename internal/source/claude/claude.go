@@ -2,8 +2,10 @@ package claude
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"path/filepath"
@@ -12,30 +14,46 @@ import (
 	"time"
 
 	"github.com/psacc/omnisess/internal/detect"
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
 func init() {
 	source.Register(&claudeSource{})
+	source.RegisterFactory(model.ToolClaude, func(dir string) source.Source {
+		return &claudeSource{dir: dir}
+	})
 }
 
-type claudeSource struct{}
+type claudeSource struct {
+	dir string // fixed data directory (another host's root); empty resolves via source.Root
+}
 
 func (s *claudeSource) Name() model.Tool { return model.ToolClaude }
 
-// claudeDir returns the path to ~/.claude.
-func claudeDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("resolve home dir: %w", err)
+// markActive looks for the process running a session. Another host's
+// processes are not visible here, so a fixed root's sessions are left
+// inactive.
+func (s *claudeSource) markActive(sess *model.Session, path string) {
+	if s.dir == "" {
+		detect.MarkActive(sess, path, lastTurn)
 	}
-	return filepath.Join(home, ".claude"), nil
+}
+
+// claudeDir returns the Claude data directory: the source's fixed dir, else
+// ~/.claude unless relocated by CLAUDE_CONFIG_DIR or a --claude-root override.
+func (s *claudeSource) claudeDir() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+	return source.Root(model.ToolClaude)
 }
 
 // historyPath returns the path to ~/.claude/history.jsonl.
-func historyPath() (string, error) {
-	dir, err := claudeDir()
+func (s *claudeSource) historyPath() (string, error) {
+	dir, err := s.claudeDir()
 	if err != nil {
 		return "", err
 	}
@@ -52,13 +70,20 @@ type sessionEntry struct {
 }
 
 // loadHistory reads history.jsonl and returns deduplicated session entries
-// ordered by UpdatedAt descending.
-func loadHistory() ([]sessionEntry, error) {
-	hp, err := historyPath()
+// ordered by UpdatedAt descending. The result is cached in the index until
+// history.jsonl changes.
+func (s *claudeSource) loadHistory() ([]sessionEntry, error) {
+	hp, err := s.historyPath()
 	if err != nil {
 		return nil, err
 	}
+	return index.Load(source.Index(), "claude.history", hp, func() ([]sessionEntry, error) {
+		return readHistory(hp)
+	})
+}
 
+// readHistory parses the history file at hp. A missing file yields no entries.
+func readHistory(hp string) ([]sessionEntry, error) {
 	f, err := os.Open(hp)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -145,8 +170,8 @@ func loadHistory() ([]sessionEntry, error) {
 
 // findSessionFile locates the JSONL file for a given session ID by globbing
 // across project directories.
-func findSessionFile(sessionID string) (string, error) {
-	dir, err := claudeDir()
+func (s *claudeSource) findSessionFile(sessionID string) (string, error) {
+	dir, err := s.claudeDir()
 	if err != nil {
 		return "", err
 	}
@@ -164,8 +189,8 @@ func findSessionFile(sessionID string) (string, error) {
 
 // findSessionFileForProject locates the JSONL file for a given session ID
 // within a specific project directory.
-func findSessionFileForProject(projectPath, sessionID string) string {
-	dir, err := claudeDir()
+func (s *claudeSource) findSessionFileForProject(projectPath, sessionID string) string {
+	dir, err := s.claudeDir()
 	if err != nil {
 		return ""
 	}
@@ -189,35 +214,102 @@ func sessionFileUpdatedAt(path string) (time.Time, bool) {
 
 // List returns sessions ordered by most recent first.
 // Messages are NOT populated.
+func (s *claudeSource) List(ctx context.Context, opts source.ListOptions) ([]model.Session, error) {
+	return source.Collect(s.Sessions(ctx, opts), opts.Limit)
+}
+
+// Sessions yields the sessions List returns, most recent first.
 //
 // Two-pass strategy:
 //  1. Load sessions from history.jsonl (the standard index).
 //  2. Scan ~/.claude/projects/*/*.jsonl for orphan session files that are
 //     NOT in history.jsonl (e.g., sessions started from Cursor's embedded
 //     Claude Code or other contexts that skip the history index).
-func (s *claudeSource) List(opts source.ListOptions) ([]model.Session, error) {
-	entries, err := loadHistory()
+//
+// Both passes only locate and stat the files; a session's file is opened
+// (and its process looked for) when it is yielded.
+func (s *claudeSource) Sessions(ctx context.Context, opts source.ListOptions) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		refs, err := s.sessionRefs(ctx, opts)
+		if err != nil {
+			yield(model.Session{}, fmt.Errorf("list claude sessions: %w", err))
+			return
+		}
+		for _, ref := range refs {
+			if err := ctx.Err(); err != nil {
+				yield(model.Session{}, fmt.Errorf("list claude sessions: %w", err))
+				return
+			}
+			sess := ref.Session
+			if ref.path != "" {
+				s.markActive(&sess, ref.path)
+			}
+			if opts.Active && !sess.Active {
+				continue
+			}
+
+			// Extract branch and model from the session file header
+			// without parsing the entire file.
+			if ref.path != "" {
+				sess.Branch, sess.Model = cachedSessionMetadata(ref.path)
+				timing, timed := source.Timing(timingKind, ref.path, opts.Timing, func() []model.Message {
+					messages, _, _, _ := parseSessionFile(ref.path)
+					return messages
+				})
+				sess.SetTiming(timing)
+				sess.Untimed = !timed
+			}
+			if ref.orphan {
+				// Orphans have no history entry: preview their first prompt.
+				sess.Preview = index.Memo(source.Index(), "claude.preview", ref.path, func() string {
+					return peekFirstUserMessage(ref.path)
+				})
+				sess.Title = sess.Preview
+			}
+			if !yield(sess, nil) {
+				return
+			}
+		}
+	}
+}
+
+// sessionRef is a session as found by the passes of Sessions, before its
+// file is opened: the fields known from history.jsonl and the file's path
+// and mtime.
+type sessionRef struct {
+	model.Session
+	path   string // "" when the session has no file
+	orphan bool   // found on disk but not in history.jsonl
+}
+
+// sessionRefs returns every session passing the opts.Since and opts.Project
+// filters, most recent first.
+func (s *claudeSource) sessionRefs(ctx context.Context, opts source.ListOptions) ([]sessionRef, error) {
+	entries, err := s.loadHistory()
 	if err != nil {
-		return nil, fmt.Errorf("list claude sessions: %w", err)
+		return nil, err
 	}
 
 	// Track seen session IDs to avoid duplicates in the orphan scan.
 	seenIDs := make(map[string]bool, len(entries))
 
-	var sessions []model.Session
+	var refs []sessionRef
 
 	// --- Pass 1: history.jsonl entries ---
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		seenIDs[entry.SessionID] = true
 
 		// Find the session file
 		var sessionFilePath string
 		if entry.Project != "" {
-			sessionFilePath = findSessionFileForProject(entry.Project, entry.SessionID)
+			sessionFilePath = s.findSessionFileForProject(entry.Project, entry.SessionID)
 		}
 		if sessionFilePath == "" {
 			var err error
-			sessionFilePath, err = findSessionFile(entry.SessionID)
+			sessionFilePath, err = s.findSessionFile(entry.SessionID)
 			if err != nil {
 				log.Printf("warning: finding session file for %s: %v", entry.SessionID, err)
 			}
@@ -233,96 +325,57 @@ func (s *claudeSource) List(opts source.ListOptions) ([]model.Session, error) {
 			}
 		}
 
-		// Check active status
-		active := false
-		if sessionFilePath != "" {
-			active = detect.IsSessionActive("claude", sessionFilePath)
-		}
-
-		// Apply filters
-		if opts.Active && !active {
-			continue
-		}
-		if opts.Since > 0 && time.Since(updatedAt) > opts.Since {
-			continue
-		}
-		if opts.Project != "" && !strings.Contains(entry.Project, opts.Project) {
-			continue
-		}
-
 		preview := detect.Truncate(entry.Display, 120)
-
-		sess := model.Session{
-			ID:        entry.SessionID,
-			Tool:      model.ToolClaude,
-			Project:   entry.Project,
-			Title:     preview,
-			StartedAt: entry.StartedAt,
-			UpdatedAt: updatedAt,
-			Active:    active,
-			Preview:   preview,
-		}
-
-		// Try to extract branch and model from the session file header
-		// without parsing the entire file: read just enough for metadata.
-		if sessionFilePath != "" {
-			if branch, mdl := peekSessionMetadata(sessionFilePath); branch != "" || mdl != "" {
-				sess.Branch = branch
-				sess.Model = mdl
-			}
-		}
-
-		sessions = append(sessions, sess)
+		refs = append(refs, sessionRef{
+			Session: model.Session{
+				ID:        entry.SessionID,
+				Tool:      model.ToolClaude,
+				Project:   entry.Project,
+				Title:     preview,
+				StartedAt: entry.StartedAt,
+				UpdatedAt: updatedAt,
+				Preview:   preview,
+			},
+			path: sessionFilePath,
+		})
 	}
 
 	// --- Pass 2: orphan session files on disk ---
 	// findOrphanSessions calls claudeDir() which already succeeded in loadHistory above,
 	// so its error is unreachable in practice — ignore it.
-	orphans, _ := findOrphanSessions(seenIDs)
+	orphans, _ := s.findOrphanSessions(seenIDs)
 
 	for _, orphan := range orphans {
-		updatedAt := orphan.UpdatedAt
-
-		active := detect.IsSessionActive("claude", orphan.FilePath)
+		refs = append(refs, sessionRef{
+			Session: model.Session{
+				ID:        orphan.SessionID,
+				Tool:      model.ToolClaude,
+				Project:   orphan.Project,
+				StartedAt: orphan.UpdatedAt, // until its messages are timed
+				UpdatedAt: orphan.UpdatedAt,
+			},
+			path:   orphan.FilePath,
+			orphan: true,
+		})
+	}
 
-		// Apply filters
-		if opts.Active && !active {
+	// Apply filters
+	kept := refs[:0]
+	for _, ref := range refs {
+		if opts.Since > 0 && time.Since(ref.UpdatedAt) > opts.Since {
 			continue
 		}
-		if opts.Since > 0 && time.Since(updatedAt) > opts.Since {
+		if !opts.MatchesProject(ref.Project) {
 			continue
 		}
-		if opts.Project != "" && !strings.Contains(orphan.Project, opts.Project) {
-			continue
-		}
-
-		sess := model.Session{
-			ID:        orphan.SessionID,
-			Tool:      model.ToolClaude,
-			Project:   orphan.Project,
-			Title:     orphan.Preview,
-			StartedAt: orphan.UpdatedAt, // best we have
-			UpdatedAt: updatedAt,
-			Active:    active,
-			Preview:   orphan.Preview,
-			Branch:    orphan.Branch,
-			Model:     orphan.Model,
-		}
-
-		sessions = append(sessions, sess)
+		kept = append(kept, ref)
 	}
 
 	// Sort all sessions (history + orphans) by UpdatedAt descending.
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].UpdatedAt.After(kept[j].UpdatedAt)
 	})
-
-	// Apply limit after sorting the merged list.
-	if opts.Limit > 0 && len(sessions) > opts.Limit {
-		sessions = sessions[:opts.Limit]
-	}
-
-	return sessions, nil
+	return kept, nil
 }
 
 // orphanSession holds data for a session file found on disk but not in history.jsonl.
@@ -331,16 +384,12 @@ type orphanSession struct {
 	Project   string
 	FilePath  string
 	UpdatedAt time.Time
-	Preview   string
-	Branch    string
-	Model     string
 }
 
 // findOrphanSessions scans ~/.claude/projects/*/*.jsonl for session files
-// whose IDs are not in the seenIDs set. For each orphan, it extracts
-// metadata from the file without parsing it fully.
-func findOrphanSessions(seenIDs map[string]bool) ([]orphanSession, error) {
-	dir, err := claudeDir()
+// whose IDs are not in the seenIDs set, without opening them.
+func (s *claudeSource) findOrphanSessions(seenIDs map[string]bool) ([]orphanSession, error) {
+	dir, err := s.claudeDir()
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +413,9 @@ func findOrphanSessions(seenIDs map[string]bool) ([]orphanSession, error) {
 
 		// Derive project path from the parent directory name.
 		parentDirName := filepath.Base(filepath.Dir(match))
-		project := projectPathFromDir(parentDirName)
+		project := index.Memo(source.Index(), "claude.project", filepath.Dir(match), func() string {
+			return projectPathFromDir(parentDirName)
+		})
 
 		// Get file modification time for UpdatedAt.
 		updatedAt := time.Time{}
@@ -372,20 +423,11 @@ func findOrphanSessions(seenIDs map[string]bool) ([]orphanSession, error) {
 			updatedAt = modTime
 		}
 
-		// Extract metadata (branch, model) from first few lines.
-		branch, mdl := peekSessionMetadata(match)
-
-		// Extract preview from first user message.
-		preview := peekFirstUserMessage(match)
-
 		orphans = append(orphans, orphanSession{
 			SessionID: sessionID,
 			Project:   project,
 			FilePath:  match,
 			UpdatedAt: updatedAt,
-			Preview:   preview,
-			Branch:    branch,
-			Model:     mdl,
 		})
 	}
 
@@ -471,6 +513,24 @@ func peekSessionMetadata(path string) (branch, mdl string) {
 	return branch, mdl
 }
 
+// peekedMetadata is the cached result of peekSessionMetadata.
+type peekedMetadata struct {
+	Branch string
+	Model  string
+}
+
+// cachedSessionMetadata is peekSessionMetadata memoized in the index.
+func cachedSessionMetadata(path string) (branch, mdl string) {
+	m := index.Memo(source.Index(), "claude.meta", path, func() peekedMetadata {
+		b, m := peekSessionMetadata(path)
+		return peekedMetadata{Branch: b, Model: m}
+	})
+	return m.Branch, m.Model
+}
+
+// timingKind is the index kind session files are timed under.
+const timingKind = "claude.timing"
+
 // jsonUnmarshalFast is a thin wrapper for json.Unmarshal used by peekSessionMetadata.
 func jsonUnmarshalFast(data []byte, v interface{}) error {
 	return jsonUnmarshal(data, v)
@@ -484,9 +544,9 @@ func jsonUnmarshalImpl(data []byte, v interface{}) error {
 
 // Get returns a single session with full message history.
 // Supports prefix matching (first 8+ chars of the UUID).
-func (s *claudeSource) Get(sessionID string) (*model.Session, error) {
+func (s *claudeSource) Get(_ context.Context, sessionID string) (*model.Session, error) {
 	// Find the session file, supporting prefix match
-	sessionFilePath, fullID, err := resolveSessionFile(sessionID)
+	sessionFilePath, fullID, err := s.resolveSessionFile(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("get claude session: %w", err)
 	}
@@ -503,11 +563,8 @@ func (s *claudeSource) Get(sessionID string) (*model.Session, error) {
 	project := projectFromSessionPath(sessionFilePath)
 
 	// Determine timestamps
-	var startedAt, updatedAt time.Time
-	if len(messages) > 0 {
-		startedAt = messages[0].Timestamp
-		updatedAt = messages[len(messages)-1].Timestamp
-	}
+	timing, _ := source.Timing(timingKind, sessionFilePath, true, func() []model.Message { return messages })
+	updatedAt := timing.End
 	// Refine from file modification time
 	if modTime, ok := sessionFileUpdatedAt(sessionFilePath); ok {
 		if modTime.After(updatedAt) {
@@ -526,8 +583,6 @@ func (s *claudeSource) Get(sessionID string) (*model.Session, error) {
 		}
 	}
 
-	active := detect.IsSessionActive("claude", sessionFilePath)
-
 	sess := &model.Session{
 		ID:        fullID,
 		Tool:      model.ToolClaude,
@@ -535,21 +590,34 @@ func (s *claudeSource) Get(sessionID string) (*model.Session, error) {
 		Branch:    branch,
 		Title:     title,
 		Model:     mdl,
-		StartedAt: startedAt,
 		UpdatedAt: updatedAt,
-		Active:    active,
 		Messages:  messages,
 		Preview:   preview,
+		Usage:     model.SumUsage(messages),
 	}
+	sess.SetTiming(timing)
+	s.markActive(sess, sessionFilePath)
 
 	return sess, nil
 }
 
+// TranscriptPath and NewTranscript implement source.Follower: session files
+// are only appended to.
+func (s *claudeSource) TranscriptPath(_ context.Context, sessionID string) (string, error) {
+	path, _, err := s.resolveSessionFile(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("follow claude session: %w", err)
+	}
+	return path, nil
+}
+
+func (s *claudeSource) NewTranscript() source.Transcript { return newTranscript() }
+
 // resolveSessionFile finds the session file, supporting prefix matching.
 // Returns (path, fullSessionID, error).
-func resolveSessionFile(sessionID string) (string, string, error) {
+func (s *claudeSource) resolveSessionFile(sessionID string) (string, string, error) {
 	// First, try exact match
-	path, err := findSessionFile(sessionID)
+	path, err := s.findSessionFile(sessionID)
 	if err != nil {
 		return "", "", err
 	}
@@ -559,7 +627,7 @@ func resolveSessionFile(sessionID string) (string, string, error) {
 
 	// Try prefix match: glob for session files starting with the prefix.
 	// claudeDir() already succeeded in findSessionFile above, so ignore error.
-	dir, _ := claudeDir()
+	dir, _ := s.claudeDir()
 	pattern := filepath.Join(dir, "projects", "*", sessionID+"*.jsonl")
 	// filepath.Glob only errors on malformed patterns (e.g. "[").
 	// sessionID passed here already survived findSessionFile's glob above,
@@ -600,52 +668,65 @@ func projectFromSessionPath(sessionFilePath string) string {
 	return projectPathFromDir(dirName)
 }
 
-// Search returns sessions containing the query string (case-insensitive substring match).
-func (s *claudeSource) Search(query string, opts source.ListOptions) ([]model.SearchResult, error) {
-	sessions, err := s.List(opts)
-	if err != nil {
-		return nil, fmt.Errorf("search claude sessions: %w", err)
-	}
-
-	queryLower := strings.ToLower(query)
-	var results []model.SearchResult
+// Search returns sessions with messages matched by m.
+func (s *claudeSource) Search(ctx context.Context, m search.Matcher, opts source.ListOptions) ([]model.SearchResult, error) {
+	return source.Collect(s.SearchResults(ctx, m, opts), 0)
+}
 
-	for _, sess := range sessions {
-		// Find the session file
-		var sessionFilePath string
-		if sess.Project != "" {
-			sessionFilePath = findSessionFileForProject(sess.Project, sess.ID)
-		}
-		if sessionFilePath == "" {
-			sessionFilePath, _ = findSessionFile(sess.ID)
-		}
-		if sessionFilePath == "" {
-			continue
+// SearchResults yields the results Search returns, each once its session
+// has been parsed and matched.
+func (s *claudeSource) SearchResults(ctx context.Context, m search.Matcher, opts source.ListOptions) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		// Every session is a candidate: --limit cuts the ranked results
+		// (source.SearchAll), not the sessions searched.
+		candidates := opts
+		candidates.Limit = 0
+		sessions, err := s.List(ctx, candidates)
+		if err != nil {
+			yield(model.SearchResult{}, fmt.Errorf("search claude sessions: %w", err))
+			return
 		}
 
-		messages, mdl, branch, err := parseSessionFile(sessionFilePath)
-		if err != nil {
-			log.Printf("warning: parsing session %s for search: %v", sess.ID, err)
-			continue
+		paths := make(map[string]string, len(sessions))
+		for _, sess := range sessions {
+			if path := s.sessionFilePath(sess); path != "" {
+				paths[sess.ID] = path
+			}
 		}
 
-		var matches []model.SearchMatch
-		for i, msg := range messages {
-			contentLower := strings.ToLower(msg.Content)
-			idx := strings.Index(contentLower, queryLower)
-			if idx < 0 {
+		// The full-text index, when open, narrows the sessions worth parsing
+		// and ranks them; the query itself is always evaluated on the parsed
+		// messages below.
+		dir, _ := s.claudeDir() // List already resolved it
+		scores := source.IndexScores(model.ToolClaude, dir, m, func(ix *index.Index) {
+			for id, path := range paths {
+				if ctx.Err() != nil {
+					return // the scan below reports it
+				}
+				t := index.Transcript{Root: dir, Tool: string(model.ToolClaude), Session: id, Path: path}
+				if err := ix.SyncLines(t, indexLine); err != nil {
+					log.Printf("warning: indexing session %s for search: %v", id, err)
+				}
+			}
+		})
+
+		for _, sess := range sessions {
+			if err := ctx.Err(); err != nil {
+				yield(model.SearchResult{}, fmt.Errorf("search claude sessions: %w", err))
+				return
+			}
+			sessionFilePath := paths[sess.ID]
+			score, candidate := scores[sess.ID]
+			if sessionFilePath == "" || (scores != nil && !candidate) {
 				continue
 			}
 
-			snippet := extractSnippet(msg.Content, idx, len(query), 200)
-			matches = append(matches, model.SearchMatch{
-				MessageIndex: i,
-				Snippet:      snippet,
-				Role:         msg.Role,
-			})
-		}
+			messages, mdl, branch, err := parseSessionFile(sessionFilePath)
+			if err != nil {
+				log.Printf("warning: parsing session %s for search: %v", sess.ID, err)
+				continue
+			}
 
-		if len(matches) > 0 {
 			sess.Messages = nil // don't populate full messages in search results
 			if mdl != "" {
 				sess.Model = mdl
@@ -653,50 +734,39 @@ func (s *claudeSource) Search(query string, opts source.ListOptions) ([]model.Se
 			if branch != "" {
 				sess.Branch = branch
 			}
-			results = append(results, model.SearchResult{
-				Session: sess,
-				Matches: matches,
-			})
+			if matches := m.Matches(&sess, messages); len(matches) > 0 {
+				r := model.SearchResult{Session: sess, Matches: matches, Score: score}
+				if !yield(r, nil) {
+					return
+				}
+			}
 		}
 	}
-
-	return results, nil
 }
 
-// extractSnippet returns a ~targetLen character snippet around a match position.
-func extractSnippet(content string, matchIdx, matchLen, targetLen int) string {
-	if len(content) <= targetLen {
-		return content
-	}
-
-	// Center the snippet around the match
-	halfWindow := (targetLen - matchLen) / 2
-	start := matchIdx - halfWindow
-	end := matchIdx + matchLen + halfWindow
-
-	if start < 0 {
-		end -= start // shift right
-		start = 0
+// indexLine is the index.LineParser for session files. Every line
+// parseSessionFile keeps is one message, so indices match Get's.
+func indexLine(line []byte, cur *index.Cursor) []index.Doc {
+	_, msg, ok := parseSessionLine(line)
+	if !ok {
+		return nil
 	}
-	if end > len(content) {
-		start -= end - len(content) // shift left
-		end = len(content)
+	doc := index.Doc{Message: cur.Message, Role: string(msg.Role), Content: msg.Content, Timestamp: msg.Timestamp}
+	cur.Message++
+	if msg.Content == "" {
+		return nil
 	}
-	// start is always ≥ 0 here: start can only go negative in the block above if
-	// end - len(content) > start, which requires len(content) < targetLen —
-	// impossible because the early return guards len(content) <= targetLen.
-
-	snippet := content[start:end]
+	return []index.Doc{doc}
+}
 
-	// Add ellipsis markers
-	prefix := ""
-	suffix := ""
-	if start > 0 {
-		prefix = "..."
+// sessionFilePath locates a listed session's JSONL file, or "" if it has none.
+func (s *claudeSource) sessionFilePath(sess model.Session) string {
+	var path string
+	if sess.Project != "" {
+		path = s.findSessionFileForProject(sess.Project, sess.ID)
 	}
-	if end < len(content) {
-		suffix = "..."
+	if path == "" {
+		path, _ = s.findSessionFile(sess.ID)
 	}
-
-	return prefix + snippet + suffix
+	return path
 }
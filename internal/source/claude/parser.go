@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/psacc/omnisess/internal/detect"
 	"github.com/psacc/omnisess/internal/model"
 )
 
@@ -32,12 +33,45 @@ type sessionLine struct {
 	StopReason string          `json:"stopReason"`
 	CWD        string          `json:"cwd"`
 	GitBranch  string          `json:"gitBranch"`
+
+	// Decoded from Message by parseSessionLine, to pair tool calls with the
+	// tool results a later user line carries.
+	toolUseIDs  []string
+	toolResults []toolResult
+	// The API message the line is part of: Claude Code writes each content
+	// block of a response on its own line, every one with the usage of the
+	// whole response.
+	messageID string
+}
+
+// toolResult is the output of the tool_use with the given id.
+type toolResult struct {
+	id     string
+	output string
+}
+
+// toolCallRef locates a tool call within the parsed messages so a later
+// tool_result can fill in its Output.
+type toolCallRef struct {
+	msg  int
+	call int
 }
 
-// messagePayload holds the role and content from the "message" field.
+// messagePayload holds the role and content from the "message" field, and
+// for assistant lines the API message's id and token usage.
 type messagePayload struct {
-	Role    string      `json:"role"`
-	Content interface{} `json:"content"`
+	Role    string       `json:"role"`
+	Content interface{}  `json:"content"`
+	ID      string       `json:"id"`
+	Usage   messageUsage `json:"usage"`
+}
+
+// messageUsage is the token usage the API reports for a response.
+type messageUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
 }
 
 // parseHistoryLine parses a single line from history.jsonl into a historyEntry.
@@ -58,73 +92,125 @@ func parseSessionFile(path string) ([]model.Message, string, string, error) {
 	}
 	defer f.Close()
 
-	var messages []model.Message
-	var sessionModel string
-	var gitBranch string
-
+	t := newTranscript()
 	scanner := bufio.NewScanner(f)
 	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024) // up to 10MB lines
 	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
-		}
+		t.Add(scanner.Bytes())
+	}
 
-		var sl sessionLine
-		if err := json.Unmarshal(line, &sl); err != nil {
-			continue // skip malformed lines
-		}
+	if err := scanner.Err(); err != nil {
+		return t.messages, t.model, t.branch, fmt.Errorf("scan session file %s: %w", path, err)
+	}
 
-		// Skip summary lines
-		if sl.Type == "summary" {
-			continue
-		}
+	return t.messages, t.model, t.branch, nil
+}
 
-		// Only process user and assistant messages
-		if sl.Type != "user" && sl.Type != "assistant" {
-			continue
-		}
+// transcript gathers the messages of a session file line by line, for
+// parseSessionFile and for following a session as it is written.
+type transcript struct {
+	messages []model.Message
+	model    string // from the first assistant line that has one
+	branch   string // from the first line that has one
+	calls    map[string]toolCallRef
+	// usageAt is the message holding the usage of each API message, which
+	// its later lines repeat.
+	usageAt map[string]int
+}
 
-		// Capture git branch from first line that has one
-		if gitBranch == "" && sl.GitBranch != "" {
-			gitBranch = sl.GitBranch
-		}
+func newTranscript() *transcript {
+	return &transcript{calls: make(map[string]toolCallRef), usageAt: make(map[string]int)}
+}
 
-		// Capture model from assistant messages
-		if sl.Type == "assistant" && sessionModel == "" && sl.Model != "" {
-			sessionModel = sl.Model
-		}
+// Add parses one line of the session file.
+func (t *transcript) Add(line []byte) {
+	if len(line) == 0 {
+		return
+	}
 
-		// Parse the message payload
-		var payload messagePayload
-		if err := json.Unmarshal(sl.Message, &payload); err != nil {
-			continue
+	sl, msg, ok := parseSessionLine(line)
+	if !ok {
+		return
+	}
+
+	if t.branch == "" && sl.GitBranch != "" {
+		t.branch = sl.GitBranch
+	}
+	if sl.Type == "assistant" && t.model == "" && sl.Model != "" {
+		t.model = sl.Model
+	}
+
+	for _, r := range sl.toolResults {
+		if ref, ok := t.calls[r.id]; ok {
+			t.messages[ref.msg].ToolCalls[ref.call].Output = r.output
 		}
+	}
+	for i, id := range sl.toolUseIDs {
+		if id != "" {
+			t.calls[id] = toolCallRef{msg: len(t.messages), call: i}
+		}
+	}
+	// The usage is counted once per API message, on its first line. The
+	// last line's is the final one: output tokens grow as it streams.
+	if id := sl.messageID; id != "" {
+		if i, ok := t.usageAt[id]; ok {
+			t.messages[i].Usage = msg.Usage
+			msg.Usage = model.Usage{}
+		} else {
+			t.usageAt[id] = len(t.messages)
+		}
+	}
 
-		ts := parseTimestamp(sl.Timestamp)
+	t.messages = append(t.messages, msg)
+}
 
-		role := model.Role(payload.Role)
-		content := extractContent(payload.Content)
+// Messages returns the messages parsed so far.
+func (t *transcript) Messages() []model.Message { return t.messages }
 
-		msg := model.Message{
-			Role:      role,
-			Content:   content,
-			Timestamp: ts,
-		}
+// parseSessionLine decodes one session JSONL line into a message. ok is
+// false for lines that are not user or assistant messages (summaries, other
+// event types, malformed JSON), which parseSessionFile and the full-text
+// indexer both skip.
+func parseSessionLine(line []byte) (sl sessionLine, msg model.Message, ok bool) {
+	if err := json.Unmarshal(line, &sl); err != nil {
+		return sl, msg, false // skip malformed lines
+	}
 
-		// Extract tool calls from assistant content blocks
-		if sl.Type == "assistant" {
-			msg.ToolCalls = extractToolCalls(payload.Content)
-		}
+	// Only user and assistant lines carry messages
+	if sl.Type != "user" && sl.Type != "assistant" {
+		return sl, msg, false
+	}
 
-		messages = append(messages, msg)
+	// Parse the message payload
+	var payload messagePayload
+	if err := json.Unmarshal(sl.Message, &payload); err != nil {
+		return sl, msg, false
 	}
 
-	if err := scanner.Err(); err != nil {
-		return messages, sessionModel, gitBranch, fmt.Errorf("scan session file %s: %w", path, err)
+	msg = model.Message{
+		Role:      model.Role(payload.Role),
+		Content:   extractContent(payload.Content),
+		Timestamp: parseTimestamp(sl.Timestamp),
 	}
 
-	return messages, sessionModel, gitBranch, nil
+	// Extract tool calls from assistant content blocks, and the results
+	// of earlier calls from user content blocks.
+	if sl.Type == "assistant" {
+		msg.ToolCalls, sl.toolUseIDs = extractToolCalls(payload.Content)
+		sl.messageID = payload.ID
+		msg.Model = sl.Model
+		msg.Usage = model.Usage{
+			InputTokens:         payload.Usage.InputTokens,
+			CacheReadTokens:     payload.Usage.CacheReadInputTokens,
+			CacheCreationTokens: payload.Usage.CacheCreationInputTokens,
+			OutputTokens:        payload.Usage.OutputTokens,
+			CostUSD:             sl.CostUSD,
+			Duration:            time.Duration(sl.DurationMs) * time.Millisecond,
+		}
+	} else {
+		sl.toolResults = extractToolResults(payload.Content)
+	}
+	return sl, msg, true
 }
 
 // extractContent handles both string content and array-of-blocks content.
@@ -161,18 +247,16 @@ func extractContent(content interface{}) string {
 	return strings.Join(parts, "\n")
 }
 
-// extractToolCalls extracts tool_use blocks from assistant content.
-func extractToolCalls(content interface{}) []model.ToolCall {
-	if content == nil {
-		return nil
-	}
-
+// extractToolCalls extracts tool_use blocks from assistant content, with
+// the id of each.
+func extractToolCalls(content interface{}) ([]model.ToolCall, []string) {
 	blocks, ok := content.([]interface{})
 	if !ok {
-		return nil
+		return nil, nil
 	}
 
 	var calls []model.ToolCall
+	var ids []string
 	for _, block := range blocks {
 		m, ok := block.(map[string]interface{})
 		if !ok {
@@ -181,18 +265,94 @@ func extractToolCalls(content interface{}) []model.ToolCall {
 		blockType, _ := m["type"].(string)
 		if blockType == "tool_use" {
 			name, _ := m["name"].(string)
+			id, _ := m["id"].(string)
 			inputRaw, _ := json.Marshal(m["input"])
-			input := string(inputRaw)
-			if len(input) > 200 {
-				input = input[:200] + "..."
-			}
 			calls = append(calls, model.ToolCall{
 				Name:  name,
-				Input: input,
+				Input: model.TruncateToolText(string(inputRaw)),
 			})
+			ids = append(ids, id)
+		}
+	}
+	return calls, ids
+}
+
+// extractToolResults extracts tool_result blocks from user content. A
+// result's content is a string or an array of text blocks, like a message's.
+func extractToolResults(content interface{}) []toolResult {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var results []toolResult
+	for _, block := range blocks {
+		m, ok := block.(map[string]interface{})
+		if !ok || m["type"] != "tool_result" {
+			continue
+		}
+		id, _ := m["tool_use_id"].(string)
+		results = append(results, toolResult{id: id, output: model.TruncateToolText(extractContent(m["content"]))})
+	}
+	return results
+}
+
+// tailLine holds the fields of a session line that tell where the agent's
+// turn is.
+type tailLine struct {
+	Type              string `json:"type"`
+	IsMeta            bool   `json:"isMeta"`            // context injected by Claude Code, not typed by the user
+	IsAPIErrorMessage bool   `json:"isApiErrorMessage"` // an assistant line reporting a failed API call
+	Level             string `json:"level"`             // system lines: "info", "error", ...
+	Message           struct {
+		Content    interface{} `json:"content"`
+		StopReason string      `json:"stop_reason"`
+	} `json:"message"`
+}
+
+// interruptedPrefix starts the user line Claude Code writes when the user
+// stops a turn with Esc.
+const interruptedPrefix = "[Request interrupted by user"
+
+// lastTurn reads where the agent's turn is from the last lines of a session
+// file, skipping lines that say nothing about it (summaries, file history
+// snapshots, meta context).
+func lastTurn(path string) detect.Turn {
+	for _, line := range detect.TailLines(path) {
+		var tl tailLine
+		if err := json.Unmarshal(line, &tl); err != nil {
+			continue
+		}
+		switch tl.Type {
+		case "assistant":
+			if tl.IsAPIErrorMessage {
+				return detect.TurnFailed
+			}
+			if calls, _ := extractToolCalls(tl.Message.Content); len(calls) > 0 {
+				return detect.TurnToolPending
+			}
+			// Text ends the turn unless tool calls follow; a thinking
+			// block alone means more is coming.
+			if extractContent(tl.Message.Content) != "" && tl.Message.StopReason != "tool_use" {
+				return detect.TurnDone
+			}
+			return detect.TurnRunning
+		case "user":
+			if tl.IsMeta {
+				continue
+			}
+			if len(extractToolResults(tl.Message.Content)) == 0 &&
+				strings.HasPrefix(extractContent(tl.Message.Content), interruptedPrefix) {
+				return detect.TurnDone
+			}
+			return detect.TurnRunning
+		case "system":
+			if tl.Level == "error" {
+				return detect.TurnFailed
+			}
 		}
 	}
-	return calls
+	return detect.TurnUnknown
 }
 
 // parseTimestamp parses an ISO 8601 timestamp string.
@@ -3,9 +3,11 @@ package claude
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/psacc/omnisess/internal/detect"
 	"github.com/psacc/omnisess/internal/model"
 )
 
@@ -114,6 +116,36 @@ func TestParseSessionFile_Simple(t *testing.T) {
 	if branch != "main" {
 		t.Errorf("branch = %q, want main", branch)
 	}
+	if want := (model.Usage{CostUSD: 0.01, Duration: 2 * time.Second}); messages[1].Usage != want {
+		t.Errorf("messages[1].Usage = %+v, want %+v", messages[1].Usage, want)
+	}
+}
+
+// TestParseSessionFile_Usage checks that the usage a response repeats on
+// each of its lines is counted once, with the last line's figures.
+func TestParseSessionFile_Usage(t *testing.T) {
+	messages, _, _, err := parseSessionFile(filepath.Join("testdata", "session_with_usage.jsonl"))
+	if err != nil {
+		t.Fatalf("parseSessionFile: %v", err)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d", len(messages))
+	}
+	want := []model.Usage{
+		{},
+		{InputTokens: 10, CacheReadTokens: 1000, CacheCreationTokens: 200, OutputTokens: 40},
+		{},
+		{},
+		{InputTokens: 3, CacheReadTokens: 1250, OutputTokens: 20, CostUSD: 0.004, Duration: 1500 * time.Millisecond},
+	}
+	for i, m := range messages {
+		if m.Usage != want[i] {
+			t.Errorf("messages[%d].Usage = %+v, want %+v", i, m.Usage, want[i])
+		}
+	}
+	if total := model.SumUsage(messages); total.Tokens() != 2523 {
+		t.Errorf("total tokens = %d, want 2523", total.Tokens())
+	}
 }
 
 func TestParseSessionFile_WithTools(t *testing.T) {
@@ -200,6 +232,9 @@ func TestParseSessionFile_EmptyLines(t *testing.T) {
 	if mdl != "test-model" {
 		t.Errorf("model = %q, want test-model", mdl)
 	}
+	if messages[0].Model != "" || messages[1].Model != "test-model" {
+		t.Errorf("message models = %q, %q; want the assistant's", messages[0].Model, messages[1].Model)
+	}
 }
 
 func TestExtractContent(t *testing.T) {
@@ -310,7 +345,7 @@ func TestExtractToolCalls(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractToolCalls(tt.content)
+			got, _ := extractToolCalls(tt.content)
 			if len(got) != tt.want {
 				t.Errorf("extractToolCalls() returned %d calls, want %d", len(got), tt.want)
 			}
@@ -320,14 +355,17 @@ func TestExtractToolCalls(t *testing.T) {
 
 func TestExtractToolCalls_Names(t *testing.T) {
 	content := []interface{}{
-		map[string]interface{}{"type": "tool_use", "name": "Read", "input": map[string]interface{}{"path": "/foo"}},
+		map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "Read", "input": map[string]interface{}{"path": "/foo"}},
 		map[string]interface{}{"type": "tool_use", "name": "Edit", "input": map[string]interface{}{"path": "/bar"}},
 	}
 
-	calls := extractToolCalls(content)
+	calls, ids := extractToolCalls(content)
 	if len(calls) != 2 {
 		t.Fatalf("expected 2 calls, got %d", len(calls))
 	}
+	if len(ids) != 2 || ids[0] != "toolu_1" || ids[1] != "" {
+		t.Errorf("ids = %q, want [toolu_1 \"\"]", ids)
+	}
 	if calls[0].Name != "Read" {
 		t.Errorf("calls[0].Name = %q, want Read", calls[0].Name)
 	}
@@ -349,7 +387,7 @@ func TestExtractToolCalls_LargeInput(t *testing.T) {
 		map[string]interface{}{"type": "tool_use", "name": "Write", "input": largeInput},
 	}
 
-	calls := extractToolCalls(content)
+	calls, _ := extractToolCalls(content)
 	if len(calls) != 1 {
 		t.Fatalf("expected 1 call, got %d", len(calls))
 	}
@@ -358,6 +396,114 @@ func TestExtractToolCalls_LargeInput(t *testing.T) {
 	}
 }
 
+func TestExtractToolResults(t *testing.T) {
+	content := []interface{}{
+		"not a map",
+		map[string]interface{}{"type": "text", "text": "ignored"},
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_1", "content": "plain output"},
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_2", "content": []interface{}{
+			map[string]interface{}{"type": "text", "text": "block"},
+			map[string]interface{}{"type": "text", "text": "output"},
+		}},
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "toolu_3", "content": strings.Repeat("x", 300)},
+	}
+
+	got := extractToolResults(content)
+	if len(got) != 3 {
+		t.Fatalf("got %d results, want 3", len(got))
+	}
+	if got[0] != (toolResult{id: "toolu_1", output: "plain output"}) || got[1] != (toolResult{id: "toolu_2", output: "block\noutput"}) {
+		t.Errorf("results = %+v", got[:2])
+	}
+	if got[2].output != strings.Repeat("x", 200)+"..." {
+		t.Errorf("long output not truncated: %d bytes", len(got[2].output))
+	}
+	if extractToolResults("string content") != nil {
+		t.Error("string content has no tool results")
+	}
+}
+
+func TestParseSessionFile_PairsToolResults(t *testing.T) {
+	lines := []string{
+		`{"type":"user","message":{"role":"user","content":"run the tests"}}`,
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"ok"},{"type":"tool_use","id":"toolu_a","name":"Bash","input":{"command":"go test ./..."}},{"type":"tool_use","id":"toolu_b","name":"Read","input":{"file_path":"/x"}}]}}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_b","content":"file body"},{"type":"tool_result","tool_use_id":"toolu_a","content":[{"type":"text","text":"FAIL pkg"}]},{"type":"tool_result","tool_use_id":"unknown","content":"orphan"}]}}`,
+	}
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	messages, _, _, err := parseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	calls := messages[1].ToolCalls
+	if len(calls) != 2 || calls[0].Output != "FAIL pkg" || calls[1].Output != "file body" {
+		t.Errorf("tool calls = %+v", calls)
+	}
+}
+
+func TestLastTurn(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  detect.Turn
+	}{
+		{"tool call awaiting its result", []string{
+			`{"type":"user","message":{"role":"user","content":"run the tests"}}`,
+			`{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{}}],"stop_reason":"tool_use"}}`,
+		}, detect.TurnToolPending},
+		{"tool result handed back", []string{
+			`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`,
+		}, detect.TurnRunning},
+		{"turn ended, summary after it", []string{
+			`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done."}],"stop_reason":"end_turn"}}`,
+			`{"type":"summary","summary":"Fix tests"}`,
+			`not json`,
+		}, detect.TurnDone},
+		{"text before tool calls", []string{
+			`{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Let me look."}],"stop_reason":"tool_use"}}`,
+		}, detect.TurnRunning},
+		{"thinking", []string{
+			`{"type":"assistant","message":{"role":"assistant","content":[{"type":"thinking","thinking":"hmm"}]}}`,
+		}, detect.TurnRunning},
+		{"prompt just sent, meta context after it", []string{
+			`{"type":"user","message":{"role":"user","content":"add a test"}}`,
+			`{"type":"user","isMeta":true,"message":{"role":"user","content":"<system-reminder>"}}`,
+		}, detect.TurnRunning},
+		{"interrupted", []string{
+			`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"[Request interrupted by user for tool use]"}]}}`,
+		}, detect.TurnDone},
+		{"api error", []string{
+			`{"type":"assistant","isApiErrorMessage":true,"message":{"role":"assistant","content":[{"type":"text","text":"API Error: 529"}]}}`,
+		}, detect.TurnFailed},
+		{"system error", []string{
+			`{"type":"user","message":{"role":"user","content":"go"}}`,
+			`{"type":"system","subtype":"api_error","level":"error"}`,
+		}, detect.TurnFailed},
+		{"system info skipped", []string{
+			`{"type":"user","message":{"role":"user","content":"go"}}`,
+			`{"type":"system","subtype":"compact_boundary","level":"info"}`,
+		}, detect.TurnRunning},
+		{"nothing telling", []string{`{"type":"file-history-snapshot"}`}, detect.TurnUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "s.jsonl")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastTurn(path); got != tt.want {
+				t.Errorf("lastTurn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseTimestamp(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -514,81 +660,6 @@ func TestProjectDirName_RoundTrip(t *testing.T) {
 	}
 }
 
-func TestExtractSnippet(t *testing.T) {
-	tests := []struct {
-		name      string
-		content   string
-		matchIdx  int
-		matchLen  int
-		targetLen int
-		wantExact string // if set, expect exact match
-		wantHas   string // if set, snippet must contain this
-	}{
-		{
-			name:      "content shorter than target",
-			content:   "short content",
-			matchIdx:  0,
-			matchLen:  5,
-			targetLen: 200,
-			wantExact: "short content",
-		},
-		{
-			name:      "match at start of long content",
-			content:   "hello world this is a very long string that exceeds the target length significantly and keeps going on",
-			matchIdx:  0,
-			matchLen:  5,
-			targetLen: 30,
-			wantHas:   "hello",
-		},
-		{
-			name:      "match in middle",
-			content:   "aaaaaaaaaa MATCH bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
-			matchIdx:  11,
-			matchLen:  5,
-			targetLen: 20,
-			wantHas:   "MATCH",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := extractSnippet(tt.content, tt.matchIdx, tt.matchLen, tt.targetLen)
-			if tt.wantExact != "" {
-				if got != tt.wantExact {
-					t.Errorf("extractSnippet() = %q, want %q", got, tt.wantExact)
-				}
-				return
-			}
-			if tt.wantHas != "" {
-				found := false
-				for i := 0; i <= len(got)-len(tt.wantHas); i++ {
-					if got[i:i+len(tt.wantHas)] == tt.wantHas {
-						found = true
-						break
-					}
-				}
-				if !found {
-					t.Errorf("extractSnippet() = %q, expected to contain %q", got, tt.wantHas)
-				}
-			}
-		})
-	}
-}
-
-func TestExtractSnippet_EllipsisMarkers(t *testing.T) {
-	// Long content, match in the middle
-	content := "aaaaaaaaaaaaaaaaaaaaa MATCH bbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
-	got := extractSnippet(content, 22, 5, 20)
-
-	// Should have leading and trailing ellipsis
-	if got[:3] != "..." {
-		t.Errorf("expected leading '...', got prefix %q", got[:3])
-	}
-	if got[len(got)-3:] != "..." {
-		t.Errorf("expected trailing '...', got suffix %q", got[len(got)-3:])
-	}
-}
-
 func TestExtractSessionIDFromPath(t *testing.T) {
 	tests := []struct {
 		name string
@@ -2,7 +2,10 @@ package codex
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"path/filepath"
@@ -11,30 +14,46 @@ import (
 	"time"
 
 	"github.com/psacc/omnisess/internal/detect"
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
 func init() {
 	source.Register(&codexSource{})
+	source.RegisterFactory(model.ToolCodex, func(dir string) source.Source {
+		return &codexSource{dir: dir}
+	})
 }
 
-type codexSource struct{}
+type codexSource struct {
+	dir string // fixed data directory (another host's root); empty resolves via source.Root
+}
 
 func (s *codexSource) Name() model.Tool { return model.ToolCodex }
 
-// codexDir returns ~/.codex.
-func codexDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("resolve home dir: %w", err)
+// markActive looks for the process running a session. Another host's
+// processes are not visible here, so a fixed root's sessions are left
+// inactive.
+func (s *codexSource) markActive(sess *model.Session, path string) {
+	if s.dir == "" {
+		detect.MarkActive(sess, path, lastTurn)
 	}
-	return filepath.Join(home, ".codex"), nil
 }
 
-// historyFilePath returns ~/.codex/history.jsonl.
-func historyFilePath() (string, error) {
-	dir, err := codexDir()
+// codexDir returns the Codex data directory: the source's fixed dir, else
+// ~/.codex unless relocated by CODEX_HOME or a --codex-root override.
+func (s *codexSource) codexDir() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+	return source.Root(model.ToolCodex)
+}
+
+// historyFilePath returns <codexDir>/history.jsonl.
+func (s *codexSource) historyFilePath() (string, error) {
+	dir, err := s.codexDir()
 	if err != nil {
 		return "", err
 	}
@@ -51,8 +70,8 @@ type sessionAccumulator struct {
 
 // loadHistory reads history.jsonl and returns deduplicated session entries
 // ordered by UpdatedAt descending.
-func loadHistory() ([]*sessionAccumulator, error) {
-	hp, err := historyFilePath()
+func (s *codexSource) loadHistory() ([]*sessionAccumulator, error) {
+	hp, err := s.historyFilePath()
 	if err != nil {
 		return nil, err
 	}
@@ -124,21 +143,100 @@ func loadHistory() ([]*sessionAccumulator, error) {
 
 // List returns Codex sessions ordered by most recent first.
 // Messages are NOT populated.
-func (s *codexSource) List(opts source.ListOptions) ([]model.Session, error) {
-	home, err := os.UserHomeDir()
+func (s *codexSource) List(ctx context.Context, opts source.ListOptions) ([]model.Session, error) {
+	return source.Collect(s.Sessions(ctx, opts), opts.Limit)
+}
+
+// Sessions yields the sessions List returns, most recent first: those in
+// history.jsonl, then rollouts on disk that it does not mention. Rollouts
+// are located and stat'ed up front; their heads are peeked (and their
+// processes looked for) only when they are reached.
+func (s *codexSource) Sessions(ctx context.Context, opts source.ListOptions) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		refs, err := s.sessionRefs(ctx, opts)
+		if err != nil {
+			yield(model.Session{}, fmt.Errorf("list codex sessions: %w", err))
+			return
+		}
+		for _, ref := range refs {
+			if err := ctx.Err(); err != nil {
+				yield(model.Session{}, fmt.Errorf("list codex sessions: %w", err))
+				return
+			}
+			sess := ref.Session
+
+			// Peek cwd, branch, model and version from the head of the
+			// session file.
+			var meta sessionMeta
+			if ref.path != "" {
+				meta = cachedSessionMeta(ref.path)
+			}
+			if !opts.MatchesProject(meta.CWD) {
+				continue
+			}
+			sess.Project, sess.Branch, sess.Model, sess.Version = meta.CWD, meta.Branch, meta.Model, meta.CLIVersion
+			if ref.path != "" {
+				s.markActive(&sess, ref.path)
+			}
+			if opts.Active && !sess.Active {
+				continue
+			}
+
+			if ref.orphan && !meta.StartedAt.IsZero() {
+				sess.StartedAt = meta.StartedAt
+			}
+			if ref.path != "" {
+				timing, timed := source.Timing(timingKind, ref.path, opts.Timing, func() []model.Message {
+					messages, _, _ := parseSessionFile(ref.path)
+					return messages
+				})
+				sess.SetTiming(timing)
+				sess.Untimed = !timed
+			}
+			if ref.orphan {
+				sess.Preview = index.Memo(source.Index(), "codex.preview", ref.path, func() string {
+					return peekFirstUserMessage(ref.path)
+				})
+				sess.Title = sess.Preview
+			}
+			if !yield(sess, nil) {
+				return
+			}
+		}
+	}
+}
+
+// sessionRef is a session as found by the passes of Sessions, before its
+// rollout is opened.
+type sessionRef struct {
+	model.Session
+	path   string // "" when the session has no rollout
+	orphan bool   // found on disk but not in history.jsonl
+}
+
+// sessionRefs returns every session passing the opts.Since filter, most
+// recent first.
+func (s *codexSource) sessionRefs(ctx context.Context, opts source.ListOptions) ([]sessionRef, error) {
+	dir, err := s.codexDir()
 	if err != nil {
-		return nil, fmt.Errorf("list codex sessions: resolve home: %w", err)
+		return nil, err
 	}
 
-	accs, err := loadHistory()
+	accs, err := s.loadHistory()
 	if err != nil {
-		return nil, fmt.Errorf("list codex sessions: %w", err)
+		return nil, err
 	}
 
-	var sessions []model.Session
+	var refs []sessionRef
+	seenIDs := make(map[string]bool, len(accs))
 
+	// --- Pass 1: sessions from history.jsonl ---
 	for _, acc := range accs {
-		sessionFilePath := findSessionFile(home, acc.sessionID)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		seenIDs[acc.sessionID] = true
+		sessionFilePath := findSessionFile(dir, acc.sessionID)
 
 		// Refine UpdatedAt from file modification time
 		updatedAt := acc.latest
@@ -150,60 +248,103 @@ func (s *codexSource) List(opts source.ListOptions) ([]model.Session, error) {
 			}
 		}
 
-		// Read cwd from first line of session file (cheap: one line).
-		cwd := ""
-		if sessionFilePath != "" {
-			cwd = readSessionCwd(sessionFilePath)
-		}
+		preview := detect.Truncate(acc.text, 120)
+		refs = append(refs, sessionRef{
+			Session: model.Session{
+				ID:        acc.sessionID,
+				Tool:      model.ToolCodex,
+				Title:     preview,
+				StartedAt: acc.earliest,
+				UpdatedAt: updatedAt,
+				Preview:   preview,
+			},
+			path: sessionFilePath,
+		})
+	}
 
-		// Check active status
-		active := false
-		if sessionFilePath != "" {
-			active = detect.IsSessionActive("codex", sessionFilePath)
-		}
+	// --- Pass 2: rollouts on disk that history.jsonl does not mention ---
+	for _, orphan := range findOrphanSessions(dir, seenIDs) {
+		refs = append(refs, sessionRef{
+			Session: model.Session{
+				ID:        orphan.SessionID,
+				Tool:      model.ToolCodex,
+				StartedAt: orphan.UpdatedAt, // unless the rollout says better
+				UpdatedAt: orphan.UpdatedAt,
+			},
+			path:   orphan.FilePath,
+			orphan: true,
+		})
+	}
 
-		// Apply filters
-		if opts.Active && !active {
-			continue
-		}
-		if opts.Since > 0 && time.Since(updatedAt) > opts.Since {
+	// Apply filters
+	kept := refs[:0]
+	for _, ref := range refs {
+		if opts.Since > 0 && time.Since(ref.UpdatedAt) > opts.Since {
 			continue
 		}
-		if opts.Project != "" && !strings.Contains(cwd, opts.Project) {
+		kept = append(kept, ref)
+	}
+
+	// Sort all sessions (history + orphans) by UpdatedAt descending.
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].UpdatedAt.After(kept[j].UpdatedAt)
+	})
+	return kept, nil
+}
+
+// orphanSession holds data for a rollout found on disk but not in history.jsonl.
+type orphanSession struct {
+	SessionID string
+	FilePath  string
+	UpdatedAt time.Time
+}
+
+// findOrphanSessions scans ~/.codex/sessions/YYYY/MM/DD/*.jsonl for rollouts
+// whose IDs are not in the seenIDs set. Rollouts written by `codex exec`, the
+// IDE extension, or dropped by history truncation only show up here. The
+// rollouts are not opened.
+func findOrphanSessions(codexDir string, seenIDs map[string]bool) []orphanSession {
+	var orphans []orphanSession
+	for _, path := range findSessionFiles(codexDir) {
+		sessionID := extractSessionIDFromPath(path)
+		if seenIDs[sessionID] {
 			continue
 		}
+		seenIDs[sessionID] = true
 
-		preview := detect.Truncate(acc.text, 120)
+		var updatedAt time.Time
+		if info, err := os.Stat(path); err == nil {
+			updatedAt = info.ModTime()
+		}
 
-		sessions = append(sessions, model.Session{
-			ID:        acc.sessionID,
-			Tool:      model.ToolCodex,
-			Project:   cwd,
-			Title:     preview,
-			StartedAt: acc.earliest,
+		orphans = append(orphans, orphanSession{
+			SessionID: sessionID,
+			FilePath:  path,
 			UpdatedAt: updatedAt,
-			Active:    active,
-			Preview:   preview,
 		})
 	}
+	return orphans
+}
 
-	// Apply limit after sorting
-	if opts.Limit > 0 && len(sessions) > opts.Limit {
-		sessions = sessions[:opts.Limit]
-	}
-
-	return sessions, nil
+// cachedSessionMeta is peekSessionMeta memoized in the index.
+func cachedSessionMeta(path string) sessionMeta {
+	return index.Memo(source.Index(), "codex.meta", path, func() sessionMeta {
+		return peekSessionMeta(path)
+	})
 }
 
+// timingKind is the index kind rollouts are timed under.
+const timingKind = "codex.timing"
+
 // Get returns a single Codex session with full message history.
 // Supports exact and prefix match on sessionID.
-func (s *codexSource) Get(sessionID string) (*model.Session, error) {
-	home, err := os.UserHomeDir()
+func (s *codexSource) Get(_ context.Context, sessionID string) (*model.Session, error) {
+	dir, err := s.codexDir()
 	if err != nil {
-		return nil, fmt.Errorf("get codex session: resolve home: %w", err)
+		return nil, fmt.Errorf("get codex session: %w", err)
 	}
 
-	sessionFilePath, fullID, err := resolveCodexSessionFile(home, sessionID)
+	sessionFilePath, fullID, err := resolveCodexSessionFile(dir, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("get codex session %s: %w", sessionID, err)
 	}
@@ -211,17 +352,14 @@ func (s *codexSource) Get(sessionID string) (*model.Session, error) {
 		return nil, nil
 	}
 
-	messages, cwd, err := parseSessionFile(sessionFilePath)
+	messages, meta, err := parseSessionFile(sessionFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("parse codex session %s: %w", fullID, err)
 	}
 
 	// Determine timestamps from messages
-	var startedAt, updatedAt time.Time
-	if len(messages) > 0 {
-		startedAt = messages[0].Timestamp
-		updatedAt = messages[len(messages)-1].Timestamp
-	}
+	timing, _ := source.Timing(timingKind, sessionFilePath, true, func() []model.Message { return messages })
+	updatedAt := timing.End
 	// Refine UpdatedAt from file modification time
 	if info, err := os.Stat(sessionFilePath); err == nil {
 		if info.ModTime().After(updatedAt) {
@@ -240,36 +378,54 @@ func (s *codexSource) Get(sessionID string) (*model.Session, error) {
 		}
 	}
 
-	active := detect.IsSessionActive("codex", sessionFilePath)
-
 	// Apply project filter on cwd
 	sess := &model.Session{
 		ID:        fullID,
 		Tool:      model.ToolCodex,
-		Project:   cwd,
+		Project:   meta.CWD,
+		Branch:    meta.Branch,
+		Model:     meta.Model,
+		Version:   meta.CLIVersion,
 		Title:     title,
-		StartedAt: startedAt,
 		UpdatedAt: updatedAt,
-		Active:    active,
 		Messages:  messages,
 		Preview:   preview,
+		Usage:     model.SumUsage(messages),
 	}
+	sess.SetTiming(timing)
+	s.markActive(sess, sessionFilePath)
 
 	return sess, nil
 }
 
+// TranscriptPath and NewTranscript implement source.Follower: rollout files
+// are only appended to.
+func (s *codexSource) TranscriptPath(_ context.Context, sessionID string) (string, error) {
+	dir, err := s.codexDir()
+	if err != nil {
+		return "", fmt.Errorf("follow codex session: %w", err)
+	}
+	path, _, err := resolveCodexSessionFile(dir, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("follow codex session %s: %w", sessionID, err)
+	}
+	return path, nil
+}
+
+func (s *codexSource) NewTranscript() source.Transcript { return newTranscript() }
+
 // resolveCodexSessionFile finds a Codex session file by exact or prefix match.
 // Returns (path, fullSessionID, error).
-func resolveCodexSessionFile(homeDir, sessionID string) (string, string, error) {
+func resolveCodexSessionFile(codexDir, sessionID string) (string, string, error) {
 	// Try exact match first
-	path := findSessionFile(homeDir, sessionID)
+	path := findSessionFile(codexDir, sessionID)
 	if path != "" {
 		return path, sessionID, nil
 	}
 
 	// Prefix match: glob for files ending with -<prefix>*.jsonl
 	// Sessions live at sessions/YYYY/MM/DD/ (4 levels), so use 4 wildcards.
-	pattern := filepath.Join(homeDir, ".codex", "sessions", "*", "*", "*", "*-"+sessionID+"*.jsonl")
+	pattern := filepath.Join(codexDir, "sessions", "*", "*", "*", "*-"+sessionID+"*.jsonl")
 	matches, err := filepath.Glob(pattern)
 	if err != nil {
 		return "", "", fmt.Errorf("glob codex prefix match: %w", err)
@@ -292,95 +448,121 @@ func resolveCodexSessionFile(homeDir, sessionID string) (string, string, error)
 	return "", "", fmt.Errorf("ambiguous session prefix %q, matches: %s", sessionID, strings.Join(ids, ", "))
 }
 
-// Search returns Codex sessions whose message content contains the query
-// (case-insensitive substring match).
-func (s *codexSource) Search(query string, opts source.ListOptions) ([]model.SearchResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("search codex sessions: resolve home: %w", err)
-	}
-
-	sessions, err := s.List(opts)
-	if err != nil {
-		return nil, fmt.Errorf("search codex sessions: %w", err)
-	}
-
-	queryLower := strings.ToLower(query)
-	var results []model.SearchResult
+// Search returns Codex sessions with messages matched by m.
+func (s *codexSource) Search(ctx context.Context, m search.Matcher, opts source.ListOptions) ([]model.SearchResult, error) {
+	return source.Collect(s.SearchResults(ctx, m, opts), 0)
+}
 
-	for _, sess := range sessions {
-		sessionFilePath := findSessionFile(home, sess.ID)
-		if sessionFilePath == "" {
-			continue
+// SearchResults yields the results Search returns, each once its rollout
+// has been parsed and matched.
+func (s *codexSource) SearchResults(ctx context.Context, m search.Matcher, opts source.ListOptions) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		dir, err := s.codexDir()
+		if err != nil {
+			yield(model.SearchResult{}, fmt.Errorf("search codex sessions: %w", err))
+			return
 		}
 
-		messages, cwd, err := parseSessionFile(sessionFilePath)
+		// Every session is a candidate: --limit cuts the ranked results
+		// (source.SearchAll), not the sessions searched.
+		candidates := opts
+		candidates.Limit = 0
+		sessions, err := s.List(ctx, candidates)
 		if err != nil {
-			log.Printf("warning: parsing codex session %s for search: %v", sess.ID, err)
-			continue
+			yield(model.SearchResult{}, fmt.Errorf("search codex sessions: %w", err))
+			return
 		}
 
-		// Project filter already applied by List() with the same opts.
-		_ = cwd
+		paths := make(map[string]string, len(sessions))
+		for _, sess := range sessions {
+			if path := findSessionFile(dir, sess.ID); path != "" {
+				paths[sess.ID] = path
+			}
+		}
+
+		// The full-text index, when open, narrows the rollouts worth parsing
+		// and ranks them; the query is still evaluated on the parsed messages.
+		scores := source.IndexScores(model.ToolCodex, dir, m, func(ix *index.Index) {
+			for id, path := range paths {
+				if ctx.Err() != nil {
+					return // the scan below reports it
+				}
+				t := index.Transcript{Root: dir, Tool: string(model.ToolCodex), Session: id, Path: path}
+				if err := ix.SyncLines(t, indexLine); err != nil {
+					log.Printf("warning: indexing codex session %s for search: %v", id, err)
+				}
+			}
+		})
 
-		var matches []model.SearchMatch
-		for i, msg := range messages {
-			contentLower := strings.ToLower(msg.Content)
-			idx := strings.Index(contentLower, queryLower)
-			if idx < 0 {
+		for _, sess := range sessions {
+			if err := ctx.Err(); err != nil {
+				yield(model.SearchResult{}, fmt.Errorf("search codex sessions: %w", err))
+				return
+			}
+			sessionFilePath := paths[sess.ID]
+			score, candidate := scores[sess.ID]
+			if sessionFilePath == "" || (scores != nil && !candidate) {
+				continue
+			}
+
+			// Project filter already applied by List() with the same opts.
+			messages, _, err := parseSessionFile(sessionFilePath)
+			if err != nil {
+				log.Printf("warning: parsing codex session %s for search: %v", sess.ID, err)
 				continue
 			}
-			snippet := extractSnippet(msg.Content, idx, len(query), 200)
-			matches = append(matches, model.SearchMatch{
-				MessageIndex: i,
-				Snippet:      snippet,
-				Role:         msg.Role,
-			})
-		}
 
-		if len(matches) > 0 {
-			sess.Project = cwd
 			sess.Messages = nil // don't include full messages in search results
-			results = append(results, model.SearchResult{
-				Session: sess,
-				Matches: matches,
-			})
+			if matches := m.Matches(&sess, messages); len(matches) > 0 {
+				r := model.SearchResult{Session: sess, Matches: matches, Score: score}
+				if !yield(r, nil) {
+					return
+				}
+			}
 		}
 	}
-
-	return results, nil
 }
 
-// extractSnippet returns a ~targetLen character snippet centred on a match.
-func extractSnippet(content string, matchIdx, matchLen, targetLen int) string {
-	if len(content) <= targetLen {
-		return content
-	}
-
-	halfWindow := (targetLen - matchLen) / 2
-	start := matchIdx - halfWindow
-	end := matchIdx + matchLen + halfWindow
-
-	if start < 0 {
-		end -= start
-		start = 0
+// indexLine is the index.LineParser for rollout files. It numbers messages
+// the way parseSessionFile does: tool calls and reasoning summaries join a
+// trailing assistant message, or start an empty one.
+func indexLine(line []byte, cur *index.Cursor) []index.Doc {
+	var sl sessionLine
+	if err := json.Unmarshal(line, &sl); err != nil || sl.Type != "response_item" {
+		return nil
 	}
-	if end > len(content) {
-		start -= end - len(content)
-		end = len(content)
+	var rip responseItemPayload
+	if err := json.Unmarshal(sl.Payload, &rip); err != nil {
+		return nil
 	}
-	// start is always ≥ 0 here: same reasoning as claude/extractSnippet.
 
-	snippet := content[start:end]
+	switch rip.Type {
+	case "message":
+		role := mapResponseItemRole(rip.Role)
+		if role == "" {
+			return nil
+		}
+		doc := index.Doc{
+			Message:   cur.Message,
+			Role:      string(role),
+			Content:   extractResponseContent(rip.Content),
+			Timestamp: parseCodexTimestamp(sl.Timestamp),
+		}
+		cur.Message++
+		cur.Role = string(role)
+		if doc.Content == "" {
+			return nil
+		}
+		return []index.Doc{doc}
 
-	prefix := ""
-	suffix := ""
-	if start > 0 {
-		prefix = "..."
-	}
-	if end < len(content) {
-		suffix = "..."
+	case "function_call", "custom_tool_call", "local_shell_call", "reasoning":
+		if rip.Type == "reasoning" && extractResponseContent(rip.Summary) == "" {
+			return nil
+		}
+		if cur.Role != string(model.RoleAssistant) {
+			cur.Message++
+			cur.Role = string(model.RoleAssistant)
+		}
 	}
-
-	return prefix + snippet + suffix
+	return nil
 }
@@ -1,16 +1,29 @@
 package codex
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
+// localSource is the codex source reading the local data directory.
+var localSource = &codexSource{}
+
 // ---------------------------------------------------------------------------
 // Name
 // ---------------------------------------------------------------------------
@@ -29,7 +42,7 @@ func TestName(t *testing.T) {
 func TestCodexDir_Success(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
-	dir, err := codexDir()
+	dir, err := localSource.codexDir()
 	if err != nil {
 		t.Fatalf("codexDir() error: %v", err)
 	}
@@ -39,9 +52,21 @@ func TestCodexDir_Success(t *testing.T) {
 	}
 }
 
+func TestCodexDir_FixedDir(t *testing.T) {
+	t.Setenv("HOME", "")
+	s := &codexSource{dir: "/srv/sessions/devbox/.codex"}
+	dir, err := s.codexDir()
+	if err != nil {
+		t.Fatalf("codexDir() error: %v", err)
+	}
+	if dir != s.dir {
+		t.Errorf("codexDir() = %q, want %q", dir, s.dir)
+	}
+}
+
 func TestCodexDir_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
-	_, err := codexDir()
+	_, err := localSource.codexDir()
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -50,7 +75,7 @@ func TestCodexDir_HomeDirError(t *testing.T) {
 func TestHistoryFilePath_Success(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
-	p, err := historyFilePath()
+	p, err := localSource.historyFilePath()
 	if err != nil {
 		t.Fatalf("historyFilePath() error: %v", err)
 	}
@@ -62,7 +87,7 @@ func TestHistoryFilePath_Success(t *testing.T) {
 
 func TestHistoryFilePath_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
-	_, err := historyFilePath()
+	_, err := localSource.historyFilePath()
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -74,7 +99,7 @@ func TestHistoryFilePath_HomeDirError(t *testing.T) {
 
 func TestLoadHistory_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
-	_, err := loadHistory()
+	_, err := localSource.loadHistory()
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -84,7 +109,7 @@ func TestLoadHistory_NoFile(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
 	// No history.jsonl file
-	entries, err := loadHistory()
+	entries, err := localSource.loadHistory()
 	if err != nil {
 		t.Fatalf("loadHistory() unexpected error: %v", err)
 	}
@@ -109,7 +134,7 @@ func TestLoadHistory_UnreadableFile(t *testing.T) {
 	}
 	defer os.Chmod(histPath, 0o644) //nolint:errcheck
 
-	_, err := loadHistory()
+	_, err := localSource.loadHistory()
 	if err == nil {
 		t.Fatal("expected error for unreadable history file, got nil")
 	}
@@ -130,7 +155,7 @@ func TestLoadHistory_EmptyLines(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entries, err := loadHistory()
+	entries, err := localSource.loadHistory()
 	if err != nil {
 		t.Fatalf("loadHistory() error: %v", err)
 	}
@@ -154,7 +179,7 @@ func TestLoadHistory_MalformedAndEmptyID(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entries, err := loadHistory()
+	entries, err := localSource.loadHistory()
 	if err != nil {
 		t.Fatalf("loadHistory() error: %v", err)
 	}
@@ -178,7 +203,7 @@ func TestLoadHistory_Deduplication(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entries, err := loadHistory()
+	entries, err := localSource.loadHistory()
 	if err != nil {
 		t.Fatalf("loadHistory() error: %v", err)
 	}
@@ -219,7 +244,7 @@ func TestLoadHistory_ScannerError(t *testing.T) {
 	f.Close()
 
 	// Should not return error itself (logs warning)
-	entries, err := loadHistory()
+	entries, err := localSource.loadHistory()
 	if err != nil {
 		t.Fatalf("loadHistory() should not return error for scanner warning: %v", err)
 	}
@@ -233,7 +258,7 @@ func TestLoadHistory_ScannerError(t *testing.T) {
 func TestList_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
 	s := &codexSource{}
-	_, err := s.List(source.ListOptions{})
+	_, err := s.List(context.Background(), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -256,7 +281,7 @@ func TestList_LoadHistoryError(t *testing.T) {
 	defer os.Chmod(histPath, 0o644) //nolint:errcheck
 
 	s := &codexSource{}
-	_, err := s.List(source.ListOptions{})
+	_, err := s.List(context.Background(), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error for unreadable history, got nil")
 	}
@@ -273,7 +298,7 @@ func TestList_ActiveFilter(t *testing.T) {
 	}
 
 	s := &codexSource{}
-	sessions, err := s.List(source.ListOptions{Active: true})
+	sessions, err := s.List(context.Background(), source.ListOptions{Active: true})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -288,13 +313,22 @@ func TestList_ProjectFilter(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &codexSource{}
-	sessions, err := s.List(source.ListOptions{Project: "nonexistent_project_xyz"})
+	sessions, err := s.List(context.Background(), source.ListOptions{Project: "nonexistent_project_xyz"})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
 	if len(sessions) != 0 {
 		t.Errorf("expected 0 sessions for non-matching project, got %d", len(sessions))
 	}
+
+	// The filter ignores case, as every source's does.
+	sessions, err = s.List(context.Background(), source.ListOptions{Project: "/USERS/TESTUSER"})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) == 0 {
+		t.Error("expected sessions for an upper-case project filter")
+	}
 }
 
 // ---------------------------------------------------------------------------
@@ -304,7 +338,7 @@ func TestList_ProjectFilter(t *testing.T) {
 func TestGet_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
 	s := &codexSource{}
-	_, err := s.Get("someid")
+	_, err := s.Get(context.Background(), "someid")
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -329,7 +363,7 @@ func TestGet_ResolveError(t *testing.T) {
 
 	s := &codexSource{}
 	// Prefix "aabbccdd" matches both fixtureSessionID and secondID
-	_, err = s.Get("aabbccdd")
+	_, err = s.Get(context.Background(), "aabbccdd")
 	if err == nil {
 		t.Fatal("expected ambiguous prefix error, got nil")
 	}
@@ -352,7 +386,7 @@ func TestGet_ParseError(t *testing.T) {
 	defer os.Chmod(sessPath, 0o644) //nolint:errcheck
 
 	s := &codexSource{}
-	_, err := s.Get(fixtureSessionID)
+	_, err := s.Get(context.Background(), fixtureSessionID)
 	if err == nil {
 		t.Fatal("expected error for unreadable session file, got nil")
 	}
@@ -382,7 +416,7 @@ func TestGet_NoMessages(t *testing.T) {
 	}
 
 	s := &codexSource{}
-	sess, err := s.Get("nomsg00-1234-5678-9abc-def012345678")
+	sess, err := s.Get(context.Background(), "nomsg00-1234-5678-9abc-def012345678")
 	if err != nil {
 		t.Fatalf("Get() error: %v", err)
 	}
@@ -405,7 +439,7 @@ func TestGet_NoMessages(t *testing.T) {
 func TestSearch_HomeDirError(t *testing.T) {
 	t.Setenv("HOME", "")
 	s := &codexSource{}
-	_, err := s.Search("query", source.ListOptions{})
+	_, err := s.Search(context.Background(), parseQuery(t, "query"), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -428,7 +462,7 @@ func TestSearch_ListError(t *testing.T) {
 	defer os.Chmod(histPath, 0o644) //nolint:errcheck
 
 	s := &codexSource{}
-	_, err := s.Search("query", source.ListOptions{})
+	_, err := s.Search(context.Background(), parseQuery(t, "query"), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error for unreadable history, got nil")
 	}
@@ -440,7 +474,7 @@ func TestSearch_ProjectFilter(t *testing.T) {
 
 	s := &codexSource{}
 	// Filter by a project path present in the session's cwd
-	results, err := s.Search("compare", source.ListOptions{Project: "/Users/testuser"})
+	results, err := s.Search(context.Background(), parseQuery(t, "compare"), source.ListOptions{Project: "/Users/testuser"})
 	if err != nil {
 		t.Fatalf("Search() error: %v", err)
 	}
@@ -455,7 +489,7 @@ func TestSearch_ProjectFilterNoMatch(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &codexSource{}
-	results, err := s.Search("compare", source.ListOptions{Project: "nonexistent_project_xyz"})
+	results, err := s.Search(context.Background(), parseQuery(t, "compare"), source.ListOptions{Project: "nonexistent_project_xyz"})
 	if err != nil {
 		t.Fatalf("Search() error: %v", err)
 	}
@@ -479,22 +513,73 @@ func TestSearch_ParseError(t *testing.T) {
 
 	s := &codexSource{}
 	// Search should skip the unreadable session gracefully
-	results, err := s.Search("compare", source.ListOptions{})
+	results, err := s.Search(context.Background(), parseQuery(t, "compare"), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("Search() unexpected error: %v", err)
 	}
 	_ = results
 }
 
+// ---------------------------------------------------------------------------
+// TranscriptPath / NewTranscript
+// ---------------------------------------------------------------------------
+
+// TestTranscript feeds a rollout file line by line, as omnisess tail does,
+// and expects what parseSessionFile reads from the whole file.
+func TestTranscript(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	s := &codexSource{}
+
+	path, err := s.TranscriptPath(context.Background(), "aabbccdd")
+	if err != nil || path == "" {
+		t.Fatalf("TranscriptPath() = %q, %v", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := s.NewTranscript()
+	for _, line := range strings.Split(string(data), "\n") {
+		tr.Add([]byte(line))
+	}
+	want, _, err := parseSessionFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Messages() = %+v, want %+v", got, want)
+	}
+
+	if path, err := s.TranscriptPath(context.Background(), "00000000"); path != "" || err != nil {
+		t.Errorf("TranscriptPath(missing) = %q, %v; want \"\", nil", path, err)
+	}
+}
+
+func TestTranscriptPath_Errors(t *testing.T) {
+	t.Run("no home", func(t *testing.T) {
+		t.Setenv("HOME", "")
+		if _, err := (&codexSource{}).TranscriptPath(context.Background(), "aabbccdd"); err == nil {
+			t.Error("expected error when HOME is empty")
+		}
+	})
+	t.Run("bad glob", func(t *testing.T) {
+		t.Setenv("HOME", "/home/[invalidbracket")
+		if _, err := (&codexSource{}).TranscriptPath(context.Background(), "aabbccdd"); err == nil {
+			t.Error("expected glob error")
+		}
+	})
+}
+
 // ---------------------------------------------------------------------------
 // resolveCodexSessionFile — glob error and ambiguous prefix
 // ---------------------------------------------------------------------------
 
 func TestResolveCodexSessionFile_GlobError(t *testing.T) {
-	// homeDir with unclosed bracket causes filepath.Glob to return syntax error
+	// codexDir with unclosed bracket causes filepath.Glob to return syntax error
 	_, _, err := resolveCodexSessionFile("/home/[invalidbracket", "someid")
 	if err == nil {
-		t.Fatal("expected glob error for malformed homeDir path, got nil")
+		t.Fatal("expected glob error for malformed codexDir path, got nil")
 	}
 }
 
@@ -513,7 +598,7 @@ func TestResolveCodexSessionFile_AmbiguousPrefix(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, _, err = resolveCodexSessionFile(home, "aabbccdd")
+	_, _, err = resolveCodexSessionFile(filepath.Join(home, ".codex"), "aabbccdd")
 	if err == nil {
 		t.Fatal("expected ambiguous error, got nil")
 	}
@@ -522,89 +607,6 @@ func TestResolveCodexSessionFile_AmbiguousPrefix(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// extractSnippet — all branches
-// ---------------------------------------------------------------------------
-
-func TestExtractSnippet_ShortContent(t *testing.T) {
-	content := "short"
-	got := extractSnippet(content, 0, 5, 200)
-	if got != content {
-		t.Errorf("short content should return as-is, got %q", got)
-	}
-}
-
-func TestExtractSnippet_MatchAtStart(t *testing.T) {
-	content := "MATCH" + strings.Repeat("b", 200)
-	got := extractSnippet(content, 0, 5, 20)
-	if !strings.Contains(got, "MATCH") {
-		t.Errorf("snippet %q should contain MATCH", got)
-	}
-	// start=0 so no leading ellipsis
-	if strings.HasPrefix(got, "...") {
-		t.Errorf("expected no leading ellipsis for start=0, got %q", got)
-	}
-}
-
-func TestExtractSnippet_MatchAtEnd(t *testing.T) {
-	content := strings.Repeat("a", 200) + "MATCH"
-	got := extractSnippet(content, 200, 5, 20)
-	if !strings.Contains(got, "MATCH") {
-		t.Errorf("snippet %q should contain MATCH", got)
-	}
-	// end==len → no trailing ellipsis
-	if strings.HasSuffix(got, "...") {
-		t.Errorf("expected no trailing ellipsis when end==len, got %q", got)
-	}
-	// start>0 → leading ellipsis
-	if !strings.HasPrefix(got, "...") {
-		t.Errorf("expected leading ellipsis, got %q", got)
-	}
-}
-
-func TestExtractSnippet_MatchInMiddle(t *testing.T) {
-	content := strings.Repeat("a", 100) + "MATCH" + strings.Repeat("b", 100)
-	got := extractSnippet(content, 100, 5, 20)
-	if !strings.Contains(got, "MATCH") {
-		t.Errorf("snippet %q should contain MATCH", got)
-	}
-	if !strings.HasPrefix(got, "...") {
-		t.Errorf("expected leading ellipsis for middle match, got %q", got)
-	}
-	if !strings.HasSuffix(got, "...") {
-		t.Errorf("expected trailing ellipsis for middle match, got %q", got)
-	}
-}
-
-func TestExtractSnippet_ShiftRight(t *testing.T) {
-	// Match near start so start < 0 → shift right
-	content := "ab" + "MATCH" + strings.Repeat("z", 300)
-	got := extractSnippet(content, 2, 5, 200)
-	if !strings.Contains(got, "MATCH") {
-		t.Errorf("snippet %q should contain MATCH", got)
-	}
-}
-
-func TestExtractSnippet_ShiftLeft(t *testing.T) {
-	// Match near end so end > len → shift left
-	content := strings.Repeat("a", 50) + "MATCH" + strings.Repeat("b", 3)
-	got := extractSnippet(content, 50, 5, 40)
-	if !strings.Contains(got, "MATCH") {
-		t.Errorf("snippet %q should contain MATCH", got)
-	}
-}
-
-func TestExtractSnippet_EllipsisMarkers(t *testing.T) {
-	content := strings.Repeat("a", 25) + "MATCH" + strings.Repeat("b", 25)
-	got := extractSnippet(content, 25, 5, 20)
-	if !strings.HasPrefix(got, "...") {
-		t.Errorf("expected leading '...', got %q", got)
-	}
-	if !strings.HasSuffix(got, "...") {
-		t.Errorf("expected trailing '...', got %q", got)
-	}
-}
-
 // ---------------------------------------------------------------------------
 // extractSessionIDFromPath — stem shorter than 36 chars (fallback path)
 // ---------------------------------------------------------------------------
@@ -639,34 +641,112 @@ func TestExtractSessionIDFromPath_36CharsNotUUID(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------------
-// readSessionCwd — edge cases
+// peekSessionMeta — edge cases
 // ---------------------------------------------------------------------------
 
-func TestReadSessionCwd_EmptyFile(t *testing.T) {
+func TestPeekSessionMeta(t *testing.T) {
+	got := peekSessionMeta(fixtureSessionFile)
+	want := sessionMeta{
+		CWD:        "/Users/testuser/prj/myproject",
+		Branch:     "feature/agents",
+		Model:      "gpt-5-codex",
+		CLIVersion: "0.46.0",
+		StartedAt:  time.Date(2026, 2, 9, 10, 1, 11, 966000000, time.UTC),
+	}
+	if got != want {
+		t.Errorf("peekSessionMeta() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPeekSessionMeta_NoGitNoTurnContext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "no_git.jsonl")
+	content := `{"timestamp":"2026-02-09T10:01:11.966Z","type":"session_meta","payload":{"cwd":"/tmp"}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[]}}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := peekSessionMeta(path)
+	if got.CWD != "/tmp" || got.Branch != "" || got.Model != "" {
+		t.Errorf("peekSessionMeta() = %+v, want only cwd", got)
+	}
+}
+
+func TestPeekSessionMeta_StopsAfterPeekWindow(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "late_context.jsonl")
+	var b strings.Builder
+	b.WriteString(`{"timestamp":"2026-02-09T10:01:11.966Z","type":"session_meta","payload":{"cwd":"/tmp"}}` + "\n")
+	b.WriteString("{bad json\n")
+	for i := 0; i < metaPeekLines; i++ {
+		b.WriteString(`{"timestamp":"2026-02-09T10:01:12.000Z","type":"event_msg","payload":{}}` + "\n")
+	}
+	b.WriteString(`{"timestamp":"2026-02-09T10:01:13.000Z","type":"turn_context","payload":{"model":"late"}}` + "\n")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := peekSessionMeta(path); got.Model != "" {
+		t.Errorf("Model = %q, want empty (turn_context beyond the peek window)", got.Model)
+	}
+}
+
+func TestPeekFirstUserMessage(t *testing.T) {
+	if got := peekFirstUserMessage(fixtureSessionFile); got != "compare AGENTS.md with CLAUDE.md" {
+		t.Errorf("peekFirstUserMessage(fixture) = %q", got)
+	}
+	if got := peekFirstUserMessage("/nonexistent/path/file.jsonl"); got != "" {
+		t.Errorf("expected empty for non-existent file, got %q", got)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "skips.jsonl")
+	content := "{bad json\n" +
+		`{"timestamp":"2026-02-09T10:01:11.966Z","type":"session_meta","payload":{"cwd":"/tmp"}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.000Z","type":"response_item","payload":"not an object"}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.100Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"text","text":"hi"}]}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.200Z","type":"response_item","payload":{"type":"message","role":"developer","content":[]}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.300Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"real prompt"}]}}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := peekFirstUserMessage(path); got != "real prompt" {
+		t.Errorf("peekFirstUserMessage() = %q, want real prompt", got)
+	}
+}
+
+func TestApplyMetaLine_MalformedTurnContext(t *testing.T) {
+	meta := sessionMeta{}
+	applyMetaLine(&meta, sessionLine{Type: "turn_context", Payload: []byte(`"not an object"`)})
+	if meta != (sessionMeta{}) {
+		t.Errorf("meta = %+v, want zero", meta)
+	}
+}
+
+func TestPeekSessionMeta_EmptyFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "empty.jsonl")
 	if err := os.WriteFile(path, []byte(""), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	got := readSessionCwd(path)
-	if got != "" {
-		t.Errorf("expected empty for empty file, got %q", got)
+	got := peekSessionMeta(path)
+	if got != (sessionMeta{}) {
+		t.Errorf("expected empty for empty file, got %+v", got)
 	}
 }
 
-func TestReadSessionCwd_MalformedFirstLine(t *testing.T) {
+func TestPeekSessionMeta_MalformedFirstLine(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "malformed.jsonl")
 	if err := os.WriteFile(path, []byte("{bad json}\n"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	got := readSessionCwd(path)
-	if got != "" {
-		t.Errorf("expected empty for malformed JSON, got %q", got)
+	got := peekSessionMeta(path)
+	if got != (sessionMeta{}) {
+		t.Errorf("expected empty for malformed JSON, got %+v", got)
 	}
 }
 
-func TestReadSessionCwd_WrongType(t *testing.T) {
+func TestPeekSessionMeta_WrongType(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "wrong_type.jsonl")
 	// First line is not session_meta
@@ -674,13 +754,13 @@ func TestReadSessionCwd_WrongType(t *testing.T) {
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	got := readSessionCwd(path)
-	if got != "" {
-		t.Errorf("expected empty for non-session_meta type, got %q", got)
+	got := peekSessionMeta(path)
+	if got != (sessionMeta{}) {
+		t.Errorf("expected empty for non-session_meta type, got %+v", got)
 	}
 }
 
-func TestReadSessionCwd_MalformedPayload(t *testing.T) {
+func TestPeekSessionMeta_MalformedPayload(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "bad_payload.jsonl")
 	// First line is session_meta but payload is a string not object
@@ -688,16 +768,16 @@ func TestReadSessionCwd_MalformedPayload(t *testing.T) {
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	got := readSessionCwd(path)
-	if got != "" {
-		t.Errorf("expected empty for malformed payload, got %q", got)
+	got := peekSessionMeta(path)
+	if got != (sessionMeta{}) {
+		t.Errorf("expected empty for malformed payload, got %+v", got)
 	}
 }
 
-func TestReadSessionCwd_NonExistent(t *testing.T) {
-	got := readSessionCwd("/nonexistent/path/file.jsonl")
-	if got != "" {
-		t.Errorf("expected empty for non-existent file, got %q", got)
+func TestPeekSessionMeta_NonExistent(t *testing.T) {
+	got := peekSessionMeta("/nonexistent/path/file.jsonl")
+	if got != (sessionMeta{}) {
+		t.Errorf("expected empty for non-existent file, got %+v", got)
 	}
 }
 
@@ -715,12 +795,12 @@ func TestParseSessionFile_EmptyLines(t *testing.T) {
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	msgs, cwd, err := parseSessionFile(path)
+	msgs, meta, err := parseSessionFile(path)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if cwd != "/tmp/test" {
-		t.Errorf("cwd = %q, want /tmp/test", cwd)
+	if meta.CWD != "/tmp/test" {
+		t.Errorf("cwd = %q, want /tmp/test", meta.CWD)
 	}
 	if len(msgs) != 1 {
 		t.Errorf("expected 1 message, got %d", len(msgs))
@@ -749,9 +829,9 @@ func TestParseSessionFile_ResponseItemUnmarshalError(t *testing.T) {
 func TestParseSessionFile_NonMessageResponseItem(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "non_message.jsonl")
-	// response_item with type != "message"
+	// response_item with an unhandled type
 	content := `{"timestamp":"2026-02-09T10:01:11.966Z","type":"session_meta","payload":{"cwd":"/tmp"}}` + "\n" +
-		`{"timestamp":"2026-02-09T10:01:12.000Z","type":"response_item","payload":{"type":"function_call","role":"developer","content":[]}}` + "\n" +
+		`{"timestamp":"2026-02-09T10:01:12.000Z","type":"response_item","payload":{"type":"web_search_call","role":"developer","content":[]}}` + "\n" +
 		`{"timestamp":"2026-02-09T10:01:13.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"good"}]}}` + "\n"
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatal(err)
@@ -761,7 +841,7 @@ func TestParseSessionFile_NonMessageResponseItem(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if len(msgs) != 1 {
-		t.Errorf("expected 1 message (non-message type skipped), got %d", len(msgs))
+		t.Errorf("expected 1 message (unhandled type skipped), got %d", len(msgs))
 	}
 }
 
@@ -816,12 +896,115 @@ func TestParseSessionFile_CwdAlreadySet(t *testing.T) {
 	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	_, cwd, err := parseSessionFile(path)
+	_, meta, err := parseSessionFile(path)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if cwd != "/first/cwd" {
-		t.Errorf("cwd = %q, want /first/cwd (first wins)", cwd)
+	if meta.CWD != "/first/cwd" {
+		t.Errorf("cwd = %q, want /first/cwd (first wins)", meta.CWD)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// parseSessionFile — tool calls, outputs and reasoning
+// ---------------------------------------------------------------------------
+
+func TestParseSessionFile_ToolCalls(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tools.jsonl")
+	lines := []string{
+		`{"timestamp":"2026-02-09T10:01:11.966Z","type":"session_meta","payload":{"cwd":"/tmp"}}`,
+		`{"timestamp":"2026-02-09T10:01:12.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"run the tests"}]}}`,
+		`{"timestamp":"2026-02-09T10:01:13.000Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"**Running tests**"}],"encrypted_content":"xyz"}}`,
+		`{"timestamp":"2026-02-09T10:01:13.500Z","type":"response_item","payload":{"type":"reasoning","summary":[],"encrypted_content":"xyz"}}`,
+		`{"timestamp":"2026-02-09T10:01:14.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"go\",\"test\"]}","call_id":"call_1"}}`,
+		`{"timestamp":"2026-02-09T10:01:15.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"{\"output\":\"ok  pkg\\n\",\"metadata\":{\"exit_code\":0}}"}}`,
+		`{"timestamp":"2026-02-09T10:01:15.500Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"**Listing files**"}]}}`,
+		`{"timestamp":"2026-02-09T10:01:16.000Z","type":"response_item","payload":{"type":"local_shell_call","call_id":"call_2","status":"completed","action":{"type":"exec","command":["ls","-la"]}}}`,
+		`{"timestamp":"2026-02-09T10:01:17.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_2","output":"total 0"}}`,
+		`{"timestamp":"2026-02-09T10:01:18.000Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch","call_id":"call_3"}}`,
+		`{"timestamp":"2026-02-09T10:01:19.000Z","type":"response_item","payload":{"type":"custom_tool_call_output","call_id":"call_3","output":{"content":"Success"}}}`,
+		`{"timestamp":"2026-02-09T10:01:19.500Z","type":"response_item","payload":{"type":"function_call_output","call_id":"unknown","output":"orphan"}}`,
+		`{"timestamp":"2026-02-09T10:01:20.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"All tests pass."}]}}`,
+		`{"timestamp":"2026-02-09T10:01:21.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{}","call_id":"call_4"}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _, err := parseSessionFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// user, assistant (calls only), assistant (reply + trailing call)
+	if len(msgs) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(msgs))
+	}
+
+	t.Run("calls before the reply start an assistant message", func(t *testing.T) {
+		m := msgs[1]
+		if m.Role != model.RoleAssistant || m.Content != "" {
+			t.Errorf("msgs[1] = %q/%q, want empty assistant message", m.Role, m.Content)
+		}
+		wantTS := time.Date(2026, 2, 9, 10, 1, 13, 0, time.UTC)
+		if !m.Timestamp.Equal(wantTS) {
+			t.Errorf("msgs[1].Timestamp = %v, want %v", m.Timestamp, wantTS)
+		}
+		if want := "**Running tests**\n\n**Listing files**"; m.Reasoning != want {
+			t.Errorf("msgs[1].Reasoning = %q, want %q", m.Reasoning, want)
+		}
+		want := []model.ToolCall{
+			{Name: "shell", Input: `{"command":["go","test"]}`, Output: "ok  pkg\n"},
+			{Name: "local_shell", Input: "ls -la", Output: "total 0"},
+			{Name: "apply_patch", Input: "*** Begin Patch", Output: "Success"},
+		}
+		if len(m.ToolCalls) != len(want) {
+			t.Fatalf("expected %d tool calls, got %d: %+v", len(want), len(m.ToolCalls), m.ToolCalls)
+		}
+		for i, w := range want {
+			if m.ToolCalls[i] != w {
+				t.Errorf("ToolCalls[%d] = %+v, want %+v", i, m.ToolCalls[i], w)
+			}
+		}
+	})
+
+	t.Run("calls after the reply attach to it", func(t *testing.T) {
+		m := msgs[2]
+		if m.Content != "All tests pass." || m.Reasoning != "" {
+			t.Errorf("msgs[2] = %q/%q, want the reply without reasoning", m.Content, m.Reasoning)
+		}
+		if len(m.ToolCalls) != 1 || m.ToolCalls[0].Name != "shell" || m.ToolCalls[0].Output != "" {
+			t.Errorf("msgs[2].ToolCalls = %+v, want one shell call without output", m.ToolCalls)
+		}
+	})
+}
+
+func TestExtractToolOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"plain string", `"hello"`, "hello"},
+		{"wrapped shell output", `"{\"output\":\"done\",\"metadata\":{}}"`, "done"},
+		{"JSON string without output key", `"{\"other\":1}"`, `{"other":1}`},
+		{"content object", `{"content":"ok","success":true}`, "ok"},
+		{"unsupported", `[1,2]`, ""},
+		{"missing", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractToolOutput(json.RawMessage(tt.raw)); got != tt.want {
+				t.Errorf("extractToolOutput(%s) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseToolCall_LocalShellWithoutAction(t *testing.T) {
+	tc := responseToolCall(responseItemPayload{Type: "local_shell_call"})
+	if tc.Name != "local_shell" || tc.Input != "" {
+		t.Errorf("responseToolCall() = %+v", tc)
 	}
 }
 
@@ -929,7 +1112,7 @@ func TestSearch_MissingSessionFile(t *testing.T) {
 	}
 
 	s := &codexSource{}
-	results, err := s.Search("find this text", source.ListOptions{})
+	results, err := s.Search(context.Background(), parseQuery(t, "find this text"), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("Search() unexpected error: %v", err)
 	}
@@ -948,7 +1131,7 @@ func TestSearch_ProjectFilterSkips(t *testing.T) {
 	s := &codexSource{}
 	// The fixture session's cwd is /Users/testuser/prj/myproject.
 	// Filter by a project string that doesn't match → session skipped.
-	results, err := s.Search("compare AGENTS", source.ListOptions{Project: "nonexistent-project-xyz"})
+	results, err := s.Search(context.Background(), parseQuery(t, "compare AGENTS"), source.ListOptions{Project: "nonexistent-project-xyz"})
 	if err != nil {
 		t.Fatalf("Search() unexpected error: %v", err)
 	}
@@ -956,3 +1139,430 @@ func TestSearch_ProjectFilterSkips(t *testing.T) {
 		t.Errorf("Search() with non-matching project filter: got %d results, want 0", len(results))
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Context cancellation
+// ---------------------------------------------------------------------------
+
+// cancelAfter is a context that reports itself cancelled once Err has been
+// called n times, to stop a source at a chosen point of its work.
+type cancelAfter struct {
+	context.Context
+	n, calls int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls++; c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestList_Cancelled(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := localSource.List(ctx, source.ListOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() error = %v, want context.Canceled", err)
+	}
+	// Without history every rollout is an orphan.
+	if err := os.WriteFile(filepath.Join(home, ".codex", "history.jsonl"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localSource.List(ctx, source.ListOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() of orphans error = %v, want context.Canceled", err)
+	}
+}
+
+func TestSearch_CancelledAfterList(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	probe := &cancelAfter{Context: context.Background(), n: math.MaxInt}
+	if _, err := localSource.List(probe, source.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			useIndex(t)
+		}
+		ctx := &cancelAfter{Context: context.Background(), n: probe.calls}
+		results, err := localSource.Search(ctx, parseQuery(t, "the"), source.ListOptions{})
+		if !errors.Is(err, context.Canceled) || len(results) != 0 {
+			t.Errorf("Search(indexed=%v) = %d results, %v; want context.Canceled", indexed, len(results), err)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Another host's root (source.AddHost)
+// ---------------------------------------------------------------------------
+
+func TestAddHost_ReadsFixedRoot(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", t.TempDir()) // the local root is empty
+	if err := source.AddHost("devbox", model.ToolCodex, filepath.Join(home, ".codex")); err != nil {
+		t.Fatalf("AddHost() error: %v", err)
+	}
+	t.Cleanup(source.ClearHosts)
+
+	var remote source.Source
+	for _, s := range source.ByName(model.ToolCodex) {
+		if source.HostOf(s) == "devbox" {
+			remote = s
+		}
+	}
+	if remote == nil {
+		t.Fatal("no source registered for host devbox")
+	}
+	sessions, err := remote.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) == 0 {
+		t.Fatal("expected sessions from the devbox root")
+	}
+	for _, s := range sessions {
+		if s.Host != "devbox" {
+			t.Errorf("session %s Host = %q, want devbox", s.ID, s.Host)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Metadata index
+// ---------------------------------------------------------------------------
+
+// useIndex points every source at a fresh metadata index for the test.
+func useIndex(t *testing.T) {
+	t.Helper()
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("open index: %v", err)
+	}
+	source.SetIndex(ix)
+	t.Cleanup(func() {
+		source.SetIndex(nil)
+		ix.Close()
+	})
+}
+
+// rewriteKeepingStat replaces a file's content with same-length content and
+// restores its mtime, so only a cache lookup can still see the old content.
+func rewriteKeepingStat(t *testing.T, path, content string) {
+	t.Helper()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(content)) != fi.Size() {
+		t.Fatalf("replacement is %d bytes, want %d", len(content), fi.Size())
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestList_UsesIndex(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	day := filepath.Join(home, ".codex", "sessions", "2026", "02", "09")
+	if err := os.MkdirAll(day, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const id = "0f0f0f0f-0000-0000-0000-000000000000"
+	rollout := func(branch, prompt string) string {
+		return `{"timestamp":"2026-02-09T10:01:11Z","type":"session_meta","payload":{"id":"` + id + `","cwd":"/tmp/app","git":{"branch":"` + branch + `"}}}` + "\n" +
+			`{"timestamp":"2026-02-09T10:01:12Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"` + prompt + `"}]}}` + "\n"
+	}
+	path := filepath.Join(day, "rollout-2026-02-09T10-01-11-"+id+".jsonl")
+	if err := os.WriteFile(path, []byte(rollout("main", "first prompt")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useIndex(t)
+
+	list := func() model.Session {
+		t.Helper()
+		sessions, err := (&codexSource{}).List(context.Background(), source.ListOptions{})
+		if err != nil || len(sessions) != 1 {
+			t.Fatalf("List() = %v, %v", sessions, err)
+		}
+		return sessions[0]
+	}
+	if s := list(); s.Branch != "main" || s.Preview != "first prompt" {
+		t.Fatalf("first List() = %+v", s)
+	}
+
+	rewriteKeepingStat(t, path, rollout("dev1", "other prompt"))
+	if s := list(); s.Branch != "main" || s.Preview != "first prompt" {
+		t.Errorf("unchanged stat should be served from the index, got %+v", s)
+	}
+
+	source.SetIndex(nil)
+	if s := list(); s.Branch != "dev1" || s.Preview != "other prompt" {
+		t.Errorf("without the index the file is re-read, got %+v", s)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Search — full-text index
+// ---------------------------------------------------------------------------
+
+// toolRollout mixes messages with the call, reasoning and output items that
+// parseSessionFile folds into assistant messages.
+const toolRollout = `{"timestamp":"2026-02-09T10:00:00Z","type":"session_meta","payload":{"id":"x","cwd":"/p"}}
+not json
+{"timestamp":"2026-02-09T10:00:01Z","type":"response_item","payload":"not an object"}
+{"timestamp":"2026-02-09T10:00:01Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{}","call_id":"c1"}}
+{"timestamp":"2026-02-09T10:00:02Z","type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"ok"}}
+{"timestamp":"2026-02-09T10:00:03Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"ran the shell tool"}]}}
+{"timestamp":"2026-02-09T10:00:04Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"now reason about it"}]}}
+{"timestamp":"2026-02-09T10:00:05Z","type":"response_item","payload":{"type":"reasoning","summary":[]}}
+{"timestamp":"2026-02-09T10:00:06Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"thinking"}]}}
+{"timestamp":"2026-02-09T10:00:07Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"patch"}}
+{"timestamp":"2026-02-09T10:00:08Z","type":"response_item","payload":{"type":"message","role":"system","content":[{"type":"input_text","text":"ignored"}]}}
+{"timestamp":"2026-02-09T10:00:09Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[]}}
+{"timestamp":"2026-02-09T10:00:10Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"patched it"}]}}
+`
+
+func TestIndexLine_MatchesParseSessionFile(t *testing.T) {
+	for name, content := range map[string]string{"fixture": "", "tools": toolRollout} {
+		t.Run(name, func(t *testing.T) {
+			path := fixtureSessionFile
+			if content != "" {
+				path = filepath.Join(t.TempDir(), "rollout.jsonl")
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			messages, _, err := parseSessionFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []index.Doc
+			for i, m := range messages {
+				if m.Content != "" {
+					want = append(want, index.Doc{Message: i, Role: string(m.Role), Content: m.Content, Timestamp: m.Timestamp})
+				}
+			}
+
+			data, _ := os.ReadFile(path)
+			cur := &index.Cursor{}
+			var got []index.Doc
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				got = append(got, indexLine([]byte(line), cur)...)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("indexLine docs =\n%v\nwant\n%v", got, want)
+			}
+			if cur.Message != len(messages) {
+				t.Errorf("cursor at %d, want %d messages", cur.Message, len(messages))
+			}
+		})
+	}
+}
+
+func TestSearch_UsesIndex(t *testing.T) {
+	home, sessionPath := setupFakeHome(t)
+	t.Setenv("HOME", home)
+
+	for _, query := range []string{"agents.md", "compare", "sc"} {
+		scanned, err := localSource.Search(context.Background(), parseQuery(t, query), source.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		useIndex(t)
+		indexed, err := localSource.Search(context.Background(), parseQuery(t, query), source.ListOptions{})
+		if err != nil {
+			t.Fatalf("Search(%q) with index error: %v", query, err)
+		}
+		source.SetIndex(nil)
+
+		if fmt.Sprint(matchesOf(indexed)) != fmt.Sprint(matchesOf(scanned)) {
+			t.Errorf("Search(%q) indexed = %v, scanned = %v", query, matchesOf(indexed), matchesOf(scanned))
+		}
+		for _, r := range indexed {
+			if (r.Score > 0) != (len(query) >= 3) {
+				t.Errorf("Search(%q) score = %v", query, r.Score)
+			}
+		}
+	}
+
+	// Lines appended after a search are indexed by the next one.
+	useIndex(t)
+	if _, err := localSource.Search(context.Background(), parseQuery(t, "agents"), source.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(sessionPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"timestamp":"2026-02-09T10:02:00.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"add a zebra"}]}}` + "\n")
+	f.Close()
+	results, err := localSource.Search(context.Background(), parseQuery(t, "zebra"), source.ListOptions{})
+	if err != nil || len(results) != 1 || results[0].Matches[0].MessageIndex != 4 {
+		t.Errorf("Search(zebra) after append = %+v, %v", results, err)
+	}
+}
+
+func TestSearch_QueryLanguage(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+
+	id := fixtureSessionID
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"agents.md role:assistant", []string{id + "#1:assistant", id + "#3:assistant"}},
+		{"agents.md -concise", []string{id + "#0:user", id + "#3:assistant"}},
+		{"differences OR orchestration tool:codex", []string{id + "#2:user", id + "#3:assistant"}},
+		{"agents.md tool:claude", nil},
+	}
+	for _, tt := range tests {
+		for _, indexed := range []bool{false, true} {
+			if indexed {
+				useIndex(t)
+			}
+			results, err := localSource.Search(context.Background(), parseQuery(t, tt.query), source.ListOptions{})
+			source.SetIndex(nil)
+			if err != nil {
+				t.Fatalf("Search(%q) error: %v", tt.query, err)
+			}
+			if got := matchesOf(results); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Search(%q, indexed=%v) = %v, want %v", tt.query, indexed, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSearch_InTools(t *testing.T) {
+	home, sessionPath := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	f, err := os.OpenFile(sessionPath, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteString(strings.Join([]string{
+		`{"timestamp":"2026-02-09T10:05:00.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"go\",\"test\",\"./pkg_under_test\"]}","call_id":"call_1"}}`,
+		`{"timestamp":"2026-02-09T10:05:01.000Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_1","output":"ok pkg_under_test"}}`,
+	}, "\n") + "\n")
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		scope search.Scope
+		want  string
+	}{
+		{search.ScopeContent, "[]"},
+		{search.ScopeTools, "[3:tool_input#0 3:tool_output#0]"},
+		{search.ScopeAll, "[3:tool_input#0 3:tool_output#0]"},
+	}
+	for _, tt := range tests {
+		results, err := localSource.Search(context.Background(), parseQuery(t, "pkg_under_test").In(tt.scope), source.ListOptions{})
+		if err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			for _, m := range r.Matches {
+				got = append(got, fmt.Sprintf("%d:%s#%d", m.MessageIndex, m.Location, m.ToolCall))
+			}
+		}
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("Search(scope %v) matches = %v, want %s", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestSearch_IndexFailureFallsBackToScan(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.Close()
+	source.SetIndex(ix)
+	defer source.SetIndex(nil)
+
+	results, err := localSource.Search(context.Background(), parseQuery(t, "agents.md"), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) == 0 || results[0].Score != 0 {
+		t.Errorf("expected scanned results, got %+v", results)
+	}
+}
+
+// matchesOf lists each result's session and matched message indices.
+func matchesOf(results []model.SearchResult) []string {
+	var out []string
+	for _, r := range results {
+		for _, m := range r.Matches {
+			out = append(out, fmt.Sprintf("%s#%d:%s", r.Session.ID, m.MessageIndex, m.Role))
+		}
+	}
+	return out
+}
+
+// parseQuery parses a search query, failing the test on syntax errors.
+func parseQuery(t *testing.T, s string) *search.Query {
+	t.Helper()
+	q, err := search.Parse(s)
+	if err != nil {
+		t.Fatalf("search.Parse(%q): %v", s, err)
+	}
+	return q
+}
+
+// ---------------------------------------------------------------------------
+// Streaming (source.Streamer)
+// ---------------------------------------------------------------------------
+
+func TestSessions_StopsEarly(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	listed, err := localSource.List(context.Background(), source.ListOptions{})
+	if err != nil || len(listed) == 0 {
+		t.Fatalf("List() = %d sessions, %v; want some", len(listed), err)
+	}
+
+	for sess, err := range localSource.Sessions(context.Background(), source.ListOptions{}) {
+		if err != nil {
+			t.Fatalf("Sessions() error: %v", err)
+		}
+		if sess.ID != listed[0].ID || sess.Project != listed[0].Project {
+			t.Errorf("Sessions() first = %s %q, want %s %q", sess.ID, sess.Project, listed[0].ID, listed[0].Project)
+		}
+		break
+	}
+}
+
+func TestSearchResults_StopsEarly(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+
+	n := 0
+	for _, err := range localSource.SearchResults(context.Background(), parseQuery(t, "role:user"), source.ListOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchResults() error: %v", err)
+		}
+		if n++; n == 1 {
+			break
+		}
+	}
+	if n != 1 {
+		t.Errorf("SearchResults() = %d results, want to stop after 1", n)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/psacc/omnisess/internal/detect"
 	"github.com/psacc/omnisess/internal/model"
 )
 
@@ -29,15 +30,53 @@ type sessionLine struct {
 
 // sessionMetaPayload holds the fields from a session_meta line's payload.
 type sessionMetaPayload struct {
-	CWD string `json:"cwd"`
+	CWD        string   `json:"cwd"`
+	CLIVersion string   `json:"cli_version"`
+	Git        *gitInfo `json:"git"` // absent outside a git repository
+}
+
+// gitInfo is the repository state Codex records in session_meta.
+type gitInfo struct {
+	Branch string `json:"branch"`
+}
+
+// turnContextPayload holds the fields from a turn_context line's payload.
+// Codex writes one per turn; the first one gives the session's model, and
+// each the model of the responses that follow it.
+type turnContextPayload struct {
+	Model string `json:"model"`
+}
+
+// sessionMeta is the session-level metadata shown by List and Get.
+type sessionMeta struct {
+	CWD        string
+	Branch     string
+	Model      string
+	CLIVersion string
+	StartedAt  time.Time // session_meta timestamp
 }
 
 // responseItemPayload holds the fields from a response_item line's payload.
-// Only lines with payload.type == "message" carry conversation content.
+// Lines with payload.type == "message" carry conversation content; tool
+// calls, their outputs and reasoning summaries use the remaining fields.
 type responseItemPayload struct {
-	Type    string            `json:"type"` // "message", etc.
+	Type    string            `json:"type"` // "message", "function_call", "local_shell_call", "reasoning", ...
 	Role    string            `json:"role"` // "developer" → RoleUser, "assistant" → RoleAssistant
 	Content []responseContent `json:"content"`
+
+	Name      string            `json:"name"`      // function_call, custom_tool_call
+	Arguments string            `json:"arguments"` // function_call: JSON-encoded arguments
+	Input     string            `json:"input"`     // custom_tool_call: raw input
+	CallID    string            `json:"call_id"`   // links a call to its *_output item
+	Output    json.RawMessage   `json:"output"`    // function_call_output, custom_tool_call_output
+	Action    *shellAction      `json:"action"`    // local_shell_call
+	Summary   []responseContent `json:"summary"`   // reasoning
+}
+
+// shellAction is the action of a local_shell_call response item.
+type shellAction struct {
+	Type    string   `json:"type"` // "exec"
+	Command []string `json:"command"`
 }
 
 // responseContent is a single element of a response_item payload's content array.
@@ -46,6 +85,45 @@ type responseContent struct {
 	Text string `json:"text"`
 }
 
+// tokenCountPayload holds the fields of a token_count event_msg. Current
+// Codex versions report the session's running total under info (null until
+// the first response); older ones the response's own counts at the top
+// level.
+type tokenCountPayload struct {
+	eventPayload
+	tokenUsage
+	Info *struct {
+		Total tokenUsage `json:"total_token_usage"`
+	} `json:"info"`
+}
+
+// tokenUsage is a Codex token count. Input tokens include the cached ones,
+// and output tokens the reasoning ones.
+type tokenUsage struct {
+	InputTokens           int64 `json:"input_tokens"`
+	CachedInputTokens     int64 `json:"cached_input_tokens"`
+	OutputTokens          int64 `json:"output_tokens"`
+	ReasoningOutputTokens int64 `json:"reasoning_output_tokens"`
+}
+
+func (u tokenUsage) minus(o tokenUsage) tokenUsage {
+	return tokenUsage{
+		InputTokens:           u.InputTokens - o.InputTokens,
+		CachedInputTokens:     u.CachedInputTokens - o.CachedInputTokens,
+		OutputTokens:          u.OutputTokens - o.OutputTokens,
+		ReasoningOutputTokens: u.ReasoningOutputTokens - o.ReasoningOutputTokens,
+	}
+}
+
+func (u tokenUsage) usage() model.Usage {
+	return model.Usage{
+		InputTokens:     u.InputTokens - u.CachedInputTokens,
+		CacheReadTokens: u.CachedInputTokens,
+		OutputTokens:    u.OutputTokens,
+		ReasoningTokens: u.ReasoningOutputTokens,
+	}
+}
+
 // parseHistoryLine parses a single line from ~/.codex/history.jsonl.
 func parseHistoryLine(line []byte) (*historyEntry, error) {
 	var entry historyEntry
@@ -56,11 +134,11 @@ func parseHistoryLine(line []byte) (*historyEntry, error) {
 }
 
 // findSessionFile locates the JSONL session file for a given session ID by
-// globbing ~/.codex/sessions/YYYY/MM/DD/*-<sessionID>.jsonl.
+// globbing <codexDir>/sessions/YYYY/MM/DD/*-<sessionID>.jsonl.
 // The path has 4 levels under sessions/ (year/month/day/file), so the pattern
 // uses 4 wildcards. Returns the first match or empty string if not found.
-func findSessionFile(homeDir, sessionID string) string {
-	pattern := filepath.Join(homeDir, ".codex", "sessions", "*", "*", "*", "*-"+sessionID+".jsonl")
+func findSessionFile(codexDir, sessionID string) string {
+	pattern := filepath.Join(codexDir, "sessions", "*", "*", "*", "*-"+sessionID+".jsonl")
 	matches, err := filepath.Glob(pattern)
 	if err != nil || len(matches) == 0 {
 		return ""
@@ -68,6 +146,13 @@ func findSessionFile(homeDir, sessionID string) string {
 	return matches[0]
 }
 
+// findSessionFiles returns every rollout under <codexDir>/sessions/YYYY/MM/DD/.
+func findSessionFiles(codexDir string) []string {
+	pattern := filepath.Join(codexDir, "sessions", "*", "*", "*", "*.jsonl")
+	matches, _ := filepath.Glob(pattern) // pattern is static and well-formed
+	return matches
+}
+
 // extractSessionIDFromPath extracts the UUID from a Codex session file path.
 // Path pattern: .../rollout-<datetime>-<uuid>.jsonl
 // The UUID is everything after the last '-' in the base filename (without .jsonl),
@@ -91,97 +176,369 @@ func extractSessionIDFromPath(path string) string {
 	return stem
 }
 
-// readSessionCwd reads only the first line of a Codex session file to extract
-// the cwd from the session_meta payload. This is cheap (one line) and avoids
-// parsing the full file during List().
-func readSessionCwd(path string) string {
+// metaPeekLines bounds how far peekSessionMeta reads. session_meta is the
+// first line and the first turn_context follows the opening user message, so
+// a handful of lines is enough.
+const metaPeekLines = 20
+
+// peekSessionMeta reads the head of a Codex session file to extract the cwd,
+// git branch, CLI version and model. It stops as soon as both session_meta and a
+// turn_context have been seen, which avoids parsing the full file during
+// List().
+func peekSessionMeta(path string) sessionMeta {
+	var meta sessionMeta
 	f, err := os.Open(path)
 	if err != nil {
-		return ""
+		return meta
 	}
 	defer f.Close()
 
 	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 64*1024), 256*1024)
-	if !scanner.Scan() {
-		return ""
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	seenMeta := false
+	for i := 0; i < metaPeekLines && scanner.Scan(); i++ {
+		var sl sessionLine
+		if err := json.Unmarshal(scanner.Bytes(), &sl); err != nil {
+			continue
+		}
+		if sl.Type == "session_meta" {
+			seenMeta = true
+		}
+		applyMetaLine(&meta, sl)
+		if seenMeta && meta.Model != "" {
+			break
+		}
 	}
-	var sl sessionLine
-	if err := json.Unmarshal(scanner.Bytes(), &sl); err != nil || sl.Type != "session_meta" {
-		return ""
+	return meta
+}
+
+// applyMetaLine fills meta from a session_meta or turn_context line. Fields
+// that are already set are kept, so the first occurrence wins.
+func applyMetaLine(meta *sessionMeta, sl sessionLine) {
+	switch sl.Type {
+	case "session_meta":
+		var p sessionMetaPayload
+		if err := json.Unmarshal(sl.Payload, &p); err != nil {
+			return
+		}
+		if meta.CWD == "" {
+			meta.CWD = p.CWD
+		}
+		if meta.StartedAt.IsZero() {
+			meta.StartedAt = parseCodexTimestamp(sl.Timestamp)
+		}
+		if meta.Branch == "" && p.Git != nil {
+			meta.Branch = p.Git.Branch
+		}
+		if meta.CLIVersion == "" {
+			meta.CLIVersion = p.CLIVersion
+		}
+	case "turn_context":
+		var p turnContextPayload
+		if err := json.Unmarshal(sl.Payload, &p); err != nil {
+			return
+		}
+		if meta.Model == "" {
+			meta.Model = p.Model
+		}
 	}
-	var meta sessionMetaPayload
-	if err := json.Unmarshal(sl.Payload, &meta); err != nil {
+}
+
+// peekFirstUserMessage reads up to metaPeekLines lines of a session file and
+// returns the content of the first user message, truncated for use as a
+// preview. Used for sessions that have no history.jsonl entry.
+func peekFirstUserMessage(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
 		return ""
 	}
-	return meta.CWD
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for i := 0; i < metaPeekLines && scanner.Scan(); i++ {
+		var sl sessionLine
+		if err := json.Unmarshal(scanner.Bytes(), &sl); err != nil || sl.Type != "response_item" {
+			continue
+		}
+		var rip responseItemPayload
+		if err := json.Unmarshal(sl.Payload, &rip); err != nil {
+			continue
+		}
+		if rip.Type != "message" || mapResponseItemRole(rip.Role) != model.RoleUser {
+			continue
+		}
+		if content := extractResponseContent(rip.Content); content != "" {
+			return detect.Truncate(content, 120)
+		}
+	}
+	return ""
 }
 
 // parseSessionFile reads a Codex session JSONL file and returns all conversation
-// messages and the cwd (project path from session_meta). Only response_item
-// lines with payload.type == "message" are used; event_msg lines are skipped
-// to avoid duplicates (both types carry the same conversation content).
-func parseSessionFile(path string) ([]model.Message, string, error) {
+// messages and the session metadata (cwd, branch, model). Messages come
+// from response_item lines only; event_msg lines carry the same conversation
+// content and are only read for their token counts. Tool calls and their outputs
+// become ToolCalls, and reasoning summaries the Reasoning, of the assistant
+// turn they belong to.
+func parseSessionFile(path string) ([]model.Message, sessionMeta, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, "", fmt.Errorf("open codex session file %s: %w", path, err)
+		return nil, sessionMeta{}, fmt.Errorf("open codex session file %s: %w", path, err)
 	}
 	defer f.Close()
 
-	var messages []model.Message
-	var cwd string
-
+	t := newTranscript()
 	scanner := bufio.NewScanner(f)
 	scanner.Buffer(make([]byte, 0, 1024*1024), 10*1024*1024)
 	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+		t.Add(scanner.Bytes())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return t.messages, t.meta, fmt.Errorf("scan codex session file %s: %w", path, err)
+	}
+
+	return t.messages, t.meta, nil
+}
+
+// transcript gathers the messages of a rollout file line by line, for
+// parseSessionFile and for following a session as it is written.
+type transcript struct {
+	messages []model.Message
+	meta     sessionMeta
+	// calls maps a call_id to its ToolCall position so the matching
+	// *_output item can fill in Output.
+	calls map[string]toolCallRef
+	// total is the last running token total of a token_count event.
+	total tokenUsage
+	// model is the model of the current turn, which a turn_context can
+	// switch.
+	model string
+}
+
+func newTranscript() *transcript {
+	return &transcript{calls: make(map[string]toolCallRef)}
+}
+
+// Add parses one line of the rollout file.
+func (t *transcript) Add(line []byte) {
+	if len(line) == 0 {
+		return
+	}
+
+	var sl sessionLine
+	if err := json.Unmarshal(line, &sl); err != nil {
+		return // skip malformed lines
+	}
+
+	ts := parseCodexTimestamp(sl.Timestamp)
+
+	switch sl.Type {
+	case "session_meta":
+		applyMetaLine(&t.meta, sl)
+
+	case "turn_context":
+		applyMetaLine(&t.meta, sl)
+		var p turnContextPayload
+		if err := json.Unmarshal(sl.Payload, &p); err == nil && p.Model != "" {
+			t.model = p.Model
 		}
 
-		var sl sessionLine
-		if err := json.Unmarshal(line, &sl); err != nil {
-			continue // skip malformed lines
+	case "event_msg":
+		t.addTokenCount(sl.Payload)
+
+	case "response_item":
+		var rip responseItemPayload
+		if err := json.Unmarshal(sl.Payload, &rip); err != nil {
+			return
 		}
+		switch rip.Type {
+		case "message":
+			role := mapResponseItemRole(rip.Role)
+			if role == "" {
+				return
+			}
+			content := extractResponseContent(rip.Content)
+			t.messages = append(t.messages, model.Message{
+				Role:      role,
+				Content:   content,
+				Timestamp: ts,
+			})
 
-		ts := parseCodexTimestamp(sl.Timestamp)
+		case "reasoning":
+			// Codex stores the reasoning itself encrypted; only its summary
+			// is readable.
+			summary := extractResponseContent(rip.Summary)
+			if summary == "" {
+				return
+			}
+			t.messages = startAssistant(t.messages, ts)
+			last := &t.messages[len(t.messages)-1]
+			if last.Reasoning != "" {
+				last.Reasoning += "\n\n"
+			}
+			last.Reasoning += model.TruncateToolText(summary)
 
-		switch sl.Type {
-		case "session_meta":
-			if cwd == "" {
-				var meta sessionMetaPayload
-				if err := json.Unmarshal(sl.Payload, &meta); err == nil {
-					cwd = meta.CWD
-				}
+		case "function_call", "custom_tool_call", "local_shell_call":
+			t.messages = attachToolCall(t.messages, responseToolCall(rip), ts)
+			if rip.CallID != "" {
+				last := len(t.messages) - 1
+				t.calls[rip.CallID] = toolCallRef{msg: last, call: len(t.messages[last].ToolCalls) - 1}
+			}
+
+		case "function_call_output", "custom_tool_call_output":
+			ref, ok := t.calls[rip.CallID]
+			if !ok {
+				return
 			}
+			t.messages[ref.msg].ToolCalls[ref.call].Output = model.TruncateToolText(extractToolOutput(rip.Output))
+		}
+	}
+}
+
+// Messages returns the messages parsed so far.
+func (t *transcript) Messages() []model.Message { return t.messages }
 
+// addTokenCount adds the usage of a token_count event to the assistant
+// message of the response it follows, if there is one, and records the
+// turn's model as the one that wrote it.
+func (t *transcript) addTokenCount(raw json.RawMessage) {
+	var p tokenCountPayload
+	if err := json.Unmarshal(raw, &p); err != nil || p.Type != "token_count" {
+		return
+	}
+	u := p.tokenUsage
+	if p.Info != nil {
+		// The response's share of a running total is what it adds, so
+		// an event repeated with the same total adds nothing.
+		u = p.Info.Total.minus(t.total)
+		t.total = p.Info.Total
+	}
+	for i := len(t.messages) - 1; i >= 0; i-- {
+		if t.messages[i].Role == model.RoleAssistant {
+			t.messages[i].Usage.Add(u.usage())
+			if t.model != "" {
+				t.messages[i].Model = t.model
+			}
+			return
+		}
+	}
+}
+
+// eventPayload holds the type of an event_msg line's payload.
+type eventPayload struct {
+	Type string `json:"type"` // "task_started", "task_complete", "user_message", "token_count", ...
+}
+
+// lastTurn reads where the agent's turn is from the last lines of a session
+// file. Codex closes every turn with a task_complete (or turn_aborted) event;
+// before that, the last response item tells whether a tool call is waiting
+// for its output.
+func lastTurn(path string) detect.Turn {
+	for _, line := range detect.TailLines(path) {
+		var sl sessionLine
+		if err := json.Unmarshal(line, &sl); err != nil {
+			continue
+		}
+		switch sl.Type {
+		case "event_msg":
+			var ev eventPayload
+			if err := json.Unmarshal(sl.Payload, &ev); err != nil {
+				continue
+			}
+			switch ev.Type {
+			case "task_complete", "turn_aborted":
+				return detect.TurnDone
+			case "error":
+				return detect.TurnFailed
+			case "exec_approval_request", "apply_patch_approval_request":
+				return detect.TurnApproval
+			case "task_started", "user_message", "agent_reasoning":
+				return detect.TurnRunning
+			}
 		case "response_item":
 			var rip responseItemPayload
 			if err := json.Unmarshal(sl.Payload, &rip); err != nil {
 				continue
 			}
-			if rip.Type != "message" {
-				continue
-			}
-			role := mapResponseItemRole(rip.Role)
-			if role == "" {
-				continue
+			switch rip.Type {
+			case "function_call", "custom_tool_call", "local_shell_call":
+				return detect.TurnToolPending
+			case "message", "reasoning", "function_call_output", "custom_tool_call_output":
+				return detect.TurnRunning
 			}
-			content := extractResponseContent(rip.Content)
-			messages = append(messages, model.Message{
-				Role:      role,
-				Content:   content,
-				Timestamp: ts,
-			})
+		}
+	}
+	return detect.TurnUnknown
+}
 
+// toolCallRef locates a ToolCall inside the parsed message slice.
+type toolCallRef struct {
+	msg  int
+	call int
+}
+
+// responseToolCall converts a call response item into a model.ToolCall.
+func responseToolCall(rip responseItemPayload) model.ToolCall {
+	switch rip.Type {
+	case "custom_tool_call":
+		return model.ToolCall{Name: rip.Name, Input: model.TruncateToolText(rip.Input)}
+	case "local_shell_call":
+		input := ""
+		if rip.Action != nil {
+			input = strings.Join(rip.Action.Command, " ")
 		}
+		return model.ToolCall{Name: "local_shell", Input: model.TruncateToolText(input)}
+	default: // "function_call"
+		return model.ToolCall{Name: rip.Name, Input: model.TruncateToolText(rip.Arguments)}
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return messages, cwd, fmt.Errorf("scan codex session file %s: %w", path, err)
+// attachToolCall appends tc to the trailing assistant message, started by
+// startAssistant if need be.
+func attachToolCall(messages []model.Message, tc model.ToolCall, ts time.Time) []model.Message {
+	messages = startAssistant(messages, ts)
+	last := &messages[len(messages)-1]
+	last.ToolCalls = append(last.ToolCalls, tc)
+	return messages
+}
+
+// startAssistant makes sure messages ends with an assistant message. Codex
+// emits reasoning and calls before the assistant's reply, so when the turn
+// has no assistant message yet an empty one is started to hold them.
+func startAssistant(messages []model.Message, ts time.Time) []model.Message {
+	if len(messages) == 0 || messages[len(messages)-1].Role != model.RoleAssistant {
+		messages = append(messages, model.Message{
+			Role:      model.RoleAssistant,
+			Timestamp: ts,
+		})
 	}
+	return messages
+}
 
-	return messages, cwd, nil
+// extractToolOutput returns the text of a *_output item. The output is a
+// string which, for shell calls, may itself be JSON of the form
+// {"output": "...", "metadata": {...}}; newer Codex versions also write an
+// object with a "content" field.
+func extractToolOutput(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		var obj struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return ""
+		}
+		return obj.Content
+	}
+	var wrapped struct {
+		Output *string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(s), &wrapped); err == nil && wrapped.Output != nil {
+		return *wrapped.Output
+	}
+	return s
 }
 
 // mapResponseItemRole maps a response_item payload role to a model.Role.
@@ -1,12 +1,15 @@
 package codex
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/psacc/omnisess/internal/detect"
 	"github.com/psacc/omnisess/internal/model"
 	"github.com/psacc/omnisess/internal/source"
 )
@@ -136,13 +139,23 @@ func TestParseHistoryLine(t *testing.T) {
 
 func TestParseSessionFile(t *testing.T) {
 	t.Run("messages in order with role mapping and cwd", func(t *testing.T) {
-		msgs, cwd, err := parseSessionFile(fixtureSessionFile)
+		msgs, meta, err := parseSessionFile(fixtureSessionFile)
 		if err != nil {
 			t.Fatalf("parseSessionFile: %v", err)
 		}
 
-		if cwd != "/Users/testuser/prj/myproject" {
-			t.Errorf("cwd = %q, want /Users/testuser/prj/myproject", cwd)
+		if meta.CWD != "/Users/testuser/prj/myproject" {
+			t.Errorf("cwd = %q, want /Users/testuser/prj/myproject", meta.CWD)
+		}
+		if meta.Branch != "feature/agents" {
+			t.Errorf("branch = %q, want feature/agents", meta.Branch)
+		}
+		if meta.CLIVersion != "0.46.0" {
+			t.Errorf("cli version = %q, want 0.46.0", meta.CLIVersion)
+		}
+		// The model comes from the first turn_context, matching peekSessionMeta.
+		if meta.Model != "gpt-5-codex" {
+			t.Errorf("model = %q, want gpt-5-codex", meta.Model)
 		}
 
 		if len(msgs) != 4 {
@@ -194,12 +207,12 @@ func TestParseSessionFile(t *testing.T) {
 		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
 			t.Fatal(err)
 		}
-		msgs, cwd, err := parseSessionFile(path)
+		msgs, meta, err := parseSessionFile(path)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		if cwd != "/tmp" {
-			t.Errorf("cwd = %q, want /tmp", cwd)
+		if meta.CWD != "/tmp" {
+			t.Errorf("cwd = %q, want /tmp", meta.CWD)
 		}
 		if len(msgs) != 1 {
 			t.Errorf("expected 1 message (malformed line skipped), got %d", len(msgs))
@@ -232,18 +245,127 @@ func TestParseSessionFile(t *testing.T) {
 			t.Fatalf("expected 0 messages (event_msg skipped), got %d", len(msgs))
 		}
 	})
+
+	t.Run("token_count events give the usage of the assistant messages", func(t *testing.T) {
+		tokenCount := func(payload string) string {
+			return `{"timestamp":"2026-02-09T10:01:12.000Z","type":"event_msg","payload":{"type":"token_count",` + payload + `}}` + "\n"
+		}
+		path := filepath.Join(t.TempDir(), "usage.jsonl")
+		content := tokenCount(`"info":null,"rate_limits":{}`) +
+			tokenCount(`"input_tokens":9,"output_tokens":9`) + // no assistant message yet: dropped
+			`{"timestamp":"2026-02-09T10:01:13.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"run ls"}]}}` + "\n" +
+			`{"timestamp":"2026-02-09T10:01:14.000Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{}","call_id":"c1"}}` + "\n" +
+			tokenCount(`"info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":800,"output_tokens":50,"reasoning_output_tokens":20,"total_tokens":1050}}`) +
+			tokenCount(`"info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":800,"output_tokens":50,"reasoning_output_tokens":20,"total_tokens":1050}}`) +
+			`{"timestamp":"2026-02-09T10:01:15.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"done"}]}}` + "\n" +
+			tokenCount(`"info":{"total_token_usage":{"input_tokens":2500,"cached_input_tokens":2000,"output_tokens":120,"reasoning_output_tokens":20,"total_tokens":2620}}`) +
+			tokenCount(`"input_tokens":10,"cached_input_tokens":4,"output_tokens":3`) // an older Codex: the response's own counts
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		msgs, _, err := parseSessionFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(msgs) != 3 {
+			t.Fatalf("expected 3 messages, got %d", len(msgs))
+		}
+		want := []model.Usage{
+			{},
+			{InputTokens: 200, CacheReadTokens: 800, OutputTokens: 50, ReasoningTokens: 20},
+			{InputTokens: 306, CacheReadTokens: 1204, OutputTokens: 73},
+		}
+		for i, m := range msgs {
+			if m.Usage != want[i] {
+				t.Errorf("msgs[%d].Usage = %+v, want %+v", i, m.Usage, want[i])
+			}
+		}
+	})
+
+	t.Run("turn_context gives the model of the turns that follow", func(t *testing.T) {
+		turn := func(model, reply string) string {
+			return `{"timestamp":"2026-02-09T10:01:12.000Z","type":"turn_context","payload":{"model":` + model + `}}` + "\n" +
+				`{"timestamp":"2026-02-09T10:01:13.000Z","type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"` + reply + `"}]}}` + "\n" +
+				`{"timestamp":"2026-02-09T10:01:14.000Z","type":"event_msg","payload":{"type":"token_count","input_tokens":10,"output_tokens":1}}` + "\n"
+		}
+		path := filepath.Join(t.TempDir(), "switch.jsonl")
+		content := turn(`"gpt-5-codex"`, "first") + turn(`"o3"`, "second") + turn(`""`, "third") + turn(`7`, "fourth")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		msgs, meta, err := parseSessionFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if meta.Model != "gpt-5-codex" {
+			t.Errorf("meta.Model = %q, want the first turn's", meta.Model)
+		}
+		var got []string
+		for _, m := range msgs {
+			got = append(got, m.Model)
+		}
+		if want := []string{"gpt-5-codex", "o3", "o3", "o3"}; !slices.Equal(got, want) {
+			t.Errorf("message models = %q, want %q", got, want)
+		}
+	})
 }
 
 // ---------------------------------------------------------------------------
 // 3.5  findSessionFile — table-driven (uses temp dir)
 // ---------------------------------------------------------------------------
 
+func TestLastTurn(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  detect.Turn
+	}{
+		{"task complete", []string{
+			`{"type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Done."}]}}`,
+			`{"type":"event_msg","payload":{"type":"agent_message","message":"Done."}}`,
+			`{"type":"event_msg","payload":{"type":"task_complete"}}`,
+			`{"type":"event_msg","payload":{"type":"token_count"}}`,
+		}, detect.TurnDone},
+		{"aborted", []string{`{"type":"event_msg","payload":{"type":"turn_aborted"}}`}, detect.TurnDone},
+		{"error", []string{`{"type":"event_msg","payload":{"type":"error","message":"quota"}}`}, detect.TurnFailed},
+		{"approval", []string{`{"type":"event_msg","payload":{"type":"exec_approval_request"}}`}, detect.TurnApproval},
+		{"tool call pending", []string{
+			`{"type":"response_item","payload":{"type":"function_call","name":"shell","call_id":"c1"}}`,
+		}, detect.TurnToolPending},
+		{"tool output", []string{
+			`{"type":"response_item","payload":{"type":"function_call_output","call_id":"c1"}}`,
+		}, detect.TurnRunning},
+		{"task started", []string{
+			`{"type":"event_msg","payload":{"type":"task_started"}}`,
+			`{"type":"turn_context","payload":{"model":"gpt-5"}}`,
+		}, detect.TurnRunning},
+		{"malformed payloads skipped", []string{
+			`{"type":"event_msg","payload":{"type":"user_message"}}`,
+			`{"type":"response_item","payload":"oops"}`,
+			`{"type":"event_msg","payload":[]}`,
+			`garbage`,
+		}, detect.TurnRunning},
+		{"nothing telling", []string{`{"type":"session_meta","payload":{"cwd":"/tmp"}}`}, detect.TurnUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rollout.jsonl")
+			if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastTurn(path); got != tt.want {
+				t.Errorf("lastTurn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestFindSessionFile(t *testing.T) {
 	home, sessionPath := setupFakeHome(t)
 	_ = sessionPath
 
 	t.Run("exact match", func(t *testing.T) {
-		got := findSessionFile(home, fixtureSessionID)
+		got := findSessionFile(filepath.Join(home, ".codex"), fixtureSessionID)
 		if got == "" {
 			t.Fatal("expected a path, got empty string")
 		}
@@ -253,7 +375,7 @@ func TestFindSessionFile(t *testing.T) {
 	})
 
 	t.Run("no match returns empty string", func(t *testing.T) {
-		got := findSessionFile(home, "00000000-0000-0000-0000-000000000000")
+		got := findSessionFile(filepath.Join(home, ".codex"), "00000000-0000-0000-0000-000000000000")
 		if got != "" {
 			t.Errorf("expected empty string, got %q", got)
 		}
@@ -280,7 +402,7 @@ func TestList(t *testing.T) {
 	defer func() { os.Setenv("HOME", origHome) }() //nolint:errcheck
 
 	s := &codexSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -297,15 +419,29 @@ func TestList(t *testing.T) {
 	if sessions[0].Tool != model.ToolCodex {
 		t.Errorf("sessions[0].Tool = %q, want %q", sessions[0].Tool, model.ToolCodex)
 	}
-	// StartedAt should be from earliest ts (1739091671)
+	// StartedAt should be from earliest ts (1739091671) until the rollout
+	// is timed
 	wantStarted := time.Unix(1739091671, 0)
-	if !sessions[0].StartedAt.Equal(wantStarted) {
-		t.Errorf("sessions[0].StartedAt = %v, want %v", sessions[0].StartedAt, wantStarted)
+	if !sessions[0].StartedAt.Equal(wantStarted) || !sessions[0].Untimed {
+		t.Errorf("sessions[0].StartedAt = %v, untimed %v, want %v, untimed", sessions[0].StartedAt, sessions[0].Untimed, wantStarted)
+	}
+	// and from the first message of the rollout once it is.
+	timed, err := s.List(context.Background(), source.ListOptions{Timing: true})
+	if err != nil {
+		t.Fatalf("List(Timing) error: %v", err)
+	}
+	if want := time.Date(2026, 2, 9, 10, 1, 12, 0, time.UTC); !timed[0].StartedAt.Equal(want) ||
+		timed[0].EndedAt.Before(want) || timed[0].Duration != timed[0].EndedAt.Sub(want) || timed[0].Untimed {
+		t.Errorf("timed StartedAt = %v, EndedAt = %v, Duration = %v, want from %v", timed[0].StartedAt, timed[0].EndedAt, timed[0].Duration, want)
 	}
 	// Preview from earliest text entry
 	if sessions[0].Preview != "compare AGENTS.md with CLAUDE.md" {
 		t.Errorf("sessions[0].Preview = %q", sessions[0].Preview)
 	}
+	// Branch, version and model peeked from session_meta / turn_context
+	if sessions[0].Branch != "feature/agents" || sessions[0].Model != "gpt-5-codex" || sessions[0].Version != "0.46.0" {
+		t.Errorf("sessions[0] branch/model/version = %q/%q/%q, want feature/agents/gpt-5-codex/0.46.0", sessions[0].Branch, sessions[0].Model, sessions[0].Version)
+	}
 
 	t.Run("deduplication: only 2 sessions for 3 history lines", func(t *testing.T) {
 		if len(sessions) != 2 {
@@ -314,7 +450,7 @@ func TestList(t *testing.T) {
 	})
 
 	t.Run("Limit filter", func(t *testing.T) {
-		limited, err := s.List(source.ListOptions{Limit: 1})
+		limited, err := s.List(context.Background(), source.ListOptions{Limit: 1})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -325,7 +461,7 @@ func TestList(t *testing.T) {
 
 	t.Run("Since filter excludes old sessions", func(t *testing.T) {
 		// Since = 1 nanosecond: all sessions should be excluded (they're old)
-		filtered, err := s.List(source.ListOptions{Since: 1})
+		filtered, err := s.List(context.Background(), source.ListOptions{Since: 1})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -335,6 +471,140 @@ func TestList(t *testing.T) {
 	})
 }
 
+func TestList_OrphanSessions(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+
+	// A rollout from `codex exec` that never made it into history.jsonl.
+	const orphanID = "99887766-5544-3322-1100-ffeeddccbbaa"
+	dir := filepath.Join(home, ".codex", "sessions", "2026", "02", "10")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"timestamp":"2026-02-10T08:00:00.000Z","type":"session_meta","payload":{"id":"` + orphanID + `","cwd":"/work/exec","git":{"branch":"main"}}}` + "\n" +
+		`{"timestamp":"2026-02-10T08:00:01.000Z","type":"response_item","payload":{"type":"message","role":"developer","content":[{"type":"input_text","text":"bump the version"}]}}` + "\n" +
+		`{"timestamp":"2026-02-10T08:00:01.100Z","type":"turn_context","payload":{"model":"gpt-5-codex"}}` + "\n"
+	path := filepath.Join(dir, "rollout-2026-02-10T08-00-00-"+orphanID+".jsonl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2026, 2, 10, 8, 5, 0, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &codexSource{}
+	sessions, err := s.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions (2 history + 1 orphan), got %d", len(sessions))
+	}
+
+	var orphan *model.Session
+	for i := range sessions {
+		if sessions[i].ID == orphanID {
+			orphan = &sessions[i]
+		}
+	}
+	if orphan == nil {
+		t.Fatal("orphan session not listed")
+	}
+	if orphan.Preview != "bump the version" || orphan.Title != "bump the version" {
+		t.Errorf("orphan preview = %q, want first user message", orphan.Preview)
+	}
+	if orphan.Project != "/work/exec" || orphan.Branch != "main" || orphan.Model != "gpt-5-codex" {
+		t.Errorf("orphan metadata = %q/%q/%q", orphan.Project, orphan.Branch, orphan.Model)
+	}
+	if want := time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC); !orphan.StartedAt.Equal(want) {
+		t.Errorf("orphan StartedAt = %v, want %v", orphan.StartedAt, want)
+	}
+	// Once timed, by its one message, not the session_meta line before it.
+	timed, err := s.List(context.Background(), source.ListOptions{Timing: true, Project: "/work/exec"})
+	if err != nil || len(timed) != 1 {
+		t.Fatalf("List(Timing) = %v, %v", timed, err)
+	}
+	if want := time.Date(2026, 2, 10, 8, 0, 1, 0, time.UTC); !timed[0].StartedAt.Equal(want) || !timed[0].EndedAt.Equal(want) || timed[0].Duration != 0 {
+		t.Errorf("timed orphan StartedAt = %v, EndedAt = %v, want both %v", timed[0].StartedAt, timed[0].EndedAt, want)
+	}
+	if !orphan.UpdatedAt.Equal(mtime) {
+		t.Errorf("orphan UpdatedAt = %v, want file mtime %v", orphan.UpdatedAt, mtime)
+	}
+
+	t.Run("merged list is sorted by UpdatedAt", func(t *testing.T) {
+		for i := 1; i < len(sessions); i++ {
+			if sessions[i].UpdatedAt.After(sessions[i-1].UpdatedAt) {
+				t.Errorf("sessions not sorted at %d", i)
+			}
+		}
+	})
+
+	t.Run("filters apply to orphans", func(t *testing.T) {
+		byProject, err := s.List(context.Background(), source.ListOptions{Project: "/work/exec"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(byProject) != 1 || byProject[0].ID != orphanID {
+			t.Errorf("Project filter: got %d sessions", len(byProject))
+		}
+		otherProject, err := s.List(context.Background(), source.ListOptions{Project: "myproject"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sess := range otherProject {
+			if sess.ID == orphanID {
+				t.Error("Project filter: orphan from another project listed")
+			}
+		}
+		active, err := s.List(context.Background(), source.ListOptions{Active: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(active) != 0 {
+			t.Errorf("Active filter: expected 0 sessions, got %d", len(active))
+		}
+		recent, err := s.List(context.Background(), source.ListOptions{Since: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recent) != 0 {
+			t.Errorf("Since filter: expected 0 sessions, got %d", len(recent))
+		}
+	})
+
+	t.Run("orphan is reachable via Get and Search", func(t *testing.T) {
+		sess, err := s.Get(context.Background(), orphanID[:8])
+		if err != nil || sess == nil {
+			t.Fatalf("Get() = %v, %v", sess, err)
+		}
+		results, err := s.Search(context.Background(), parseQuery(t, "bump"), source.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Session.ID != orphanID {
+			t.Errorf("Search() returned %d results", len(results))
+		}
+	})
+
+	t.Run("missing session_meta falls back to mtime for StartedAt", func(t *testing.T) {
+		const bareID = "00000000-1111-2222-3333-444444444444"
+		bare := filepath.Join(dir, "rollout-2026-02-10T09-00-00-"+bareID+".jsonl")
+		if err := os.WriteFile(bare, []byte("\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		all, err := s.List(context.Background(), source.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sess := range all {
+			if sess.ID == bareID && !sess.StartedAt.Equal(sess.UpdatedAt) {
+				t.Errorf("StartedAt = %v, want UpdatedAt %v", sess.StartedAt, sess.UpdatedAt)
+			}
+		}
+	})
+}
+
 // ---------------------------------------------------------------------------
 // 3.7  Get() — messages populated, project set
 // ---------------------------------------------------------------------------
@@ -349,7 +619,7 @@ func TestGet(t *testing.T) {
 	s := &codexSource{}
 
 	t.Run("valid session ID returns session with messages", func(t *testing.T) {
-		sess, err := s.Get(fixtureSessionID)
+		sess, err := s.Get(context.Background(), fixtureSessionID)
 		if err != nil {
 			t.Fatalf("Get() error: %v", err)
 		}
@@ -366,6 +636,9 @@ func TestGet(t *testing.T) {
 		if sess.Project != "/Users/testuser/prj/myproject" {
 			t.Errorf("sess.Project = %q", sess.Project)
 		}
+		if sess.Branch != "feature/agents" || sess.Model != "gpt-5-codex" || sess.Version != "0.46.0" {
+			t.Errorf("sess branch/model/version = %q/%q/%q, want feature/agents/gpt-5-codex/0.46.0", sess.Branch, sess.Model, sess.Version)
+		}
 		if len(sess.Messages) != 4 {
 			t.Errorf("expected 4 messages, got %d", len(sess.Messages))
 		}
@@ -374,7 +647,7 @@ func TestGet(t *testing.T) {
 	t.Run("prefix match returns session", func(t *testing.T) {
 		// Use first 8 chars as prefix
 		prefix := fixtureSessionID[:8]
-		sess, err := s.Get(prefix)
+		sess, err := s.Get(context.Background(), prefix)
 		if err != nil {
 			t.Fatalf("Get(%q) error: %v", prefix, err)
 		}
@@ -387,7 +660,7 @@ func TestGet(t *testing.T) {
 	})
 
 	t.Run("unknown ID returns nil nil", func(t *testing.T) {
-		sess, err := s.Get("00000000-0000-0000-0000-000000000000")
+		sess, err := s.Get(context.Background(), "00000000-0000-0000-0000-000000000000")
 		if err != nil {
 			t.Fatalf("Get() unexpected error: %v", err)
 		}
@@ -411,7 +684,7 @@ func TestSearch(t *testing.T) {
 	s := &codexSource{}
 
 	t.Run("query matches content", func(t *testing.T) {
-		results, err := s.Search("agents.md", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "agents.md"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -429,7 +702,7 @@ func TestSearch(t *testing.T) {
 	})
 
 	t.Run("query matches nothing returns empty", func(t *testing.T) {
-		results, err := s.Search("zzznomatchzzz", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "zzznomatchzzz"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -439,7 +712,7 @@ func TestSearch(t *testing.T) {
 	})
 
 	t.Run("case-insensitive match", func(t *testing.T) {
-		results, err := s.Search("COMPARE", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "COMPARE"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/source"
 )
 
 type chatMeta struct {
@@ -17,13 +20,13 @@ type chatMeta struct {
 	Model     string `json:"lastUsedModel"`
 }
 
-// readAllChatMeta scans ~/.cursor/chats/<workspace>/<agent>/store.db files
+// readAllChatMeta scans <cursorDir>/chats/<workspace>/<agent>/store.db files
 // and reads their hex-encoded JSON metadata.
 // Returns a map from agentId to chatMeta.
-func readAllChatMeta(homeDir string) map[string]chatMeta {
+func readAllChatMeta(cursorDir string) map[string]chatMeta {
 	result := make(map[string]chatMeta)
 
-	chatsDir := filepath.Join(homeDir, ".cursor", "chats")
+	chatsDir := filepath.Join(cursorDir, "chats")
 	workspaces, err := os.ReadDir(chatsDir)
 	if err != nil {
 		return result
@@ -42,7 +45,9 @@ func readAllChatMeta(homeDir string) map[string]chatMeta {
 				continue
 			}
 			dbPath := filepath.Join(chatsDir, ws.Name(), agent.Name(), "store.db")
-			meta, err := readChatStoreMeta(dbPath)
+			meta, err := index.Load(source.Index(), "cursor.chatmeta", dbPath, func() (chatMeta, error) {
+				return readChatStoreMeta(dbPath)
+			})
 			if err != nil {
 				continue
 			}
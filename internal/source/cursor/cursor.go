@@ -1,105 +1,222 @@
 package cursor
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/psacc/omnisess/internal/detect"
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
 func init() {
 	source.Register(&cursorSource{})
+	source.RegisterFactory(model.ToolCursor, func(dir string) source.Source {
+		return &cursorSource{dir: dir}
+	})
 }
 
-type cursorSource struct{}
+type cursorSource struct {
+	dir string // fixed data directory (another host's root); empty resolves via source.Root
+}
 
 func (s *cursorSource) Name() model.Tool { return model.ToolCursor }
 
+// markActive looks for the process running a session. Another host's
+// processes are not visible here, so a fixed root's sessions are left
+// inactive.
+func (s *cursorSource) markActive(sess *model.Session, path string) {
+	if s.dir == "" {
+		detect.MarkActive(sess, path, nil)
+	}
+}
+
+// cursorDir returns the Cursor data directory: the source's fixed dir, else
+// ~/.cursor unless relocated by a --cursor-root override.
+func (s *cursorSource) cursorDir() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+	return source.Root(model.ToolCursor)
+}
+
+// trackingDBPath returns the path of Cursor's AI code tracking database.
+func trackingDBPath(cursorDir string) string {
+	return filepath.Join(cursorDir, "ai-tracking", "ai-code-tracking.db")
+}
+
+// transcriptPreview returns the first non-empty user message of a
+// transcript, truncated for use as a preview.
+func transcriptPreview(path string) string {
+	messages, err := parseTranscript(path)
+	if err != nil {
+		return ""
+	}
+	for _, m := range messages {
+		if m.Role == model.RoleUser && strings.TrimSpace(m.Content) != "" {
+			return detect.Truncate(m.Content, 120)
+		}
+	}
+	return ""
+}
+
 // List returns Cursor sessions ordered by most recent first.
 // It uses the SQLite tracking DB as the primary metadata source,
 // enriched with project path info from transcript file locations.
-func (s *cursorSource) List(opts source.ListOptions) ([]model.Session, error) {
-	homeDir, err := os.UserHomeDir()
+func (s *cursorSource) List(ctx context.Context, opts source.ListOptions) ([]model.Session, error) {
+	return source.Collect(s.Sessions(ctx, opts), opts.Limit)
+}
+
+// Sessions yields the sessions List returns, most recent first. Sessions
+// are gathered from the tracking DB and the transcripts on disk; active
+// detection and transcript previews wait until a session is reached.
+func (s *cursorSource) Sessions(ctx context.Context, opts source.ListOptions) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		refs, err := s.sessionRefs(ctx, opts)
+		if err != nil {
+			yield(model.Session{}, fmt.Errorf("cursor: %w", err))
+			return
+		}
+		for _, ref := range refs {
+			if err := ctx.Err(); err != nil {
+				yield(model.Session{}, fmt.Errorf("cursor: %w", err))
+				return
+			}
+			sess := ref.Session
+			if ref.path != "" {
+				s.markActive(&sess, ref.path)
+			}
+			if opts.Active && !sess.Active {
+				continue
+			}
+			if ref.orphan {
+				// Try to derive a preview from the first user message in the transcript.
+				sess.Preview = index.Memo(source.Index(), "cursor.preview", ref.path, func() string {
+					return transcriptPreview(ref.path)
+				})
+				if sess.Preview == "" && sess.Title != "" {
+					sess.Preview = detect.Truncate(sess.Title, 120)
+				}
+			}
+			if !yield(sess, nil) {
+				return
+			}
+		}
+	}
+}
+
+// sessionRef is a session as gathered by Sessions, before the work that
+// waits until it is reached.
+type sessionRef struct {
+	model.Session
+	path   string // the transcript, "" when there is none
+	orphan bool   // a transcript the tracking DB does not know
+}
+
+// sessionRefs returns every session passing the opts.Since and opts.Project
+// filters, most recent first.
+func (s *cursorSource) sessionRefs(ctx context.Context, opts source.ListOptions) ([]sessionRef, error) {
+	dir, err := s.cursorDir()
 	if err != nil {
-		return nil, fmt.Errorf("cursor: resolve home dir: %w", err)
+		return nil, err
 	}
 
 	// Build a lookup from conversationID to transcript entry for project resolution.
-	transcripts := listAllTranscripts(homeDir)
+	transcripts := listAllTranscripts(dir)
 	transcriptMap := make(map[string]transcriptEntry, len(transcripts))
 	for _, t := range transcripts {
 		transcriptMap[t.ConversationID] = t
 	}
 
-	dbPath := filepath.Join(homeDir, ".cursor", "ai-tracking", "ai-code-tracking.db")
-	summaries, err := readConversationSummaries(dbPath)
+	dbPath := trackingDBPath(dir)
+	summaries, err := readConversationSummaries(ctx, dbPath)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "cursor: warning: could not read tracking db: %v\n", err)
 		summaries = nil
 	}
 
 	// Read chat store metadata for session names and creation times.
-	chatMetas := readAllChatMeta(homeDir)
+	chatMetas := readAllChatMeta(dir)
 
 	// Track which conversation IDs we've seen from the DB.
 	seen := make(map[string]bool, len(summaries))
-	var sessions []model.Session
+	var refs []sessionRef
 
 	cutoff := time.Time{}
 	if opts.Since > 0 {
 		cutoff = time.Now().Add(-opts.Since)
 	}
+	filter := opts
+	filter.Active = false // Sessions checks it once the session is reached
 
 	for _, sum := range summaries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		seen[sum.ConversationID] = true
 
-		sess := model.Session{
+		ref := sessionRef{Session: model.Session{
 			ID:        sum.ConversationID,
 			Tool:      model.ToolCursor,
 			Title:     sum.Title,
 			Summary:   sum.TLDR,
 			Model:     sum.Model,
-			StartedAt: sum.UpdatedAt, // best effort
+			StartedAt: sum.UpdatedAt, // unless the chat store has its creation
 			UpdatedAt: sum.UpdatedAt,
+		}}
+
+		// Transcripts carry no timestamps, so the chat's creation is the best
+		// start there is; EndedAt and the durations stay zero.
+		if cm, ok := chatMetas[sum.ConversationID]; ok && cm.CreatedAt > 0 {
+			ref.StartedAt = chatMetaCreatedAt(cm)
 		}
 
 		// Build preview from title or TLDR.
 		if sum.Title != "" {
-			sess.Preview = detect.Truncate(sum.Title, 120)
+			ref.Preview = detect.Truncate(sum.Title, 120)
 		} else if sum.TLDR != "" {
-			sess.Preview = detect.Truncate(sum.TLDR, 120)
+			ref.Preview = detect.Truncate(sum.TLDR, 120)
 		}
 
 		// Enrich with transcript location info.
 		if t, ok := transcriptMap[sum.ConversationID]; ok {
-			sess.Project = t.ProjectPath
-			sess.Active = detect.IsSessionActive("cursor", t.FilePath)
+			ref.Project = t.ProjectPath
+			ref.path = t.FilePath
 
 			// Use file mod time for timestamps if DB is missing them.
 			if info, err := os.Stat(t.FilePath); err == nil {
-				if sess.UpdatedAt.IsZero() {
-					sess.UpdatedAt = info.ModTime()
+				if ref.UpdatedAt.IsZero() {
+					ref.UpdatedAt = info.ModTime()
 				}
-				if sess.StartedAt.IsZero() {
-					sess.StartedAt = info.ModTime()
+				if ref.StartedAt.IsZero() {
+					ref.StartedAt = info.ModTime()
 				}
 			}
 		}
 
-		if !matchesFilter(sess, opts, cutoff) {
+		if !matchesFilter(ref.Session, filter, cutoff) {
 			continue
 		}
 
-		sessions = append(sessions, sess)
+		refs = append(refs, ref)
 	}
 
 	// Add any transcript files not present in the DB (orphan transcripts).
 	for _, t := range transcripts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if seen[t.ConversationID] {
 			continue
 		}
@@ -111,73 +228,59 @@ func (s *cursorSource) List(opts source.ListOptions) ([]model.Session, error) {
 			startedAt = updatedAt // best effort: use mtime as both
 		}
 
-		// Try to derive a preview from the first user message in the transcript.
-		preview := ""
-		if messages, err := parseTranscript(t.FilePath); err == nil && len(messages) > 0 {
-			for _, m := range messages {
-				if m.Role == model.RoleUser && strings.TrimSpace(m.Content) != "" {
-					preview = detect.Truncate(m.Content, 120)
-					break
-				}
-			}
-		}
-
-		sess := model.Session{
-			ID:        t.ConversationID,
-			Tool:      model.ToolCursor,
-			Project:   t.ProjectPath,
-			StartedAt: startedAt,
-			UpdatedAt: updatedAt,
-			Preview:   preview,
-			Active:    detect.IsSessionActive("cursor", t.FilePath),
+		ref := sessionRef{
+			Session: model.Session{
+				ID:        t.ConversationID,
+				Tool:      model.ToolCursor,
+				Project:   t.ProjectPath,
+				StartedAt: startedAt,
+				UpdatedAt: updatedAt,
+			},
+			path:   t.FilePath,
+			orphan: true,
 		}
 
 		// Enrich with chat store metadata (name, creation time).
 		if cm, ok := chatMetas[t.ConversationID]; ok {
 			if cm.Name != "" {
-				sess.Title = cm.Name
-				if sess.Preview == "" {
-					sess.Preview = detect.Truncate(cm.Name, 120)
-				}
+				ref.Title = cm.Name
 			}
 			if cm.CreatedAt > 0 {
-				sess.StartedAt = chatMetaCreatedAt(cm)
+				ref.StartedAt = chatMetaCreatedAt(cm)
 			}
 			if cm.Model != "" && cm.Model != "default" {
-				sess.Model = cm.Model
+				ref.Model = cm.Model
 			}
 		}
 
-		if !matchesFilter(sess, opts, cutoff) {
+		if !matchesFilter(ref.Session, filter, cutoff) {
 			continue
 		}
 
-		sessions = append(sessions, sess)
+		refs = append(refs, ref)
 	}
 
-	// Apply limit.
-	if opts.Limit > 0 && len(sessions) > opts.Limit {
-		sessions = sessions[:opts.Limit]
-	}
-
-	return sessions, nil
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].UpdatedAt.After(refs[j].UpdatedAt)
+	})
+	return refs, nil
 }
 
 // Get returns a single session with full message history.
 // Supports prefix matching: if sessionID is shorter than a full ID, it matches
 // on the first 8+ characters.
-func (s *cursorSource) Get(sessionID string) (*model.Session, error) {
-	homeDir, err := os.UserHomeDir()
+func (s *cursorSource) Get(ctx context.Context, sessionID string) (*model.Session, error) {
+	dir, err := s.cursorDir()
 	if err != nil {
-		return nil, fmt.Errorf("cursor: resolve home dir: %w", err)
+		return nil, fmt.Errorf("cursor: %w", err)
 	}
 
 	// Find the transcript file — try exact match first, then prefix match.
-	projectPath, transcriptPath := findTranscriptFile(homeDir, sessionID)
+	projectPath, transcriptPath := findTranscriptFile(dir, sessionID)
 
 	if transcriptPath == "" && len(sessionID) >= 8 {
 		// Prefix match: scan all transcripts.
-		transcripts := listAllTranscripts(homeDir)
+		transcripts := listAllTranscripts(dir)
 		for _, t := range transcripts {
 			if strings.HasPrefix(t.ConversationID, sessionID) {
 				projectPath = t.ProjectPath
@@ -198,21 +301,24 @@ func (s *cursorSource) Get(sessionID string) (*model.Session, error) {
 	}
 
 	// Load metadata from DB if available.
-	dbPath := filepath.Join(homeDir, ".cursor", "ai-tracking", "ai-code-tracking.db")
-	summaries, _ := readConversationSummaries(dbPath)
+	dbPath := trackingDBPath(dir)
+	summaries, _ := readConversationSummaries(ctx, dbPath)
 
 	sess := &model.Session{
 		ID:       sessionID,
 		Tool:     model.ToolCursor,
 		Project:  projectPath,
 		Messages: messages,
-		Active:   detect.IsSessionActive("cursor", transcriptPath),
 	}
+	s.markActive(sess, transcriptPath)
 
-	// Set timestamp from file.
+	// Set timestamps from file and chat store.
 	if info, err := os.Stat(transcriptPath); err == nil {
 		sess.UpdatedAt = info.ModTime()
 	}
+	if cm, ok := readAllChatMeta(dir)[sessionID]; ok {
+		sess.StartedAt = chatMetaCreatedAt(cm)
+	}
 
 	// Enrich with DB metadata.
 	for _, sum := range summaries {
@@ -247,62 +353,96 @@ func (s *cursorSource) Get(sessionID string) (*model.Session, error) {
 	return sess, nil
 }
 
-// Search returns sessions containing the query string in their transcripts.
-func (s *cursorSource) Search(query string, opts source.ListOptions) ([]model.SearchResult, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cursor: resolve home dir: %w", err)
-	}
-
-	// List only fails if os.UserHomeDir() fails, which already succeeded above.
-	sessions, _ := s.List(opts)
-
-	queryLower := strings.ToLower(query)
-	var results []model.SearchResult
-
-	// Build transcript map for file path resolution.
-	transcripts := listAllTranscripts(homeDir)
-	transcriptMap := make(map[string]transcriptEntry, len(transcripts))
-	for _, t := range transcripts {
-		transcriptMap[t.ConversationID] = t
-	}
+// Search returns sessions with transcript messages matched by m.
+func (s *cursorSource) Search(ctx context.Context, m search.Matcher, opts source.ListOptions) ([]model.SearchResult, error) {
+	return source.Collect(s.SearchResults(ctx, m, opts), 0)
+}
 
-	for _, sess := range sessions {
-		t, ok := transcriptMap[sess.ID]
-		if !ok {
-			continue
+// SearchResults yields the results Search returns, each once its transcript
+// has been parsed and matched.
+func (s *cursorSource) SearchResults(ctx context.Context, m search.Matcher, opts source.ListOptions) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		dir, err := s.cursorDir()
+		if err != nil {
+			yield(model.SearchResult{}, fmt.Errorf("cursor: %w", err))
+			return
 		}
 
-		messages, err := parseTranscript(t.FilePath)
+		// Every session is a candidate: --limit cuts the ranked results
+		// (source.SearchAll), not the sessions searched.
+		candidates := opts
+		candidates.Limit = 0
+		sessions, err := s.List(ctx, candidates)
 		if err != nil {
-			continue
+			yield(model.SearchResult{}, err)
+			return
+		}
+
+		// Build transcript map for file path resolution.
+		transcripts := listAllTranscripts(dir)
+		transcriptMap := make(map[string]transcriptEntry, len(transcripts))
+		for _, t := range transcripts {
+			transcriptMap[t.ConversationID] = t
 		}
 
-		var matches []model.SearchMatch
-		for i, msg := range messages {
-			contentLower := strings.ToLower(msg.Content)
-			idx := strings.Index(contentLower, queryLower)
-			if idx < 0 {
+		// The full-text index, when open, narrows the transcripts worth parsing
+		// and ranks them. Transcripts are re-indexed whole whenever they change,
+		// since a new message can change how the previous block parses.
+		scores := source.IndexScores(model.ToolCursor, dir, m, func(ix *index.Index) {
+			for _, sess := range sessions {
+				if ctx.Err() != nil {
+					return // the scan below reports it
+				}
+				te, ok := transcriptMap[sess.ID]
+				if !ok {
+					continue
+				}
+				t := index.Transcript{Root: dir, Tool: string(model.ToolCursor), Session: sess.ID, Path: te.FilePath}
+				// Unreadable transcripts are skipped, here as by the scan below.
+				_ = ix.SyncFile(t, func() ([]index.Doc, error) { return transcriptDocs(te.FilePath) })
+			}
+		})
+
+		for _, sess := range sessions {
+			if err := ctx.Err(); err != nil {
+				yield(model.SearchResult{}, fmt.Errorf("cursor: %w", err))
+				return
+			}
+			t, ok := transcriptMap[sess.ID]
+			score, candidate := scores[sess.ID]
+			if !ok || (scores != nil && !candidate) {
 				continue
 			}
 
-			snippet := extractSnippet(msg.Content, idx, len(query), 200)
-			matches = append(matches, model.SearchMatch{
-				MessageIndex: i,
-				Snippet:      snippet,
-				Role:         msg.Role,
-			})
-		}
+			messages, err := parseTranscript(t.FilePath)
+			if err != nil {
+				continue
+			}
 
-		if len(matches) > 0 {
-			results = append(results, model.SearchResult{
-				Session: sess,
-				Matches: matches,
-			})
+			if matches := m.Matches(&sess, messages); len(matches) > 0 {
+				r := model.SearchResult{Session: sess, Matches: matches, Score: score}
+				if !yield(r, nil) {
+					return
+				}
+			}
 		}
 	}
+}
 
-	return results, nil
+// transcriptDocs parses a transcript into index docs, one per message with
+// content.
+func transcriptDocs(path string) ([]index.Doc, error) {
+	messages, err := parseTranscript(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs []index.Doc
+	for i, m := range messages {
+		if m.Content != "" {
+			docs = append(docs, index.Doc{Message: i, Role: string(m.Role), Content: m.Content, Timestamp: m.Timestamp})
+		}
+	}
+	return docs, nil
 }
 
 // matchesFilter checks whether a session passes the list options filters.
@@ -310,7 +450,7 @@ func matchesFilter(sess model.Session, opts source.ListOptions, cutoff time.Time
 	if !cutoff.IsZero() && sess.UpdatedAt.Before(cutoff) {
 		return false
 	}
-	if opts.Project != "" && !strings.Contains(strings.ToLower(sess.Project), strings.ToLower(opts.Project)) {
+	if !opts.MatchesProject(sess.Project) {
 		return false
 	}
 	if opts.Active && !sess.Active {
@@ -318,42 +458,3 @@ func matchesFilter(sess model.Session, opts source.ListOptions, cutoff time.Time
 	}
 	return true
 }
-
-// extractSnippet returns ~maxLen characters of context around a match at the given position.
-func extractSnippet(content string, matchStart, matchLen, maxLen int) string {
-	contextBefore := (maxLen - matchLen) / 2
-	if contextBefore < 0 {
-		contextBefore = 0
-	}
-
-	start := matchStart - contextBefore
-	if start < 0 {
-		start = 0
-	}
-
-	end := start + maxLen
-	if end > len(content) {
-		end = len(content)
-		// Recalculate start to try to fill maxLen.
-		start = end - maxLen
-		if start < 0 {
-			start = 0
-		}
-	}
-
-	snippet := content[start:end]
-	// Clean up whitespace for display.
-	snippet = strings.ReplaceAll(snippet, "\n", " ")
-	snippet = strings.ReplaceAll(snippet, "\r", "")
-
-	prefix := ""
-	suffix := ""
-	if start > 0 {
-		prefix = "..."
-	}
-	if end < len(content) {
-		suffix = "..."
-	}
-
-	return prefix + snippet + suffix
-}
@@ -1,6 +1,7 @@
 package cursor
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -27,7 +28,7 @@ func openSQLiteDB(path string) (*sql.DB, error) {
 // readConversationSummaries queries the ai-code-tracking.db for all conversation metadata.
 // Returns results ordered by updatedAt descending (most recent first).
 // Returns nil, nil if the database file does not exist.
-func readConversationSummaries(dbPath string) ([]conversationSummary, error) {
+func readConversationSummaries(ctx context.Context, dbPath string) ([]conversationSummary, error) {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return nil, nil
 	}
@@ -41,7 +42,7 @@ func readConversationSummaries(dbPath string) ([]conversationSummary, error) {
 		err       error
 		tableName string
 	)
-	err = db.QueryRow(
+	err = db.QueryRowContext(ctx,
 		"SELECT name FROM sqlite_master WHERE type='table' AND name='conversation_summaries'",
 	).Scan(&tableName)
 	if err != nil {
@@ -49,7 +50,7 @@ func readConversationSummaries(dbPath string) ([]conversationSummary, error) {
 		return nil, nil
 	}
 
-	rows, err := db.Query(`
+	rows, err := db.QueryContext(ctx, `
 		SELECT conversationId, title, tldr, overview, model, mode, updatedAt
 		FROM conversation_summaries
 		ORDER BY updatedAt DESC
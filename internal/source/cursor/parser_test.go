@@ -1,9 +1,13 @@
 package cursor
 
 import (
+	"context"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,7 +16,9 @@ import (
 
 	_ "modernc.org/sqlite"
 
+	"github.com/psacc/omnisess/internal/index"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
@@ -548,7 +554,7 @@ func TestFindTranscriptFile_ExactMatch(t *testing.T) {
 	home := setupFakeHome(t)
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nhello\n")
 
-	projPath, filePath := findTranscriptFile(home, fixtureConvID)
+	projPath, filePath := findTranscriptFile(filepath.Join(home, ".cursor"), fixtureConvID)
 	if filePath == "" {
 		t.Fatal("expected file path, got empty")
 	}
@@ -562,7 +568,7 @@ func TestFindTranscriptFile_NoMatch(t *testing.T) {
 	home := setupFakeHome(t)
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nhello\n")
 
-	_, filePath := findTranscriptFile(home, "no-such-id")
+	_, filePath := findTranscriptFile(filepath.Join(home, ".cursor"), "no-such-id")
 	if filePath != "" {
 		t.Errorf("expected empty path, got %q", filePath)
 	}
@@ -570,7 +576,7 @@ func TestFindTranscriptFile_NoMatch(t *testing.T) {
 
 func TestFindTranscriptFile_NoProjectsDir(t *testing.T) {
 	home := t.TempDir() // no .cursor/projects created
-	_, filePath := findTranscriptFile(home, fixtureConvID)
+	_, filePath := findTranscriptFile(filepath.Join(home, ".cursor"), fixtureConvID)
 	if filePath != "" {
 		t.Errorf("expected empty path, got %q", filePath)
 	}
@@ -581,7 +587,7 @@ func TestListAllTranscripts_Basic(t *testing.T) {
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nhello\n")
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID2, "user:\nworld\n")
 
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if len(entries) != 2 {
 		t.Fatalf("expected 2 entries, got %d", len(entries))
 	}
@@ -599,7 +605,7 @@ func TestListAllTranscripts_Basic(t *testing.T) {
 
 func TestListAllTranscripts_NoProjectsDir(t *testing.T) {
 	home := t.TempDir()
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if entries != nil {
 		t.Errorf("expected nil entries, got %v", entries)
 	}
@@ -615,7 +621,7 @@ func TestListAllTranscripts_SkipsNonDirEntries(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if len(entries) != 1 {
 		t.Errorf("expected 1 entry (non-.txt skipped), got %d", len(entries))
 	}
@@ -626,7 +632,7 @@ func TestListAllTranscripts_SkipsNonDirEntries(t *testing.T) {
 // ---------------------------------------------------------------------------
 
 func TestReadConversationSummaries_NonExistentDB(t *testing.T) {
-	summaries, err := readConversationSummaries("/tmp/nonexistent/db.sqlite")
+	summaries, err := readConversationSummaries(context.Background(), "/tmp/nonexistent/db.sqlite")
 	if err != nil {
 		t.Fatalf("expected nil error for nonexistent db, got %v", err)
 	}
@@ -650,7 +656,7 @@ func TestReadConversationSummaries_WithData(t *testing.T) {
 		},
 	})
 
-	summaries, err := readConversationSummaries(dbPath)
+	summaries, err := readConversationSummaries(context.Background(), dbPath)
 	if err != nil {
 		t.Fatalf("readConversationSummaries: %v", err)
 	}
@@ -683,7 +689,7 @@ func TestReadConversationSummaries_NoTable(t *testing.T) {
 	}
 	db.Close()
 
-	summaries, err := readConversationSummaries(dbPath)
+	summaries, err := readConversationSummaries(context.Background(), dbPath)
 	if err != nil {
 		t.Fatalf("expected nil error when table missing, got %v", err)
 	}
@@ -712,7 +718,7 @@ func TestReadConversationSummaries_NullUpdatedAt(t *testing.T) {
 	}
 	db.Close()
 
-	summaries, err := readConversationSummaries(dbPath)
+	summaries, err := readConversationSummaries(context.Background(), dbPath)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -857,7 +863,7 @@ func TestReadAllChatMeta_Basic(t *testing.T) {
 	}
 	addChatStoreDB(t, home, "workspace1", fixtureConvID, meta)
 
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 1 {
 		t.Fatalf("expected 1 chat meta, got %d", len(result))
 	}
@@ -868,7 +874,7 @@ func TestReadAllChatMeta_Basic(t *testing.T) {
 
 func TestReadAllChatMeta_NoChatsDir(t *testing.T) {
 	home := t.TempDir()
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 0 {
 		t.Errorf("expected empty map for missing chats dir, got %d entries", len(result))
 	}
@@ -888,7 +894,7 @@ func TestReadAllChatMeta_SkipsNonDirs(t *testing.T) {
 	meta := chatMeta{AgentID: fixtureConvID, Name: "session"}
 	addChatStoreDB(t, home, "workspace1", fixtureConvID, meta)
 
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 1 {
 		t.Errorf("expected 1 entry (file under chats skipped), got %d", len(result))
 	}
@@ -905,7 +911,7 @@ func TestReadAllChatMeta_SkipsNonDirAgents(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(wsDir, "notadir.txt"), []byte("x"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 0 {
 		t.Errorf("expected 0 entries (non-dir agent skipped), got %d", len(result))
 	}
@@ -986,82 +992,6 @@ func TestMatchesFilter(t *testing.T) {
 	}
 }
 
-// ---------------------------------------------------------------------------
-// cursor.go — extractSnippet
-// ---------------------------------------------------------------------------
-
-func TestExtractSnippet(t *testing.T) {
-	tests := []struct {
-		name      string
-		content   string
-		matchIdx  int
-		matchLen  int
-		maxLen    int
-		wantExact string
-		wantHas   string
-	}{
-		{
-			name:      "short content no ellipsis",
-			content:   "short",
-			matchIdx:  0,
-			matchLen:  5,
-			maxLen:    200,
-			wantExact: "short",
-		},
-		{
-			name:     "match in middle has ellipsis",
-			content:  "aaaaaaaaaaaaaaaa MATCH bbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
-			matchIdx: 17,
-			matchLen: 5,
-			maxLen:   20,
-			wantHas:  "MATCH",
-		},
-		{
-			name:     "match at start no leading ellipsis",
-			content:  "MATCH bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
-			matchIdx: 0,
-			matchLen: 5,
-			maxLen:   10,
-			wantHas:  "MATCH",
-		},
-		{
-			name:      "newlines converted to spaces",
-			content:   "line1\nline2",
-			matchIdx:  0,
-			matchLen:  5,
-			maxLen:    200,
-			wantExact: "line1 line2",
-		},
-		{
-			name:     "match near end recalculates start",
-			content:  "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa MATCH",
-			matchIdx: 40,
-			matchLen: 5,
-			maxLen:   15,
-			wantHas:  "MATCH",
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := extractSnippet(tt.content, tt.matchIdx, tt.matchLen, tt.maxLen)
-			if tt.wantExact != "" && got != tt.wantExact {
-				t.Errorf("extractSnippet() = %q, want %q", got, tt.wantExact)
-			}
-			if tt.wantHas != "" && !strings.Contains(got, tt.wantHas) {
-				t.Errorf("extractSnippet() = %q, want to contain %q", got, tt.wantHas)
-			}
-		})
-	}
-}
-
-func TestExtractSnippet_MatchLenExceedsMaxLen(t *testing.T) {
-	// matchLen > maxLen: contextBefore goes negative, clipped to 0
-	got := extractSnippet("hello world", 0, 50, 5)
-	if got == "" {
-		t.Error("expected non-empty snippet")
-	}
-}
-
 // ---------------------------------------------------------------------------
 // cursor.go — cursorSource (Name / List / Get / Search)
 // ---------------------------------------------------------------------------
@@ -1113,7 +1043,7 @@ func TestList_Basic(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1139,7 +1069,7 @@ func TestList_Limit(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{Limit: 1})
+	sessions, err := s.List(context.Background(), source.ListOptions{Limit: 1})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1154,7 +1084,7 @@ func TestList_SinceFilter(t *testing.T) {
 
 	s := &cursorSource{}
 	// 1 nanosecond Since: all old sessions excluded
-	sessions, err := s.List(source.ListOptions{Since: 1})
+	sessions, err := s.List(context.Background(), source.ListOptions{Since: 1})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1170,7 +1100,7 @@ func TestList_ProjectFilter(t *testing.T) {
 	s := &cursorSource{}
 
 	// Matching project substring
-	sessions, err := s.List(source.ListOptions{Project: "myproject"})
+	sessions, err := s.List(context.Background(), source.ListOptions{Project: "myproject"})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1179,7 +1109,7 @@ func TestList_ProjectFilter(t *testing.T) {
 	}
 
 	// Non-matching project
-	sessions, err = s.List(source.ListOptions{Project: "zzznomatch"})
+	sessions, err = s.List(context.Background(), source.ListOptions{Project: "zzznomatch"})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1195,7 +1125,7 @@ func TestList_OrphanTranscript(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1222,7 +1152,7 @@ func TestList_OrphanTranscriptWithChatMeta(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1237,6 +1167,36 @@ func TestList_OrphanTranscriptWithChatMeta(t *testing.T) {
 	}
 }
 
+func TestList_StartedAtFromChatMeta(t *testing.T) {
+	// Transcripts carry no timestamps: sessions start when their chat was
+	// created, and their durations are unknown.
+	home := setupFakeHome(t)
+	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nHello\n")
+	createdAt := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+	addTrackingDB(t, home, []conversationSummary{
+		{ConversationID: fixtureConvID, Title: "Timed", UpdatedAt: createdAt.Add(2 * time.Hour)},
+	})
+	addChatStoreDB(t, home, "ws1", fixtureConvID, chatMeta{AgentID: fixtureConvID, CreatedAt: createdAt.UnixMilli()})
+	t.Setenv("HOME", home)
+
+	s := &cursorSource{}
+	sessions, err := s.List(context.Background(), source.ListOptions{})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("List() = %v, %v", sessions, err)
+	}
+	if !sessions[0].StartedAt.Equal(createdAt) || !sessions[0].EndedAt.IsZero() || sessions[0].Duration != 0 {
+		t.Errorf("listed StartedAt = %v, EndedAt = %v, Duration = %v, want the chat's creation and no end", sessions[0].StartedAt, sessions[0].EndedAt, sessions[0].Duration)
+	}
+
+	sess, err := s.Get(context.Background(), fixtureConvID)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if !sess.StartedAt.Equal(createdAt) {
+		t.Errorf("Get StartedAt = %v, want %v", sess.StartedAt, createdAt)
+	}
+}
+
 func TestList_DBWithNoTranscript(t *testing.T) {
 	// DB has a summary but no matching transcript file — UpdatedAt from DB used
 	home := setupFakeHome(t)
@@ -1251,7 +1211,7 @@ func TestList_DBWithNoTranscript(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1277,7 +1237,7 @@ func TestList_DBEntryNoTitleUsesTLDR(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1303,7 +1263,7 @@ func TestList_DBWithZeroTimestampUsesFileMTime(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1322,7 +1282,7 @@ func TestList_NoProjectsDir(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1336,7 +1296,7 @@ func TestGet_ExactMatch(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sess, err := s.Get(convID)
+	sess, err := s.Get(context.Background(), convID)
 	if err != nil {
 		t.Fatalf("Get() error: %v", err)
 	}
@@ -1364,7 +1324,7 @@ func TestGet_PrefixMatch(t *testing.T) {
 
 	s := &cursorSource{}
 	prefix := convID[:8]
-	sess, err := s.Get(prefix)
+	sess, err := s.Get(context.Background(), prefix)
 	if err != nil {
 		t.Fatalf("Get(%q) error: %v", prefix, err)
 	}
@@ -1381,7 +1341,7 @@ func TestGet_NotFound(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	_, err := s.Get("nonexistentid")
+	_, err := s.Get(context.Background(), "nonexistentid")
 	if err == nil {
 		t.Fatal("expected error for not-found session, got nil")
 	}
@@ -1394,7 +1354,7 @@ func TestGet_NoTitleDerivesFromMessages(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sess, err := s.Get(fixtureConvID)
+	sess, err := s.Get(context.Background(), fixtureConvID)
 	if err != nil {
 		t.Fatalf("Get() error: %v", err)
 	}
@@ -1422,7 +1382,7 @@ func TestGet_PreviewFromSummary(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sess, err := s.Get(fixtureConvID)
+	sess, err := s.Get(context.Background(), fixtureConvID)
 	if err != nil {
 		t.Fatalf("Get() error: %v", err)
 	}
@@ -1438,7 +1398,7 @@ func TestSearch_HitAndMiss(t *testing.T) {
 	s := &cursorSource{}
 
 	t.Run("hit", func(t *testing.T) {
-		results, err := s.Search("Help me with Go", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "Help me with Go"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -1455,7 +1415,7 @@ func TestSearch_HitAndMiss(t *testing.T) {
 	})
 
 	t.Run("miss", func(t *testing.T) {
-		results, err := s.Search("zzznomatchzzz", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "zzznomatchzzz"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -1465,7 +1425,7 @@ func TestSearch_HitAndMiss(t *testing.T) {
 	})
 
 	t.Run("case insensitive", func(t *testing.T) {
-		results, err := s.Search("HELP ME WITH GO", source.ListOptions{})
+		results, err := s.Search(context.Background(), parseQuery(t, "HELP ME WITH GO"), source.ListOptions{})
 		if err != nil {
 			t.Fatalf("Search() error: %v", err)
 		}
@@ -1489,13 +1449,18 @@ func TestSearch_SessionNotInTranscriptMap(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	results, err := s.Search("No file", source.ListOptions{})
-	if err != nil {
-		t.Fatalf("Search() error: %v", err)
-	}
-	// No transcript file → no search results
-	if len(results) != 0 {
-		t.Errorf("expected 0 results (no transcript), got %d", len(results))
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			useIndex(t)
+		}
+		results, err := s.Search(context.Background(), parseQuery(t, "No file"), source.ListOptions{})
+		if err != nil {
+			t.Fatalf("Search(indexed=%v) error: %v", indexed, err)
+		}
+		// No transcript file → no search results
+		if len(results) != 0 {
+			t.Errorf("expected 0 results (no transcript, indexed=%v), got %d", indexed, len(results))
+		}
 	}
 }
 
@@ -1535,7 +1500,7 @@ func TestReadConversationSummaries_QueryError(t *testing.T) {
 	home := t.TempDir()
 	dbPath := addWrongSchemaTrackingDB(t, home)
 
-	_, err := readConversationSummaries(dbPath)
+	_, err := readConversationSummaries(context.Background(), dbPath)
 	if err == nil {
 		t.Fatal("expected error when querying wrong schema, got nil")
 	}
@@ -1550,7 +1515,7 @@ func TestList_TrackingDBQueryError(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1581,7 +1546,7 @@ func TestReadAllChatMeta_UnreadableAgentsDir(t *testing.T) {
 	})
 
 	// Should not panic — error reading agents dir is silently skipped
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 0 {
 		t.Errorf("expected empty result, got %d entries", len(result))
 	}
@@ -1604,7 +1569,7 @@ func TestReadAllChatMeta_StoreDBError(t *testing.T) {
 	db.Exec("CREATE TABLE dummy (x TEXT)") //nolint:errcheck
 	db.Close()
 
-	result := readAllChatMeta(home)
+	result := readAllChatMeta(filepath.Join(home, ".cursor"))
 	if len(result) != 0 {
 		t.Errorf("expected 0 entries (store.db error skipped), got %d", len(result))
 	}
@@ -1626,7 +1591,7 @@ func TestFindTranscriptFile_NonDirEntry(t *testing.T) {
 	// Also add a real project
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nhello\n")
 
-	_, filePath := findTranscriptFile(home, fixtureConvID)
+	_, filePath := findTranscriptFile(filepath.Join(home, ".cursor"), fixtureConvID)
 	if filePath == "" {
 		t.Fatal("expected to find transcript file, got empty")
 	}
@@ -1647,7 +1612,7 @@ func TestListAllTranscripts_NonDirProjectEntry(t *testing.T) {
 	// Also add a valid project
 	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nhello\n")
 
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if len(entries) != 1 {
 		t.Errorf("expected 1 entry (non-dir project skipped), got %d", len(entries))
 	}
@@ -1663,7 +1628,7 @@ func TestListAllTranscripts_DirEntryInTranscriptsDir(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if len(entries) != 1 {
 		t.Errorf("expected 1 entry (subdir skipped), got %d", len(entries))
 	}
@@ -1678,7 +1643,7 @@ func TestListAllTranscripts_NoAgentTranscriptsDir(t *testing.T) {
 		t.Fatal(err)
 	}
 	// No agent-transcripts subdir
-	entries := listAllTranscripts(home)
+	entries := listAllTranscripts(filepath.Join(home, ".cursor"))
 	if len(entries) != 0 {
 		t.Errorf("expected 0 entries, got %d", len(entries))
 	}
@@ -1701,7 +1666,7 @@ func TestGet_ParseTranscriptError(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	_, err := s.Get(fixtureConvID)
+	_, err := s.Get(context.Background(), fixtureConvID)
 	if err == nil {
 		t.Fatal("expected error for unreadable transcript, got nil")
 	}
@@ -1718,7 +1683,7 @@ func TestSearch_ParseTranscriptError(t *testing.T) {
 
 	// Run List first to confirm session appears
 	s := &cursorSource{}
-	sessions, _ := s.List(source.ListOptions{})
+	sessions, _ := s.List(context.Background(), source.ListOptions{})
 	if len(sessions) != 1 {
 		t.Fatalf("setup: expected 1 session, got %d", len(sessions))
 	}
@@ -1730,7 +1695,7 @@ func TestSearch_ParseTranscriptError(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(transcriptPath, 0o644) }) //nolint:errcheck
 
-	results, err := s.Search("hello world", source.ListOptions{})
+	results, err := s.Search(context.Background(), parseQuery(t, "hello world"), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("Search() error: %v", err)
 	}
@@ -1748,7 +1713,7 @@ func TestList_OrphanFilterExcluded(t *testing.T) {
 
 	s := &cursorSource{}
 	// Use very short Since to exclude the old file
-	sessions, err := s.List(source.ListOptions{Since: 1})
+	sessions, err := s.List(context.Background(), source.ListOptions{Since: 1})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1765,16 +1730,28 @@ func TestList_HomeDir_Error(t *testing.T) {
 	// Setting HOME="" causes os.UserHomeDir() to return an error on Unix/macOS.
 	t.Setenv("HOME", "")
 	s := &cursorSource{}
-	_, err := s.List(source.ListOptions{})
+	_, err := s.List(context.Background(), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
 }
 
+func TestCursorDir_FixedDir(t *testing.T) {
+	t.Setenv("HOME", "")
+	s := &cursorSource{dir: "/srv/sessions/devbox/.cursor"}
+	dir, err := s.cursorDir()
+	if err != nil {
+		t.Fatalf("cursorDir() error: %v", err)
+	}
+	if dir != s.dir {
+		t.Errorf("cursorDir() = %q, want %q", dir, s.dir)
+	}
+}
+
 func TestGet_HomeDir_Error(t *testing.T) {
 	t.Setenv("HOME", "")
 	s := &cursorSource{}
-	_, err := s.Get("anyid")
+	_, err := s.Get(context.Background(), "anyid")
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -1783,7 +1760,7 @@ func TestGet_HomeDir_Error(t *testing.T) {
 func TestSearch_HomeDir_Error(t *testing.T) {
 	t.Setenv("HOME", "")
 	s := &cursorSource{}
-	_, err := s.Search("query", source.ListOptions{})
+	_, err := s.Search(context.Background(), parseQuery(t, "query"), source.ListOptions{})
 	if err == nil {
 		t.Fatal("expected error when HOME is empty, got nil")
 	}
@@ -1817,7 +1794,7 @@ func TestList_OrphanChatMetaNameNoPreview(t *testing.T) {
 	t.Setenv("HOME", home)
 
 	s := &cursorSource{}
-	sessions, err := s.List(source.ListOptions{})
+	sessions, err := s.List(context.Background(), source.ListOptions{})
 	if err != nil {
 		t.Fatalf("List() error: %v", err)
 	}
@@ -1870,7 +1847,7 @@ func TestReadConversationSummaries_ScanError(t *testing.T) {
 	}
 	db.Close()
 
-	_, err = readConversationSummaries(dbPath)
+	_, err = readConversationSummaries(context.Background(), dbPath)
 	if err == nil {
 		t.Fatal("expected scan error for NULL conversationId, got nil")
 	}
@@ -1904,3 +1881,325 @@ func TestParseTranscript_ScannerError(t *testing.T) {
 		t.Fatal("expected scanner error for line > 1 MB, got nil")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Another host's root (source.AddHost)
+// ---------------------------------------------------------------------------
+
+func TestAddHost_ReadsFixedRoot(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", t.TempDir()) // the local root is empty
+	if err := source.AddHost("devbox", model.ToolCursor, filepath.Join(home, ".cursor")); err != nil {
+		t.Fatalf("AddHost() error: %v", err)
+	}
+	t.Cleanup(source.ClearHosts)
+
+	var remote source.Source
+	for _, s := range source.ByName(model.ToolCursor) {
+		if source.HostOf(s) == "devbox" {
+			remote = s
+		}
+	}
+	if remote == nil {
+		t.Fatal("no source registered for host devbox")
+	}
+	sessions, err := remote.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) == 0 {
+		t.Fatal("expected sessions from the devbox root")
+	}
+	for _, s := range sessions {
+		if s.Host != "devbox" {
+			t.Errorf("session %s Host = %q, want devbox", s.ID, s.Host)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Context cancellation
+// ---------------------------------------------------------------------------
+
+// cancelAfter is a context that reports itself cancelled once Err has been
+// called n times, to stop a source at a chosen point of its work.
+type cancelAfter struct {
+	context.Context
+	n, calls int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls++; c.calls > c.n {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestList_Cancelled(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+
+	// After the tracking DB, in the DB rows, in the transcripts, and as the
+	// session is reached.
+	for n := range 4 {
+		ctx := &cancelAfter{Context: context.Background(), n: n}
+		if _, err := (&cursorSource{}).List(ctx, source.ListOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("List(cancelled after %d checks) error = %v, want context.Canceled", n, err)
+		}
+	}
+}
+
+func TestSearch_Cancelled(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	s := &cursorSource{}
+	probe := &cancelAfter{Context: context.Background(), n: math.MaxInt}
+	if _, err := s.List(probe, source.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{0, probe.calls} {
+		for _, indexed := range []bool{false, true} {
+			if indexed {
+				useIndex(t)
+			}
+			ctx := &cancelAfter{Context: context.Background(), n: n}
+			results, err := s.Search(ctx, parseQuery(t, "help"), source.ListOptions{})
+			if !errors.Is(err, context.Canceled) || len(results) != 0 {
+				t.Errorf("Search(cancelled after %d checks, indexed=%v) = %d results, %v; want context.Canceled", n, indexed, len(results), err)
+			}
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Metadata index
+// ---------------------------------------------------------------------------
+
+// useIndex points every source at a fresh metadata index for the test.
+func useIndex(t *testing.T) {
+	t.Helper()
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatalf("open index: %v", err)
+	}
+	source.SetIndex(ix)
+	t.Cleanup(func() {
+		source.SetIndex(nil)
+		ix.Close()
+	})
+}
+
+// rewriteKeepingStat replaces a file's content with same-length content and
+// restores its mtime, so only a cache lookup can still see the old content.
+func rewriteKeepingStat(t *testing.T, path, content string) {
+	t.Helper()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(content)) != fi.Size() {
+		t.Fatalf("replacement is %d bytes, want %d", len(content), fi.Size())
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTranscriptPreview(t *testing.T) {
+	dir := t.TempDir()
+	if got := transcriptPreview(filepath.Join(dir, "missing.txt")); got != "" {
+		t.Errorf("missing transcript preview = %q, want empty", got)
+	}
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte("assistant:\nhello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := transcriptPreview(path); got != "" {
+		t.Errorf("assistant-only preview = %q, want empty", got)
+	}
+}
+
+func TestList_UsesIndex(t *testing.T) {
+	home := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	path := addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID, "user:\nfirst prompt\n")
+	useIndex(t)
+
+	list := func() model.Session {
+		t.Helper()
+		sessions, err := (&cursorSource{}).List(context.Background(), source.ListOptions{})
+		if err != nil || len(sessions) != 1 {
+			t.Fatalf("List() = %v, %v", sessions, err)
+		}
+		return sessions[0]
+	}
+	if s := list(); s.Preview != "first prompt" {
+		t.Fatalf("first List() = %+v", s)
+	}
+
+	rewriteKeepingStat(t, path, "user:\nother prompt\n")
+	if s := list(); s.Preview != "first prompt" {
+		t.Errorf("unchanged stat should be served from the index, got %+v", s)
+	}
+
+	source.SetIndex(nil)
+	if s := list(); s.Preview != "other prompt" {
+		t.Errorf("without the index the file is re-read, got %+v", s)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Search — full-text index
+// ---------------------------------------------------------------------------
+
+func TestSearch_UsesIndex(t *testing.T) {
+	home, convID, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	useIndex(t)
+
+	s := &cursorSource{}
+	results, err := s.Search(context.Background(), parseQuery(t, "help me with go"), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Session.ID != convID || results[0].Score <= 0 {
+		t.Fatalf("Search() = %+v, want one scored result", results)
+	}
+	if m := results[0].Matches; len(m) != 1 || m[0].MessageIndex != 0 || m[0].Role != model.RoleUser {
+		t.Errorf("matches = %+v", m)
+	}
+
+	// A rewritten transcript is re-indexed.
+	addTranscriptFile(t, home, fixtureProjDirName, convID,
+		"user:\nHelp me with Go.\n\nassistant:\nSure, I can help.\n\nuser:\nNow explain goroutines please.\n")
+	results, err = s.Search(context.Background(), parseQuery(t, "goroutines"), source.ListOptions{})
+	if err != nil || len(results) != 1 || results[0].Matches[0].MessageIndex != 2 {
+		t.Errorf("Search(goroutines) = %+v, %v", results, err)
+	}
+}
+
+func TestSearch_InTools(t *testing.T) {
+	home, convID, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	addTranscriptFile(t, home, fixtureProjDirName, convID,
+		"user:\nRun the tests.\n\nassistant:\nRunning them.\n\n[Tool call: Shell]\ngo test ./...\n\n[Tool result]\nFAIL ./pkg\n")
+	useIndex(t)
+
+	s := &cursorSource{}
+	for _, scope := range []search.Scope{search.ScopeContent, search.ScopeTools} {
+		results, err := s.Search(context.Background(), parseQuery(t, "go test OR fail").In(scope), source.ListOptions{})
+		if err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		var got []string
+		for _, r := range results {
+			for _, m := range r.Matches {
+				got = append(got, fmt.Sprintf("%d:%s#%d", m.MessageIndex, m.Location, m.ToolCall))
+			}
+		}
+		want := "[]"
+		if scope == search.ScopeTools {
+			want = "[1:tool_input#0 1:tool_output#0]"
+		}
+		if fmt.Sprint(got) != want {
+			t.Errorf("Search(scope %v) matches = %v, want %s", scope, got, want)
+		}
+	}
+}
+
+func TestSearch_IndexSkipsUnparseableTranscript(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	useIndex(t)
+
+	// A line past the scanner's 1MB limit makes parseTranscript fail.
+	addTranscriptFile(t, home, fixtureProjDirName, fixtureConvID,
+		"user:\nhelp me\n"+strings.Repeat("x", 2*1024*1024)+"\n")
+	results, err := (&cursorSource{}).Search(context.Background(), parseQuery(t, "help me"), source.ListOptions{})
+	if err != nil || len(results) != 0 {
+		t.Errorf("Search() = %+v, %v; want no results", results, err)
+	}
+}
+
+func TestSearch_IndexFailureFallsBackToScan(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix.Close()
+	source.SetIndex(ix)
+	defer source.SetIndex(nil)
+
+	results, err := (&cursorSource{}).Search(context.Background(), parseQuery(t, "help me with go"), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Score != 0 {
+		t.Errorf("expected scanned results, got %+v", results)
+	}
+}
+
+// parseQuery parses a search query, failing the test on syntax errors.
+func parseQuery(t *testing.T, s string) *search.Query {
+	t.Helper()
+	q, err := search.Parse(s)
+	if err != nil {
+		t.Fatalf("search.Parse(%q): %v", s, err)
+	}
+	return q
+}
+
+// ---------------------------------------------------------------------------
+// Streaming (source.Streamer)
+// ---------------------------------------------------------------------------
+
+func TestSessions_ActiveCheckedLazily(t *testing.T) {
+	home, convID, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	addTranscriptFile(t, home, fixtureProjDirName, "orphan-conv", "user:\nAnother question.\n")
+
+	var got []string
+	for sess, err := range (&cursorSource{}).Sessions(context.Background(), source.ListOptions{}) {
+		if err != nil {
+			t.Fatalf("Sessions() error: %v", err)
+		}
+		got = append(got, sess.ID)
+		break
+	}
+	// The orphan was just written, so it is the most recent.
+	if fmt.Sprint(got) != "[orphan-conv]" {
+		t.Errorf("Sessions() first = %v, want [orphan-conv] (then %s)", got, convID)
+	}
+
+	// Nothing runs in the test, so no session is active.
+	sessions, err := (&cursorSource{}).List(context.Background(), source.ListOptions{Active: true})
+	if err != nil || len(sessions) != 0 {
+		t.Errorf("List(Active) = %d sessions, %v; want none", len(sessions), err)
+	}
+}
+
+func TestSearchResults_StopsEarly(t *testing.T) {
+	home, _, _ := setupCursorHome(t)
+	t.Setenv("HOME", home)
+	addTranscriptFile(t, home, fixtureProjDirName, "orphan-conv", "user:\nHelp again.\n")
+
+	n := 0
+	for _, err := range (&cursorSource{}).SearchResults(context.Background(), parseQuery(t, "help"), source.ListOptions{}) {
+		if err != nil {
+			t.Fatalf("SearchResults() error: %v", err)
+		}
+		if n++; n == 1 {
+			break
+		}
+	}
+	if n != 1 {
+		t.Errorf("SearchResults() = %d results, want to stop after 1", n)
+	}
+}
@@ -111,10 +111,10 @@ func resolvePathGreedy(base string, encoded string) string {
 	return ""
 }
 
-// findTranscriptFile scans ~/.cursor/projects/*/agent-transcripts/ for a file
+// findTranscriptFile scans <cursorDir>/projects/*/agent-transcripts/ for a file
 // matching conversationID.txt.
-func findTranscriptFile(homeDir string, conversationID string) (projectPath string, transcriptPath string) {
-	projectsDir := filepath.Join(homeDir, ".cursor", "projects")
+func findTranscriptFile(cursorDir string, conversationID string) (projectPath string, transcriptPath string) {
+	projectsDir := filepath.Join(cursorDir, "projects")
 	entries, err := os.ReadDir(projectsDir)
 	if err != nil {
 		return "", ""
@@ -142,8 +142,8 @@ type transcriptEntry struct {
 	FilePath       string
 }
 
-func listAllTranscripts(homeDir string) []transcriptEntry {
-	projectsDir := filepath.Join(homeDir, ".cursor", "projects")
+func listAllTranscripts(cursorDir string) []transcriptEntry {
+	projectsDir := filepath.Join(cursorDir, "projects")
 	projectEntries, err := os.ReadDir(projectsDir)
 	if err != nil {
 		return nil
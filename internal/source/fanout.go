@@ -0,0 +1,462 @@
+package source
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+// ListAll lists sessions from every source concurrently and merges them:
+// duplicates (same QualifiedID) are dropped, the rest are ordered most
+// recent first and cut to opts.Limit. Once the limit is reached the sources
+// are stopped, so streaming sources never do the work for older sessions.
+//
+// Each source gets its own timeout (none when timeout is 0). A source that
+// fails or runs out of time contributes whatever it returned before giving
+// up, and one warning; ListAll never waits on a source past its deadline.
+func ListAll(ctx context.Context, sources []Source, opts ListOptions, timeout time.Duration) ([]model.Session, []error) {
+	var (
+		all      []model.Session
+		warnings []error
+	)
+	for sess, err := range MergeSessions(ctx, sources, opts, timeout) {
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		all = append(all, sess)
+		if opts.Limit > 0 && len(all) == opts.Limit {
+			break
+		}
+	}
+	return all, warnings
+}
+
+// SearchAll runs a search on every source concurrently and merges the
+// results like ListAll, ranked by RankSearchResults. Ranking needs every
+// result, so unlike ListAll it always waits for all sources.
+func SearchAll(ctx context.Context, sources []Source, m search.Matcher, opts ListOptions, timeout time.Duration) ([]model.SearchResult, []error) {
+	var (
+		all      []model.SearchResult
+		from     []int // the source of each result in all
+		byID     = make(map[string]int)
+		warnings []error
+	)
+	for r, err := range searchEvents(ctx, sources, m, opts, timeout) {
+		if err != nil {
+			warnings = append(warnings, err)
+			continue
+		}
+		// Of duplicates, keep the one from the first source, whichever
+		// finished first.
+		id := r.Session.QualifiedID()
+		if i, ok := byID[id]; ok {
+			if r.source < from[i] {
+				all[i], from[i] = r.SearchResult, r.source
+			}
+			continue
+		}
+		byID[id] = len(all)
+		all = append(all, r.SearchResult)
+		from = append(from, r.source)
+	}
+	RankSearchResults(all)
+	return truncate(all, opts.Limit), warnings
+}
+
+// SortByDuration sorts sessions by time on task, longest first, then by
+// wall-clock time. Sessions without message timestamps keep their order at
+// the end.
+func SortByDuration(sessions []model.Session) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		if sessions[i].ActiveDuration != sessions[j].ActiveDuration {
+			return sessions[i].ActiveDuration > sessions[j].ActiveDuration
+		}
+		return sessions[i].Duration > sessions[j].Duration
+	})
+}
+
+// RankSearchResults sorts results most relevant first (BM25 from the
+// full-text index); scanned results carry no score and follow by recency.
+func RankSearchResults(results []model.SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Session.UpdatedAt.After(results[j].Session.UpdatedAt)
+	})
+}
+
+// GetAll reads the full sessions, messages included, behind listed ones:
+// each from the source among sources that listed it (same tool and host),
+// a few at a time. Each source gets its own timeout (none when timeout is
+// 0), after which its remaining sessions are not read. A session that is
+// not read is kept as listed, and each source that failed adds one warning.
+func GetAll(ctx context.Context, sources []Source, sessions []model.Session, timeout time.Duration) ([]model.Session, []error) {
+	type sourceRun struct {
+		ctx    context.Context
+		failed int
+		err    error // the first failure
+	}
+	runs := make(map[Source]*sourceRun)
+	for _, s := range sources {
+		r := &sourceRun{}
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			r.ctx, cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			r.ctx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+		runs[s] = r
+	}
+	sourceOf := func(sess model.Session) Source {
+		for _, s := range sources {
+			if s.Name() == sess.Tool && HostOf(s) == sess.Host {
+				return s
+			}
+		}
+		return nil
+	}
+
+	out := slices.Clone(sessions)
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		next = make(chan int)
+	)
+	for range runtime.GOMAXPROCS(0) {
+		wg.Go(func() {
+			for i := range next {
+				src := sourceOf(out[i])
+				if src == nil {
+					continue
+				}
+				r := runs[src]
+				err := r.ctx.Err()
+				var full *model.Session
+				if err == nil {
+					full, err = src.Get(r.ctx, out[i].ID)
+				}
+				if err == nil && full == nil {
+					err = fmt.Errorf("session %s not found", out[i].ID)
+				}
+				if err != nil {
+					mu.Lock()
+					if r.failed++; r.err == nil {
+						r.err = err
+					}
+					mu.Unlock()
+					continue
+				}
+				out[i] = *full
+			}
+		})
+	}
+	for i := range out {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var warnings []error
+	for _, s := range sources {
+		r := runs[s]
+		if r.failed == 0 {
+			continue
+		}
+		err := r.err
+		if timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		warnings = append(warnings, fmt.Errorf("%s: %d sessions not read; results are incomplete: %w", label(s), r.failed, err))
+	}
+	return out, warnings
+}
+
+// MergeSessions yields the sessions of every source, most recent first,
+// with duplicates dropped and opts.State applied. Sources run concurrently, each under its own
+// timeout, and are merged with a heap holding the next session of each, so
+// a source never runs more than one session ahead of the merge. A source
+// that fails or times out yields one warning (a zero Session with the
+// error) where its sessions end. Stopping the iteration stops the sources.
+func MergeSessions(ctx context.Context, sources []Source, opts ListOptions, timeout time.Duration) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		streams := startStreams(ctx, sources, timeout, func(ctx context.Context, s Source) iter.Seq2[model.Session, error] {
+			return Sessions(ctx, s, opts)
+		})
+		defer stopStreams(streams)
+
+		h := &sessionHeap{}
+		// pull pushes the next session of stream i, or reports why it ended.
+		pull := func(i int) bool {
+			if sess, ok := streams[i].next(); ok {
+				heap.Push(h, heapEntry{sess, i})
+				return true
+			}
+			if err := streams[i].error(); err != nil {
+				return yield(model.Session{}, err)
+			}
+			return true
+		}
+		for i := range streams {
+			if !pull(i) {
+				return
+			}
+		}
+
+		seen := make(map[string]bool)
+		for h.Len() > 0 {
+			e := heap.Pop(h).(heapEntry)
+			if id := e.sess.QualifiedID(); !seen[id] && (opts.State == "" || e.sess.State == opts.State) {
+				seen[id] = true
+				if !yield(e.sess, nil) {
+					return
+				}
+			}
+			if !pull(e.stream) {
+				return
+			}
+		}
+	}
+}
+
+// SearchStream yields the search results of every source as they are
+// found, in no particular order, with duplicates dropped. Warnings are
+// yielded as in MergeSessions.
+func SearchStream(ctx context.Context, sources []Source, m search.Matcher, opts ListOptions, timeout time.Duration) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		seen := make(map[string]bool)
+		for r, err := range searchEvents(ctx, sources, m, opts, timeout) {
+			if err == nil {
+				id := r.Session.QualifiedID()
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+			}
+			if !yield(r.SearchResult, err) {
+				return
+			}
+		}
+	}
+}
+
+// sourcedResult is a search result with the index of its source.
+type sourcedResult struct {
+	model.SearchResult
+	source int
+}
+
+// searchEvents yields every source's search results as they arrive, and a
+// warning for each source that fails or times out.
+func searchEvents(ctx context.Context, sources []Source, m search.Matcher, opts ListOptions, timeout time.Duration) iter.Seq2[sourcedResult, error] {
+	return func(yield func(sourcedResult, error) bool) {
+		streams := startStreams(ctx, sources, timeout, func(ctx context.Context, s Source) iter.Seq2[model.SearchResult, error] {
+			return SearchResults(ctx, s, m, opts)
+		})
+		defer stopStreams(streams)
+
+		type event struct {
+			r   sourcedResult
+			err error
+			end bool
+		}
+		events := make(chan event)
+		quit := make(chan struct{})
+		defer close(quit)
+		send := func(e event) bool {
+			select {
+			case events <- e:
+				return true
+			case <-quit:
+				return false
+			}
+		}
+		for i, st := range streams {
+			go func() {
+				for {
+					r, ok := st.next()
+					if !ok {
+						send(event{err: st.error(), end: true})
+						return
+					}
+					if !send(event{r: sourcedResult{r, i}}) {
+						return
+					}
+				}
+			}()
+		}
+
+		for running := len(streams); running > 0; {
+			e := <-events
+			if e.end {
+				running--
+				if e.err == nil {
+					continue
+				}
+			}
+			if !yield(e.r, e.err) {
+				return
+			}
+		}
+	}
+}
+
+// cancelGrace is how long a stream still waits, once its source's context
+// is done, for the source to hand over what it found and stop.
+const cancelGrace = 100 * time.Millisecond
+
+// stream is one source's sequence, run by its own goroutine under the
+// source's deadline and handed over one item at a time.
+type stream[T any] struct {
+	src     Source
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+	items   chan T
+	stop    chan struct{} // closed when nobody will read items any more
+	done    chan struct{} // closed once the producer has returned
+	err     error         // the producer's error, valid once done is closed
+
+	grace     <-chan time.Time // started when ctx is first seen done
+	abandoned error            // set when the source did not stop in time
+}
+
+// startStreams starts one producer per source.
+func startStreams[T any](ctx context.Context, sources []Source, timeout time.Duration, seq func(context.Context, Source) iter.Seq2[T, error]) []*stream[T] {
+	streams := make([]*stream[T], len(sources))
+	for i, s := range sources {
+		st := &stream[T]{
+			src: s, timeout: timeout,
+			items: make(chan T), stop: make(chan struct{}), done: make(chan struct{}),
+		}
+		if timeout > 0 {
+			st.ctx, st.cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			st.ctx, st.cancel = context.WithCancel(ctx)
+		}
+		go st.produce(seq)
+		streams[i] = st
+	}
+	return streams
+}
+
+// stopStreams cancels every producer still running. A source that ignores
+// its context keeps running in the background; its late items are dropped.
+func stopStreams[T any](streams []*stream[T]) {
+	for _, st := range streams {
+		close(st.stop)
+		st.cancel()
+	}
+}
+
+func (st *stream[T]) produce(seq func(context.Context, Source) iter.Seq2[T, error]) {
+	defer close(st.done)
+	for it, err := range seq(st.ctx, st.src) {
+		if err != nil {
+			st.err = err
+			return
+		}
+		select {
+		case st.items <- it:
+		case <-st.stop:
+			return
+		}
+	}
+}
+
+// next returns the source's next item, or false once it has ended, failed,
+// or missed its deadline.
+func (st *stream[T]) next() (T, bool) {
+	for {
+		expired := st.ctx.Done()
+		if st.grace != nil {
+			expired = nil
+		}
+		select {
+		case it := <-st.items:
+			return it, true
+		case <-st.done:
+			var zero T
+			return zero, false
+		case <-expired:
+			// A source that honours ctx hands over what it has and
+			// stops promptly.
+			st.grace = time.After(cancelGrace)
+		case <-st.grace:
+			st.abandoned = st.ctx.Err()
+			var zero T
+			return zero, false
+		}
+	}
+}
+
+// error returns why an ended stream stopped early, labeled with its source,
+// or nil when the source simply ran out of items.
+func (st *stream[T]) error() error {
+	err := st.abandoned
+	if err == nil {
+		err = st.err
+	}
+	if err == nil {
+		return nil
+	}
+	if st.timeout > 0 && errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s; results are incomplete", st.timeout)
+	}
+	return fmt.Errorf("%s: %w", label(st.src), err)
+}
+
+// heapEntry is a session waiting in the merge, with the stream it came from.
+type heapEntry struct {
+	sess   model.Session
+	stream int
+}
+
+// sessionHeap orders sessions most recent first, then by stream so that
+// merging is deterministic.
+type sessionHeap []heapEntry
+
+func (h sessionHeap) Len() int { return len(h) }
+func (h sessionHeap) Less(i, j int) bool {
+	if !h[i].sess.UpdatedAt.Equal(h[j].sess.UpdatedAt) {
+		return h[i].sess.UpdatedAt.After(h[j].sess.UpdatedAt)
+	}
+	return h[i].stream < h[j].stream
+}
+func (h sessionHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *sessionHeap) Push(x any)   { *h = append(*h, x.(heapEntry)) }
+func (h *sessionHeap) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// label names a source in warnings: its tool, plus the host for other
+// machines' roots ("claude@devvm").
+func label(s Source) string {
+	if host := HostOf(s); host != "" {
+		return string(s.Name()) + "@" + host
+	}
+	return string(s.Name())
+}
+
+// truncate cuts items to limit; a limit of 0 means no limit.
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,514 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+// funcSource is a Source whose List and Search run the given function, which
+// returns sessions (search results wrap them, scored by their ID's index).
+type funcSource struct {
+	tool model.Tool
+	fn   func(ctx context.Context) ([]model.Session, error)
+}
+
+func (f *funcSource) Name() model.Tool { return f.tool }
+func (f *funcSource) List(ctx context.Context, _ ListOptions) ([]model.Session, error) {
+	return f.fn(ctx)
+}
+func (f *funcSource) Get(_ context.Context, _ string) (*model.Session, error) { return nil, nil }
+func (f *funcSource) Search(ctx context.Context, _ search.Matcher, _ ListOptions) ([]model.SearchResult, error) {
+	sessions, err := f.fn(ctx)
+	var results []model.SearchResult
+	for _, s := range sessions {
+		results = append(results, model.SearchResult{Session: s})
+	}
+	return results, err
+}
+
+// sessionsAt returns a source function listing tool's sessions with the
+// given IDs, updated hoursAgo[i] hours ago.
+func sessionsAt(tool model.Tool, ids []string, hoursAgo []int) func(context.Context) ([]model.Session, error) {
+	return func(context.Context) ([]model.Session, error) {
+		var sessions []model.Session
+		for i, id := range ids {
+			sessions = append(sessions, model.Session{
+				ID: id, Tool: tool, UpdatedAt: time.Now().Add(-time.Duration(hoursAgo[i]) * time.Hour),
+			})
+		}
+		return sessions, nil
+	}
+}
+
+// streamSource is a Streamer over its funcSource's sessions (which must be
+// most recent first) that counts how many it has produced.
+type streamSource struct {
+	funcSource
+	produced atomic.Int32
+}
+
+func (s *streamSource) Sessions(ctx context.Context, _ ListOptions) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		sessions, err := s.fn(ctx)
+		for _, sess := range sessions {
+			s.produced.Add(1)
+			if !yield(sess, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(model.Session{}, err)
+		}
+	}
+}
+
+func (s *streamSource) SearchResults(ctx context.Context, m search.Matcher, opts ListOptions) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		for sess, err := range s.Sessions(ctx, opts) {
+			if !yield(model.SearchResult{Session: sess}, err) {
+				return
+			}
+		}
+	}
+}
+
+func qualifiedIDs(sessions []model.Session) string {
+	var ids []string
+	for _, s := range sessions {
+		ids = append(ids, s.QualifiedID())
+	}
+	return strings.Join(ids, " ")
+}
+
+func warningText(warnings []error) string {
+	var ws []string
+	for _, w := range warnings {
+		ws = append(ws, w.Error())
+	}
+	return strings.Join(ws, "; ")
+}
+
+// ---------------------------------------------------------------------------
+// ListAll
+// ---------------------------------------------------------------------------
+
+func TestListAll_MergesSortsAndLimits(t *testing.T) {
+	sources := []Source{
+		&funcSource{tool: "a", fn: sessionsAt("a", []string{"1", "2"}, []int{5, 1})},
+		&funcSource{tool: "b", fn: sessionsAt("b", []string{"1", "2"}, []int{3, 0})},
+		// A root registered twice yields the same sessions twice.
+		&funcSource{tool: "a", fn: sessionsAt("a", []string{"1"}, []int{5})},
+		&funcSource{tool: "c", fn: func(context.Context) ([]model.Session, error) { return nil, errors.New("boom") }},
+	}
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{0, "b:2 a:2 b:1 a:1"},
+		{3, "b:2 a:2 b:1"},
+		{10, "b:2 a:2 b:1 a:1"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.limit), func(t *testing.T) {
+			got, warnings := ListAll(context.Background(), sources, ListOptions{Limit: tt.limit}, 0)
+			if ids := qualifiedIDs(got); ids != tt.want {
+				t.Errorf("ListAll() = %s, want %s", ids, tt.want)
+			}
+			if w := warningText(warnings); w != "c: boom" {
+				t.Errorf("warnings = %q, want %q", w, "c: boom")
+			}
+		})
+	}
+}
+
+func TestSortByDuration(t *testing.T) {
+	sessions := []model.Session{
+		{ID: "untimed", Tool: "a"},
+		{ID: "short", Tool: "a", Duration: time.Hour, ActiveDuration: 5 * time.Minute},
+		{ID: "long", Tool: "a", Duration: time.Hour, ActiveDuration: 40 * time.Minute},
+		{ID: "wide", Tool: "a", Duration: 3 * time.Hour, ActiveDuration: 5 * time.Minute},
+		{ID: "untimed2", Tool: "a"},
+	}
+	SortByDuration(sessions)
+	if got, want := qualifiedIDs(sessions), "a:long a:wide a:short a:untimed a:untimed2"; got != want {
+		t.Errorf("SortByDuration() = %s, want %s", got, want)
+	}
+}
+
+func TestListAll_RunsSourcesConcurrently(t *testing.T) {
+	// Each source waits for the other to start: run one after the other,
+	// they would both time out.
+	var started sync.WaitGroup
+	started.Add(2)
+	rendezvous := func(tool model.Tool) func(context.Context) ([]model.Session, error) {
+		return func(ctx context.Context) ([]model.Session, error) {
+			started.Done()
+			waited := make(chan struct{})
+			go func() { started.Wait(); close(waited) }()
+			select {
+			case <-waited:
+				return []model.Session{{ID: "1", Tool: tool}}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	sources := []Source{&funcSource{tool: "a", fn: rendezvous("a")}, &funcSource{tool: "b", fn: rendezvous("b")}}
+	got, warnings := ListAll(context.Background(), sources, ListOptions{}, 5*time.Second)
+	if len(got) != 2 || len(warnings) != 0 {
+		t.Errorf("ListAll() = %s, %v; want both sessions", qualifiedIDs(got), warnings)
+	}
+}
+
+func TestListAll_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	sources := []Source{
+		&funcSource{tool: "fast", fn: sessionsAt("fast", []string{"1"}, []int{0})},
+		// stuck ignores its context, as a blocked read would.
+		&funcSource{tool: "stuck", fn: func(context.Context) ([]model.Session, error) {
+			<-release
+			return []model.Session{{ID: "late", Tool: "stuck"}}, nil
+		}},
+		// partial stops at its deadline with what it has.
+		&funcSource{tool: "partial", fn: func(ctx context.Context) ([]model.Session, error) {
+			<-ctx.Done()
+			return []model.Session{{ID: "some", Tool: "partial", UpdatedAt: time.Now().Add(-time.Hour)}}, ctx.Err()
+		}},
+	}
+
+	start := time.Now()
+	got, warnings := ListAll(context.Background(), sources, ListOptions{}, 20*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ListAll() took %v, should give up on stuck sources", elapsed)
+	}
+	if ids := qualifiedIDs(got); ids != "fast:1 partial:some" {
+		t.Errorf("ListAll() = %s, want fast:1 partial:some", ids)
+	}
+	want := "stuck: timed out after 20ms; results are incomplete; partial: timed out after 20ms; results are incomplete"
+	if w := warningText(warnings); w != want {
+		t.Errorf("warnings = %q, want %q", w, want)
+	}
+}
+
+func TestListAll_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sources := []Source{&funcSource{tool: "a", fn: func(ctx context.Context) ([]model.Session, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("list a: %w", ctx.Err())
+	}}}
+	_, warnings := ListAll(ctx, sources, ListOptions{}, 0)
+	if len(warnings) != 1 || !errors.Is(warnings[0], context.Canceled) {
+		t.Errorf("warnings = %v, want one wrapping context.Canceled", warnings)
+	}
+}
+
+func TestListAll_LabelsHosts(t *testing.T) {
+	sources := []Source{&hostSource{
+		Source: &funcSource{tool: "a", fn: func(context.Context) ([]model.Session, error) { return nil, errors.New("boom") }},
+		host:   "devbox",
+	}}
+	if _, warnings := ListAll(context.Background(), sources, ListOptions{}, 0); warningText(warnings) != "a@devbox: boom" {
+		t.Errorf("warnings = %q, want a@devbox: boom", warningText(warnings))
+	}
+}
+
+func TestListAll_StopsStreamingAtLimit(t *testing.T) {
+	ids := make([]string, 100)
+	hours := make([]int, 100)
+	for i := range ids {
+		ids[i], hours[i] = fmt.Sprint(i), 2*i
+	}
+	streamed := &streamSource{funcSource: funcSource{tool: "s", fn: sessionsAt("s", ids, hours)}}
+	listed := &funcSource{tool: "l", fn: sessionsAt("l", []string{"1"}, []int{1})}
+
+	got, warnings := ListAll(context.Background(), []Source{streamed, listed}, ListOptions{Limit: 3}, 0)
+	if ids := qualifiedIDs(got); ids != "s:0 l:1 s:1" || len(warnings) != 0 {
+		t.Errorf("ListAll() = %s, %v; want s:0 l:1 s:1", ids, warnings)
+	}
+	// The merge holds one session per source, and the producer may have
+	// the next one ready.
+	if n := streamed.produced.Load(); n > 4 {
+		t.Errorf("source produced %d sessions for a limit of 3", n)
+	}
+}
+
+func TestMergeSessions_StopsOnBreak(t *testing.T) {
+	sources := []Source{
+		&funcSource{tool: "a", fn: func(context.Context) ([]model.Session, error) { return nil, errors.New("boom") }},
+		&funcSource{tool: "b", fn: sessionsAt("b", []string{"1", "2"}, []int{1, 2})},
+	}
+	for _, n := range []int{1, 2} {
+		var got []string
+		for sess, err := range MergeSessions(context.Background(), sources, ListOptions{}, 0) {
+			if err != nil {
+				got = append(got, err.Error())
+			} else {
+				got = append(got, sess.QualifiedID())
+			}
+			if len(got) == n {
+				break
+			}
+		}
+		want := []string{"a: boom", "b:1"}[:n]
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("MergeSessions() up to %d = %v, want %v", n, got, want)
+		}
+	}
+
+	// A source ending in an error after its sessions.
+	failing := &funcSource{tool: "c", fn: func(context.Context) ([]model.Session, error) {
+		return []model.Session{{ID: "1", Tool: "c"}}, errors.New("late")
+	}}
+	for _, err := range MergeSessions(context.Background(), []Source{failing}, ListOptions{}, 0) {
+		if err != nil {
+			break
+		}
+	}
+}
+
+func TestListOptions_MatchesProject(t *testing.T) {
+	tests := []struct {
+		filter, project string
+		want            bool
+	}{
+		{"", "/src/api", true},
+		{"", "", true},
+		{"api", "/src/api", true},
+		{"API", "/src/Api", true},
+		{"web", "/src/api", false},
+		{"api", "", false},
+	}
+	for _, tt := range tests {
+		if got := (ListOptions{Project: tt.filter}).MatchesProject(tt.project); got != tt.want {
+			t.Errorf("MatchesProject(%q) with filter %q = %v, want %v", tt.project, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestListAll_FiltersState(t *testing.T) {
+	src := &funcSource{tool: "a", fn: func(context.Context) ([]model.Session, error) {
+		now := time.Now()
+		return []model.Session{
+			{ID: "1", Tool: "a", UpdatedAt: now, State: model.StateWorking},
+			{ID: "2", Tool: "a", UpdatedAt: now.Add(-time.Hour), State: model.StateWaiting},
+			{ID: "3", Tool: "a", UpdatedAt: now.Add(-2 * time.Hour), State: model.StateWaiting},
+		}, nil
+	}}
+	got, _ := ListAll(context.Background(), []Source{src}, ListOptions{State: model.StateWaiting, Limit: 1}, 0)
+	if len(got) != 1 || got[0].ID != "2" {
+		t.Errorf("ListAll(State: waiting, Limit: 1) = %+v, want session 2", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// SearchAll
+// ---------------------------------------------------------------------------
+
+func TestSearchAll_RanksAndLimits(t *testing.T) {
+	scored := &funcSource{tool: "a", fn: sessionsAt("a", []string{"1", "2"}, []int{9, 8})}
+	// The first source's results win over their duplicates, even when they
+	// arrive last.
+	late := &funcSource{tool: "a", fn: func(ctx context.Context) ([]model.Session, error) {
+		time.Sleep(50 * time.Millisecond)
+		return scored.fn(ctx)
+	}}
+	sources := []Source{
+		scoreSource{late, map[string]float64{"1": 2, "2": 5}},
+		&funcSource{tool: "b", fn: sessionsAt("b", []string{"1", "2"}, []int{3, 1})},
+		scoreSource{scored, nil},
+	}
+	got, warnings := SearchAll(context.Background(), sources, nil, ListOptions{Limit: 3}, 0)
+	var ids []string
+	for _, r := range got {
+		ids = append(ids, r.Session.QualifiedID())
+	}
+	if fmt.Sprint(ids) != "[a:2 a:1 b:2]" || len(warnings) != 0 {
+		t.Errorf("SearchAll() = %v, %v; want [a:2 a:1 b:2] without warnings", ids, warnings)
+	}
+}
+
+// scoreSource sets the Score of its source's search results by session ID.
+type scoreSource struct {
+	Source
+	scores map[string]float64
+}
+
+func (s scoreSource) Search(ctx context.Context, m search.Matcher, opts ListOptions) ([]model.SearchResult, error) {
+	results, err := s.Source.Search(ctx, m, opts)
+	for i := range results {
+		results[i].Score = s.scores[results[i].Session.ID]
+	}
+	return results, err
+}
+
+func TestSearchAll_Warnings(t *testing.T) {
+	sources := []Source{
+		&funcSource{tool: "a", fn: sessionsAt("a", []string{"1"}, []int{1})},
+		&funcSource{tool: "b", fn: func(context.Context) ([]model.Session, error) { return nil, errors.New("boom") }},
+	}
+	got, warnings := SearchAll(context.Background(), sources, nil, ListOptions{}, 0)
+	if len(got) != 1 || warningText(warnings) != "b: boom" {
+		t.Errorf("SearchAll() = %d results, %q; want 1 result and b: boom", len(got), warningText(warnings))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// SearchStream
+// ---------------------------------------------------------------------------
+
+func TestSearchStream_YieldsAsFound(t *testing.T) {
+	// slow only finishes once fast's result has been received.
+	received := make(chan struct{})
+	fast := &streamSource{funcSource: funcSource{tool: "fast", fn: sessionsAt("fast", []string{"1"}, []int{1})}}
+	slow := &funcSource{tool: "slow", fn: func(ctx context.Context) ([]model.Session, error) {
+		select {
+		case <-received:
+			return []model.Session{{ID: "1", Tool: "slow"}}, errors.New("boom")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}}
+	sources := []Source{slow, fast, fast}
+
+	var got []string
+	for r, err := range SearchStream(context.Background(), sources, nil, ListOptions{}, 5*time.Second) {
+		if err != nil {
+			got = append(got, err.Error())
+			continue
+		}
+		if got = append(got, r.Session.QualifiedID()); len(got) == 1 {
+			close(received)
+		}
+	}
+	// fast's duplicate is dropped.
+	if want := "[fast:1 slow:1 slow: boom]"; fmt.Sprint(got) != want {
+		t.Errorf("SearchStream() = %v, want %s", got, want)
+	}
+
+	// Stop while a source has its next result ready.
+	ids := []string{"1", "2", "3", "4"}
+	many := &streamSource{funcSource: funcSource{tool: "many", fn: sessionsAt("many", ids, []int{1, 2, 3, 4})}}
+	for range SearchStream(context.Background(), []Source{many}, nil, ListOptions{}, 0) {
+		for many.produced.Load() < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		break
+	}
+	stuck := &funcSource{tool: "stuck", fn: func(ctx context.Context) ([]model.Session, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	for _, err := range SearchStream(context.Background(), []Source{stuck}, nil, ListOptions{}, 20*time.Millisecond) {
+		if err == nil {
+			t.Error("SearchStream() of a stuck source yielded a result")
+		}
+		break
+	}
+}
+
+// ---------------------------------------------------------------------------
+// GetAll
+// ---------------------------------------------------------------------------
+
+// getSource is a Source whose Get runs the given function.
+type getSource struct {
+	funcSource
+	get func(ctx context.Context, id string) (*model.Session, error)
+}
+
+func (g *getSource) Get(ctx context.Context, id string) (*model.Session, error) {
+	return g.get(ctx, id)
+}
+
+// fullSession returns the session id of tool with one message.
+func fullSession(tool model.Tool) func(context.Context, string) (*model.Session, error) {
+	return func(_ context.Context, id string) (*model.Session, error) {
+		return &model.Session{ID: id, Tool: tool, Messages: []model.Message{{Content: id}}}, nil
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	sources := []Source{
+		&getSource{funcSource: funcSource{tool: "a"}, get: fullSession("a")},
+		&hostSource{Source: &getSource{funcSource: funcSource{tool: "a"}, get: fullSession("a")}, host: "devbox"},
+		&getSource{funcSource: funcSource{tool: "b"}, get: func(_ context.Context, id string) (*model.Session, error) {
+			if id == "gone" {
+				return nil, nil
+			}
+			return nil, errors.New("boom")
+		}},
+	}
+	listed := []model.Session{
+		{ID: "1", Tool: "a"},
+		{ID: "2", Tool: "a", Host: "devbox"},
+		{ID: "3", Tool: "b", Title: "kept"},
+		{ID: "gone", Tool: "b"},
+		{ID: "4", Tool: "c"}, // no source
+	}
+	got, warnings := GetAll(context.Background(), sources, listed, 0)
+	if ids := qualifiedIDs(got); ids != "a:1 a@devbox:2 b:3 b:gone c:4" {
+		t.Errorf("GetAll() = %s, want the listed sessions in order", ids)
+	}
+	for i, want := range []int{1, 1, 0, 0, 0} {
+		if len(got[i].Messages) != want {
+			t.Errorf("got[%d] has %d messages, want %d", i, len(got[i].Messages), want)
+		}
+	}
+	if got[2].Title != "kept" {
+		t.Errorf("got[2] = %+v, want the listed session", got[2])
+	}
+	if w := warningText(warnings); w != "b: 2 sessions not read; results are incomplete: boom" &&
+		w != "b: 2 sessions not read; results are incomplete: session gone not found" {
+		t.Errorf("warnings = %q, want one for b", w)
+	}
+}
+
+func TestGetAll_Timeout(t *testing.T) {
+	sources := []Source{&getSource{funcSource: funcSource{tool: "slow"}, get: func(ctx context.Context, _ string) (*model.Session, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}}
+	listed := []model.Session{{ID: "1", Tool: "slow"}, {ID: "2", Tool: "slow"}}
+	_, warnings := GetAll(context.Background(), sources, listed, 20*time.Millisecond)
+	if w, want := warningText(warnings), "slow: 2 sessions not read; results are incomplete: timed out after 20ms"; w != want {
+		t.Errorf("warnings = %q, want %q", w, want)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Collect
+// ---------------------------------------------------------------------------
+
+func TestCollect(t *testing.T) {
+	src := &streamSource{funcSource: funcSource{tool: "a", fn: func(context.Context) ([]model.Session, error) {
+		return []model.Session{{ID: "1"}, {ID: "2"}}, errors.New("boom")
+	}}}
+	tests := []struct {
+		limit   int
+		want    int
+		wantErr bool
+	}{
+		{0, 2, true},
+		{1, 1, false},
+		{2, 2, false},
+	}
+	for _, tt := range tests {
+		got, err := Collect(src.Sessions(context.Background(), ListOptions{}), tt.limit)
+		if len(got) != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("Collect(limit %d) = %d items, %v; want %d, error %v", tt.limit, len(got), err, tt.want, tt.wantErr)
+		}
+	}
+}
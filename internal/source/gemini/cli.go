@@ -0,0 +1,229 @@
+package gemini
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listSessionsTimeout bounds a single `gemini --list-sessions` run so a hung
+// CLI cannot stall List().
+const listSessionsTimeout = 10 * time.Second
+
+// listSessionsFn runs `gemini --list-sessions` in a project directory and
+// returns its stdout. Tests may replace it; the default looks the binary up
+// on PATH, so a fake `gemini` script works too.
+var listSessionsFn = runListSessions
+
+// errGeminiNotInstalled is returned by runListSessions when the gemini binary
+// is not on PATH. The CLI fallback is silently skipped in that case.
+var errGeminiNotInstalled = errors.New("gemini not found in PATH")
+
+func runListSessions(ctx context.Context, projectDir string) ([]byte, error) {
+	geminiPath, err := exec.LookPath("gemini")
+	if err != nil {
+		return nil, errGeminiNotInstalled
+	}
+	ctx, cancel := context.WithTimeout(ctx, listSessionsTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, geminiPath, "--list-sessions")
+	cmd.Dir = projectDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gemini --list-sessions in %s: %w", projectDir, err)
+	}
+	return out, nil
+}
+
+// listingLineRe matches one session row of `gemini --list-sessions`:
+//
+//  1. Fix the login bug (2 hours ago) [a1b2c3d4-...]
+var listingLineRe = regexp.MustCompile(`^\s*\d+\.\s+(.*?)\s+\(([^()]*)\)\s+\[([^\]]+)\]`)
+
+// relativeTimeRe matches "<n> <unit>(s) ago" in the listing's time column.
+var relativeTimeRe = regexp.MustCompile(`^(\d+)\s+(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+// relativeUnits maps the units accepted by relativeTimeRe to durations.
+// Months and years are approximate; the listing is not more precise anyway.
+var relativeUnits = map[string]time.Duration{
+	"second": time.Second,
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+	"month":  30 * 24 * time.Hour,
+	"year":   365 * 24 * time.Hour,
+}
+
+// parseListSessions parses `gemini --list-sessions` output into
+// metadata-only session records for the given project. Relative times are
+// resolved against now.
+func parseListSessions(out []byte, project string, now time.Time) []sessionRecord {
+	var records []sessionRecord
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		m := listingLineRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		ts := parseRelativeTime(m[2], now)
+		records = append(records, sessionRecord{
+			ID:        strings.TrimSpace(m[3]),
+			Project:   project,
+			Title:     m[1],
+			StartedAt: ts,
+			UpdatedAt: ts,
+		})
+	}
+	return records
+}
+
+// parseRelativeTime converts the listing's "2 hours ago" / "Just now" /
+// "yesterday" column to an absolute time. Unknown formats yield zero time.
+func parseRelativeTime(s string, now time.Time) time.Time {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "just now", "now":
+		return now
+	case "yesterday":
+		return now.Add(-24 * time.Hour)
+	}
+	m := relativeTimeRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}
+	}
+	n, _ := strconv.Atoi(m[1]) // \d+ always parses
+	return now.Add(-time.Duration(n) * relativeUnits[m[2]])
+}
+
+// knownProjects returns the absolute project paths Gemini CLI knows about,
+// from ~/.gemini/projects.json and ~/.gemini/history/<project>/. History
+// directories are resolved through their .project_root marker, falling back
+// to the projects.json short name. Only existing directories are returned,
+// since the CLI has to run inside them.
+func knownProjects(dir string) []string {
+	names := loadProjectMap(filepath.Join(dir, "projects.json"))
+	seen := make(map[string]bool)
+	for _, p := range names {
+		seen[p] = true
+	}
+
+	historyDirs, _ := os.ReadDir(filepath.Join(dir, "history"))
+	for _, h := range historyDirs {
+		if !h.IsDir() {
+			continue
+		}
+		root, err := os.ReadFile(filepath.Join(dir, "history", h.Name(), ".project_root"))
+		if err == nil && strings.TrimSpace(string(root)) != "" {
+			seen[strings.TrimSpace(string(root))] = true
+			continue
+		}
+		if p, ok := names[h.Name()]; ok {
+			seen[p] = true
+		}
+	}
+
+	var projects []string
+	for p := range seen {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			projects = append(projects, p)
+		}
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// withListedSessions adds the sessions `gemini --list-sessions` reports that
+// have no readable checkpoint (e.g. encrypted antigravity conversations).
+// These carry metadata only — no messages — so they are never searched.
+func withListedSessions(ctx context.Context, dir string, records []sessionRecord) []sessionRecord {
+	known := make(map[string]bool, len(records))
+	for _, r := range records {
+		known[r.ID] = true
+	}
+
+	added := false
+	for _, project := range knownProjects(dir) {
+		if ctx.Err() != nil {
+			break // the caller reports it
+		}
+		listed, ok := listProject(ctx, project)
+		if !ok {
+			break
+		}
+		for _, r := range listed {
+			if known[r.ID] {
+				continue
+			}
+			known[r.ID] = true
+			records = append(records, r)
+			added = true
+		}
+	}
+
+	if added {
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].UpdatedAt.After(records[j].UpdatedAt)
+		})
+	}
+	return records
+}
+
+// listedTTL is how long a project's `gemini --list-sessions` output is
+// reused. The CLI takes seconds to start, but a long-running watch or TUI
+// should still see new sessions, and the listing's times are relative to
+// when it ran.
+var listedTTL = time.Minute
+
+// listing is the output of one `gemini --list-sessions` run.
+type listing struct {
+	records []sessionRecord
+	at      time.Time
+}
+
+// listedSessions caches the listing of each project for listedTTL.
+var listedSessions = struct {
+	sync.Mutex
+	byProject map[string]listing
+	missingAt time.Time // when gemini was last found not installed
+}{byProject: make(map[string]listing)}
+
+// listProject returns the sessions `gemini --list-sessions` reports in
+// project, running it at most once per listedTTL. ok is false when gemini is
+// not installed. A failed run is logged and not cached, so the next call
+// retries it.
+func listProject(ctx context.Context, project string) (records []sessionRecord, ok bool) {
+	listedSessions.Lock()
+	defer listedSessions.Unlock()
+	now := time.Now()
+	if now.Sub(listedSessions.missingAt) < listedTTL {
+		return nil, false
+	}
+	if l, ok := listedSessions.byProject[project]; ok && now.Sub(l.at) < listedTTL {
+		return l.records, true
+	}
+	out, err := listSessionsFn(ctx, project)
+	if errors.Is(err, errGeminiNotInstalled) {
+		listedSessions.missingAt = now
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("warning: %v", err)
+		return nil, true
+	}
+	records = parseListSessions(out, project, now)
+	listedSessions.byProject[project] = listing{records: records, at: now}
+	return records, true
+}
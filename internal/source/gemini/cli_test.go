@@ -0,0 +1,391 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/source"
+)
+
+// writeFakeGemini places an executable `gemini` script on a fresh PATH that
+// prints the given output for --list-sessions.
+func writeFakeGemini(t *testing.T, script string) {
+	t.Helper()
+	resetListedSessions(t)
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "gemini"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir)
+}
+
+// setListSessionsFn overrides listSessionsFn for the duration of the test.
+func setListSessionsFn(t *testing.T, fn func(context.Context, string) ([]byte, error)) {
+	t.Helper()
+	resetListedSessions(t)
+	orig := listSessionsFn
+	listSessionsFn = fn
+	t.Cleanup(func() { listSessionsFn = orig })
+}
+
+// resetListedSessions empties the listing cache before and after the test.
+func resetListedSessions(t *testing.T) {
+	reset := func() {
+		listedSessions.byProject = make(map[string]listing)
+		listedSessions.missingAt = time.Time{}
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+// ---------------------------------------------------------------------------
+// runListSessions
+// ---------------------------------------------------------------------------
+
+func TestRunListSessions_NotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, err := runListSessions(context.Background(), t.TempDir())
+	if !errors.Is(err, errGeminiNotInstalled) {
+		t.Errorf("err = %v, want errGeminiNotInstalled", err)
+	}
+}
+
+func TestRunListSessions_FakeBinary(t *testing.T) {
+	writeFakeGemini(t, "#!/bin/sh\n[ \"$1\" = \"--list-sessions\" ] || exit 2\npwd\n")
+	dir := t.TempDir()
+	out, err := runListSessions(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("runListSessions(context.Background(), ) error: %v", err)
+	}
+	// The CLI must run inside the project directory.
+	want, _ := filepath.EvalSymlinks(dir)
+	if got := strings.TrimSpace(string(out)); got != want && got != dir {
+		t.Errorf("cwd = %q, want %q", got, dir)
+	}
+}
+
+func TestRunListSessions_CommandFails(t *testing.T) {
+	writeFakeGemini(t, "#!/bin/sh\nexit 1\n")
+	if _, err := runListSessions(context.Background(), t.TempDir()); err == nil {
+		t.Error("expected error when gemini exits non-zero")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// parseListSessions / parseRelativeTime
+// ---------------------------------------------------------------------------
+
+func TestParseListSessions(t *testing.T) {
+	out, err := os.ReadFile("testdata/list_sessions.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	records := parseListSessions(out, "/work/app", now)
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	r := records[0]
+	if r.ID != "a1b2c3d4-0000-1111-2222-333344445555" {
+		t.Errorf("ID = %q", r.ID)
+	}
+	if r.Title != "Fix the login bug" {
+		t.Errorf("Title = %q", r.Title)
+	}
+	if r.Project != "/work/app" {
+		t.Errorf("Project = %q", r.Project)
+	}
+	if want := now.Add(-2 * time.Hour); !r.UpdatedAt.Equal(want) || !r.StartedAt.Equal(want) {
+		t.Errorf("times = %v / %v, want %v", r.StartedAt, r.UpdatedAt, want)
+	}
+	if r.FilePath != "" || r.Messages != nil {
+		t.Error("listed sessions must be metadata-only")
+	}
+	// Trailing markers after the ID are ignored.
+	if records[2].ID != "e5f6a7b8-0000-1111-2222-333344445555" {
+		t.Errorf("records[2].ID = %q", records[2].ID)
+	}
+}
+
+func TestParseRelativeTime(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"Just now", now},
+		{"now", now},
+		{"Yesterday", now.Add(-24 * time.Hour)},
+		{"1 second ago", now.Add(-time.Second)},
+		{"5 minutes ago", now.Add(-5 * time.Minute)},
+		{"2 weeks ago", now.Add(-14 * 24 * time.Hour)},
+		{"1 month ago", now.Add(-30 * 24 * time.Hour)},
+		{"1 year ago", now.Add(-365 * 24 * time.Hour)},
+		{"2026-02-09", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := parseRelativeTime(tt.in, now); !got.Equal(tt.want) {
+			t.Errorf("parseRelativeTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// knownProjects
+// ---------------------------------------------------------------------------
+
+func TestKnownProjects(t *testing.T) {
+	dir := t.TempDir()
+	fromMap := t.TempDir()
+	fromMarker := t.TempDir()
+	fromName := t.TempDir()
+
+	pj := `{"projects":{"` + fromMap + `":"mapped","/does/not/exist":"gone","` + fromName + `":"named"}}`
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mk := func(rel string) string {
+		p := filepath.Join(dir, "history", rel)
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	marker := mk("withmarker")
+	if err := os.WriteFile(filepath.Join(marker, ".project_root"), []byte(fromMarker+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mk("named")
+	mk("unknown")
+	if err := os.WriteFile(filepath.Join(dir, "history", "stray"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := knownProjects(dir)
+	want := map[string]bool{fromMap: true, fromMarker: true, fromName: true}
+	if len(got) != len(want) {
+		t.Fatalf("knownProjects() = %v, want %d entries", got, len(want))
+	}
+	for _, p := range got {
+		if !want[p] {
+			t.Errorf("unexpected project %q", p)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// withListedSessions / List integration
+// ---------------------------------------------------------------------------
+
+func TestWithListedSessions(t *testing.T) {
+	dir := t.TempDir()
+	projA := t.TempDir()
+	projB := t.TempDir()
+	pj := `{"projects":{"` + projA + `":"a","` + projB + `":"b"}}`
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _ := os.ReadFile("testdata/list_sessions.txt")
+	setListSessionsFn(t, func(_ context.Context, project string) ([]byte, error) {
+		if project == projB {
+			return nil, errors.New("boom")
+		}
+		return out, nil
+	})
+
+	existing := []sessionRecord{{ID: fixtureChatID, FilePath: "/x.json", UpdatedAt: time.Now().Add(-time.Minute)}}
+	got := withListedSessions(context.Background(), dir, existing)
+	// 3 listed, one of which duplicates the existing checkpoint.
+	if len(got) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(got))
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].UpdatedAt.After(got[i-1].UpdatedAt) {
+			t.Errorf("records not sorted by UpdatedAt desc at %d", i)
+		}
+	}
+	for _, r := range got {
+		if r.ID == fixtureChatID && r.FilePath == "" {
+			t.Error("checkpoint session was replaced by its listing")
+		}
+	}
+}
+
+func TestWithListedSessions_NotInstalledStopsEarly(t *testing.T) {
+	dir := t.TempDir()
+	pj := `{"projects":{"` + t.TempDir() + `":"a","` + t.TempDir() + `":"b"}}`
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	setListSessionsFn(t, func(context.Context, string) ([]byte, error) {
+		calls++
+		return nil, errGeminiNotInstalled
+	})
+	if got := withListedSessions(context.Background(), dir, nil); len(got) != 0 {
+		t.Errorf("expected no records, got %d", len(got))
+	}
+	if calls != 1 {
+		t.Errorf("listSessionsFn called %d times, want 1", calls)
+	}
+	// Not installed is remembered.
+	withListedSessions(context.Background(), dir, nil)
+	if calls != 1 {
+		t.Errorf("listSessionsFn called %d times after a second listing, want 1", calls)
+	}
+}
+
+func TestWithListedSessions_EveryProjectOncePerTTL(t *testing.T) {
+	dir := t.TempDir()
+	projA := t.TempDir()
+	projB := t.TempDir()
+	pj := `{"projects":{"` + projA + `":"a","` + projB + `":"b"}}`
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := os.ReadFile("testdata/list_sessions.txt")
+	var ran []string
+	setListSessionsFn(t, func(_ context.Context, project string) ([]byte, error) {
+		ran = append(ran, project)
+		return out, nil
+	})
+
+	// projA has a checkpoint, but may still hold sessions only the CLI can
+	// read, so both projects are listed. Both list the same IDs, which are
+	// added once.
+	existing := []sessionRecord{{ID: "other", Project: projA, FilePath: "/x.json"}}
+	first := withListedSessions(context.Background(), dir, existing)
+	second := withListedSessions(context.Background(), dir, existing)
+	if len(ran) != 2 {
+		t.Errorf("listed %q, want each project once", ran)
+	}
+	if len(first) != 4 || len(second) != 4 {
+		t.Errorf("got %d then %d records, want the checkpoint and 3 listed each time", len(first), len(second))
+	}
+
+	// Once the listing expires, the projects are listed again.
+	orig := listedTTL
+	listedTTL = 0
+	t.Cleanup(func() { listedTTL = orig })
+	withListedSessions(context.Background(), dir, existing)
+	if len(ran) != 4 {
+		t.Errorf("listed %d times after expiry, want 4", len(ran))
+	}
+}
+
+func TestWithListedSessions_NotInstalledExpires(t *testing.T) {
+	dir := t.TempDir()
+	pj := `{"projects":{"` + t.TempDir() + `":"a"}}`
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	setListSessionsFn(t, func(context.Context, string) ([]byte, error) {
+		calls++
+		return nil, errGeminiNotInstalled
+	})
+	orig := listedTTL
+	listedTTL = 0
+	t.Cleanup(func() { listedTTL = orig })
+
+	withListedSessions(context.Background(), dir, nil)
+	withListedSessions(context.Background(), dir, nil)
+	if calls != 2 {
+		t.Errorf("listSessionsFn called %d times, want 2 once not installed expired", calls)
+	}
+}
+
+func TestList_CancelledSkipsCLI(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	pj := `{"projects":{"` + t.TempDir() + `":"a"}}`
+	if err := os.MkdirAll(filepath.Join(home, ".gemini"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".gemini", "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setListSessionsFn(t, func(context.Context, string) ([]byte, error) {
+		t.Error("gemini --list-sessions run after cancellation")
+		return nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := (&geminiSource{}).List(ctx, source.ListOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("List() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestList_WithCLIFallback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(home, ".gemini"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	pj := `{"projects":{"` + project + `":"app"}}`
+	if err := os.WriteFile(filepath.Join(home, ".gemini", "projects.json"), []byte(pj), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// PATH only holds the fake binary, so the script sticks to shell builtins.
+	writeFakeGemini(t, "#!/bin/sh\necho '  1. Encrypted chat (Just now) [abcd1234-0000-1111-2222-333344445555]'\n")
+
+	s := &geminiSource{}
+	sessions, err := s.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 listed session, got %d", len(sessions))
+	}
+	if sessions[0].Preview != "Encrypted chat" || sessions[0].Project != project {
+		t.Errorf("session = %+v", sessions[0])
+	}
+	if sessions[0].Active {
+		t.Error("listed sessions have no file and cannot be active")
+	}
+
+	// Get falls back to the listing: metadata, no history.
+	sess, err := s.Get(context.Background(), "abcd1234")
+	if err != nil || sess == nil {
+		t.Fatalf("Get(listed) = %v, %v; want the listed session", sess, err)
+	}
+	if sess.Preview != "Encrypted chat" || len(sess.Messages) != 0 {
+		t.Errorf("Get(listed) = %+v, want metadata without messages", sess)
+	}
+
+	results, err := s.Search(context.Background(), parseQuery(t, "encrypted"), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("listed sessions must not be searchable, got %d results", len(results))
+	}
+}
+
+func TestList_FixedDirSkipsCLIFallback(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, "devbox", ".gemini")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeFakeGemini(t, "#!/bin/sh\necho '  1. Local chat (Just now) [abcd1234-0000-1111-2222-333344445555]'\n")
+
+	s := &geminiSource{dir: dir}
+	sessions, err := s.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("another host's root must not list local CLI sessions, got %+v", sessions)
+	}
+}
@@ -1,27 +1,374 @@
 package gemini
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/psacc/omnisess/internal/detect"
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 	"github.com/psacc/omnisess/internal/source"
 )
 
 func init() {
 	source.Register(&geminiSource{})
+	source.RegisterFactory(model.ToolGemini, func(dir string) source.Source {
+		return &geminiSource{dir: dir}
+	})
 }
 
-type geminiSource struct{}
+type geminiSource struct {
+	dir string // fixed data directory (another host's root); empty resolves via source.Root
+}
 
 func (s *geminiSource) Name() model.Tool { return model.ToolGemini }
 
-func (s *geminiSource) List(opts source.ListOptions) ([]model.Session, error) {
-	// TODO: implement — parse gemini --list-sessions output
-	return nil, nil
+// geminiDir returns the Gemini data directory: the source's fixed dir, else
+// ~/.gemini unless relocated by a --gemini-root override.
+func (s *geminiSource) geminiDir() (string, error) {
+	if s.dir != "" {
+		return s.dir, nil
+	}
+	return source.Root(model.ToolGemini)
+}
+
+// sessionRecord is a Gemini session assembled from a chat checkpoint, from
+// the user prompts in logs.json, or — metadata only — from the
+// `gemini --list-sessions` fallback.
+type sessionRecord struct {
+	ID        string
+	Project   string
+	Title     string // listing title; only set for CLI-listed sessions
+	FilePath  string // chat checkpoint or logs.json; empty for CLI-listed sessions
+	StartedAt time.Time
+	UpdatedAt time.Time
+	Model     string
+	Messages  []model.Message
+}
+
+// loadSessions walks ~/.gemini/tmp/<project>/ and returns every session found
+// in chats/*.json and logs.json, ordered by UpdatedAt descending. Sessions
+// present in both keep the richer chat checkpoint.
+func loadSessions(dir string) []sessionRecord {
+	projects := loadProjectMap(filepath.Join(dir, "projects.json"))
+
+	tmpDir := filepath.Join(dir, "tmp")
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		return nil
+	}
+
+	byID := make(map[string]*sessionRecord)
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(tmpDir, e.Name())
+		project := projects[e.Name()]
+
+		chatFiles, _ := filepath.Glob(filepath.Join(projectDir, "chats", "*.json"))
+		for _, path := range chatFiles {
+			rec, err := parseChatFile(path)
+			if err != nil {
+				log.Printf("warning: %v", err)
+				continue
+			}
+			if rec.SessionID == "" {
+				continue
+			}
+			sr := chatSessionRecord(rec, path, project, projects)
+			if prev, ok := byID[sr.ID]; ok && !sr.UpdatedAt.After(prev.UpdatedAt) {
+				continue
+			}
+			byID[sr.ID] = &sr
+		}
+
+		logsPath := filepath.Join(projectDir, "logs.json")
+		if _, err := os.Stat(logsPath); err != nil {
+			continue
+		}
+		logs, err := parseLogsFile(logsPath)
+		if err != nil {
+			log.Printf("warning: %v", err)
+			continue
+		}
+		for _, sr := range logSessionRecords(logs, logsPath, project) {
+			if _, ok := byID[sr.ID]; ok {
+				continue
+			}
+			byID[sr.ID] = &sr
+		}
+	}
+
+	records := make([]sessionRecord, 0, len(byID))
+	for _, sr := range byID {
+		records = append(records, *sr)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].UpdatedAt.After(records[j].UpdatedAt)
+	})
+	return records
 }
 
-func (s *geminiSource) Get(sessionID string) (*model.Session, error) {
-	return nil, nil
+// chatSessionRecord converts a decoded chat checkpoint into a sessionRecord.
+// The project is taken from the temp directory name when known, otherwise
+// from the record's projectHash.
+func chatSessionRecord(rec *chatRecord, path, project string, projects map[string]string) sessionRecord {
+	if project == "" {
+		project = projects[rec.ProjectHash]
+	}
+	messages, mdl := convertMessages(rec.Messages)
+
+	startedAt := parseGeminiTimestamp(rec.StartTime)
+	updatedAt := parseGeminiTimestamp(rec.LastUpdated)
+	if len(messages) > 0 {
+		if startedAt.IsZero() {
+			startedAt = messages[0].Timestamp
+		}
+		if last := messages[len(messages)-1].Timestamp; last.After(updatedAt) {
+			updatedAt = last
+		}
+	}
+	// Refine UpdatedAt from file modification time.
+	if info, err := os.Stat(path); err == nil && info.ModTime().After(updatedAt) {
+		updatedAt = info.ModTime()
+	}
+
+	return sessionRecord{
+		ID:        rec.SessionID,
+		Project:   project,
+		FilePath:  path,
+		StartedAt: startedAt,
+		UpdatedAt: updatedAt,
+		Model:     mdl,
+		Messages:  messages,
+	}
+}
+
+// logSessionRecords groups logs.json user prompts by session ID.
+func logSessionRecords(logs []logEntry, path, project string) []sessionRecord {
+	byID := make(map[string]*sessionRecord)
+	var order []string
+	for _, le := range logs {
+		if le.SessionID == "" || le.Type != "user" {
+			continue
+		}
+		ts := parseGeminiTimestamp(le.Timestamp)
+		sr, ok := byID[le.SessionID]
+		if !ok {
+			sr = &sessionRecord{
+				ID:        le.SessionID,
+				Project:   project,
+				FilePath:  path,
+				StartedAt: ts,
+				UpdatedAt: ts,
+			}
+			byID[le.SessionID] = sr
+			order = append(order, le.SessionID)
+		}
+		if ts.Before(sr.StartedAt) {
+			sr.StartedAt = ts
+		}
+		if ts.After(sr.UpdatedAt) {
+			sr.UpdatedAt = ts
+		}
+		sr.Messages = append(sr.Messages, model.Message{
+			Role:      model.RoleUser,
+			Content:   le.Message,
+			Timestamp: ts,
+		})
+	}
+
+	records := make([]sessionRecord, 0, len(order))
+	for _, id := range order {
+		records = append(records, *byID[id])
+	}
+	return records
+}
+
+// toSession builds a model.Session from a record. Messages are only attached
+// when withMessages is set (Get); List and Search leave them empty.
+func (sr *sessionRecord) toSession(withMessages bool) model.Session {
+	preview := ""
+	for _, m := range sr.Messages {
+		if m.Role == model.RoleUser && strings.TrimSpace(m.Content) != "" {
+			preview = detect.Truncate(m.Content, 120)
+			break
+		}
+	}
+	if preview == "" {
+		preview = detect.Truncate(sr.Title, 120)
+	}
+	sess := model.Session{
+		ID:        sr.ID,
+		Tool:      model.ToolGemini,
+		Project:   sr.Project,
+		Title:     preview,
+		Model:     sr.Model,
+		StartedAt: sr.StartedAt,
+		UpdatedAt: sr.UpdatedAt,
+		Preview:   preview,
+	}
+	sess.SetTiming(model.TimingOf(sr.Messages))
+	if withMessages {
+		sess.Messages = sr.Messages
+	}
+	return sess
+}
+
+// session builds the model.Session of a record and looks for its process,
+// unless the root is another host's: its processes are not visible here.
+func (s *geminiSource) session(sr *sessionRecord, withMessages bool) model.Session {
+	sess := sr.toSession(withMessages)
+	if sr.FilePath != "" && s.dir == "" {
+		detect.MarkActive(&sess, sr.FilePath, nil)
+	}
+	return sess
+}
+
+// records loads the sessions under dir for List. The `gemini
+// --list-sessions` fallback only describes this machine, so it is skipped for
+// another host's root.
+func (s *geminiSource) records(ctx context.Context, dir string) []sessionRecord {
+	if s.dir != "" {
+		return loadSessions(dir)
+	}
+	return withListedSessions(ctx, dir, loadSessions(dir))
+}
+
+// List returns Gemini sessions ordered by most recent first.
+// Messages are NOT populated.
+func (s *geminiSource) List(ctx context.Context, opts source.ListOptions) ([]model.Session, error) {
+	dir, err := s.geminiDir()
+	if err != nil {
+		return nil, fmt.Errorf("list gemini sessions: %w", err)
+	}
+
+	records := s.records(ctx, dir)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("list gemini sessions: %w", err)
+	}
+
+	var sessions []model.Session
+	for _, sr := range records {
+		sess := s.session(&sr, false)
+		if !matchesFilter(sess, opts) {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	// opts.State is applied after the merge, so a limit applied here could
+	// cut the sessions it keeps: it is left to the caller.
+	if opts.Limit > 0 && opts.State == "" && len(sessions) > opts.Limit {
+		sessions = sessions[:opts.Limit]
+	}
+
+	return sessions, nil
+}
+
+// Get returns a single Gemini session with full message history.
+// Supports exact and prefix match on sessionID. A session known only from
+// `gemini --list-sessions` is returned with metadata and no messages.
+func (s *geminiSource) Get(ctx context.Context, sessionID string) (*model.Session, error) {
+	dir, err := s.geminiDir()
+	if err != nil {
+		return nil, fmt.Errorf("get gemini session: %w", err)
+	}
+
+	records := loadSessions(dir)
+	matches, exact := matchRecords(records, sessionID)
+	if len(matches) == 0 && s.dir == "" {
+		// Running the CLI is slow, so it is only consulted on a miss.
+		matches, exact = matchRecords(withListedSessions(ctx, dir, records), sessionID)
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("get gemini session: %w", err)
+		}
+	}
+	if exact {
+		sess := s.session(&matches[0], true)
+		return &sess, nil
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		sess := s.session(&matches[0], true)
+		return &sess, nil
+	default:
+		var ids []string
+		for _, m := range matches {
+			ids = append(ids, m.ID)
+		}
+		return nil, fmt.Errorf("get gemini session: ambiguous session prefix %q, matches: %s", sessionID, strings.Join(ids, ", "))
+	}
+}
+
+// matchRecords returns the records whose ID starts with sessionID. exact
+// reports that the only one returned matches sessionID exactly.
+func matchRecords(records []sessionRecord, sessionID string) (matches []sessionRecord, exact bool) {
+	for _, sr := range records {
+		if sr.ID == sessionID {
+			return []sessionRecord{sr}, true
+		}
+		if strings.HasPrefix(sr.ID, sessionID) {
+			matches = append(matches, sr)
+		}
+	}
+	return matches, false
+}
+
+// Search returns Gemini sessions with messages matched by m. Sessions known only from
+// `gemini --list-sessions` have no content and are not searched.
+func (s *geminiSource) Search(ctx context.Context, m search.Matcher, opts source.ListOptions) ([]model.SearchResult, error) {
+	dir, err := s.geminiDir()
+	if err != nil {
+		return nil, fmt.Errorf("search gemini sessions: %w", err)
+	}
+
+	var results []model.SearchResult
+
+	for _, sr := range loadSessions(dir) {
+		if err := ctx.Err(); err != nil {
+			return results, fmt.Errorf("search gemini sessions: %w", err)
+		}
+		sess := s.session(&sr, false)
+		if !matchesFilter(sess, opts) {
+			continue
+		}
+
+		if matches := m.Matches(&sess, sr.Messages); len(matches) > 0 {
+			results = append(results, model.SearchResult{
+				Session: sess,
+				Matches: matches,
+			})
+		}
+	}
+
+	if opts.Limit > 0 && opts.State == "" && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
 }
 
-func (s *geminiSource) Search(query string, opts source.ListOptions) ([]model.SearchResult, error) {
-	return nil, nil
+// matchesFilter checks whether a session passes the list options filters.
+func matchesFilter(sess model.Session, opts source.ListOptions) bool {
+	if opts.Active && !sess.Active {
+		return false
+	}
+	if opts.Since > 0 && time.Since(sess.UpdatedAt) > opts.Since {
+		return false
+	}
+	if !opts.MatchesProject(sess.Project) {
+		return false
+	}
+	return true
 }
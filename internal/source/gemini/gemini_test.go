@@ -0,0 +1,427 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+	"github.com/psacc/omnisess/internal/source"
+)
+
+// ---------------------------------------------------------------------------
+// Name / geminiDir
+// ---------------------------------------------------------------------------
+
+func TestName(t *testing.T) {
+	s := &geminiSource{}
+	if s.Name() != model.ToolGemini {
+		t.Errorf("Name() = %q, want %q", s.Name(), model.ToolGemini)
+	}
+}
+
+func TestGeminiDir_Success(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir, err := (&geminiSource{}).geminiDir()
+	if err != nil {
+		t.Fatalf("geminiDir() error: %v", err)
+	}
+	if want := filepath.Join(home, ".gemini"); dir != want {
+		t.Errorf("geminiDir() = %q, want %q", dir, want)
+	}
+}
+
+func TestGeminiDir_FixedDir(t *testing.T) {
+	t.Setenv("HOME", "")
+	s := &geminiSource{dir: "/srv/sessions/devbox/.gemini"}
+	if dir, err := s.geminiDir(); err != nil || dir != s.dir {
+		t.Errorf("geminiDir() = %q, %v; want %q", dir, err, s.dir)
+	}
+}
+
+func TestHomeDirErrors(t *testing.T) {
+	t.Setenv("HOME", "")
+	s := &geminiSource{}
+	if _, err := s.geminiDir(); err == nil {
+		t.Error("geminiDir: expected error when HOME is empty")
+	}
+	if _, err := s.List(context.Background(), source.ListOptions{}); err == nil {
+		t.Error("List: expected error when HOME is empty")
+	}
+	if _, err := s.Get(context.Background(), "x"); err == nil {
+		t.Error("Get: expected error when HOME is empty")
+	}
+	if _, err := s.Search(context.Background(), parseQuery(t, "x"), source.ListOptions{}); err == nil {
+		t.Error("Search: expected error when HOME is empty")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// List
+// ---------------------------------------------------------------------------
+
+func TestList(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+
+	s := &geminiSource{}
+	sessions, err := s.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+
+	// One chat checkpoint + one logs-only session; the chat session's own
+	// logs.json entry is deduplicated.
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	chat := sessions[0]
+	if chat.ID != fixtureChatID {
+		t.Errorf("sessions[0].ID = %q, want %q", chat.ID, fixtureChatID)
+	}
+	if chat.Tool != model.ToolGemini {
+		t.Errorf("Tool = %q", chat.Tool)
+	}
+	if chat.Project != fixtureProject {
+		t.Errorf("Project = %q, want %q", chat.Project, fixtureProject)
+	}
+	if chat.Model != "gemini-2.5-pro" {
+		t.Errorf("Model = %q", chat.Model)
+	}
+	if chat.Preview != "explain the retry logic in client.go" {
+		t.Errorf("Preview = %q", chat.Preview)
+	}
+	if chat.Messages != nil {
+		t.Error("List must not populate Messages")
+	}
+	// Timed by its messages, not the checkpoint's startTime (10:01:11.966).
+	wantStarted := time.Date(2026, 2, 9, 10, 1, 12, 0, time.UTC)
+	if !chat.StartedAt.Equal(wantStarted) {
+		t.Errorf("StartedAt = %v, want %v", chat.StartedAt, wantStarted)
+	}
+	if chat.Duration != 2*time.Minute+48*time.Second || chat.ActiveDuration != chat.Duration {
+		t.Errorf("Duration = %v (%v active), want 2m48s", chat.Duration, chat.ActiveDuration)
+	}
+
+	logsOnly := sessions[1]
+	if logsOnly.ID != fixtureLogsOnlyID {
+		t.Errorf("sessions[1].ID = %q, want %q", logsOnly.ID, fixtureLogsOnlyID)
+	}
+	if logsOnly.Preview != "write a migration for the orders table" {
+		t.Errorf("logs-only Preview = %q", logsOnly.Preview)
+	}
+	wantUpdated := time.Date(2026, 2, 8, 9, 30, 0, 0, time.UTC)
+	if !logsOnly.UpdatedAt.Equal(wantUpdated) {
+		t.Errorf("logs-only UpdatedAt = %v, want %v", logsOnly.UpdatedAt, wantUpdated)
+	}
+
+	t.Run("Limit", func(t *testing.T) {
+		got, _ := s.List(context.Background(), source.ListOptions{Limit: 1})
+		if len(got) != 1 {
+			t.Errorf("expected 1 session with Limit=1, got %d", len(got))
+		}
+	})
+
+	t.Run("Limit is left to the caller with State", func(t *testing.T) {
+		got, _ := s.List(context.Background(), source.ListOptions{Limit: 1, State: model.StateIdle})
+		if len(got) != 2 {
+			t.Errorf("expected 2 sessions with Limit=1 and State, got %d", len(got))
+		}
+	})
+
+	t.Run("Since excludes old sessions", func(t *testing.T) {
+		got, _ := s.List(context.Background(), source.ListOptions{Since: time.Hour})
+		if len(got) != 0 {
+			t.Errorf("expected 0 sessions, got %d", len(got))
+		}
+	})
+
+	t.Run("Project filter is case-insensitive", func(t *testing.T) {
+		got, _ := s.List(context.Background(), source.ListOptions{Project: "MyProject"})
+		if len(got) != 2 {
+			t.Errorf("expected 2 sessions, got %d", len(got))
+		}
+		got, _ = s.List(context.Background(), source.ListOptions{Project: "nope"})
+		if len(got) != 0 {
+			t.Errorf("expected 0 sessions, got %d", len(got))
+		}
+	})
+
+	t.Run("Active filter", func(t *testing.T) {
+		got, _ := s.List(context.Background(), source.ListOptions{Active: true})
+		if len(got) != 0 {
+			t.Errorf("expected 0 active sessions (old mtimes), got %d", len(got))
+		}
+	})
+}
+
+func TestList_NoGeminiDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sessions, err := (&geminiSource{}).List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if sessions != nil {
+		t.Errorf("expected nil sessions, got %v", sessions)
+	}
+}
+
+// TestLoadSessions_EdgeCases covers the short-name project directory, the
+// projectHash fallback, malformed files, empty IDs, and a newer duplicate
+// chat file replacing an older one.
+func TestLoadSessions_EdgeCases(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "projects.json"),
+		[]byte(`{"projects":{"/work/named":"named","/work/hashed":"hashed"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, "tmp")
+	write := func(rel, content string, mtime time.Time) {
+		t.Helper()
+		p := filepath.Join(tmp, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Directory named by short name; a stray file at tmp/ level is ignored.
+	write("stray.txt", "x", old)
+	write("named/chats/session-a.json",
+		`{"sessionId":"aaaa0001","lastUpdated":"2026-01-01T00:00:00Z","messages":[{"type":"user","timestamp":"2026-01-01T00:00:00Z","content":"first"}]}`, old)
+	write("named/chats/session-b.json",
+		`{"sessionId":"aaaa0001","lastUpdated":"2026-01-02T00:00:00Z","messages":[{"type":"user","timestamp":"2026-01-01T00:00:00Z","content":"resumed"}]}`, old)
+	write("named/chats/session-c.json", `{bad`, old)
+	write("named/chats/session-z.json",
+		`{"sessionId":"aaaa0001","lastUpdated":"2025-12-31T00:00:00Z","messages":[{"type":"user","content":"stale copy"}]}`, old.Add(-48*time.Hour))
+	write("named/chats/session-d.json", `{"sessionId":""}`, old)
+	write("named/logs.json", `not json`, old)
+
+	// Unknown directory name: project resolved through the record's projectHash.
+	write("unknown/chats/session-e.json",
+		`{"sessionId":"bbbb0001","projectHash":"`+projectHash("/work/hashed")+`","messages":[]}`, old)
+
+	records := loadSessions(dir)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	byID := map[string]sessionRecord{}
+	for _, r := range records {
+		byID[r.ID] = r
+	}
+	if r := byID["aaaa0001"]; r.Project != "/work/named" || r.Messages[0].Content != "resumed" {
+		t.Errorf("aaaa0001 = %+v, want newer chat in /work/named", r)
+	}
+	if r := byID["bbbb0001"]; r.Project != "/work/hashed" {
+		t.Errorf("bbbb0001 project = %q, want /work/hashed", r.Project)
+	}
+	// No timestamps in the record: falls back to file mtime.
+	if r := byID["bbbb0001"]; !r.UpdatedAt.Equal(old) || !r.StartedAt.IsZero() {
+		t.Errorf("bbbb0001 times = %v / %v", r.StartedAt, r.UpdatedAt)
+	}
+}
+
+func TestChatSessionRecord_TimesFromMessages(t *testing.T) {
+	rec := &chatRecord{
+		SessionID:   "cccc0001",
+		LastUpdated: "2026-01-01T00:00:00Z",
+		Messages: []chatMessage{
+			{Type: "user", Timestamp: "2026-01-01T00:00:00Z"},
+			{Type: "gemini", Timestamp: "2026-01-01T01:00:00Z"},
+		},
+	}
+	sr := chatSessionRecord(rec, filepath.Join(t.TempDir(), "missing.json"), "", nil)
+	if want := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC); !sr.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want first message time", sr.StartedAt)
+	}
+	if want := time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC); !sr.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want last message time", sr.UpdatedAt)
+	}
+}
+
+func TestLogSessionRecords(t *testing.T) {
+	logs := []logEntry{
+		{SessionID: "s1", Type: "user", Message: "second", Timestamp: "2026-01-01T01:00:00Z"},
+		{SessionID: "s1", Type: "user", Message: "first", Timestamp: "2026-01-01T00:00:00Z"},
+		{SessionID: "s1", Type: "gemini", Message: "ignored"},
+		{SessionID: "", Type: "user", Message: "no id"},
+	}
+	records := logSessionRecords(logs, "/x/logs.json", "/x")
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(records))
+	}
+	r := records[0]
+	if len(r.Messages) != 2 {
+		t.Errorf("expected 2 messages, got %d", len(r.Messages))
+	}
+	if r.StartedAt.Hour() != 0 || r.UpdatedAt.Hour() != 1 {
+		t.Errorf("StartedAt/UpdatedAt = %v / %v", r.StartedAt, r.UpdatedAt)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Get
+// ---------------------------------------------------------------------------
+
+func TestGet(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	s := &geminiSource{}
+
+	t.Run("exact ID returns messages", func(t *testing.T) {
+		sess, err := s.Get(context.Background(), fixtureChatID)
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		if sess == nil {
+			t.Fatal("expected session, got nil")
+		}
+		if len(sess.Messages) != 5 {
+			t.Errorf("expected 5 messages, got %d", len(sess.Messages))
+		}
+		if sess.Project != fixtureProject {
+			t.Errorf("Project = %q", sess.Project)
+		}
+	})
+
+	t.Run("prefix match", func(t *testing.T) {
+		sess, err := s.Get(context.Background(), fixtureLogsOnlyID[:8])
+		if err != nil {
+			t.Fatalf("Get() error: %v", err)
+		}
+		if sess == nil || sess.ID != fixtureLogsOnlyID {
+			t.Fatalf("expected %s, got %+v", fixtureLogsOnlyID, sess)
+		}
+		if len(sess.Messages) != 2 {
+			t.Errorf("expected 2 user messages from logs, got %d", len(sess.Messages))
+		}
+	})
+
+	t.Run("unknown ID returns nil nil", func(t *testing.T) {
+		sess, err := s.Get(context.Background(), "00000000")
+		if err != nil || sess != nil {
+			t.Errorf("Get(unknown) = %v, %v; want nil, nil", sess, err)
+		}
+	})
+
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		_, err := s.Get(context.Background(), "")
+		if err == nil || !strings.Contains(err.Error(), "ambiguous") {
+			t.Errorf("expected ambiguous error, got %v", err)
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Search
+// ---------------------------------------------------------------------------
+
+func TestSearch(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", home)
+	s := &geminiSource{}
+
+	t.Run("case-insensitive hit", func(t *testing.T) {
+		results, err := s.Search(context.Background(), parseQuery(t, "EXPONENTIAL BACKOFF"), source.ListOptions{})
+		if err != nil {
+			t.Fatalf("Search() error: %v", err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(results))
+		}
+		r := results[0]
+		if r.Session.ID != fixtureChatID {
+			t.Errorf("result session = %q", r.Session.ID)
+		}
+		if len(r.Matches) != 2 {
+			t.Errorf("expected 2 matches (user + assistant), got %d", len(r.Matches))
+		}
+		if r.Session.Messages != nil {
+			t.Error("search results must not carry full messages")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := s.Search(ctx, parseQuery(t, "backoff"), source.ListOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Search() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		results, _ := s.Search(context.Background(), parseQuery(t, "kubernetes"), source.ListOptions{})
+		if len(results) != 0 {
+			t.Errorf("expected 0 results, got %d", len(results))
+		}
+	})
+
+	t.Run("filters and limit apply", func(t *testing.T) {
+		results, _ := s.Search(context.Background(), parseQuery(t, "the"), source.ListOptions{Limit: 1})
+		if len(results) != 1 {
+			t.Errorf("expected 1 result with Limit=1, got %d", len(results))
+		}
+		results, _ = s.Search(context.Background(), parseQuery(t, "the"), source.ListOptions{Project: "nope"})
+		if len(results) != 0 {
+			t.Errorf("expected 0 results with project filter, got %d", len(results))
+		}
+	})
+}
+
+// ---------------------------------------------------------------------------
+// Another host's root (source.AddHost)
+// ---------------------------------------------------------------------------
+
+func TestAddHost_ReadsFixedRoot(t *testing.T) {
+	home, _ := setupFakeHome(t)
+	t.Setenv("HOME", t.TempDir()) // the local root is empty
+	if err := source.AddHost("devbox", model.ToolGemini, filepath.Join(home, ".gemini")); err != nil {
+		t.Fatalf("AddHost() error: %v", err)
+	}
+	t.Cleanup(source.ClearHosts)
+
+	var remote source.Source
+	for _, s := range source.ByName(model.ToolGemini) {
+		if source.HostOf(s) == "devbox" {
+			remote = s
+		}
+	}
+	if remote == nil {
+		t.Fatal("no source registered for host devbox")
+	}
+	sessions, err := remote.List(context.Background(), source.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(sessions) == 0 {
+		t.Fatal("expected sessions from the devbox root")
+	}
+	for _, s := range sessions {
+		if s.Host != "devbox" {
+			t.Errorf("session %s Host = %q, want devbox", s.ID, s.Host)
+		}
+	}
+}
+
+// parseQuery parses a search query, failing the test on syntax errors.
+func parseQuery(t *testing.T, s string) *search.Query {
+	t.Helper()
+	q, err := search.Parse(s)
+	if err != nil {
+		t.Fatalf("search.Parse(%q): %v", s, err)
+	}
+	return q
+}
@@ -0,0 +1,224 @@
+package gemini
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// chatRecord is the top-level object of a Gemini CLI chat checkpoint:
+// ~/.gemini/tmp/<project>/chats/session-<datetime>-<short-id>.json.
+type chatRecord struct {
+	SessionID   string        `json:"sessionId"`
+	ProjectHash string        `json:"projectHash"`
+	StartTime   string        `json:"startTime"`   // ISO 8601
+	LastUpdated string        `json:"lastUpdated"` // ISO 8601
+	Messages    []chatMessage `json:"messages"`
+}
+
+// chatMessage is a single entry of a chat checkpoint's messages array.
+type chatMessage struct {
+	ID        string          `json:"id"`
+	Timestamp string          `json:"timestamp"` // ISO 8601
+	Type      string          `json:"type"`      // "user", "gemini", "info", "error", ...
+	Content   json.RawMessage `json:"content"`   // string or array of parts
+	ToolCalls []chatToolCall  `json:"toolCalls"`
+	Model     string          `json:"model"`
+}
+
+// chatToolCall is a tool invocation recorded on a "gemini" message.
+type chatToolCall struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Args          json.RawMessage `json:"args"`
+	Result        json.RawMessage `json:"result"`
+	ResultDisplay json.RawMessage `json:"resultDisplay"` // string or structured diff
+	Status        string          `json:"status"`
+}
+
+// logEntry is a single element of ~/.gemini/tmp/<project>/logs.json.
+// The log only records user prompts, but it covers sessions for which no
+// chat checkpoint was written.
+type logEntry struct {
+	SessionID string `json:"sessionId"`
+	MessageID int    `json:"messageId"`
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"` // ISO 8601
+}
+
+// projectsFile is the layout of ~/.gemini/projects.json: absolute project
+// path → short project name.
+type projectsFile struct {
+	Projects map[string]string `json:"projects"`
+}
+
+// parseChatFile reads and decodes a chat checkpoint file.
+func parseChatFile(path string) (*chatRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gemini chat file %s: %w", path, err)
+	}
+	var rec chatRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("parse gemini chat file %s: %w", path, err)
+	}
+	return &rec, nil
+}
+
+// parseLogsFile reads and decodes a logs.json file.
+func parseLogsFile(path string) ([]logEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gemini logs %s: %w", path, err)
+	}
+	var entries []logEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse gemini logs %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// loadProjectMap reads ~/.gemini/projects.json and returns a lookup from both
+// the project short name and the SHA-256 hash of the project path (the two
+// naming schemes Gemini CLI has used for ~/.gemini/tmp/<project>/) to the
+// absolute project path. A missing or malformed file yields an empty map.
+func loadProjectMap(path string) map[string]string {
+	lookup := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lookup
+	}
+	var pf projectsFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return lookup
+	}
+	for projectPath, name := range pf.Projects {
+		lookup[projectHash(projectPath)] = projectPath
+		if name != "" {
+			lookup[name] = projectPath
+		}
+	}
+	return lookup
+}
+
+// projectHash returns the hex SHA-256 of a project path, as used by Gemini CLI
+// for per-project temp directories and the chat record's projectHash field.
+func projectHash(projectPath string) string {
+	sum := sha256.Sum256([]byte(projectPath))
+	return hex.EncodeToString(sum[:])
+}
+
+// convertMessages maps chat checkpoint messages to model.Messages and returns
+// the first model name reported by an assistant message.
+func convertMessages(msgs []chatMessage) ([]model.Message, string) {
+	var messages []model.Message
+	var sessionModel string
+	for _, cm := range msgs {
+		role := mapMessageType(cm.Type)
+		if role == "" {
+			continue
+		}
+		if role == model.RoleAssistant && sessionModel == "" && cm.Model != "" {
+			sessionModel = cm.Model
+		}
+		msg := model.Message{
+			Role:      role,
+			Content:   extractContent(cm.Content),
+			Timestamp: parseGeminiTimestamp(cm.Timestamp),
+		}
+		for _, tc := range cm.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, model.ToolCall{
+				Name:   tc.Name,
+				Input:  model.TruncateToolText(compactJSON(tc.Args)),
+				Output: model.TruncateToolText(toolCallOutput(tc)),
+			})
+		}
+		messages = append(messages, msg)
+	}
+	return messages, sessionModel
+}
+
+// mapMessageType maps a chat message type to a model.Role.
+// "user" → RoleUser, "gemini" → RoleAssistant, "info"/"error"/"warning" →
+// RoleSystem, others → "".
+func mapMessageType(t string) model.Role {
+	switch t {
+	case "user":
+		return model.RoleUser
+	case "gemini":
+		return model.RoleAssistant
+	case "info", "error", "warning":
+		return model.RoleSystem
+	default:
+		return ""
+	}
+}
+
+// extractContent handles both string content and array-of-parts content.
+// Returns concatenated text from all parts that carry text.
+func extractContent(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// toolCallOutput prefers the human-readable resultDisplay string and falls
+// back to the raw function response.
+func toolCallOutput(tc chatToolCall) string {
+	var display string
+	if err := json.Unmarshal(tc.ResultDisplay, &display); err == nil && display != "" {
+		return display
+	}
+	return compactJSON(tc.Result)
+}
+
+// compactJSON returns raw JSON without insignificant whitespace, or "" for
+// empty/null input.
+func compactJSON(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// parseGeminiTimestamp parses an ISO 8601 timestamp string from a Gemini file.
+func parseGeminiTimestamp(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	// RFC3339 parsing also accepts fractional seconds.
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
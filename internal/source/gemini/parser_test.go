@@ -0,0 +1,304 @@
+package gemini
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Fixture constants
+// ---------------------------------------------------------------------------
+
+const (
+	fixtureChatID     = "9f8e7d6c-1234-5678-9abc-def012345678"
+	fixtureLogsOnlyID = "11223344-aaaa-bbbb-cccc-ddddeeeeffff"
+	fixtureProject    = "/Users/testuser/prj/myproject"
+	fixtureChatFile   = "testdata/chat_session.json"
+	fixtureLogsFile   = "testdata/logs.json"
+)
+
+// ---------------------------------------------------------------------------
+// setupFakeHome builds a minimal ~/.gemini layout in a temp dir and returns
+// the home directory path and the chat file path it created.
+//
+// Layout:
+//
+//	<home>/.gemini/projects.json                      (copy of testdata/projects.json)
+//	<home>/.gemini/tmp/<sha256(project)>/logs.json    (copy of testdata/logs.json)
+//	<home>/.gemini/tmp/<sha256(project)>/chats/
+//	    session-2026-02-09T10-01-9f8e7d6c.json        (copy of testdata/chat_session.json)
+//
+// ---------------------------------------------------------------------------
+func setupFakeHome(t *testing.T) (homeDir, chatPath string) {
+	t.Helper()
+	home := t.TempDir()
+
+	projectDir := filepath.Join(home, ".gemini", "tmp", projectHash(fixtureProject))
+	chatsDir := filepath.Join(projectDir, "chats")
+	if err := os.MkdirAll(chatsDir, 0o755); err != nil {
+		t.Fatalf("create chats dir: %v", err)
+	}
+
+	copyFixture(t, "testdata/projects.json", filepath.Join(home, ".gemini", "projects.json"))
+	copyFixture(t, fixtureLogsFile, filepath.Join(projectDir, "logs.json"))
+	chatPath = filepath.Join(chatsDir, "session-2026-02-09T10-01-9f8e7d6c.json")
+	copyFixture(t, fixtureChatFile, chatPath)
+
+	// Pin mtimes to the fixture's lastUpdated so ordering is deterministic.
+	old := time.Date(2026, 2, 9, 10, 5, 0, 0, time.UTC)
+	for _, p := range []string{chatPath, filepath.Join(projectDir, "logs.json")} {
+		if err := os.Chtimes(p, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return home, chatPath
+}
+
+func copyFixture(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("read %s: %v", src, err)
+	}
+	if err := os.WriteFile(dst, data, 0o644); err != nil {
+		t.Fatalf("write %s: %v", dst, err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// parseChatFile / convertMessages
+// ---------------------------------------------------------------------------
+
+func TestParseChatFile(t *testing.T) {
+	rec, err := parseChatFile(fixtureChatFile)
+	if err != nil {
+		t.Fatalf("parseChatFile() error: %v", err)
+	}
+	if rec.SessionID != fixtureChatID {
+		t.Errorf("SessionID = %q, want %q", rec.SessionID, fixtureChatID)
+	}
+
+	msgs, mdl := convertMessages(rec.Messages)
+
+	t.Run("model from first assistant message", func(t *testing.T) {
+		if mdl != "gemini-2.5-pro" {
+			t.Errorf("model = %q, want gemini-2.5-pro", mdl)
+		}
+	})
+
+	t.Run("unknown types skipped and roles mapped", func(t *testing.T) {
+		wantRoles := []model.Role{model.RoleUser, model.RoleAssistant, model.RoleSystem, model.RoleUser, model.RoleAssistant}
+		if len(msgs) != len(wantRoles) {
+			t.Fatalf("expected %d messages, got %d", len(wantRoles), len(msgs))
+		}
+		for i, want := range wantRoles {
+			if msgs[i].Role != want {
+				t.Errorf("msgs[%d].Role = %q, want %q", i, msgs[i].Role, want)
+			}
+		}
+	})
+
+	t.Run("array content joined, non-text parts ignored", func(t *testing.T) {
+		if msgs[3].Content != "now add exponential backoff" {
+			t.Errorf("msgs[3].Content = %q", msgs[3].Content)
+		}
+	})
+
+	t.Run("tool calls with input and output", func(t *testing.T) {
+		calls := msgs[1].ToolCalls
+		if len(calls) != 2 {
+			t.Fatalf("expected 2 tool calls, got %d", len(calls))
+		}
+		if calls[0].Name != "read_file" {
+			t.Errorf("calls[0].Name = %q", calls[0].Name)
+		}
+		if !strings.Contains(calls[0].Input, "client.go") {
+			t.Errorf("calls[0].Input = %q, want args JSON", calls[0].Input)
+		}
+		// Empty resultDisplay falls back to the raw function response.
+		if !strings.Contains(calls[0].Output, "package client") {
+			t.Errorf("calls[0].Output = %q, want raw result", calls[0].Output)
+		}
+		if calls[1].Output != "ok  \tclient\t0.01s" {
+			t.Errorf("calls[1].Output = %q, want resultDisplay", calls[1].Output)
+		}
+	})
+
+	t.Run("timestamps parsed", func(t *testing.T) {
+		want := time.Date(2026, 2, 9, 10, 1, 12, 0, time.UTC)
+		if !msgs[0].Timestamp.Equal(want) {
+			t.Errorf("msgs[0].Timestamp = %v, want %v", msgs[0].Timestamp, want)
+		}
+	})
+}
+
+func TestParseChatFile_Errors(t *testing.T) {
+	if _, err := parseChatFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte(`{bad`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseChatFile(bad); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestParseLogsFile(t *testing.T) {
+	entries, err := parseLogsFile(fixtureLogsFile)
+	if err != nil {
+		t.Fatalf("parseLogsFile() error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(entries))
+	}
+
+	if _, err := parseLogsFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"not":"an array"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseLogsFile(bad); err == nil {
+		t.Error("expected error for non-array logs")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// loadProjectMap / projectHash
+// ---------------------------------------------------------------------------
+
+func TestLoadProjectMap(t *testing.T) {
+	m := loadProjectMap("testdata/projects.json")
+	if got := m["myproject"]; got != fixtureProject {
+		t.Errorf("by name = %q, want %q", got, fixtureProject)
+	}
+	if got := m[projectHash(fixtureProject)]; got != fixtureProject {
+		t.Errorf("by hash = %q, want %q", got, fixtureProject)
+	}
+
+	if m := loadProjectMap(filepath.Join(t.TempDir(), "missing.json")); len(m) != 0 {
+		t.Errorf("missing file: expected empty map, got %v", m)
+	}
+
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`[]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m := loadProjectMap(bad); len(m) != 0 {
+		t.Errorf("malformed file: expected empty map, got %v", m)
+	}
+
+	// A project without a short name is still resolvable by hash.
+	noName := filepath.Join(dir, "noname.json")
+	if err := os.WriteFile(noName, []byte(`{"projects":{"/tmp/x":""}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m = loadProjectMap(noName)
+	if len(m) != 1 || m[projectHash("/tmp/x")] != "/tmp/x" {
+		t.Errorf("no-name project: got %v", m)
+	}
+}
+
+func TestProjectHash(t *testing.T) {
+	// sha256("/tmp") — pinned so a change in the hashing scheme is caught.
+	want := "e9671acd244849c57167c658fa2f969752048f7ab184a3dcf5c46cb4d56ae124"
+	if got := projectHash("/tmp"); got != want {
+		t.Errorf("projectHash(/tmp) = %q, want %q", got, want)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// extractContent / toolCallOutput / compactJSON
+// ---------------------------------------------------------------------------
+
+func TestExtractContent(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"empty", ``, ""},
+		{"string", `"hello"`, "hello"},
+		{"parts", `[{"text":"a"},{"text":""},{"text":"b"}]`, "a\nb"},
+		{"object is not content", `{"text":"x"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractContent(json.RawMessage(tt.raw)); got != tt.want {
+				t.Errorf("extractContent(%s) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolCallOutput(t *testing.T) {
+	tc := chatToolCall{
+		ResultDisplay: json.RawMessage(`{"fileDiff":"..."}`),
+		Result:        json.RawMessage(`[ {"output": "x"} ]`),
+	}
+	if got := toolCallOutput(tc); got != `[{"output":"x"}]` {
+		t.Errorf("structured resultDisplay: got %q, want compact result", got)
+	}
+}
+
+func TestCompactJSON(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{``, ""},
+		{`null`, ""},
+		{`{ "a" : 1 }`, `{"a":1}`},
+		{`{bad`, `{bad`},
+	}
+	for _, tt := range tests {
+		if got := compactJSON(json.RawMessage(tt.raw)); got != tt.want {
+			t.Errorf("compactJSON(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// mapMessageType / parseGeminiTimestamp
+// ---------------------------------------------------------------------------
+
+func TestMapMessageType(t *testing.T) {
+	tests := map[string]model.Role{
+		"user":    model.RoleUser,
+		"gemini":  model.RoleAssistant,
+		"info":    model.RoleSystem,
+		"error":   model.RoleSystem,
+		"warning": model.RoleSystem,
+		"thought": "",
+		"":        "",
+	}
+	for in, want := range tests {
+		if got := mapMessageType(in); got != want {
+			t.Errorf("mapMessageType(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseGeminiTimestamp(t *testing.T) {
+	if !parseGeminiTimestamp("").IsZero() {
+		t.Error("empty string should give zero time")
+	}
+	if !parseGeminiTimestamp("not-a-time").IsZero() {
+		t.Error("invalid string should give zero time")
+	}
+	want := time.Date(2026, 2, 9, 10, 1, 11, 966000000, time.UTC)
+	if got := parseGeminiTimestamp("2026-02-09T10:01:11.966Z"); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,213 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+// LocalHost is the name accepted by host filters for the local machine.
+// Sessions from the local roots have an empty Session.Host.
+const LocalHost = "local"
+
+// Factory builds a Source that reads a fixed data directory instead of
+// resolving it with Root. Sources register one via RegisterFactory so that
+// extra roots (synced from other machines) can be added at runtime.
+type Factory func(dir string) Source
+
+var factories = map[model.Tool]Factory{}
+
+// RegisterFactory records how to build a tool's Source for an arbitrary
+// data directory. Called from each source's init() function.
+func RegisterFactory(tool model.Tool, f Factory) {
+	factories[tool] = f
+}
+
+// hostSource wraps a Source reading another machine's data directory. It
+// labels every session with the host and disables active detection, since
+// the processes of a remote machine are not visible locally.
+type hostSource struct {
+	Source
+	host string
+	dir  string // the data directory read
+}
+
+// AddHost registers an extra root for tool, read from dir and labeled with
+// host. Adding the same host and tool again replaces the previous root.
+// A leading "~/" in dir is expanded.
+func AddHost(host string, tool model.Tool, dir string) error {
+	if host == "" || host == LocalHost {
+		return fmt.Errorf("invalid host name %q", host)
+	}
+	f, ok := factories[tool]
+	if !ok {
+		return fmt.Errorf("unknown tool %q for host %s", tool, host)
+	}
+	dir, err := expandHome(dir)
+	if err != nil {
+		return err
+	}
+	hs := &hostSource{Source: f(dir), host: host, dir: dir}
+	for i, s := range registry {
+		if existing, ok := s.(*hostSource); ok && existing.host == host && existing.Name() == tool {
+			registry[i] = hs
+			return nil
+		}
+	}
+	registry = append(registry, hs)
+	return nil
+}
+
+// AddHostHome registers every tool data directory found under home, laid out
+// like a home directory (home/.claude, home/.codex, ...), labeled with host.
+// It fails when home holds none of them.
+func AddHostHome(host, home string) error {
+	home, err := expandHome(home)
+	if err != nil {
+		return err
+	}
+	tools := make([]string, 0, len(factories))
+	for tool := range factories {
+		tools = append(tools, string(tool))
+	}
+	sort.Strings(tools)
+
+	found := false
+	for _, tool := range tools {
+		dir := filepath.Join(home, "."+tool)
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		if err := AddHost(host, model.Tool(tool), dir); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("host %s: no tool data directories under %s", host, home)
+	}
+	return nil
+}
+
+// ClearHosts removes every root added with AddHost.
+func ClearHosts() {
+	kept := registry[:0:0]
+	for _, s := range registry {
+		if _, ok := s.(*hostSource); !ok {
+			kept = append(kept, s)
+		}
+	}
+	registry = kept
+}
+
+// HostOf returns the host label of a Source, or "" for local sources.
+func HostOf(s Source) string {
+	if hs, ok := s.(*hostSource); ok {
+		return hs.host
+	}
+	return ""
+}
+
+// MatchesHost reports whether a Source belongs to host. LocalHost matches
+// the local roots; an empty host matches every Source.
+func MatchesHost(s Source, host string) bool {
+	switch host {
+	case "":
+		return true
+	case LocalHost:
+		return HostOf(s) == ""
+	default:
+		return HostOf(s) == host
+	}
+}
+
+// label marks a session as the host's. Fixed roots skip active detection,
+// and whatever a source reports is overridden all the same: a remote
+// session has ended as far as this machine can tell.
+func (h *hostSource) label(sess *model.Session) {
+	sess.Host = h.host
+	sess.Active, sess.PID, sess.Confidence = false, 0, ""
+	sess.State = model.StateEnded
+}
+
+func (h *hostSource) List(ctx context.Context, opts ListOptions) ([]model.Session, error) {
+	if opts.Active {
+		return nil, nil
+	}
+	sessions, err := h.Source.List(ctx, opts)
+	for i := range sessions {
+		h.label(&sessions[i])
+	}
+	return sessions, err
+}
+
+func (h *hostSource) Get(ctx context.Context, sessionID string) (*model.Session, error) {
+	sess, err := h.Source.Get(ctx, sessionID)
+	if sess != nil {
+		h.label(sess)
+	}
+	return sess, err
+}
+
+// TranscriptPath and NewTranscript forward the Source's Follower, so that
+// another host's transcripts are read as they grow too. For a Source that is
+// not one, TranscriptPath returns "" and sessions are read again with Get.
+func (h *hostSource) TranscriptPath(ctx context.Context, sessionID string) (string, error) {
+	if f, ok := h.Source.(Follower); ok {
+		return f.TranscriptPath(ctx, sessionID)
+	}
+	return "", nil
+}
+
+func (h *hostSource) NewTranscript() Transcript {
+	return h.Source.(Follower).NewTranscript()
+}
+
+func (h *hostSource) Search(ctx context.Context, m search.Matcher, opts ListOptions) ([]model.SearchResult, error) {
+	if opts.Active {
+		return nil, nil
+	}
+	results, err := h.Source.Search(ctx, m, opts)
+	for i := range results {
+		h.label(&results[i].Session)
+	}
+	return results, err
+}
+
+func (h *hostSource) Sessions(ctx context.Context, opts ListOptions) iter.Seq2[model.Session, error] {
+	return func(yield func(model.Session, error) bool) {
+		if opts.Active {
+			return
+		}
+		for sess, err := range Sessions(ctx, h.Source, opts) {
+			if err == nil {
+				h.label(&sess)
+			}
+			if !yield(sess, err) {
+				return
+			}
+		}
+	}
+}
+
+func (h *hostSource) SearchResults(ctx context.Context, m search.Matcher, opts ListOptions) iter.Seq2[model.SearchResult, error] {
+	return func(yield func(model.SearchResult, error) bool) {
+		if opts.Active {
+			return
+		}
+		for r, err := range SearchResults(ctx, h.Source, m, opts) {
+			if err == nil {
+				h.label(&r.Session)
+			}
+			if !yield(r, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+// dirSource is a Source built by a test factory. It returns canned sessions
+// and remembers the directory it was created for.
+type dirSource struct {
+	dir string
+	err error
+}
+
+func (d *dirSource) Name() model.Tool { return "test-tool" }
+func (d *dirSource) List(_ context.Context, _ ListOptions) ([]model.Session, error) {
+	return []model.Session{{ID: "s1", Active: true, State: model.StateWorking}, {ID: "s2"}}, d.err
+}
+func (d *dirSource) Get(_ context.Context, id string) (*model.Session, error) {
+	if id == "missing" {
+		return nil, d.err
+	}
+	return &model.Session{ID: id, Active: true, PID: 4242, Confidence: model.ConfidenceExact, State: model.StateWaiting}, nil
+}
+func (d *dirSource) Search(_ context.Context, _ search.Matcher, _ ListOptions) ([]model.SearchResult, error) {
+	return []model.SearchResult{{Session: model.Session{ID: "s1", Active: true}}}, d.err
+}
+
+// withTestFactory isolates the registry and factories and registers a
+// factory for "test-tool" building dirSource values.
+func withTestFactory(t *testing.T) {
+	t.Helper()
+	origRegistry, origFactories := registry, factories
+	t.Cleanup(func() { registry, factories = origRegistry, origFactories })
+	registry = []Source{&mockSource{name: "test-tool"}}
+	factories = map[model.Tool]Factory{}
+	RegisterFactory("test-tool", func(dir string) Source { return &dirSource{dir: dir} })
+}
+
+// ---------------------------------------------------------------------------
+// AddHost / ClearHosts
+// ---------------------------------------------------------------------------
+
+func TestAddHost(t *testing.T) {
+	withTestFactory(t)
+
+	if err := AddHost("devbox", "test-tool", "/srv/devbox"); err != nil {
+		t.Fatalf("AddHost() error: %v", err)
+	}
+	if len(registry) != 2 {
+		t.Fatalf("registry has %d sources, want 2", len(registry))
+	}
+	hs, ok := registry[1].(*hostSource)
+	if !ok {
+		t.Fatalf("registry[1] = %T, want *hostSource", registry[1])
+	}
+	if hs.host != "devbox" || hs.Source.(*dirSource).dir != "/srv/devbox" {
+		t.Errorf("hostSource = %+v", hs)
+	}
+	if hs.Name() != "test-tool" {
+		t.Errorf("Name() = %q, want test-tool", hs.Name())
+	}
+
+	// Same host and tool replaces the root instead of adding another.
+	if err := AddHost("devbox", "test-tool", "/srv/devbox2"); err != nil {
+		t.Fatalf("AddHost() replace error: %v", err)
+	}
+	if len(registry) != 2 {
+		t.Fatalf("registry has %d sources after replace, want 2", len(registry))
+	}
+	if got := registry[1].(*hostSource).Source.(*dirSource).dir; got != "/srv/devbox2" {
+		t.Errorf("replaced dir = %q, want /srv/devbox2", got)
+	}
+
+	if err := AddHost("laptop", "test-tool", "/srv/laptop"); err != nil {
+		t.Fatalf("AddHost() second host error: %v", err)
+	}
+	if len(registry) != 3 {
+		t.Fatalf("registry has %d sources, want 3", len(registry))
+	}
+
+	ClearHosts()
+	if len(registry) != 1 || HostOf(registry[0]) != "" {
+		t.Errorf("after ClearHosts registry = %v, want only the local source", registry)
+	}
+}
+
+func TestAddHost_ExpandsHome(t *testing.T) {
+	withTestFactory(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := AddHost("devbox", "test-tool", "~/sync/devbox"); err != nil {
+		t.Fatalf("AddHost() error: %v", err)
+	}
+	got := registry[1].(*hostSource).Source.(*dirSource).dir
+	if want := filepath.Join(home, "sync", "devbox"); got != want {
+		t.Errorf("dir = %q, want %q", got, want)
+	}
+}
+
+func TestAddHost_Errors(t *testing.T) {
+	withTestFactory(t)
+
+	tests := []struct {
+		name string
+		host string
+		tool model.Tool
+		dir  string
+		home string
+	}{
+		{"empty host", "", "test-tool", "/srv", "/home"},
+		{"local host", LocalHost, "test-tool", "/srv", "/home"},
+		{"unknown tool", "devbox", "nope", "/srv", "/home"},
+		{"home error", "devbox", "test-tool", "~/srv", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", tt.home)
+			if err := AddHost(tt.host, tt.tool, tt.dir); err == nil {
+				t.Error("expected error, got nil")
+			}
+			if len(registry) != 1 {
+				t.Errorf("registry has %d sources, want 1", len(registry))
+			}
+		})
+	}
+}
+
+func TestAddHostHome(t *testing.T) {
+	withTestFactory(t)
+	RegisterFactory("other-tool", func(dir string) Source { return &dirSource{dir: dir} })
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, ".test-tool"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// A plain file named like a tool dir is ignored.
+	if err := os.WriteFile(filepath.Join(home, ".other-tool"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddHostHome("devbox", home); err != nil {
+		t.Fatalf("AddHostHome() error: %v", err)
+	}
+	if len(registry) != 2 {
+		t.Fatalf("registry has %d sources, want 2", len(registry))
+	}
+	hs := registry[1].(*hostSource)
+	if want := filepath.Join(home, ".test-tool"); hs.host != "devbox" || hs.Source.(*dirSource).dir != want {
+		t.Errorf("hostSource host=%q dir=%q, want devbox %q", hs.host, hs.Source.(*dirSource).dir, want)
+	}
+}
+
+func TestAddHostHome_Errors(t *testing.T) {
+	withTestFactory(t)
+	home := t.TempDir()
+
+	t.Run("no tool dirs", func(t *testing.T) {
+		if err := AddHostHome("devbox", home); err == nil {
+			t.Error("expected error for a home without tool dirs")
+		}
+	})
+	t.Run("invalid host", func(t *testing.T) {
+		if err := os.Mkdir(filepath.Join(home, ".test-tool"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := AddHostHome(LocalHost, home); err == nil {
+			t.Error("expected error for the reserved local host name")
+		}
+	})
+	t.Run("home error", func(t *testing.T) {
+		t.Setenv("HOME", "")
+		if err := AddHostHome("devbox", "~/sync"); err == nil {
+			t.Error("expected error when HOME is empty")
+		}
+	})
+	if len(registry) != 1 {
+		t.Errorf("registry has %d sources, want 1", len(registry))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HostOf / MatchesHost
+// ---------------------------------------------------------------------------
+
+func TestMatchesHost(t *testing.T) {
+	local := &mockSource{name: "test-tool"}
+	remote := &hostSource{Source: local, host: "devbox"}
+
+	if HostOf(local) != "" || HostOf(remote) != "devbox" {
+		t.Errorf("HostOf() = %q, %q", HostOf(local), HostOf(remote))
+	}
+
+	tests := []struct {
+		host       string
+		wantLocal  bool
+		wantRemote bool
+	}{
+		{"", true, true},
+		{LocalHost, true, false},
+		{"devbox", false, true},
+		{"laptop", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := MatchesHost(local, tt.host); got != tt.wantLocal {
+				t.Errorf("MatchesHost(local, %q) = %v, want %v", tt.host, got, tt.wantLocal)
+			}
+			if got := MatchesHost(remote, tt.host); got != tt.wantRemote {
+				t.Errorf("MatchesHost(remote, %q) = %v, want %v", tt.host, got, tt.wantRemote)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// hostSource labeling
+// ---------------------------------------------------------------------------
+
+func TestHostSource_LabelsSessions(t *testing.T) {
+	errBoom := errors.New("boom")
+	hs := &hostSource{Source: &dirSource{err: errBoom}, host: "devbox"}
+
+	sessions, err := hs.List(context.Background(), ListOptions{})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("List() error = %v, want %v", err, errBoom)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("List() returned %d sessions, want 2", len(sessions))
+	}
+	for _, s := range sessions {
+		if s.Host != "devbox" || s.Active || s.State != model.StateEnded {
+			t.Errorf("session %s: Host=%q Active=%v State=%q, want devbox/false/ended", s.ID, s.Host, s.Active, s.State)
+		}
+	}
+
+	sess, err := hs.Get(context.Background(), "s1")
+	if err != nil || sess == nil {
+		t.Fatalf("Get() = %v, %v", sess, err)
+	}
+	if sess.Host != "devbox" || sess.Active || sess.PID != 0 || sess.Confidence != "" || sess.State != model.StateEnded {
+		t.Errorf("Get(): Host=%q Active=%v PID=%d State=%q, want devbox/false/0/ended", sess.Host, sess.Active, sess.PID, sess.State)
+	}
+	if sess, err := hs.Get(context.Background(), "missing"); sess != nil || !errors.Is(err, errBoom) {
+		t.Errorf("Get(missing) = %v, %v; want nil, %v", sess, err, errBoom)
+	}
+
+	results, err := hs.Search(context.Background(), nil, ListOptions{})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("Search() error = %v, want %v", err, errBoom)
+	}
+	if len(results) != 1 || results[0].Session.Host != "devbox" || results[0].Session.Active {
+		t.Errorf("Search() = %+v", results)
+	}
+
+	streamed, err := Collect(hs.Sessions(context.Background(), ListOptions{}), 0)
+	if !errors.Is(err, errBoom) || len(streamed) != 2 || streamed[1].Host != "devbox" {
+		t.Errorf("Sessions() = %+v, %v; want 2 labeled sessions, %v", streamed, err, errBoom)
+	}
+	if first, err := Collect(hs.Sessions(context.Background(), ListOptions{}), 1); err != nil || len(first) != 1 {
+		t.Errorf("Sessions() stopped after 1 = %d sessions, %v", len(first), err)
+	}
+	found, err := Collect(hs.SearchResults(context.Background(), nil, ListOptions{}), 0)
+	if !errors.Is(err, errBoom) || len(found) != 1 || found[0].Session.Host != "devbox" {
+		t.Errorf("SearchResults() = %+v, %v; want 1 labeled result, %v", found, err, errBoom)
+	}
+	if first, err := Collect(hs.SearchResults(context.Background(), nil, ListOptions{}), 1); err != nil || len(first) != 1 {
+		t.Errorf("SearchResults() stopped after 1 = %d results, %v", len(first), err)
+	}
+}
+
+func TestHostSource_ActiveDisabled(t *testing.T) {
+	hs := &hostSource{Source: &dirSource{}, host: "devbox"}
+
+	sessions, err := hs.List(context.Background(), ListOptions{Active: true})
+	if err != nil || sessions != nil {
+		t.Errorf("List(Active) = %v, %v; want nil, nil", sessions, err)
+	}
+	results, err := hs.Search(context.Background(), nil, ListOptions{Active: true})
+	if err != nil || results != nil {
+		t.Errorf("Search(Active) = %v, %v; want nil, nil", results, err)
+	}
+	if sessions, err := Collect(hs.Sessions(context.Background(), ListOptions{Active: true}), 0); err != nil || sessions != nil {
+		t.Errorf("Sessions(Active) = %v, %v; want nil, nil", sessions, err)
+	}
+	if results, err := Collect(hs.SearchResults(context.Background(), nil, ListOptions{Active: true}), 0); err != nil || results != nil {
+		t.Errorf("SearchResults(Active) = %v, %v; want nil, nil", results, err)
+	}
+}
+
+// followSource is a dirSource that is also a Follower.
+type followSource struct {
+	dirSource
+}
+
+func (f *followSource) TranscriptPath(_ context.Context, id string) (string, error) {
+	return filepath.Join(f.dir, id+".jsonl"), nil
+}
+func (f *followSource) NewTranscript() Transcript { return nil }
+
+func TestHostSource_Follower(t *testing.T) {
+	ctx := context.Background()
+	following := &hostSource{Source: &followSource{dirSource{dir: "/srv/devbox"}}, host: "devbox"}
+	if path, err := following.TranscriptPath(ctx, "s1"); err != nil || path != filepath.Join("/srv/devbox", "s1.jsonl") {
+		t.Errorf("TranscriptPath() = %q, %v; want the source's", path, err)
+	}
+	if tr := following.NewTranscript(); tr != nil {
+		t.Errorf("NewTranscript() = %v, want the source's", tr)
+	}
+
+	// A Source that is not a Follower has no file to follow.
+	plain := &hostSource{Source: &dirSource{}, host: "devbox"}
+	if path, err := plain.TranscriptPath(ctx, "s1"); err != nil || path != "" {
+		t.Errorf("TranscriptPath() = %q, %v; want none", path, err)
+	}
+}
@@ -0,0 +1,43 @@
+package source
+
+import (
+	"errors"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// currentIndex is the metadata cache sources memoize per-file work in.
+var currentIndex *index.Index
+
+// SetIndex sets the metadata cache used by every source. nil (the default)
+// disables caching. Not safe for concurrent use; call it before reading
+// sessions.
+func SetIndex(ix *index.Index) {
+	currentIndex = ix
+}
+
+// Index returns the metadata cache, or nil when caching is disabled. The
+// index package treats a nil *Index as "always recompute".
+func Index() *index.Index {
+	return currentIndex
+}
+
+// errNotTimed keeps Timing from parsing a transcript the index has not timed.
+var errNotTimed = errors.New("not timed")
+
+// Timing returns the timing of a transcript, memoized in the index under
+// kind. Timing a transcript parses all of it, so unless parse is set only a
+// timing the index already holds is returned, and zero otherwise: listing
+// stays a peek at each file, and sessions are timed once read in full (Get)
+// or when asked for (ListOptions.Timing). timed is false when the timing was
+// left to a later read.
+func Timing(kind, path string, parse bool, messages func() []model.Message) (t model.Timing, timed bool) {
+	t, err := index.Load(Index(), kind, path, func() (model.Timing, error) {
+		if !parse {
+			return model.Timing{}, errNotTimed
+		}
+		return model.TimingOf(messages()), nil
+	})
+	return t, err == nil
+}
@@ -0,0 +1,71 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/model"
+)
+
+func TestSetIndex(t *testing.T) {
+	t.Cleanup(func() { SetIndex(nil) })
+
+	if Index() != nil {
+		t.Fatal("Index() should default to nil")
+	}
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+
+	SetIndex(ix)
+	if Index() != ix {
+		t.Error("Index() did not return the index set with SetIndex")
+	}
+	SetIndex(nil)
+	if Index() != nil {
+		t.Error("SetIndex(nil) should disable the index")
+	}
+}
+
+func TestTiming(t *testing.T) {
+	t.Cleanup(func() { SetIndex(nil) })
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	parsed := 0
+	messages := func() []model.Message {
+		parsed++
+		return []model.Message{{Timestamp: start}, {Timestamp: start.Add(time.Minute)}}
+	}
+	want := model.Timing{Start: start, End: start.Add(time.Minute), Active: time.Minute}
+
+	// Without the index, only parse times.
+	if got, timed := Timing("test.timing", path, false, messages); got != (model.Timing{}) || timed || parsed != 0 {
+		t.Errorf("Timing(no parse) = %+v, %v after %d parses, want zero, untimed and none", got, timed, parsed)
+	}
+	if got, timed := Timing("test.timing", path, true, messages); got != want || !timed {
+		t.Errorf("Timing(parse) = %+v, %v, want %+v, timed", got, timed, want)
+	}
+
+	ix, err := index.Open(filepath.Join(t.TempDir(), "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	SetIndex(ix)
+	if got, timed := Timing("test.timing", path, false, messages); got != (model.Timing{}) || timed {
+		t.Errorf("Timing(cold index) = %+v, %v, want zero and untimed", got, timed)
+	}
+	Timing("test.timing", path, true, messages)
+	parsed = 0
+	if got, timed := Timing("test.timing", path, false, messages); !got.Start.Equal(want.Start) || !got.End.Equal(want.End) || got.Active != want.Active || !timed || parsed != 0 {
+		t.Errorf("Timing(warm index) = %+v, %v after %d parses, want %+v from the index", got, timed, parsed, want)
+	}
+}
@@ -1,9 +1,11 @@
 package source
 
 import (
+	"context"
 	"testing"
 
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 )
 
 // mockSource implements Source for testing.
@@ -12,13 +14,13 @@ type mockSource struct {
 }
 
 func (m *mockSource) Name() model.Tool { return m.name }
-func (m *mockSource) List(_ ListOptions) ([]model.Session, error) {
+func (m *mockSource) List(_ context.Context, _ ListOptions) ([]model.Session, error) {
 	return nil, nil
 }
-func (m *mockSource) Get(_ string) (*model.Session, error) {
+func (m *mockSource) Get(_ context.Context, _ string) (*model.Session, error) {
 	return nil, nil
 }
-func (m *mockSource) Search(_ string, _ ListOptions) ([]model.SearchResult, error) {
+func (m *mockSource) Search(_ context.Context, _ search.Matcher, _ ListOptions) ([]model.SearchResult, error) {
 	return nil, nil
 }
 
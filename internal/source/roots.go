@@ -0,0 +1,102 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// rootEnvVars maps each tool to the environment variable the tool itself
+// reads to relocate its data directory. Tools without one are absent.
+var rootEnvVars = map[model.Tool]string{
+	model.ToolClaude: "CLAUDE_CONFIG_DIR",
+	model.ToolCodex:  "CODEX_HOME",
+}
+
+// sessionDirs are where, under its root, each tool writes its transcripts.
+var sessionDirs = map[model.Tool][]string{
+	model.ToolClaude: {"projects"},
+	model.ToolCodex:  {"sessions"},
+	model.ToolCursor: {"projects", "chats", "ai-tracking"},
+	model.ToolGemini: {"tmp"},
+}
+
+// rootOverrides holds explicit data directories set via SetRoot
+// (--<tool>-root flags and config keys).
+var rootOverrides = map[model.Tool]string{}
+
+// SetRoot overrides the data directory for a tool. An empty dir removes the
+// override. Not safe for concurrent use; call it before reading sessions.
+func SetRoot(tool model.Tool, dir string) {
+	if dir == "" {
+		delete(rootOverrides, tool)
+		return
+	}
+	rootOverrides[tool] = dir
+}
+
+// Root returns the data directory for a tool. Resolution order:
+//
+//  1. an explicit override (SetRoot)
+//  2. the tool's own environment variable (CLAUDE_CONFIG_DIR, CODEX_HOME)
+//  3. ~/.<tool>
+//
+// A leading "~/" in overrides and environment values is expanded.
+func Root(tool model.Tool) (string, error) {
+	if dir, ok := rootOverrides[tool]; ok {
+		return expandHome(dir)
+	}
+	if env := rootEnvVars[tool]; env != "" {
+		if dir := os.Getenv(env); dir != "" {
+			return expandHome(dir)
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, "."+string(tool)), nil
+}
+
+// SessionDirs returns the directories a tool writes its session transcripts
+// under, for `watch` to be told when they change.
+func SessionDirs(tool model.Tool) ([]string, error) {
+	root, err := Root(tool)
+	if err != nil {
+		return nil, err
+	}
+	return sessionDirsUnder(tool, root), nil
+}
+
+// DirsOf returns the directories s writes its session transcripts under:
+// SessionDirs for a local source, and the same directories under its root
+// for another host's.
+func DirsOf(s Source) ([]string, error) {
+	if hs, ok := s.(*hostSource); ok {
+		return sessionDirsUnder(hs.Name(), hs.dir), nil
+	}
+	return SessionDirs(s.Name())
+}
+
+func sessionDirsUnder(tool model.Tool, root string) []string {
+	var dirs []string
+	for _, d := range sessionDirs[tool] {
+		dirs = append(dirs, filepath.Join(root, d))
+	}
+	return dirs
+}
+
+// expandHome replaces a leading "~" path element with the home directory.
+func expandHome(dir string) (string, error) {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home dir: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(dir, "~")), nil
+}
@@ -0,0 +1,106 @@
+package source
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+func TestRoot(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	t.Cleanup(func() { rootOverrides = map[model.Tool]string{} })
+
+	tests := []struct {
+		name     string
+		tool     model.Tool
+		env      map[string]string
+		override string
+		want     string
+	}{
+		{"default claude", model.ToolClaude, nil, "", filepath.Join(home, ".claude")},
+		{"default cursor", model.ToolCursor, nil, "", filepath.Join(home, ".cursor")},
+		{"CLAUDE_CONFIG_DIR", model.ToolClaude, map[string]string{"CLAUDE_CONFIG_DIR": "/shared/claude"}, "", "/shared/claude"},
+		{"CODEX_HOME", model.ToolCodex, map[string]string{"CODEX_HOME": "/shared/codex"}, "", "/shared/codex"},
+		{"env with tilde", model.ToolCodex, map[string]string{"CODEX_HOME": "~/alt-codex"}, "", filepath.Join(home, "alt-codex")},
+		{"override beats env", model.ToolCodex, map[string]string{"CODEX_HOME": "/shared/codex"}, "/flag/codex", "/flag/codex"},
+		{"override for tool without env var", model.ToolGemini, nil, "~", home},
+		{"other tool's env ignored", model.ToolGemini, map[string]string{"CODEX_HOME": "/shared/codex"}, "", filepath.Join(home, ".gemini")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			SetRoot(tt.tool, tt.override)
+			defer SetRoot(tt.tool, "")
+
+			got, err := Root(tt.tool)
+			if err != nil {
+				t.Fatalf("Root(%s) error: %v", tt.tool, err)
+			}
+			if got != tt.want {
+				t.Errorf("Root(%s) = %q, want %q", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRoot_EmptyClearsOverride(t *testing.T) {
+	t.Cleanup(func() { rootOverrides = map[model.Tool]string{} })
+	SetRoot(model.ToolCursor, "/x")
+	SetRoot(model.ToolCursor, "")
+	if _, ok := rootOverrides[model.ToolCursor]; ok {
+		t.Error("SetRoot with empty dir should remove the override")
+	}
+}
+
+func TestRoot_HomeUnresolvable(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("CODEX_HOME", "")
+	t.Cleanup(func() { rootOverrides = map[model.Tool]string{} })
+
+	if _, err := Root(model.ToolCodex); err == nil {
+		t.Error("expected error when HOME is empty and no override is set")
+	}
+	SetRoot(model.ToolCodex, "~/codex")
+	if _, err := Root(model.ToolCodex); err == nil {
+		t.Error("expected error expanding ~ when HOME is empty")
+	}
+	SetRoot(model.ToolCodex, "/abs/codex")
+	if got, err := Root(model.ToolCodex); err != nil || got != "/abs/codex" {
+		t.Errorf("absolute override = %q, %v", got, err)
+	}
+}
+
+func TestSessionDirs(t *testing.T) {
+	t.Cleanup(func() { rootOverrides = map[model.Tool]string{} })
+	SetRoot(model.ToolCodex, "/data/codex")
+	got, err := SessionDirs(model.ToolCodex)
+	if err != nil || len(got) != 1 || got[0] != filepath.Join("/data/codex", "sessions") {
+		t.Errorf("SessionDirs(codex) = %q, %v", got, err)
+	}
+
+	t.Setenv("HOME", "")
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	if _, err := SessionDirs(model.ToolClaude); err == nil {
+		t.Error("expected error when the root cannot be resolved")
+	}
+}
+
+func TestDirsOf(t *testing.T) {
+	t.Cleanup(func() { rootOverrides = map[model.Tool]string{} })
+	SetRoot(model.ToolCodex, "/data/codex")
+	local := &mockSource{name: model.ToolCodex}
+	if got, err := DirsOf(local); err != nil || len(got) != 1 || got[0] != filepath.Join("/data/codex", "sessions") {
+		t.Errorf("DirsOf(local) = %q, %v", got, err)
+	}
+	// Another host's are under its own root.
+	remote := &hostSource{Source: local, host: "devbox", dir: "/srv/devbox/.codex"}
+	if got, err := DirsOf(remote); err != nil || len(got) != 1 || got[0] != filepath.Join("/srv/devbox/.codex", "sessions") {
+		t.Errorf("DirsOf(host) = %q, %v", got, err)
+	}
+}
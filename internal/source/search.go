@@ -0,0 +1,33 @@
+package source
+
+import (
+	"log"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+// IndexScores narrows a search with the full-text index. When the index is
+// open and can narrow m, it calls sync to bring the source's transcripts up
+// to date, then returns the BM25 score of every session under root that may
+// match m; sessions missing from the map cannot match. It returns nil when
+// every session has to be scanned: no index, a search the index cannot
+// narrow, or an index failure (logged).
+func IndexScores(tool model.Tool, root string, m search.Matcher, sync func(ix *index.Index)) map[string]float64 {
+	ix := Index()
+	if ix == nil {
+		return nil
+	}
+	expr, ok := m.FTS()
+	if !ok {
+		return nil
+	}
+	sync(ix)
+	scores, err := ix.Search(root, expr)
+	if err != nil {
+		log.Printf("warning: searching %s index: %v; scanning sessions instead", tool, err)
+		return nil
+	}
+	return scores
+}
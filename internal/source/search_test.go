@@ -0,0 +1,68 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/psacc/omnisess/internal/index"
+	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
+)
+
+func TestIndexScores(t *testing.T) {
+	t.Cleanup(func() { SetIndex(nil) })
+
+	dir := t.TempDir()
+	ix, err := index.Open(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "s1.jsonl")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	synced := 0
+	sync := func(ix *index.Index) {
+		synced++
+		tr := index.Transcript{Root: "/root", Tool: "claude", Session: "s1", Path: path}
+		if err := ix.SyncFile(tr, func() ([]index.Doc, error) {
+			return []index.Doc{{Content: "please deploy"}}, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parse := func(s string) *search.Query {
+		q, err := search.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return q
+	}
+
+	// No index: every session is scanned.
+	if got := IndexScores(model.ToolClaude, "/root", parse("deploy"), sync); got != nil || synced != 0 {
+		t.Errorf("without index: scores = %v, synced %d times", got, synced)
+	}
+
+	SetIndex(ix)
+
+	// A query the index cannot narrow does not sync.
+	if got := IndexScores(model.ToolClaude, "/root", parse("role:user"), sync); got != nil || synced != 0 {
+		t.Errorf("role:user: scores = %v, synced %d times", got, synced)
+	}
+
+	got := IndexScores(model.ToolClaude, "/root", parse("deploy"), sync)
+	if synced != 1 || len(got) != 1 || got["s1"] <= 0 {
+		t.Errorf("deploy: scores = %v, synced %d times", got, synced)
+	}
+	if got := IndexScores(model.ToolClaude, "/root", parse("missing"), sync); got == nil || len(got) != 0 {
+		t.Errorf("missing: scores = %v, want an empty non-nil map", got)
+	}
+
+	// Index failures fall back to scanning.
+	ix.Close()
+	if got := IndexScores(model.ToolClaude, "/root", parse("deploy"), func(*index.Index) {}); got != nil {
+		t.Errorf("closed index: scores = %v, want nil", got)
+	}
+}
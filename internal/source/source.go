@@ -1,32 +1,147 @@
 package source
 
 import (
+	"context"
+	"iter"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/psacc/omnisess/internal/model"
+	"github.com/psacc/omnisess/internal/search"
 )
 
 // ListOptions controls filtering for List and Search operations.
 type ListOptions struct {
 	Since   time.Duration // only sessions updated within this duration
 	Limit   int           // max results (0 = unlimited)
-	Project string        // filter by project path substring
+	Project string        // filter by project path substring, see MatchesProject
 	Active  bool          // only active sessions
+	// State keeps only sessions in this state. It is applied by
+	// MergeSessions, not by sources, which leave Limit to the caller when
+	// it is set.
+	State model.State
+	// Timing times every session by its messages, parsing the transcripts
+	// the index has not timed yet; otherwise only those it has are (see
+	// Timing).
+	Timing bool
+}
+
+// MatchesProject reports whether project passes the Project filter: every
+// source applies it as a substring of the project path, ignoring case.
+func (o ListOptions) MatchesProject(project string) bool {
+	return o.Project == "" || strings.Contains(strings.ToLower(project), strings.ToLower(o.Project))
 }
 
 // Source is the interface that each tool's session parser implements.
 // See AGENTS.md for the full contract.
+//
+// Sources are called concurrently (see ListAll). When ctx is done they stop
+// early and return ctx.Err(), along with the results found so far if they
+// have any.
 type Source interface {
 	// Name returns the tool identifier ("claude", "cursor", "codex", "gemini").
 	Name() model.Tool
 
 	// List returns sessions ordered by most recent first.
 	// Messages are NOT populated — use Get() for full content.
-	List(opts ListOptions) ([]model.Session, error)
+	List(ctx context.Context, opts ListOptions) ([]model.Session, error)
 
 	// Get returns a single session with full message history.
-	Get(sessionID string) (*model.Session, error)
+	Get(ctx context.Context, sessionID string) (*model.Session, error)
+
+	// Search returns sessions with messages matched by m.
+	Search(ctx context.Context, m search.Matcher, opts ListOptions) ([]model.SearchResult, error)
+}
+
+// Streamer is implemented by sources that can produce their sessions one at
+// a time, so that callers wanting only the first few (--limit) never pay for
+// the rest. The sequences yield an error, with a zero value, as their last
+// element when they stop early; they honour ctx like the Source methods.
+type Streamer interface {
+	// Sessions yields what List returns, most recent first, doing each
+	// session's expensive work (metadata, active detection) only when it
+	// is reached. opts.Limit is left to the caller.
+	Sessions(ctx context.Context, opts ListOptions) iter.Seq2[model.Session, error]
+
+	// SearchResults yields what Search returns, each as soon as it is found.
+	SearchResults(ctx context.Context, m search.Matcher, opts ListOptions) iter.Seq2[model.SearchResult, error]
+}
+
+// Transcript gathers a session's messages from the lines of its transcript,
+// fed in order from the first.
+type Transcript interface {
+	Add(line []byte)
+	// Messages returns the messages so far. Later lines may add tool calls
+	// to the last of them.
+	Messages() []model.Message
+}
+
+// Follower is implemented by sources whose transcripts are line-based files
+// that only grow, so that a session can be followed by reading what is
+// appended (omnisess tail) rather than parsing it again with Get.
+type Follower interface {
+	// TranscriptPath returns the file a session is written to, or "" if
+	// there is no such session or file to follow.
+	TranscriptPath(ctx context.Context, sessionID string) (string, error)
+	// NewTranscript returns an empty Transcript to feed a file's lines to.
+	NewTranscript() Transcript
+}
+
+// Sessions returns s's sessions most recent first: streamed when s is a
+// Streamer, otherwise from one List call.
+func Sessions(ctx context.Context, s Source, opts ListOptions) iter.Seq2[model.Session, error] {
+	if st, ok := s.(Streamer); ok {
+		return st.Sessions(ctx, opts)
+	}
+	return func(yield func(model.Session, error) bool) {
+		sessions, err := s.List(ctx, opts)
+		// The merge relies on the order, so don't take it on trust.
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+		})
+		yieldAll(sessions, err, yield)
+	}
+}
+
+// SearchResults returns s's search results: streamed when s is a Streamer,
+// otherwise from one Search call.
+func SearchResults(ctx context.Context, s Source, m search.Matcher, opts ListOptions) iter.Seq2[model.SearchResult, error] {
+	if st, ok := s.(Streamer); ok {
+		return st.SearchResults(ctx, m, opts)
+	}
+	return func(yield func(model.SearchResult, error) bool) {
+		results, err := s.Search(ctx, m, opts)
+		yieldAll(results, err, yield)
+	}
+}
+
+// yieldAll yields items, then err if there is one.
+func yieldAll[T any](items []T, err error, yield func(T, error) bool) {
+	for _, it := range items {
+		if !yield(it, nil) {
+			return
+		}
+	}
+	if err != nil {
+		var zero T
+		yield(zero, err)
+	}
+}
 
-	// Search returns sessions containing the query string.
-	Search(query string, opts ListOptions) ([]model.SearchResult, error)
+// Collect gathers up to limit items of seq (all of them when limit is 0).
+// It stops at the first error, returning it with the items gathered so far,
+// which is how a Streamer implements List and Search.
+func Collect[T any](seq iter.Seq2[T, error], limit int) ([]T, error) {
+	var items []T
+	for it, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, it)
+		if limit > 0 && len(items) == limit {
+			break
+		}
+	}
+	return items, nil
 }
@@ -0,0 +1,138 @@
+// Package stats aggregates sessions into usage figures (sessions, messages,
+// tool calls, tokens, cost) grouped by tool, project, model, branch, day or
+// week.
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// GroupBy is what sessions are grouped by.
+type GroupBy string
+
+const (
+	ByTool    GroupBy = "tool"
+	ByProject GroupBy = "project"
+	ByModel   GroupBy = "model"
+	ByBranch  GroupBy = "branch"
+	ByDay     GroupBy = "day"  // the day the session started, local time
+	ByWeek    GroupBy = "week" // the ISO week the session started
+)
+
+// GroupBys lists every grouping, in the order the help text gives them.
+var GroupBys = []GroupBy{ByTool, ByProject, ByModel, ByBranch, ByDay, ByWeek}
+
+// ParseGroupBy returns the grouping named s.
+func ParseGroupBy(s string) (GroupBy, error) {
+	if g := GroupBy(s); slices.Contains(GroupBys, g) {
+		return g, nil
+	}
+	names := make([]string, len(GroupBys))
+	for i, g := range GroupBys {
+		names[i] = string(g)
+	}
+	return "", fmt.Errorf("unknown grouping %q: want %s", s, strings.Join(names, ", "))
+}
+
+// Row holds the figures of one group. Usage is what the transcripts record,
+// so it is zero for tools that record none.
+type Row struct {
+	Key       string // the group: a tool, project path, ..., "2026-10-17" or "2026-W42"; empty for sessions without one
+	Sessions  int
+	Messages  int
+	ToolCalls int
+	Usage     model.Usage `json:",omitzero"`
+}
+
+func (r *Row) add(s model.Session) {
+	r.Sessions++
+	r.Messages += len(s.Messages)
+	for _, m := range s.Messages {
+		r.ToolCalls += len(m.ToolCalls)
+	}
+	r.Usage.Add(s.Usage)
+}
+
+// Report is a set of sessions aggregated by one grouping.
+type Report struct {
+	GroupBy GroupBy
+	Rows    []Row
+	Total   Row // over every session; its Key is empty
+}
+
+// Aggregate groups sessions, which should carry their messages, by by. Days
+// and weeks come in order; other groups cost most first, then by tokens,
+// sessions and key.
+func Aggregate(sessions []model.Session, by GroupBy) Report {
+	r := Report{GroupBy: by}
+	groups := make(map[string]*Row)
+	for _, s := range sessions {
+		k := Key(s, by)
+		g, ok := groups[k]
+		if !ok {
+			g = &Row{Key: k}
+			groups[k] = g
+		}
+		g.add(s)
+		r.Total.add(s)
+	}
+	for _, g := range groups {
+		r.Rows = append(r.Rows, *g)
+	}
+	if by == ByDay || by == ByWeek {
+		slices.SortFunc(r.Rows, func(a, b Row) int { return strings.Compare(a.Key, b.Key) })
+		return r
+	}
+	slices.SortFunc(r.Rows, func(a, b Row) int {
+		switch {
+		case a.Usage.Cost() != b.Usage.Cost():
+			return compareDesc(a.Usage.Cost(), b.Usage.Cost())
+		case a.Usage.Tokens() != b.Usage.Tokens():
+			return compareDesc(a.Usage.Tokens(), b.Usage.Tokens())
+		case a.Sessions != b.Sessions:
+			return compareDesc(a.Sessions, b.Sessions)
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return r
+}
+
+func compareDesc[T int | int64 | float64](a, b T) int {
+	if a > b {
+		return -1
+	}
+	return 1
+}
+
+// Key returns the group of s under by.
+func Key(s model.Session, by GroupBy) string {
+	switch by {
+	case ByTool:
+		return string(s.Tool)
+	case ByProject:
+		return s.Project
+	case ByModel:
+		return s.Model
+	case ByBranch:
+		return s.Branch
+	case ByDay:
+		return startDay(s).Format(time.DateOnly)
+	default: // ByWeek
+		year, week := startDay(s).ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+}
+
+// startDay is when s started, in local time, or when it was last updated
+// for sources that do not record the start.
+func startDay(s model.Session) time.Time {
+	if s.StartedAt.IsZero() {
+		return s.UpdatedAt.Local()
+	}
+	return s.StartedAt.Local()
+}
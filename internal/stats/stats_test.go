@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// day returns noon of 2026-10-<d> in local time.
+func day(d int) time.Time { return time.Date(2026, 10, d, 12, 0, 0, 0, time.Local) }
+
+var sessions = []model.Session{
+	{
+		Tool: model.ToolClaude, Project: "/src/api", Model: "claude-opus-4", Branch: "main", StartedAt: day(12),
+		Messages: []model.Message{
+			{Role: model.RoleUser},
+			{Role: model.RoleAssistant, ToolCalls: []model.ToolCall{{Name: "Bash"}, {Name: "Read"}}},
+		},
+		Usage: model.Usage{InputTokens: 100, OutputTokens: 50, CostUSD: 0.5},
+	},
+	{
+		Tool: model.ToolCodex, Project: "/src/api", Model: "gpt-5", StartedAt: day(16),
+		Messages: []model.Message{{Role: model.RoleUser}, {Role: model.RoleAssistant}},
+		Usage:    model.Usage{InputTokens: 2000, CacheReadTokens: 1000},
+	},
+	{
+		Tool: model.ToolCursor, Project: "/src/web", UpdatedAt: day(14),
+		Messages: []model.Message{{Role: model.RoleUser}},
+	},
+	{
+		Tool: model.ToolClaude, Project: "/src/web", Model: "claude-opus-4", Branch: "main", StartedAt: day(19),
+		Messages: []model.Message{{Role: model.RoleAssistant, ToolCalls: []model.ToolCall{{Name: "Edit"}}}},
+		Usage:    model.Usage{OutputTokens: 10, CostUSD: 1},
+	},
+}
+
+// keys returns the keys of rows, "-" standing for the empty one.
+func keys(rows []Row) string {
+	var ks []string
+	for _, r := range rows {
+		if r.Key == "" {
+			r.Key = "-"
+		}
+		ks = append(ks, r.Key)
+	}
+	return strings.Join(ks, " ")
+}
+
+func TestParseGroupBy(t *testing.T) {
+	for _, g := range GroupBys {
+		if got, err := ParseGroupBy(string(g)); got != g || err != nil {
+			t.Errorf("ParseGroupBy(%q) = %q, %v", g, got, err)
+		}
+	}
+	if _, err := ParseGroupBy("month"); err == nil || !strings.Contains(err.Error(), "want tool, project, model, branch, day, week") {
+		t.Errorf("ParseGroupBy(month) error = %v, want the groupings", err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := []struct {
+		by   GroupBy
+		want string
+	}{
+		// Cost first, then tokens, then sessions.
+		{ByTool, "claude codex cursor"},
+		{ByProject, "/src/web /src/api"},
+		{ByModel, "claude-opus-4 gpt-5 -"},
+		{ByBranch, "main -"},
+		{ByDay, "2026-10-12 2026-10-14 2026-10-16 2026-10-19"},
+		{ByWeek, "2026-W42 2026-W43"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.by), func(t *testing.T) {
+			r := Aggregate(sessions, tt.by)
+			if r.GroupBy != tt.by {
+				t.Errorf("GroupBy = %q, want %q", r.GroupBy, tt.by)
+			}
+			if got := keys(r.Rows); got != tt.want {
+				t.Errorf("rows = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAggregate_Figures(t *testing.T) {
+	r := Aggregate(sessions, ByProject)
+	want := []Row{
+		{Key: "/src/web", Sessions: 2, Messages: 2, ToolCalls: 1, Usage: model.Usage{OutputTokens: 10, CostUSD: 1}},
+		{Key: "/src/api", Sessions: 2, Messages: 4, ToolCalls: 2, Usage: model.Usage{InputTokens: 2100, CacheReadTokens: 1000, OutputTokens: 50, CostUSD: 0.5}},
+	}
+	for i, row := range r.Rows {
+		if row != want[i] {
+			t.Errorf("Rows[%d] = %+v, want %+v", i, row, want[i])
+		}
+	}
+	total := Row{Sessions: 4, Messages: 6, ToolCalls: 3, Usage: model.Usage{InputTokens: 2100, CacheReadTokens: 1000, OutputTokens: 60, CostUSD: 1.5}}
+	if r.Total != total {
+		t.Errorf("Total = %+v, want %+v", r.Total, total)
+	}
+
+	if empty := Aggregate(nil, ByTool); len(empty.Rows) != 0 || empty.Total != (Row{}) {
+		t.Errorf("Aggregate(nil) = %+v, want no rows", empty)
+	}
+}
+
+func TestAggregate_Ties(t *testing.T) {
+	r := Aggregate([]model.Session{
+		{Tool: model.ToolGemini}, {Tool: model.ToolCursor}, {Tool: model.ToolCodex}, {Tool: model.ToolCursor},
+		{Tool: model.ToolClaude, Usage: model.Usage{CostUSD: 0.1}},
+		{Tool: "other", Usage: model.Usage{EstimatedCostUSD: 0.2}}, // estimates count as cost
+	}, ByTool)
+	if got := keys(r.Rows); got != "other claude cursor codex gemini" {
+		t.Errorf("rows = %s, want other claude cursor codex gemini", got)
+	}
+}
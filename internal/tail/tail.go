@@ -0,0 +1,96 @@
+// Package tail reads the lines appended to a file as it grows, like
+// tail -F: a line is returned once its newline is written, and a file that
+// is replaced or truncated is read again from the start.
+package tail
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// File follows one file. It is not safe for concurrent use.
+type File struct {
+	path    string
+	f       *os.File
+	info    os.FileInfo // of f, to tell when path names another file
+	off     int64       // bytes of f read so far
+	partial []byte      // the unterminated line at off
+}
+
+// Open starts following the file at path from its first byte.
+func Open(path string) (*File, error) {
+	t := &File{path: path}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *File) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	// Stat does not fail on an open file; if it did, the nil info would
+	// match no file and the next Read would open the file again.
+	info, _ := f.Stat()
+	if t.f != nil {
+		t.f.Close()
+	}
+	t.f, t.info, t.off, t.partial = f, info, 0, nil
+	return nil
+}
+
+// Read returns the complete, non-empty lines written since the last call,
+// without their newlines. restarted is set when the file was replaced
+// (rotated) or truncated since: the lines are then the new file's from its
+// start, and earlier ones no longer apply. While path is missing, as in the
+// middle of a rotation, Read returns nothing and keeps waiting for it.
+func (t *File) Read() (lines [][]byte, restarted bool, err error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	switch {
+	case !os.SameFile(info, t.info):
+		if err := t.open(); err != nil {
+			return nil, false, err
+		}
+		restarted = true
+	case info.Size() < t.off:
+		t.off, t.partial = 0, nil
+		restarted = true
+	}
+
+	// Read up to the size just seen; what is written meanwhile is for the
+	// next call.
+	buf := make([]byte, max(info.Size()-t.off, 0))
+	n, err := t.f.ReadAt(buf, t.off)
+	if err != nil && err != io.EOF {
+		return nil, restarted, err
+	}
+	t.off += int64(n)
+	data := append(t.partial, buf[:n]...)
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimRight(data[:i], "\r"); len(line) > 0 {
+			lines = append(lines, line)
+		}
+		data = data[i+1:]
+	}
+	t.partial = bytes.Clone(data)
+	return lines, restarted, nil
+}
+
+// Close closes the file.
+func (t *File) Close() error {
+	return t.f.Close()
+}
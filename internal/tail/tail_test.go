@@ -0,0 +1,180 @@
+package tail
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// helpers
+// ---------------------------------------------------------------------------
+
+func appendFile(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readLines calls Read and returns the lines as strings.
+func readLines(t *testing.T, f *File) ([]string, bool) {
+	t.Helper()
+	lines, restarted, err := f.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	var out []string
+	for _, l := range lines {
+		out = append(out, string(l))
+	}
+	return out, restarted
+}
+
+func expectLines(t *testing.T, f *File, want []string, wantRestarted bool) {
+	t.Helper()
+	got, restarted := readLines(t, f)
+	if !reflect.DeepEqual(got, want) || restarted != wantRestarted {
+		t.Errorf("Read = %q, restarted %v; want %q, restarted %v", got, restarted, want, wantRestarted)
+	}
+}
+
+func openTail(t *testing.T, path string) *File {
+	t.Helper()
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// ---------------------------------------------------------------------------
+// Read
+// ---------------------------------------------------------------------------
+
+func TestRead_AppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	appendFile(t, path, "one\n\ntwo\r\n")
+	f := openTail(t, path)
+
+	expectLines(t, f, []string{"one", "two"}, false)
+	expectLines(t, f, nil, false)
+
+	appendFile(t, path, "three\n")
+	expectLines(t, f, []string{"three"}, false)
+}
+
+func TestRead_PartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	appendFile(t, path, "one\n{\"type\":")
+	f := openTail(t, path)
+
+	expectLines(t, f, []string{"one"}, false)
+	appendFile(t, path, "\"user\"")
+	expectLines(t, f, nil, false)
+	appendFile(t, path, "}\ntw")
+	expectLines(t, f, []string{`{"type":"user"}`}, false)
+	appendFile(t, path, "o\n")
+	expectLines(t, f, []string{"two"}, false)
+}
+
+func TestRead_Rotated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s.jsonl")
+	appendFile(t, path, "old\npart")
+	f := openTail(t, path)
+	expectLines(t, f, []string{"old"}, false)
+
+	// Moved away: nothing to read until the new file appears.
+	if err := os.Rename(path, filepath.Join(dir, "s.jsonl.1")); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, f, nil, false)
+
+	appendFile(t, path, "new\n")
+	expectLines(t, f, []string{"new"}, true)
+	appendFile(t, path, "next\n")
+	expectLines(t, f, []string{"next"}, false)
+}
+
+func TestRead_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	appendFile(t, path, "a long first line\n")
+	f := openTail(t, path)
+	expectLines(t, f, []string{"a long first line"}, false)
+
+	if err := os.WriteFile(path, []byte("short\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expectLines(t, f, []string{"short"}, true)
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace func(t *testing.T, dir, path string)
+	}{
+		{
+			// Stat fails with something other than "not found".
+			name: "parent is a file",
+			replace: func(t *testing.T, dir, path string) {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, dir, "")
+			},
+		},
+		{
+			// The replacement cannot be opened.
+			name: "socket",
+			replace: func(t *testing.T, dir, path string) {
+				os.Remove(path)
+				l, err := net.Listen("unix", path)
+				if err != nil {
+					t.Skipf("unix sockets unavailable: %v", err)
+				}
+				t.Cleanup(func() { l.Close() })
+			},
+		},
+		{
+			// The replacement opens but cannot be read.
+			name: "directory",
+			replace: func(t *testing.T, dir, path string) {
+				os.Remove(path)
+				if err := os.Mkdir(path, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, filepath.Join(path, "x"), "x\n")
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "d")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, "s")
+			appendFile(t, path, "x\n")
+			f := openTail(t, path)
+			tc.replace(t, dir, path)
+			if _, _, err := f.Read(); err == nil {
+				t.Error("Read: expected error")
+			}
+		})
+	}
+}
+
+func TestOpen_Missing(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Open(missing): expected error")
+	}
+}
@@ -0,0 +1,133 @@
+// Package timeline lays sessions out over time from their message
+// timestamps: an hourly heatmap of messages, and spans of activity packed
+// into lanes so that sessions running at once sit on different lanes.
+package timeline
+
+import (
+	"slices"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// Gap is the longest pause within a span: a session quiet for longer is
+// drawn as separate spans, so a session resumed days later does not look
+// busy in between. It is longer than model.IdleThreshold, which decides what
+// counts as active time: a column of the default week-long chart covers
+// well over an hour, so splitting at shorter pauses would only break a
+// sitting into fragments that land on separate lanes.
+const Gap = 30 * time.Minute
+
+// Span is a stretch of activity of one session.
+type Span struct {
+	Session  string // qualified ID
+	Tool     model.Tool
+	Project  string
+	Start    time.Time
+	End      time.Time // the last message; equal to Start for a single one
+	Messages int
+	Lane     int // from 0; spans on a lane never overlap
+}
+
+// Day is the number of messages per hour of a day, in local time.
+type Day struct {
+	Date  string // 2006-01-02
+	Hours [24]int
+}
+
+// Timeline is the activity of sessions between From and To.
+type Timeline struct {
+	From, To time.Time
+	Heatmap  []Day  // every day from From to To, in order
+	Spans    []Span // by Start
+	Lanes    int    // the most spans running at once
+}
+
+// Build lays out the messages of sessions sent between from and to. A
+// session without message timestamps (as some sources record none) is
+// taken to be one span from StartedAt to UpdatedAt, cut to the window, and
+// adds nothing to the heatmap.
+func Build(sessions []model.Session, from, to time.Time) Timeline {
+	t := Timeline{From: from, To: to}
+	hours := make(map[string]*[24]int)
+	for d := startOfDay(from); !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := Day{Date: d.Format(time.DateOnly)}
+		t.Heatmap = append(t.Heatmap, day)
+	}
+	for i := range t.Heatmap {
+		hours[t.Heatmap[i].Date] = &t.Heatmap[i].Hours
+	}
+
+	inWindow := func(ts time.Time) bool { return !ts.IsZero() && !ts.Before(from) && !ts.After(to) }
+	for _, s := range sessions {
+		span := Span{Session: s.QualifiedID(), Tool: s.Tool, Project: s.Project}
+		timed := false
+		for _, m := range s.Messages {
+			if m.Timestamp.IsZero() {
+				continue
+			}
+			timed = true
+			if !inWindow(m.Timestamp) {
+				continue
+			}
+			local := m.Timestamp.Local()
+			hours[local.Format(time.DateOnly)][local.Hour()]++
+
+			if span.Messages > 0 && m.Timestamp.Sub(span.End) > Gap {
+				t.Spans = append(t.Spans, span)
+				span.Messages = 0
+			}
+			if span.Messages == 0 {
+				span.Start = m.Timestamp
+			}
+			span.End = m.Timestamp
+			span.Messages++
+		}
+		switch {
+		case span.Messages > 0:
+			t.Spans = append(t.Spans, span)
+		case !timed && !s.StartedAt.IsZero() && !s.UpdatedAt.Before(from) && !s.StartedAt.After(to):
+			span.Start, span.End = latest(s.StartedAt, from), earliest(s.UpdatedAt, to)
+			t.Spans = append(t.Spans, span)
+		}
+	}
+
+	slices.SortStableFunc(t.Spans, func(a, b Span) int { return a.Start.Compare(b.Start) })
+	t.Lanes = assignLanes(t.Spans)
+	return t
+}
+
+// assignLanes puts each span on the first lane free at its start. Spans
+// come by Start, so this uses as few lanes as the most spans overlapping.
+func assignLanes(spans []Span) int {
+	var ends []time.Time // the End of the last span of each lane
+	for i := range spans {
+		lane := slices.IndexFunc(ends, func(end time.Time) bool { return end.Before(spans[i].Start) })
+		if lane < 0 {
+			lane = len(ends)
+			ends = append(ends, time.Time{})
+		}
+		spans[i].Lane = lane
+		ends[lane] = spans[i].End
+	}
+	return len(ends)
+}
+
+func startOfDay(ts time.Time) time.Time {
+	y, m, d := ts.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
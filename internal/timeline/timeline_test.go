@@ -0,0 +1,89 @@
+package timeline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// at returns 2026-10-<day> hh:mm in local time.
+func at(day, hh, mm int) time.Time { return time.Date(2026, 10, day, hh, mm, 0, 0, time.Local) }
+
+// messages returns one message at each time.
+func messages(times ...time.Time) []model.Message {
+	var msgs []model.Message
+	for _, ts := range times {
+		msgs = append(msgs, model.Message{Role: model.RoleUser, Timestamp: ts})
+	}
+	return msgs
+}
+
+// layout describes spans as "id lane start-end messages".
+func layout(spans []Span) string {
+	var parts []string
+	for _, s := range spans {
+		parts = append(parts, fmt.Sprintf("%s %d %s-%s %d",
+			s.Session, s.Lane, s.Start.Local().Format("02 15:04"), s.End.Local().Format("02 15:04"), s.Messages))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func TestBuild(t *testing.T) {
+	sessions := []model.Session{
+		{ID: "a", Tool: model.ToolClaude, Project: "/src/api", Messages: messages(
+			at(10, 9, 0), at(10, 9, 20), at(10, 9, 45), // one span: the pauses are short
+			at(10, 14, 0), at(10, 14, 10), // a second after a long pause
+		)},
+		{ID: "b", Tool: model.ToolCodex, Messages: messages(at(10, 9, 30), at(10, 10, 0))},
+		{ID: "c", Tool: model.ToolClaude, Messages: messages(at(10, 9, 50), at(10, 10, 5))},
+		{ID: "d", Tool: model.ToolCodex, Messages: messages(
+			at(8, 23, 0),  // before the window
+			at(10, 10, 1), // after b, so on its lane
+		)},
+		// No timestamps: the session's own times, cut to the window.
+		{ID: "e", Tool: model.ToolCursor, StartedAt: at(9, 12, 0), UpdatedAt: at(11, 18, 0), Messages: []model.Message{{Role: model.RoleUser}}},
+		{ID: "f", Tool: model.ToolCursor, StartedAt: at(1, 0, 0), UpdatedAt: at(2, 0, 0)}, // before the window
+		{ID: "g", Tool: model.ToolGemini}, // no times at all
+		{ID: "i", Tool: model.ToolCursor, StartedAt: at(10, 20, 0), UpdatedAt: at(10, 21, 0)},
+		{ID: "h", Tool: model.ToolCodex, StartedAt: at(9, 0, 0), UpdatedAt: at(10, 0, 0), Messages: messages(at(1, 1, 0))}, // timed, none in the window
+	}
+	tl := Build(sessions, at(10, 0, 0), at(11, 12, 0))
+
+	want := "cursor:e 0 10 00:00-11 12:00 0; " +
+		"claude:a 1 10 09:00-10 09:45 3; " +
+		"codex:b 2 10 09:30-10 10:00 2; " +
+		"claude:c 1 10 09:50-10 10:05 2; " +
+		"codex:d 2 10 10:01-10 10:01 1; " +
+		"claude:a 1 10 14:00-10 14:10 2; " +
+		"cursor:i 1 10 20:00-10 21:00 0"
+	if got := layout(tl.Spans); got != want {
+		t.Errorf("spans =\n%s\nwant\n%s", got, want)
+	}
+	if tl.Lanes != 3 {
+		t.Errorf("Lanes = %d, want 3", tl.Lanes)
+	}
+	if tl.Spans[1].Tool != model.ToolClaude || tl.Spans[1].Project != "/src/api" {
+		t.Errorf("span = %+v, want a's tool and project", tl.Spans[1])
+	}
+
+	if len(tl.Heatmap) != 2 || tl.Heatmap[0].Date != "2026-10-10" || tl.Heatmap[1].Date != "2026-10-11" {
+		t.Fatalf("Heatmap = %+v, want the 10th and 11th", tl.Heatmap)
+	}
+	hours := tl.Heatmap[0].Hours
+	if hours[9] != 5 || hours[10] != 3 || hours[14] != 2 || hours[11] != 0 {
+		t.Errorf("Heatmap[0].Hours = %v, want 5 at 9h, 3 at 10h, 2 at 14h", hours)
+	}
+	if tl.Heatmap[1].Hours != ([24]int{}) {
+		t.Errorf("Heatmap[1].Hours = %v, want none", tl.Heatmap[1].Hours)
+	}
+}
+
+func TestBuild_Empty(t *testing.T) {
+	tl := Build(nil, at(10, 12, 0), at(10, 13, 0))
+	if len(tl.Spans) != 0 || tl.Lanes != 0 || len(tl.Heatmap) != 1 {
+		t.Errorf("Build(nil) = %+v, want one empty day", tl)
+	}
+}
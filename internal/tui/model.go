@@ -18,7 +18,7 @@ const (
 	colProject = 26
 	colPreview = 0 // dynamic: fills remaining space
 	colTime    = 6
-	colStatus  = 6
+	colStatus  = 7 // widest state: "working", "waiting"
 
 	// Lines reserved for header + column headers + footer.
 	chromeLines = 4
@@ -27,7 +27,9 @@ const (
 // Styles.
 var (
 	styleSelected = lipgloss.NewStyle().Bold(true).Reverse(true)
-	styleActive   = lipgloss.NewStyle().Foreground(lipgloss.Color("2")) // green
+	styleActive   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))            // green
+	styleWaiting  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3")) // yellow
+	styleError    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))            // red
 	styleHeader   = lipgloss.NewStyle().Bold(true)
 	styleFooter   = lipgloss.NewStyle().Faint(true)
 	styleMessage  = lipgloss.NewStyle().Foreground(lipgloss.Color("3")) // yellow
@@ -179,14 +181,20 @@ func (m Model) View() string {
 
 	var b strings.Builder
 
-	// Header: "Sessions (X active)"
-	activeCount := 0
+	// Header: "Sessions (X active)", plus how many wait on the user.
+	activeCount, waitingCount := 0, 0
 	for _, s := range m.sessions {
 		if s.Active {
 			activeCount++
 		}
+		if s.State == model.StateWaiting {
+			waitingCount++
+		}
 	}
 	header := fmt.Sprintf("Sessions (%d active)", activeCount)
+	if waitingCount > 0 {
+		header = fmt.Sprintf("Sessions (%d active, %d waiting)", activeCount, waitingCount)
+	}
 	b.WriteString(styleHeader.Render(header))
 	b.WriteByte('\n')
 
@@ -277,21 +285,35 @@ func (m Model) renderRow(idx, previewWidth int) string {
 	preview := truncatePad(previewText, previewWidth)
 	ago := truncatePad(output.FormatDuration(time.Since(s.UpdatedAt)), colTime)
 
-	// Status indicator: pad to colStatus visible width for alignment with header.
-	var status string
+	// Status: the state of a running session, padded before styling so the
+	// escape codes don't count towards the width.
+	status := strings.Repeat(" ", colStatus)
 	if s.Active {
-		status = styleActive.Render("*") + strings.Repeat(" ", colStatus-1)
-	} else {
-		status = strings.Repeat(" ", colStatus)
+		label := strings.ToLower(output.StatusLabel(s))
+		status = stateStyle(s.State).Render(truncatePad(label, colStatus))
 	}
 
 	return fmt.Sprintf("  %s %s %s %s %s", tool, project, preview, ago, status)
 }
 
+// stateStyle colors a state: waiting (the user's move) stands out, idle
+// sessions fade.
+func stateStyle(st model.State) lipgloss.Style {
+	switch st {
+	case model.StateWaiting:
+		return styleWaiting
+	case model.StateError:
+		return styleError
+	case model.StateIdle:
+		return styleFooter
+	}
+	return styleActive
+}
+
 // previewWidth computes the dynamic preview column width.
 func (m Model) previewWidth() int {
-	// Layout: indent(2) TOOL(8) sp PROJECT(26) sp PREVIEW(pw) sp AGO(6) sp STATUS(*)
-	// STATUS is unpadded (last column, variable width: "  " or "* "), so not counted.
+	// Layout: indent(2) TOOL(8) sp PROJECT(26) sp PREVIEW(pw) sp AGO(6) sp STATUS(7)
+	// STATUS is padded to colStatus.
 	fixed := 2 + colTool + 1 + colProject + 1 + 1 + colTime + 1 + colStatus
 	pw := m.width - fixed
 	if pw < 10 {
@@ -430,6 +430,32 @@ func TestViewScrolling(t *testing.T) {
 	}
 }
 
+func TestView_States(t *testing.T) {
+	sessions := []model.Session{
+		{ID: "a", Tool: model.ToolClaude, Active: true, State: model.StateWaiting},
+		{ID: "b", Tool: model.ToolCodex, Active: true, State: model.StateWorking},
+		{ID: "c", Tool: model.ToolCodex, Active: true, State: model.StateIdle},
+		{ID: "d", Tool: model.ToolClaude, Active: true, State: model.StateError},
+		{ID: "e", Tool: model.ToolCursor, Active: true},
+		{ID: "f", Tool: model.ToolClaude, State: model.StateEnded},
+	}
+	m := New(sessions, testToolModes())
+	m.width, m.height = 120, 30
+	view := stripAnsi(m.View())
+
+	if !strings.Contains(view, "Sessions (5 active, 1 waiting)") {
+		t.Errorf("header should count waiting sessions, got:\n%s", view)
+	}
+	for _, state := range []string{"waiting", "working", "idle", "error", "active"} {
+		if !strings.Contains(view, state) {
+			t.Errorf("view should show %q, got:\n%s", state, view)
+		}
+	}
+	if strings.Contains(view, "ended") {
+		t.Errorf("ended sessions should show no status, got:\n%s", view)
+	}
+}
+
 func TestRenderRowWidthBudget(t *testing.T) {
 	sessions := testSessions()
 	for _, width := range []int{80, 120, 200} {
@@ -0,0 +1,111 @@
+//go:build linux
+
+package watch
+
+import (
+	"context"
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// inotifyInit opens an inotify instance. Tests replace it to fake a system
+// without inotify.
+var inotifyInit = func() (int, error) {
+	return syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+}
+
+// watchMask are the changes that trigger a check: transcripts written,
+// created, moved in or removed.
+const watchMask = syscall.IN_MODIFY | syscall.IN_CREATE | syscall.IN_MOVED_TO | syscall.IN_DELETE
+
+// notifier turns inotify events under a set of directory trees into
+// signals on ch.
+type notifier struct {
+	fd    int
+	file  *os.File         // fd, read through the runtime poller so Close ends a Read
+	paths map[int32]string // watched directory of each watch descriptor
+	ch    chan struct{}
+}
+
+// Changes returns a channel that receives a value after files under dirs
+// change, until ctx is done, or nil when inotify is unavailable so that only
+// the caller's polling applies. Signals coalesce: one may stand for many
+// changes. Directories created later (Codex's per-day ones) are watched as they
+// appear; dirs that do not exist are skipped.
+func Changes(ctx context.Context, dirs []string) <-chan struct{} {
+	fd, err := inotifyInit()
+	if err != nil {
+		return nil
+	}
+	n := &notifier{
+		fd:    fd,
+		file:  os.NewFile(uintptr(fd), "inotify"),
+		paths: make(map[int32]string),
+		ch:    make(chan struct{}, 1),
+	}
+	for _, d := range dirs {
+		n.addTree(d)
+	}
+	go func() {
+		<-ctx.Done()
+		n.file.Close()
+	}()
+	go n.read()
+	return n.ch
+}
+
+// addTree watches dir and every directory below it.
+func (n *notifier) addTree(dir string) {
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // gone or unreadable: nothing to watch there
+		}
+		if d.IsDir() {
+			if wd, err := syscall.InotifyAddWatch(n.fd, path, watchMask); err == nil {
+				n.paths[int32(wd)] = path
+			}
+		}
+		return nil
+	})
+}
+
+// read signals every batch of events until the file is closed, watching
+// the directories that appear.
+func (n *notifier) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		k, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		// Each event is a struct inotify_event: wd, mask, cookie, len,
+		// then len bytes of NUL-padded name.
+		for off := 0; off+syscall.SizeofInotifyEvent <= k; {
+			wd := int32(binary.NativeEndian.Uint32(buf[off:]))
+			mask := binary.NativeEndian.Uint32(buf[off+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[off+12:]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+nameLen]
+			if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+				n.addTree(filepath.Join(n.paths[wd], cString(name)))
+			}
+			off += syscall.SizeofInotifyEvent + nameLen
+		}
+		select {
+		case n.ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// cString is the string before the first NUL of b.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
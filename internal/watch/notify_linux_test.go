@@ -0,0 +1,156 @@
+//go:build linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// waitSignal fails the test unless ch receives within a second.
+func waitSignal(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("no signal after %s", what)
+	}
+}
+
+// drain empties ch of signals already sent.
+func drain(ch <-chan struct{}) {
+	for {
+		select {
+		case <-ch:
+		case <-time.After(50 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := Changes(ctx, []string{dir, filepath.Join(dir, "missing")})
+	if ch == nil {
+		t.Fatal("Changes() = nil, want a channel on Linux")
+	}
+
+	f := filepath.Join(dir, "s.jsonl")
+	if err := os.WriteFile(f, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitSignal(t, ch, "writing a file")
+
+	// A directory created later is watched too.
+	sub := filepath.Join(dir, "2026", "03")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitSignal(t, ch, "creating a directory")
+	drain(ch)
+	if err := os.WriteFile(filepath.Join(sub, "rollout.jsonl"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitSignal(t, ch, "writing in a new directory")
+
+	// Once ctx is done, no more signals.
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	drain(ch)
+	if err := os.WriteFile(f, []byte("{}\n{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+		t.Error("signal after ctx was done")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRun_SettlesChanges(t *testing.T) {
+	orig := settle
+	settle = 50 * time.Millisecond
+	t.Cleanup(func() { settle = orig })
+
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		listed int
+	)
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return listed
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run(ctx, Options{
+			Interval: time.Hour,
+			Dirs:     []string{dir},
+			List: func(context.Context) []model.Session {
+				mu.Lock()
+				defer mu.Unlock()
+				listed++
+				return nil
+			},
+			Emit: func([]Event, []model.Session) {},
+		})
+	}()
+	for count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A burst of writes makes one check.
+	for i := range 5 {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("s%d.jsonl", i)), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := count(); n != 2 {
+		t.Errorf("List called %d times after a burst of writes, want 2", n)
+	}
+
+	// A change still settling when ctx is done makes none.
+	if err := os.WriteFile(filepath.Join(dir, "s0.jsonl"), []byte("{}\n{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+	if n := count(); n != 2 {
+		t.Errorf("List called %d times after cancelling, want 2", n)
+	}
+}
+
+func TestNotify_Unavailable(t *testing.T) {
+	orig := inotifyInit
+	inotifyInit = func() (int, error) { return -1, errors.New("ENOSYS") }
+	t.Cleanup(func() { inotifyInit = orig })
+
+	if ch := Changes(context.Background(), []string{t.TempDir()}); ch != nil {
+		t.Error("Changes() without inotify should return nil")
+	}
+}
+
+func TestCString(t *testing.T) {
+	for in, want := range map[string]string{"abc\x00\x00\x00": "abc", "abc": "abc", "": ""} {
+		if got := cString([]byte(in)); got != want {
+			t.Errorf("cString(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
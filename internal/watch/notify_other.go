@@ -0,0 +1,11 @@
+//go:build !linux
+
+package watch
+
+import "context"
+
+// Changes returns nil where inotify is unavailable: callers then poll at
+// their interval only.
+func Changes(ctx context.Context, dirs []string) <-chan struct{} {
+	return nil
+}
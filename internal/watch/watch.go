@@ -0,0 +1,140 @@
+// Package watch follows the active sessions as they start, change and end.
+package watch
+
+import (
+	"context"
+	"time"
+
+	"github.com/psacc/omnisess/internal/detect"
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// EventType says what happened to a session between two checks.
+type EventType string
+
+const (
+	SessionStarted EventType = "session_started" // became active
+	SessionUpdated EventType = "session_updated" // wrote to its transcript, or changed state or process
+	SessionWaiting EventType = "session_waiting" // switched to waiting for the user
+	SessionIdle    EventType = "session_idle"    // still running, but went quiet
+	SessionError   EventType = "session_error"   // its turn failed
+	SessionEnded   EventType = "session_ended"   // no longer active
+)
+
+// stateEvents are the states a session announces switching to with their
+// own event rather than SessionUpdated.
+var stateEvents = map[model.State]EventType{
+	model.StateWaiting: SessionWaiting,
+	model.StateIdle:    SessionIdle,
+	model.StateError:   SessionError,
+}
+
+// Event is a change to one session. Session is the session as last seen:
+// for SessionEnded, as it was before it ended, with Active cleared.
+type Event struct {
+	Type    EventType     `json:"event"`
+	Time    time.Time     `json:"time"`
+	Session model.Session `json:"session"`
+}
+
+// Diff returns the events that take the active sessions from prev to cur:
+// those of cur in its order, then the sessions of prev that ended.
+func Diff(prev, cur []model.Session, now time.Time) []Event {
+	before := make(map[string]model.Session, len(prev))
+	for _, s := range prev {
+		before[s.QualifiedID()] = s
+	}
+	var events []Event
+	still := make(map[string]bool, len(cur))
+	for _, s := range cur {
+		id := s.QualifiedID()
+		still[id] = true
+		old, ok := before[id]
+		switch {
+		case !ok:
+			events = append(events, Event{SessionStarted, now, s})
+		case s.State != old.State && stateEvents[s.State] != "":
+			events = append(events, Event{stateEvents[s.State], now, s})
+		case !s.UpdatedAt.Equal(old.UpdatedAt) || s.State != old.State || s.PID != old.PID:
+			events = append(events, Event{SessionUpdated, now, s})
+		}
+	}
+	for _, s := range prev {
+		if !still[s.QualifiedID()] {
+			s.Active, s.PID, s.State = false, 0, model.StateEnded
+			events = append(events, Event{SessionEnded, now, s})
+		}
+	}
+	return events
+}
+
+// Options configure Run.
+type Options struct {
+	// Interval is how often sessions are checked when no file changes, to
+	// notice processes exiting and sessions going idle.
+	Interval time.Duration
+	// Dirs are the directories whose changes trigger a check.
+	Dirs []string
+	// List returns the active sessions.
+	List func(ctx context.Context) []model.Session
+	// Emit receives the events of every check that found any, and of the
+	// first check, along with the active sessions.
+	Emit func(events []Event, sessions []model.Session)
+}
+
+// settle is how long Run waits after a file change before checking, so that
+// a burst of changes (a response written line by line, a turn touching
+// several files) makes one check rather than one each.
+var settle = 300 * time.Millisecond
+
+// Run checks the active sessions now, then whenever a file under
+// opts.Dirs changes (where inotify is available, once the changes settle) or
+// opts.Interval passes without a check, until ctx is done. Each check takes a
+// new process snapshot, so sessions end when their process exits.
+func Run(ctx context.Context, opts Options) {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	changes := Changes(ctx, opts.Dirs)
+
+	var prev []model.Session
+	check := func(first bool) {
+		detect.Refresh()
+		cur := opts.List(ctx)
+		if events := Diff(prev, cur, time.Now()); first || len(events) > 0 {
+			opts.Emit(events, cur)
+		}
+		prev = cur
+	}
+	check(true)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+			if !settled(ctx, changes) {
+				return
+			}
+		}
+		if ctx.Err() == nil {
+			check(false)
+		}
+		ticker.Reset(opts.Interval)
+	}
+}
+
+// settled waits out settle, absorbing the changes that arrive meanwhile. It
+// returns false if ctx is done first.
+func settled(ctx context.Context, changes <-chan struct{}) bool {
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changes:
+		case <-timer.C:
+			return true
+		}
+	}
+}
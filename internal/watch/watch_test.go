@@ -0,0 +1,125 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/psacc/omnisess/internal/model"
+)
+
+// ---------------------------------------------------------------------------
+// Diff
+// ---------------------------------------------------------------------------
+
+func TestDiff(t *testing.T) {
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	sess := func(id string, updated time.Time, state model.State) model.Session {
+		return model.Session{ID: id, Tool: model.ToolClaude, Active: true, PID: 7, UpdatedAt: updated, State: state}
+	}
+	prev := []model.Session{
+		sess("same", t0, model.StateWorking),
+		sess("wrote", t0, model.StateWorking),
+		sess("waits", t0, model.StateWorking),
+		sess("quiet", t0, model.StateWaiting),
+		sess("still-idle", t0, model.StateIdle),
+		sess("fails", t0, model.StateWorking),
+		sess("resumed", t0, model.StateWaiting),
+		sess("gone", t0, model.StateWaiting),
+	}
+	moved := sess("moved", t0, model.StateWorking)
+	cur := []model.Session{
+		sess("new", t0, model.StateWorking),
+		sess("same", t0, model.StateWorking),
+		sess("wrote", t0.Add(time.Second), model.StateWorking),
+		sess("waits", t0, model.StateWaiting),
+		sess("quiet", t0, model.StateIdle),
+		sess("still-idle", t0, model.StateIdle),
+		sess("fails", t0, model.StateError),
+		sess("resumed", t0.Add(time.Second), model.StateWorking),
+	}
+	prev = append(prev, moved)
+	moved.PID = 8
+	cur = append(cur, moved)
+
+	now := t0.Add(time.Minute)
+	var got []string
+	for _, e := range Diff(prev, cur, now) {
+		if !e.Time.Equal(now) {
+			t.Errorf("%s: Time = %v, want %v", e.Session.ID, e.Time, now)
+		}
+		got = append(got, fmt.Sprintf("%s:%s", e.Type, e.Session.ID))
+	}
+	want := "[session_started:new session_updated:wrote session_waiting:waits session_idle:quiet session_error:fails session_updated:resumed session_updated:moved session_ended:gone]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("Diff() = %v, want %v", got, want)
+	}
+
+	ended := Diff(prev[7:8], nil, now)[0].Session
+	if ended.Active || ended.PID != 0 || ended.State != model.StateEnded {
+		t.Errorf("ended session = Active %v PID %d State %q, want inactive and ended", ended.Active, ended.PID, ended.State)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Run
+// ---------------------------------------------------------------------------
+
+func TestRun(t *testing.T) {
+	a := model.Session{ID: "a", Tool: model.ToolCodex, Active: true}
+	b := model.Session{ID: "b", Tool: model.ToolCodex, Active: true}
+	rounds := [][]model.Session{{a}, {a}, {a, b}, {b}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		listed int
+		got    []string
+	)
+	Run(ctx, Options{
+		Interval: time.Millisecond,
+		Dirs:     []string{t.TempDir()},
+		List: func(context.Context) []model.Session {
+			mu.Lock()
+			defer mu.Unlock()
+			r := rounds[min(listed, len(rounds)-1)]
+			listed++
+			return r
+		},
+		Emit: func(events []Event, sessions []model.Session) {
+			var types []string
+			for _, e := range events {
+				types = append(types, string(e.Type)+":"+e.Session.ID)
+			}
+			got = append(got, fmt.Sprintf("%d%v", len(sessions), types))
+			if len(got) == 3 {
+				cancel()
+			}
+		},
+	})
+
+	// Round 2 changed nothing, so it emitted nothing.
+	want := "[1[session_started:a] 2[session_started:b] 1[session_ended:a]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("Run() emitted %v, want %v", got, want)
+	}
+}
+
+func TestRun_EmitsFirstCheckEvenIfEmpty(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	emitted := 0
+	Run(ctx, Options{
+		Interval: time.Hour,
+		List:     func(context.Context) []model.Session { return nil },
+		Emit: func(events []Event, sessions []model.Session) {
+			emitted++
+			cancel()
+		},
+	})
+	if emitted != 1 {
+		t.Errorf("Emit called %d times, want once", emitted)
+	}
+}